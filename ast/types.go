@@ -6,6 +6,27 @@ import (
 	"strings"
 )
 
+// Location in a localization file.
+type Location struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (l Location) IsValid() bool {
+	return l.File != ""
+}
+
+func (l Location) String() string {
+	if l.Line == 0 {
+		return l.File
+	}
+	if l.Column == 0 {
+		return l.File + ":" + strconv.Itoa(l.Line)
+	}
+	return l.File + ":" + strconv.Itoa(l.Line) + ":" + strconv.Itoa(l.Column)
+}
+
 type GoType struct {
 	Import  string
 	Package string
@@ -18,35 +39,82 @@ func (t *GoType) IsZero() bool {
 		t.Type == ""
 }
 
+func (t *GoType) String() string {
+	if t.Package == "" {
+		return t.Type
+	}
+	return t.Package + "." + t.Type
+}
+
 type Value interface {
 	value()
 	IsZero() bool
 	GetArgumentNames() (names []string)
+	// Returns every text the value can result in
+	Forms() (forms []FormatParts)
 }
 
 type Plural struct {
-	Arg   string
+	Location Location
+	Arg      string
+	// Forms that match numbers exactly, which take precedence over the other ones
+	Exact []ExactForm
 	Zero  FormatParts
 	One   FormatParts
+	Two   FormatParts
+	Few   FormatParts
 	Many  FormatParts
 	Other FormatParts
 }
 
+// Form of a plural that matches the number exactly, like "=1" in ICU MessageFormat.
+// Unlike "zero" form, "=0" only ever matches zero.
+type ExactForm struct {
+	Number int
+	Parts  FormatParts
+}
+
+// Returns name of the form the way it is written in localization files: "=1".
+func (f *ExactForm) Name() string {
+	return "=" + strconv.Itoa(f.Number)
+}
+
 func (Plural) value() {}
 
 func (p *Plural) IsZero() bool {
 	return p.Arg == "" &&
+		p.Exact == nil &&
 		p.Zero == nil &&
 		p.One == nil &&
+		p.Two == nil &&
+		p.Few == nil &&
 		p.Many == nil &&
 		p.Other == nil
 }
 
+// Returns texts of the exact forms followed by the texts of the other forms,
+// which are nil if they are not specified.
+func (p *Plural) Forms() (forms []FormatParts) {
+	for i := 0; i < len(p.Exact); i++ {
+		forms = append(forms, p.Exact[i].Parts)
+	}
+	return append(forms, p.Zero, p.One, p.Two, p.Few, p.Many, p.Other)
+}
+
+// Returns the exact form that matches the number, or nil if there is none.
+func (p *Plural) ExactForm(n int) *ExactForm {
+	for i := 0; i < len(p.Exact); i++ {
+		if p.Exact[i].Number == n {
+			return &p.Exact[i]
+		}
+	}
+	return nil
+}
+
 func (p *Plural) GetArgumentNames() (args []string) {
 	args = append(args, p.Arg)
-	formatParts := []FormatParts{p.Zero, p.One, p.Many, p.Other}
 
-	for _, parts := range formatParts {
+	for _, parts := range p.Forms() {
 		names := parts.GetArgumentNames()
 		for _, name := range names {
 			if !slices.Contains(args, name) {
@@ -59,9 +127,7 @@ func (p *Plural) GetArgumentNames() (args []string) {
 }
 
 func (p *Plural) IsSimple() bool {
-	formatParts := []FormatParts{p.Zero, p.One, p.Many, p.Other}
-
-	for _, parts := range formatParts {
+	for _, parts := range p.Forms() {
 		if !parts.IsSimple() {
 			return false
 		}
@@ -70,17 +136,72 @@ func (p *Plural) IsSimple() bool {
 	return true
 }
 
+// Text that depends on the value of a string argument, like ICU select.
+type Select struct {
+	Location Location
+	Arg      string
+	Cases    []SelectCase
+	// Text for the values that don't match any of the cases
+	Other FormatParts
+}
+
+type SelectCase struct {
+	Value string
+	Parts FormatParts
+}
+
+func (Select) value() {}
+
+func (s *Select) IsZero() bool {
+	return s.Arg == "" &&
+		s.Cases == nil &&
+		s.Other == nil
+}
+
+// Returns texts of the cases followed by the text of "other", which is nil if it is not specified.
+func (s *Select) Forms() (forms []FormatParts) {
+	for i := 0; i < len(s.Cases); i++ {
+		forms = append(forms, s.Cases[i].Parts)
+	}
+	return append(forms, s.Other)
+}
+
+func (s *Select) GetArgumentNames() (args []string) {
+	args = append(args, s.Arg)
+
+	for _, parts := range s.Forms() {
+		names := parts.GetArgumentNames()
+		for _, name := range names {
+			if !slices.Contains(args, name) {
+				args = append(args, name)
+			}
+		}
+	}
+
+	return args
+}
+
 type Variable struct {
-	Name   string
-	Plural Plural
-	String FormatParts
+	Location Location
+	Name     string
+	Plural   Plural
+	Select   Select
+	String   FormatParts
 }
 
 type Message struct {
+	Location  Location
 	Name      string
+	Arguments []ArgInfo
 	Variables []Variable
 	Plural    Plural
 	String    FormatParts
+	// Description of the message for developers and translators
+	Description string
+	// Whether undeclared arguments go in the order of their first appearance in the text.
+	// Messages of the default syntax keep the order of processing,
+	// so that changing the syntax doesn't swap arguments of existing methods.
+	TextOrder bool
 }
 
 type GoImport struct {
@@ -118,6 +239,34 @@ func (i *FmtInfo) HasOptions() bool {
 		i.Flags != nil
 }
 
+// Returns format the same way it is specified inside of ${...} blocks.
+func (i *FmtInfo) String() string {
+	var b strings.Builder
+
+	for _, flag := range i.Flags {
+		b.WriteRune(flag)
+	}
+
+	if i.Width.Valid {
+		b.WriteString(strconv.Itoa(i.Width.Value))
+	}
+
+	if i.Prec.Valid {
+		b.WriteByte('.')
+		b.WriteString(strconv.Itoa(i.Prec.Value))
+	}
+
+	if i.Spec != 0 {
+		b.WriteRune(i.Spec)
+	}
+
+	if i.Mod.Valid {
+		b.WriteRune(i.Mod.Value)
+	}
+
+	return b.String()
+}
+
 func (i *FmtInfo) GoFormat(goType GoType) string {
 	var spec rune
 
@@ -163,12 +312,14 @@ type FormatPart interface {
 }
 
 type ArgInfo struct {
-	Name    string
-	FmtInfo FmtInfo
+	Location Location
+	Name     string
+	FmtInfo  FmtInfo
 }
 
 type VarInfo struct {
-	Name string
+	Location Location
+	Name     string
 }
 
 type Text string
@@ -181,6 +332,10 @@ type FormatParts []FormatPart
 
 func (FormatParts) value() {}
 
+func (f FormatParts) Forms() (forms []FormatParts) {
+	return []FormatParts{f}
+}
+
 func (f FormatParts) IsZero() bool {
 	return len(f) == 0
 }
@@ -195,6 +350,45 @@ func (f FormatParts) GetArgumentNames() (args []string) {
 	return args
 }
 
+// Returns format parts the same way they are written in localization files.
+func (f FormatParts) String() string {
+	var b strings.Builder
+
+	for _, part := range f {
+		switch part := part.(type) {
+		case Text:
+			text := strings.ReplaceAll(string(part), "$", "$$")
+			text = strings.ReplaceAll(text, "&", "&&")
+			b.WriteString(text)
+		case ArgInfo:
+			b.WriteString("${")
+			if format := part.FmtInfo.String(); format != "" {
+				b.WriteString(format)
+				b.WriteByte(':')
+			}
+			b.WriteString(part.Name)
+			b.WriteByte('}')
+		case VarInfo:
+			b.WriteString("&{")
+			b.WriteString(part.Name)
+			b.WriteByte('}')
+		}
+	}
+
+	return b.String()
+}
+
+// Returns names of the variables the parts refer to.
+func (f FormatParts) GetVariableNames() (vars []string) {
+	for _, part := range f {
+		v, ok := part.(VarInfo)
+		if ok && !slices.Contains(vars, v.Name) {
+			vars = append(vars, v.Name)
+		}
+	}
+	return vars
+}
+
 func (f FormatParts) IsSimple() bool {
 	for _, part := range f {
 		if _, ok := part.(Text); !ok {
@@ -0,0 +1,213 @@
+package codegen
+
+import (
+	goast "go/ast"
+	gotoken "go/token"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/infastin/go-l10n/common"
+	"github.com/infastin/go-l10n/scope"
+)
+
+// Returns build tag that includes all of the languages: l10n_all.
+func getAllBuildTag() string {
+	return common.Config.PackageName + "_all"
+}
+
+// Returns build tag of the language, which is named after the package
+// and the lowercased language tag, like the file of the language: l10n_pt_br.
+func getBuildTag(loc *scope.Localization) string {
+	return common.Config.PackageName + "_" + strings.ToLower(getLanguageIdent(loc))
+}
+
+// Returns localizations that l10n.go refers to directly.
+// If files of the languages are under build tags, it is only the base one,
+// and the other ones register themselves when their files are built.
+func getBuiltinLocalizations(locs []scope.Localization) []scope.Localization {
+	if common.Config.BuildTags {
+		return locs[:1]
+	}
+	return locs
+}
+
+// Returns build constraint of the file of the localization.
+// The file is built with its own tag and with the tags of the languages
+// that fall back to it, since their messages call its methods.
+// Fallbacks are followed through chains, e.g. es-AR falls back to es-419,
+// which falls back to es, so es is built with es-AR tag too.
+func getBuildConstraint(locs []scope.Localization, loc *scope.Localization) string {
+	tags := []string{getAllBuildTag(), getBuildTag(loc)}
+
+	// Localizations whose files are built along with the file of the localization
+	built := []*scope.Localization{loc}
+
+	for i := 0; i < len(built); i++ {
+		for j := 0; j < len(locs); j++ {
+			dependent := &locs[j]
+
+			if slices.ContainsFunc(built, func(l *scope.Localization) bool { return l.Lang == dependent.Lang }) {
+				continue
+			}
+
+			if slices.ContainsFunc(dependent.Scopes, func(ms scope.MessageScope) bool {
+				return ms.Fallback != nil && ms.Fallback.Lang == built[i].Lang
+			}) {
+				built = append(built, dependent)
+				tags = append(tags, getBuildTag(dependent))
+			}
+		}
+	}
+
+	return "//go:build " + strings.Join(tags, " || ")
+}
+
+// Generates function that adds localizer to the supported ones,
+// which files of the languages call when they are built.
+func generateGeneralFuncRegister(_ []scope.Localization, decls *[]goast.Decl) {
+	funcDecl := &goast.FuncDecl{
+		Name: goast.NewIdent("registerLocalizer"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("lang")},
+						Type:  goast.NewIdent("string"),
+					},
+					{
+						Names: []*goast.Ident{goast.NewIdent("loc")},
+						Type:  goast.NewIdent("Localizer"),
+					},
+				},
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{
+						&goast.IndexExpr{
+							X:     goast.NewIdent("mapLangToLocalizer"),
+							Index: goast.NewIdent("lang"),
+						},
+					},
+					Tok: gotoken.ASSIGN,
+					Rhs: []goast.Expr{goast.NewIdent("loc")},
+				},
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{goast.NewIdent("Supported")},
+					Tok: gotoken.ASSIGN,
+					Rhs: []goast.Expr{
+						&goast.CallExpr{
+							Fun:  goast.NewIdent("append"),
+							Args: []goast.Expr{goast.NewIdent("Supported"), goast.NewIdent("lang")},
+						},
+					},
+				},
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{goast.NewIdent("matcherTags")},
+					Tok: gotoken.ASSIGN,
+					Rhs: []goast.Expr{
+						&goast.CallExpr{
+							Fun: goast.NewIdent("append"),
+							Args: []goast.Expr{
+								goast.NewIdent("matcherTags"),
+								&goast.CallExpr{
+									Fun: &goast.SelectorExpr{
+										X:   goast.NewIdent("language"),
+										Sel: goast.NewIdent("MustParse"),
+									},
+									Args: []goast.Expr{goast.NewIdent("lang")},
+								},
+							},
+						},
+					},
+				},
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{goast.NewIdent("Matcher")},
+					Tok: gotoken.ASSIGN,
+					Rhs: []goast.Expr{
+						&goast.CallExpr{
+							Fun: &goast.SelectorExpr{
+								X:   goast.NewIdent("language"),
+								Sel: goast.NewIdent("NewMatcher"),
+							},
+							Args: []goast.Expr{goast.NewIdent("matcherTags")},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if common.Config.Live {
+		generateGeneralLiveHook(decls)
+		funcDecl.Body.List = append([]goast.Stmt{generateLiveHookCall()}, funcDecl.Body.List...)
+	}
+
+	*decls = append(*decls, funcDecl)
+}
+
+// Returns body of Language function, which looks the localizer up among the registered ones.
+// Localizers of the languages are comparable, so comparing them with anything never panics.
+func generateRegisteredLanguageBody() []goast.Stmt {
+	return []goast.Stmt{
+		&goast.RangeStmt{
+			Key:   goast.NewIdent("_"),
+			Value: goast.NewIdent("lang"),
+			Tok:   gotoken.DEFINE,
+			X:     goast.NewIdent("Supported"),
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.IfStmt{
+						Cond: &goast.BinaryExpr{
+							X: &goast.IndexExpr{
+								X:     goast.NewIdent("mapLangToLocalizer"),
+								Index: goast.NewIdent("lang"),
+							},
+							Op: gotoken.EQL,
+							Y:  goast.NewIdent("loc"),
+						},
+						Body: &goast.BlockStmt{
+							List: []goast.Stmt{
+								&goast.ReturnStmt{Results: []goast.Expr{goast.NewIdent("lang")}},
+							},
+						},
+					},
+				},
+			},
+		},
+		&goast.ReturnStmt{
+			Results: []goast.Expr{&goast.BasicLit{Kind: gotoken.STRING, Value: `""`}},
+		},
+	}
+}
+
+// Puts the file of the localization under build constraint
+// and generates init function that registers its localizer.
+func generateMessagesRegistration(locs []scope.Localization, loc *scope.Localization, file *goast.File) {
+	file.Doc.List = append(file.Doc.List,
+		&goast.Comment{Text: getBuildConstraint(locs, loc)},
+		&goast.Comment{Text: ""},
+	)
+
+	file.Decls = append(file.Decls, &goast.FuncDecl{
+		Name: goast.NewIdent("init"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.ExprStmt{
+					X: &goast.CallExpr{
+						Fun: goast.NewIdent("registerLocalizer"),
+						Args: []goast.Expr{
+							&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(loc.Lang.String())},
+							&goast.CompositeLit{Type: goast.NewIdent(getLocalizerTypeName(loc))},
+						},
+					},
+				},
+			},
+		},
+	})
+}
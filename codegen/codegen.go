@@ -3,6 +3,7 @@ package codegen
 import (
 	goast "go/ast"
 	gotoken "go/token"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -11,11 +12,23 @@ import (
 	"github.com/infastin/go-l10n/scope"
 )
 
+// Comment that generated files start with.
+const GeneratedComment = "// Code generated by go-l10n; DO NOT EDIT."
+
 func GenerateLocalizations(locs []scope.Localization) (files []*goast.File) {
-	files = append(files, generateGeneral(locs))
+	// General file goes first, but it depends on what
+	// has been generated for localizations
+	files = append(files, nil)
 
 	for i := 0; i < len(locs); i++ {
-		files = append(files, generateMessages(&locs[i]))
+		files = append(files, generateMessages(locs, &locs[i]))
+	}
+
+	files[0] = generateGeneral(locs)
+
+	// File of live localizers goes last
+	if common.Config.Live {
+		files = append(files, generateLive(locs))
 	}
 
 	return files
@@ -25,7 +38,7 @@ func generateGeneral(locs []scope.Localization) (file *goast.File) {
 	file = &goast.File{
 		Doc: &goast.CommentGroup{
 			List: []*goast.Comment{
-				{Text: "// Code generated by go-l10n; DO NOT EDIT."},
+				{Text: GeneratedComment},
 				{Text: ""},
 			},
 		},
@@ -33,12 +46,35 @@ func generateGeneral(locs []scope.Localization) (file *goast.File) {
 		Decls: []goast.Decl{},
 	}
 
-	if len(common.Config.Imports) != 0 {
+	imports := []ast.GoImport{
+		{Import: "context", Package: "context"},
+		{Import: "errors", Package: "errors"},
+		{Import: "fmt", Package: "fmt"},
+		{Import: "io", Package: "io"},
+		{Import: "log/slog", Package: "slog"},
+	}
+	if usesIntArguments(locs) {
+		imports = append(imports, ast.GoImport{Import: "math", Package: "math"})
+	}
+	if common.Config.Middleware {
+		imports = append(imports, ast.GoImport{Import: "net/http", Package: "http"})
+	}
+	imports = append(imports, ast.GoImport{Import: "sync", Package: "sync"})
+	if usesFormatHelpers(locs) {
+		imports = append(imports, ast.GoImport{Import: "unicode/utf8", Package: "utf8"})
+	}
+	imports = append(imports, common.Config.Imports...)
+	if usesPluralForms(locs) {
+		imports = append(imports, ast.GoImport{Import: "golang.org/x/text/feature/plural", Package: "plural"})
+	}
+	imports = append(imports, ast.GoImport{Import: "golang.org/x/text/language", Package: "language"})
+
+	if len(imports) != 0 {
 		importDecl := &goast.GenDecl{
 			Tok: gotoken.IMPORT,
 		}
 
-		for _, imp := range common.Config.Imports {
+		for _, imp := range imports {
 			importDecl.Specs = append(importDecl.Specs, &goast.ImportSpec{
 				Path: &goast.BasicLit{
 					Kind:  gotoken.STRING,
@@ -50,55 +86,239 @@ func generateGeneral(locs []scope.Localization) (file *goast.File) {
 		file.Decls = append(file.Decls, importDecl)
 	}
 
-	file.Decls = append(file.Decls, &goast.GenDecl{
-		Tok: gotoken.TYPE,
-		Specs: []goast.Spec{
-			&goast.TypeSpec{
-				Name: goast.NewIdent("Localizer"),
-				Type: generateGeneralInterface(locs[0].Scopes),
+	// Top-level namespace is Localizer itself
+	namespaces := append([]string{""}, getNamespaces(locs[0].Scopes)...)
+
+	for _, namespace := range namespaces {
+		file.Decls = append(file.Decls, &goast.GenDecl{
+			Tok: gotoken.TYPE,
+			Specs: []goast.Spec{
+				&goast.TypeSpec{
+					Name: goast.NewIdent(getNamespaceInterfaceName(namespace)),
+					Type: generateGeneralInterface(locs[0].Scopes, namespace, namespaces),
+				},
 			},
-		},
-	})
+		})
+	}
 
-	generateGeneralTable(locs, &file.Decls)
-	generateGeneralSupported(locs, &file.Decls)
+	builtin := getBuiltinLocalizations(locs)
+
+	generateGeneralTable(builtin, &file.Decls)
+	generateGeneralBuffers(locs, &file.Decls)
+	generateGeneralSupported(builtin, &file.Decls)
+	generateGeneralMatcher(builtin, &file.Decls)
 	generateGeneralFuncs(locs, &file.Decls)
 
 	return file
 }
 
-func generateGeneralInterface(msgs []scope.MessageScope) (ifaceType *goast.InterfaceType) {
+// Generates interface of the namespace, which contains methods of its messages
+// and methods that return interfaces of its child namespaces.
+func generateGeneralInterface(
+	msgs []scope.MessageScope,
+	namespace string,
+	namespaces []string,
+) (ifaceType *goast.InterfaceType) {
 	ifaceType = &goast.InterfaceType{
 		Methods: &goast.FieldList{},
 	}
 
 	for i := 0; i < len(msgs); i++ {
 		msg := &msgs[i]
-		funcType := &goast.FuncType{
-			Params: &goast.FieldList{},
-			Results: &goast.FieldList{
-				List: []*goast.Field{
-					{Type: goast.NewIdent("string")},
+		if getMessageNamespace(msg) != namespace {
+			continue
+		}
+
+		ifaceType.Methods.List = append(ifaceType.Methods.List,
+			&goast.Field{
+				Doc:   generateGeneralInterfaceDoc(msg),
+				Names: []*goast.Ident{goast.NewIdent(getMessageFuncName(msg))},
+				Type: &goast.FuncType{
+					Params:  getMessageParams(msg),
+					Results: getMessageResults(),
 				},
 			},
-		}
+			&goast.Field{
+				Doc: &goast.CommentGroup{
+					List: []*goast.Comment{
+						{Text: "// " + getAppendFuncName(msg) + " appends " + getMessageFuncName(msg) + " to " + bufferName + " and returns the extended buffer."},
+					},
+				},
+				Names: []*goast.Ident{goast.NewIdent(getAppendFuncName(msg))},
+				Type: &goast.FuncType{
+					Params:  getMessageParams(msg, getBufferField()),
+					Results: getAppendResults(),
+				},
+			},
+			&goast.Field{
+				Doc: &goast.CommentGroup{
+					List: []*goast.Comment{
+						{Text: "// " + getWriteFuncName(msg) + " writes " + getMessageFuncName(msg) + " to " + writerName + "."},
+					},
+				},
+				Names: []*goast.Ident{goast.NewIdent(getWriteFuncName(msg))},
+				Type: &goast.FuncType{
+					Params:  getMessageParams(msg, getWriterField()),
+					Results: getWriteResults(),
+				},
+			},
+		)
+	}
 
-		for i := 0; i < len(msg.Arguments); i++ {
-			funcType.Params.List = append(funcType.Params.List, &goast.Field{
-				Names: []*goast.Ident{goast.NewIdent(msg.Arguments[i].Name)},
-				Type:  getPackageFieldType(&msg.Arguments[i]),
-			})
+	for _, child := range namespaces {
+		if child == "" || getNamespaceParent(child) != namespace {
+			continue
 		}
 
 		ifaceType.Methods.List = append(ifaceType.Methods.List, &goast.Field{
-			Names: []*goast.Ident{goast.NewIdent(msg.Name)},
-			Type:  funcType,
+			Doc: &goast.CommentGroup{
+				List: []*goast.Comment{
+					{Text: "// " + getNamespaceFuncName(child) + " returns messages of " + child + " namespace."},
+				},
+			},
+			Names: []*goast.Ident{goast.NewIdent(getNamespaceFuncName(child))},
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{},
+				Results: &goast.FieldList{
+					List: []*goast.Field{
+						{Type: goast.NewIdent(getNamespaceInterfaceName(child))},
+					},
+				},
+			},
 		})
 	}
 
 	return ifaceType
 }
 
+// Generates documentation of the message from its text and description in the base localization.
+func generateGeneralInterfaceDoc(ms *scope.MessageScope) (doc *goast.CommentGroup) {
+	doc = &goast.CommentGroup{}
+
+	values := []ast.Value{&ms.Plural, ms.String}
+
+	for _, value := range values {
+		if !value.IsZero() {
+			generateValueDoc(getMessageFuncName(ms)+" returns", value, &doc.List)
+			break
+		}
+	}
+
+	if ms.Description != "" {
+		doc.List = append(doc.List, &goast.Comment{Text: "//"})
+		for _, line := range strings.Split(ms.Description, "\n") {
+			doc.List = append(doc.List, &goast.Comment{Text: strings.TrimRight("// "+line, " ")})
+		}
+	}
+
+	for i := 0; i < len(ms.Variables); i++ {
+		variable := &ms.Variables[i]
+		values := []ast.Value{&variable.Plural, &variable.Select, variable.String}
+
+		for _, value := range values {
+			if !value.IsZero() {
+				doc.List = append(doc.List, &goast.Comment{Text: "//"})
+				generateValueDoc("&{"+variable.Name+"} is", value, &doc.List)
+				break
+			}
+		}
+	}
+
+	return doc
+}
+
+func generateValueDoc(prefix string, value ast.Value, list *[]*goast.Comment) {
+	switch v := value.(type) {
+	case *ast.Plural:
+		forms := []struct {
+			Name        string
+			FormatParts ast.FormatParts
+		}{
+			{"zero", v.Zero},
+			{"one", v.One},
+			{"two", v.Two},
+			{"few", v.Few},
+			{"many", v.Many},
+			{"other", v.Other},
+		}
+
+		*list = append(*list, &goast.Comment{Text: "// " + prefix + ", depending on " + v.Arg + ":"})
+
+		for i := 0; i < len(v.Exact); i++ {
+			*list = append(*list, &goast.Comment{
+				Text: "//   - " + v.Exact[i].Name() + ": " + strconv.Quote(v.Exact[i].Parts.String()),
+			})
+		}
+
+		for _, form := range forms {
+			if form.FormatParts != nil {
+				*list = append(*list, &goast.Comment{
+					Text: "//   - " + form.Name + ": " + strconv.Quote(form.FormatParts.String()),
+				})
+			}
+		}
+	case *ast.Select:
+		*list = append(*list, &goast.Comment{Text: "// " + prefix + ", depending on " + v.Arg + ":"})
+
+		for i := 0; i < len(v.Cases); i++ {
+			*list = append(*list, &goast.Comment{
+				Text: "//   - " + strconv.Quote(v.Cases[i].Value) + ": " + strconv.Quote(v.Cases[i].Parts.String()),
+			})
+		}
+
+		*list = append(*list, &goast.Comment{
+			Text: "//   - other: " + strconv.Quote(v.Other.String()),
+		})
+	case ast.FormatParts:
+		*list = append(*list, &goast.Comment{Text: "// " + prefix + " " + strconv.Quote(v.String())})
+	}
+}
+
+// Generates pool of buffers that messages are built in before they are written.
+func generateGeneralBuffers(_ []scope.Localization, decls *[]goast.Decl) {
+	*decls = append(*decls, &goast.GenDecl{
+		Tok: gotoken.VAR,
+		Specs: []goast.Spec{
+			&goast.ValueSpec{
+				Names: []*goast.Ident{goast.NewIdent("buffers")},
+				Values: []goast.Expr{
+					&goast.CompositeLit{
+						Type: &goast.SelectorExpr{
+							X:   goast.NewIdent("sync"),
+							Sel: goast.NewIdent("Pool"),
+						},
+						Elts: []goast.Expr{
+							&goast.KeyValueExpr{
+								Key: goast.NewIdent("New"),
+								Value: &goast.FuncLit{
+									Type: &goast.FuncType{
+										Params: &goast.FieldList{},
+										Results: &goast.FieldList{
+											List: []*goast.Field{{Type: goast.NewIdent("any")}},
+										},
+									},
+									Body: &goast.BlockStmt{
+										List: []goast.Stmt{
+											&goast.ReturnStmt{
+												Results: []goast.Expr{
+													&goast.CallExpr{
+														Fun:  goast.NewIdent("new"),
+														Args: []goast.Expr{&goast.ArrayType{Elt: goast.NewIdent("byte")}},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
 func generateGeneralSupported(locs []scope.Localization, decls *[]goast.Decl) {
 	sliceLit := &goast.CompositeLit{
 		Type: &goast.ArrayType{
@@ -126,6 +346,69 @@ func generateGeneralSupported(locs []scope.Localization, decls *[]goast.Decl) {
 	*decls = append(*decls, varDecl)
 }
 
+// Generates language matcher, whose tags go in the same order as in Supported.
+// The base localization goes first, so it is used when nothing matches.
+// If languages are registered, their tags are kept to make the matcher anew.
+func generateGeneralMatcher(locs []scope.Localization, decls *[]goast.Decl) {
+	sliceLit := &goast.CompositeLit{
+		Type: &goast.ArrayType{
+			Elt: &goast.SelectorExpr{
+				X:   goast.NewIdent("language"),
+				Sel: goast.NewIdent("Tag"),
+			},
+		},
+	}
+
+	for i := 0; i < len(locs); i++ {
+		sliceLit.Elts = append(sliceLit.Elts, &goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   goast.NewIdent("language"),
+				Sel: goast.NewIdent("MustParse"),
+			},
+			Args: []goast.Expr{
+				&goast.BasicLit{
+					Kind:  gotoken.STRING,
+					Value: strconv.Quote(locs[i].Lang.String()),
+				},
+			},
+		})
+	}
+
+	var tags goast.Expr = sliceLit
+
+	if common.Config.BuildTags {
+		*decls = append(*decls, &goast.GenDecl{
+			Tok: gotoken.VAR,
+			Specs: []goast.Spec{
+				&goast.ValueSpec{
+					Names:  []*goast.Ident{goast.NewIdent("matcherTags")},
+					Values: []goast.Expr{sliceLit},
+				},
+			},
+		})
+
+		tags = goast.NewIdent("matcherTags")
+	}
+
+	*decls = append(*decls, &goast.GenDecl{
+		Tok: gotoken.VAR,
+		Specs: []goast.Spec{
+			&goast.ValueSpec{
+				Names: []*goast.Ident{goast.NewIdent("Matcher")},
+				Values: []goast.Expr{
+					&goast.CallExpr{
+						Fun: &goast.SelectorExpr{
+							X:   goast.NewIdent("language"),
+							Sel: goast.NewIdent("NewMatcher"),
+						},
+						Args: []goast.Expr{tags},
+					},
+				},
+			},
+		},
+	})
+}
+
 func generateGeneralTable(locs []scope.Localization, decls *[]goast.Decl) {
 	mapLit := &goast.CompositeLit{
 		Type: &goast.MapType{
@@ -157,8 +440,127 @@ func generateGeneralTable(locs []scope.Localization, decls *[]goast.Decl) {
 }
 
 func generateGeneralFuncs(locs []scope.Localization, decls *[]goast.Decl) {
+	if common.Config.BuildTags {
+		generateGeneralFuncRegister(locs, decls)
+	}
+
 	generateGeneralFuncNew(locs, decls)
+	generateGeneralFuncMatch(locs, decls)
+	generateGeneralFuncFromAcceptLanguage(locs, decls)
 	generateGeneralFuncLang(locs, decls)
+	generateGeneralContext(locs, decls)
+	generateGeneralTranslate(locs, decls)
+	generateGeneralLazyMessages(locs, decls)
+	if common.Config.Middleware {
+		generateGeneralMiddleware(locs, decls)
+	}
+
+	if usesPluralForms(locs) {
+		generateGeneralFuncPluralForm(locs, decls)
+	}
+
+	if usesFormatHelpers(locs) {
+		generateGeneralFormatHelpers(locs, decls)
+	}
+}
+
+// Reports whether any of the localizations matches plural forms.
+func usesPluralForms(locs []scope.Localization) bool {
+	for i := 0; i < len(locs); i++ {
+		if slices.ContainsFunc(locs[i].Imports, func(imp ast.GoImport) bool { return imp.Package == "plural" }) {
+			return true
+		}
+	}
+	return false
+}
+
+// Generates function that returns plural form of the integer in the language,
+// which has to choose the same forms as the one of live catalogs.
+func generateGeneralFuncPluralForm(_ []scope.Localization, decls *[]goast.Decl) {
+	*decls = append(*decls, &goast.FuncDecl{
+		Name: goast.NewIdent("pluralForm"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("lang")},
+						Type: &goast.SelectorExpr{
+							X:   goast.NewIdent("language"),
+							Sel: goast.NewIdent("Tag"),
+						},
+					},
+					{
+						Names: []*goast.Ident{goast.NewIdent("n")},
+						Type:  goast.NewIdent("int"),
+					},
+				},
+			},
+			Results: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Type: &goast.SelectorExpr{
+							X:   goast.NewIdent("plural"),
+							Sel: goast.NewIdent("Form"),
+						},
+					},
+				},
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				// Plural operands must be non-negative, and it is okay
+				// to pass them modulo 10,000,000
+				&goast.IfStmt{
+					Cond: &goast.BinaryExpr{
+						X:  goast.NewIdent("n"),
+						Op: gotoken.LSS,
+						Y:  &goast.BasicLit{Kind: gotoken.INT, Value: "0"},
+					},
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							&goast.AssignStmt{
+								Lhs: []goast.Expr{goast.NewIdent("n")},
+								Tok: gotoken.ASSIGN,
+								Rhs: []goast.Expr{
+									&goast.UnaryExpr{
+										Op: gotoken.SUB,
+										X: &goast.ParenExpr{
+											X: &goast.BinaryExpr{
+												X:  goast.NewIdent("n"),
+												Op: gotoken.REM,
+												Y:  &goast.BasicLit{Kind: gotoken.INT, Value: "10000000"},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&goast.ReturnStmt{
+					Results: []goast.Expr{
+						&goast.CallExpr{
+							Fun: &goast.SelectorExpr{
+								X: &goast.SelectorExpr{
+									X:   goast.NewIdent("plural"),
+									Sel: goast.NewIdent("Cardinal"),
+								},
+								Sel: goast.NewIdent("MatchPlural"),
+							},
+							Args: []goast.Expr{
+								goast.NewIdent("lang"),
+								goast.NewIdent("n"),
+								&goast.BasicLit{Kind: gotoken.INT, Value: "0"},
+								&goast.BasicLit{Kind: gotoken.INT, Value: "0"},
+								&goast.BasicLit{Kind: gotoken.INT, Value: "0"},
+								&goast.BasicLit{Kind: gotoken.INT, Value: "0"},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
 }
 
 func generateGeneralFuncNew(_ []scope.Localization, decls *[]goast.Decl) {
@@ -209,6 +611,140 @@ func generateGeneralFuncNew(_ []scope.Localization, decls *[]goast.Decl) {
 	})
 }
 
+// Returns fields of the results of Match and FromAcceptLanguage functions.
+func getMatchResults() []*goast.Field {
+	return []*goast.Field{
+		{
+			Names: []*goast.Ident{goast.NewIdent("loc")},
+			Type:  goast.NewIdent("Localizer"),
+		},
+		{
+			Names: []*goast.Ident{goast.NewIdent("tag")},
+			Type: &goast.SelectorExpr{
+				X:   goast.NewIdent("language"),
+				Sel: goast.NewIdent("Tag"),
+			},
+		},
+		{
+			Names: []*goast.Ident{goast.NewIdent("conf")},
+			Type: &goast.SelectorExpr{
+				X:   goast.NewIdent("language"),
+				Sel: goast.NewIdent("Confidence"),
+			},
+		},
+	}
+}
+
+func generateGeneralFuncMatch(_ []scope.Localization, decls *[]goast.Decl) {
+	*decls = append(*decls, &goast.FuncDecl{
+		Name: goast.NewIdent("Match"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("tags")},
+						Type: &goast.Ellipsis{
+							Elt: &goast.SelectorExpr{
+								X:   goast.NewIdent("language"),
+								Sel: goast.NewIdent("Tag"),
+							},
+						},
+					},
+				},
+			},
+			Results: &goast.FieldList{
+				List: getMatchResults(),
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{
+						goast.NewIdent("tag"),
+						goast.NewIdent("idx"),
+						goast.NewIdent("conf"),
+					},
+					Tok: gotoken.DEFINE,
+					Rhs: []goast.Expr{
+						&goast.CallExpr{
+							Fun: &goast.SelectorExpr{
+								X:   goast.NewIdent("Matcher"),
+								Sel: goast.NewIdent("Match"),
+							},
+							Args:     []goast.Expr{goast.NewIdent("tags")},
+							Ellipsis: 1,
+						},
+					},
+				},
+				&goast.ReturnStmt{
+					Results: []goast.Expr{
+						&goast.IndexExpr{
+							X: goast.NewIdent("mapLangToLocalizer"),
+							Index: &goast.IndexExpr{
+								X:     goast.NewIdent("Supported"),
+								Index: goast.NewIdent("idx"),
+							},
+						},
+						goast.NewIdent("tag"),
+						goast.NewIdent("conf"),
+					},
+				},
+			},
+		},
+	})
+}
+
+func generateGeneralFuncFromAcceptLanguage(_ []scope.Localization, decls *[]goast.Decl) {
+	*decls = append(*decls, &goast.FuncDecl{
+		Name: goast.NewIdent("FromAcceptLanguage"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("header")},
+						Type:  goast.NewIdent("string"),
+					},
+				},
+			},
+			Results: &goast.FieldList{
+				List: getMatchResults(),
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				// If the header is malformed, there are no tags,
+				// and the base localization is returned
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{
+						goast.NewIdent("tags"),
+						goast.NewIdent("_"),
+						goast.NewIdent("_"),
+					},
+					Tok: gotoken.DEFINE,
+					Rhs: []goast.Expr{
+						&goast.CallExpr{
+							Fun: &goast.SelectorExpr{
+								X:   goast.NewIdent("language"),
+								Sel: goast.NewIdent("ParseAcceptLanguage"),
+							},
+							Args: []goast.Expr{goast.NewIdent("header")},
+						},
+					},
+				},
+				&goast.ReturnStmt{
+					Results: []goast.Expr{
+						&goast.CallExpr{
+							Fun:      goast.NewIdent("Match"),
+							Args:     []goast.Expr{goast.NewIdent("tags")},
+							Ellipsis: 1,
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
 func generateGeneralFuncLang(locs []scope.Localization, decls *[]goast.Decl) {
 	switchStmt := &goast.TypeSwitchStmt{
 		Assign: &goast.ExprStmt{
@@ -241,6 +777,15 @@ func generateGeneralFuncLang(locs []scope.Localization, decls *[]goast.Decl) {
 		},
 	}
 
+	// Types of the localizers that aren't built can't be referred to,
+	// and live localizers replace the compiled ones in the table
+	if common.Config.BuildTags || common.Config.Live {
+		funcDecl.Body.List = generateRegisteredLanguageBody()
+		*decls = append(*decls, funcDecl)
+
+		return
+	}
+
 	for i := 0; i < len(locs); i++ {
 		switchStmt.Body.List = append(switchStmt.Body.List, &goast.CaseClause{
 			List: []goast.Expr{
@@ -275,13 +820,156 @@ func generateGeneralFuncLang(locs []scope.Localization, decls *[]goast.Decl) {
 	*decls = append(*decls, funcDecl)
 }
 
-func generateMessages(loc *scope.Localization) (file *goast.File) {
+// Generates functions that carry localizer in context.
+// Localizer of the context is the default one if the context doesn't have any,
+// which is the base localizer unless it is changed.
+func generateGeneralContext(locs []scope.Localization, decls *[]goast.Decl) {
+	ctxParam := &goast.Field{
+		Names: []*goast.Ident{goast.NewIdent("ctx")},
+		Type: &goast.SelectorExpr{
+			X:   goast.NewIdent("context"),
+			Sel: goast.NewIdent("Context"),
+		},
+	}
+
+	contextKey := &goast.CompositeLit{Type: goast.NewIdent("contextKey")}
+
+	*decls = append(*decls,
+		&goast.GenDecl{
+			Tok: gotoken.TYPE,
+			Specs: []goast.Spec{
+				&goast.TypeSpec{
+					Name: goast.NewIdent("contextKey"),
+					Type: &goast.StructType{Fields: &goast.FieldList{}},
+				},
+			},
+		},
+		&goast.GenDecl{
+			Tok: gotoken.VAR,
+			Specs: []goast.Spec{
+				&goast.ValueSpec{
+					Names: []*goast.Ident{goast.NewIdent("Default")},
+					Type:  goast.NewIdent("Localizer"),
+					Values: []goast.Expr{
+						&goast.CompositeLit{Type: goast.NewIdent(getLocalizerTypeName(&locs[0]))},
+					},
+				},
+			},
+		},
+		&goast.FuncDecl{
+			Name: goast.NewIdent("NewContext"),
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{
+					List: []*goast.Field{
+						ctxParam,
+						{
+							Names: []*goast.Ident{goast.NewIdent("loc")},
+							Type:  goast.NewIdent("Localizer"),
+						},
+					},
+				},
+				Results: &goast.FieldList{
+					List: []*goast.Field{{Type: ctxParam.Type}},
+				},
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ReturnStmt{
+						Results: []goast.Expr{
+							&goast.CallExpr{
+								Fun: &goast.SelectorExpr{
+									X:   goast.NewIdent("context"),
+									Sel: goast.NewIdent("WithValue"),
+								},
+								Args: []goast.Expr{goast.NewIdent("ctx"), contextKey, goast.NewIdent("loc")},
+							},
+						},
+					},
+				},
+			},
+		},
+		&goast.FuncDecl{
+			Name: goast.NewIdent("FromContext"),
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{
+					List: []*goast.Field{ctxParam},
+				},
+				Results: &goast.FieldList{
+					List: []*goast.Field{{Type: goast.NewIdent("Localizer")}},
+				},
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.IfStmt{
+						Init: &goast.AssignStmt{
+							Lhs: []goast.Expr{goast.NewIdent("loc"), goast.NewIdent("ok")},
+							Tok: gotoken.DEFINE,
+							Rhs: []goast.Expr{
+								&goast.TypeAssertExpr{
+									X: &goast.CallExpr{
+										Fun: &goast.SelectorExpr{
+											X:   goast.NewIdent("ctx"),
+											Sel: goast.NewIdent("Value"),
+										},
+										Args: []goast.Expr{contextKey},
+									},
+									Type: goast.NewIdent("Localizer"),
+								},
+							},
+						},
+						Cond: goast.NewIdent("ok"),
+						Body: &goast.BlockStmt{
+							List: []goast.Stmt{
+								&goast.ReturnStmt{
+									Results: []goast.Expr{goast.NewIdent("loc")},
+								},
+							},
+						},
+					},
+					&goast.ReturnStmt{
+						Results: []goast.Expr{goast.NewIdent("Default")},
+					},
+				},
+			},
+		},
+		&goast.FuncDecl{
+			Name: goast.NewIdent("LanguageFromContext"),
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{
+					List: []*goast.Field{ctxParam},
+				},
+				Results: &goast.FieldList{
+					List: []*goast.Field{{Type: goast.NewIdent("string")}},
+				},
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ReturnStmt{
+						Results: []goast.Expr{
+							&goast.CallExpr{
+								Fun: goast.NewIdent("Language"),
+								Args: []goast.Expr{
+									&goast.CallExpr{
+										Fun:  goast.NewIdent("FromContext"),
+										Args: []goast.Expr{goast.NewIdent("ctx")},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	)
+}
+
+func generateMessages(locs []scope.Localization, loc *scope.Localization) (file *goast.File) {
 	file = &goast.File{
 		Name:  goast.NewIdent(common.Config.PackageName),
 		Decls: []goast.Decl{},
 		Doc: &goast.CommentGroup{
 			List: []*goast.Comment{
-				{Text: "// Code generated by go-l10n; DO NOT EDIT."},
+				{Text: GeneratedComment},
 				{Text: ""},
 			},
 		},
@@ -291,7 +979,17 @@ func generateMessages(loc *scope.Localization) (file *goast.File) {
 
 	for i := 0; i < len(loc.Scopes); i++ {
 		ms := &loc.Scopes[i]
-		if ms.IsSimple() {
+
+		// Types of the arguments appear in signatures of the methods
+		for j := 0; j < len(ms.Arguments); j++ {
+			if goType := &ms.Arguments[j].GoType; goType.Import != "" {
+				loc.AddImport(ast.GoImport{Import: goType.Import, Package: goType.Package})
+			}
+		}
+
+		if ms.Fallback != nil {
+			generateFallbackMessage(loc, ms, &decls)
+		} else if ms.IsSimple() {
 			generateSimpleMessage(loc, ms, &decls)
 		} else {
 			generateMessage(loc, ms, &decls)
@@ -301,6 +999,19 @@ func generateMessages(loc *scope.Localization) (file *goast.File) {
 	generateMessagesImportDecl(loc, &file.Decls)
 	generateMessagesTypeDecl(loc, &file.Decls)
 
+	if common.Config.ExportTypes {
+		generateMessagesTypeAssertion(loc, &file.Decls)
+	}
+
+	generateMessagesLangDecl(loc, &file.Decls)
+	generateMessagesNamespaceFuncs(loc, &file.Decls)
+
+	// Base localization is always built, since it is the default one
+	// and the other ones fall back to it
+	if common.Config.BuildTags && loc != &locs[0] {
+		generateMessagesRegistration(locs, loc, file)
+	}
+
 	file.Decls = append(file.Decls, decls...)
 
 	return file
@@ -326,132 +1037,375 @@ func generateMessagesImportDecl(loc *scope.Localization, decls *[]goast.Decl) {
 	}
 }
 
+// Generates localizer type along with types of its namespaces.
 func generateMessagesTypeDecl(loc *scope.Localization, decls *[]goast.Decl) {
 	typeDecl := &goast.GenDecl{
 		Tok: gotoken.TYPE,
-		Specs: []goast.Spec{
-			&goast.TypeSpec{
-				Name: goast.NewIdent(getLocalizerTypeName(loc)),
-				Type: &goast.StructType{
-					Fields: &goast.FieldList{},
-				},
+	}
+
+	namespaces := append([]string{""}, getNamespaces(loc.Scopes)...)
+
+	for _, namespace := range namespaces {
+		typeDecl.Specs = append(typeDecl.Specs, &goast.TypeSpec{
+			Name: goast.NewIdent(getNamespaceTypeName(loc, namespace)),
+			Type: &goast.StructType{
+				Fields: &goast.FieldList{},
 			},
-		},
+		})
 	}
 
 	*decls = append(*decls, typeDecl)
 }
 
-func generateMessage(loc *scope.Localization, ms *scope.MessageScope, decls *[]goast.Decl) {
-	const builderName = "b0"
-
-	loc.AddImport(ast.GoImport{Import: "strings", Package: "strings"})
-
-	builderSpec := &goast.ValueSpec{
-		Names: []*goast.Ident{
-			goast.NewIdent(builderName),
-		},
-		Type: &goast.SelectorExpr{
-			X:   goast.NewIdent("strings"),
-			Sel: goast.NewIdent("Builder"),
-		},
+// Generates methods that return child namespaces of namespaces.
+func generateMessagesNamespaceFuncs(loc *scope.Localization, decls *[]goast.Decl) {
+	for _, namespace := range getNamespaces(loc.Scopes) {
+		*decls = append(*decls, &goast.FuncDecl{
+			Name: goast.NewIdent(getNamespaceFuncName(namespace)),
+			Recv: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent(getLocalizerName(loc))},
+						Type:  goast.NewIdent(getNamespaceTypeName(loc, getNamespaceParent(namespace))),
+					},
+				},
+			},
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{},
+				Results: &goast.FieldList{
+					List: []*goast.Field{
+						{Type: goast.NewIdent(getNamespaceInterfaceName(namespace))},
+					},
+				},
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ReturnStmt{
+						Results: []goast.Expr{
+							&goast.CompositeLit{
+								Type: goast.NewIdent(getNamespaceTypeName(loc, namespace)),
+							},
+						},
+					},
+				},
+			},
+		})
 	}
+}
 
-	funcDecl := &goast.FuncDecl{
-		Name: goast.NewIdent(getMessageFuncName(ms)),
-		Recv: &goast.FieldList{
-			List: []*goast.Field{
-				{
-					Names: []*goast.Ident{goast.NewIdent(getLocalizerName(loc))},
-					Type:  goast.NewIdent(getLocalizerTypeName(loc)),
+// Generates compile-time assertion that the localizer implements Localizer.
+func generateMessagesTypeAssertion(loc *scope.Localization, decls *[]goast.Decl) {
+	*decls = append(*decls, &goast.GenDecl{
+		Tok: gotoken.VAR,
+		Specs: []goast.Spec{
+			&goast.ValueSpec{
+				Names: []*goast.Ident{goast.NewIdent("_")},
+				Type:  goast.NewIdent("Localizer"),
+				Values: []goast.Expr{
+					&goast.CompositeLit{
+						Type: goast.NewIdent(getLocalizerTypeName(loc)),
+					},
 				},
 			},
 		},
-		Type: &goast.FuncType{
-			Params: &goast.FieldList{},
-			Results: &goast.FieldList{
-				List: []*goast.Field{
-					{Type: goast.NewIdent("string")},
+	})
+}
+
+func generateMessagesLangDecl(loc *scope.Localization, decls *[]goast.Decl) {
+	// Language tag is only needed to match plural forms
+	if !slices.ContainsFunc(loc.Imports, func(imp ast.GoImport) bool { return imp.Package == "language" }) {
+		return
+	}
+
+	*decls = append(*decls, &goast.GenDecl{
+		Tok: gotoken.VAR,
+		Specs: []goast.Spec{
+			&goast.ValueSpec{
+				Names: []*goast.Ident{goast.NewIdent(getLanguageVarName(loc))},
+				Values: []goast.Expr{
+					&goast.CallExpr{
+						Fun: &goast.SelectorExpr{
+							X:   goast.NewIdent("language"),
+							Sel: goast.NewIdent("MustParse"),
+						},
+						Args: []goast.Expr{
+							&goast.BasicLit{
+								Kind:  gotoken.STRING,
+								Value: strconv.Quote(loc.Lang.String()),
+							},
+						},
+					},
 				},
 			},
 		},
-		Body: &goast.BlockStmt{
-			List: []goast.Stmt{
-				&goast.DeclStmt{
-					Decl: &goast.GenDecl{
-						Tok:   gotoken.VAR,
-						Specs: []goast.Spec{builderSpec},
-					},
-				},
+	})
+}
+
+// Names of the buffer and the writer parameters of message methods,
+// which can't collide with arguments, since argument names consist of letters only.
+const (
+	bufferName = "b0"
+	writerName = "w0"
+)
+
+// Returns receiver of methods of the message in the localization.
+func getMessageRecv(loc *scope.Localization, ms *scope.MessageScope) *goast.FieldList {
+	return &goast.FieldList{
+		List: []*goast.Field{
+			{
+				Names: []*goast.Ident{goast.NewIdent(getLocalizerName(loc))},
+				Type:  goast.NewIdent(getNamespaceTypeName(loc, getMessageNamespace(ms))),
 			},
 		},
 	}
+}
 
+// Returns parameters of the message method, which come after the given ones.
+func getMessageParams(ms *scope.MessageScope, params ...*goast.Field) *goast.FieldList {
 	for i := 0; i < len(ms.Arguments); i++ {
-		funcDecl.Type.Params.List = append(funcDecl.Type.Params.List, &goast.Field{
+		params = append(params, &goast.Field{
 			Names: []*goast.Ident{goast.NewIdent(ms.Arguments[i].Name)},
 			Type:  getPackageFieldType(&ms.Arguments[i]),
 		})
 	}
+	return &goast.FieldList{List: params}
+}
+
+// Returns arguments of the message as expressions, which come after the given ones.
+func getMessageArgs(ms *scope.MessageScope, args ...goast.Expr) []goast.Expr {
+	for i := 0; i < len(ms.Arguments); i++ {
+		args = append(args, goast.NewIdent(ms.Arguments[i].Name))
+	}
+	return args
+}
+
+func getBufferField() *goast.Field {
+	return &goast.Field{
+		Names: []*goast.Ident{goast.NewIdent(bufferName)},
+		Type:  &goast.ArrayType{Elt: goast.NewIdent("byte")},
+	}
+}
+
+func getWriterField() *goast.Field {
+	return &goast.Field{
+		Names: []*goast.Ident{goast.NewIdent(writerName)},
+		Type: &goast.SelectorExpr{
+			X:   goast.NewIdent("io"),
+			Sel: goast.NewIdent("Writer"),
+		},
+	}
+}
+
+func getMessageResults() *goast.FieldList {
+	return &goast.FieldList{
+		List: []*goast.Field{{Type: goast.NewIdent("string")}},
+	}
+}
+
+func getAppendResults() *goast.FieldList {
+	return &goast.FieldList{
+		List: []*goast.Field{{Type: &goast.ArrayType{Elt: goast.NewIdent("byte")}}},
+	}
+}
+
+func getWriteResults() *goast.FieldList {
+	return &goast.FieldList{
+		List: []*goast.Field{
+			{Type: goast.NewIdent("int")},
+			{Type: goast.NewIdent("error")},
+		},
+	}
+}
+
+// Returns method of the message in the localization that returns the result of the given expression.
+func generateMessageMethod(
+	loc *scope.Localization,
+	ms *scope.MessageScope,
+	name string,
+	funcType *goast.FuncType,
+	results ...goast.Expr,
+) *goast.FuncDecl {
+	return &goast.FuncDecl{
+		Name: goast.NewIdent(name),
+		Recv: getMessageRecv(loc, ms),
+		Type: funcType,
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.ReturnStmt{Results: results},
+			},
+		},
+	}
+}
+
+// Returns call of the method of the localization.
+func generateMethodCall(loc *scope.Localization, name string, args []goast.Expr) *goast.CallExpr {
+	return &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   goast.NewIdent(getLocalizerName(loc)),
+			Sel: goast.NewIdent(name),
+		},
+		Args: args,
+	}
+}
+
+// Returns buffer that fits the text of the message along with its arguments,
+// so that it doesn't have to grow unless arguments are long.
+func generateBuffer(ms *scope.MessageScope) goast.Expr {
+	n := max(estimateLength(ms, &ms.Plural), estimateLength(ms, ms.String))
+	if n == 0 {
+		return goast.NewIdent("nil")
+	}
+
+	return &goast.CallExpr{
+		Fun: goast.NewIdent("make"),
+		Args: []goast.Expr{
+			&goast.ArrayType{Elt: goast.NewIdent("byte")},
+			&goast.BasicLit{Kind: gotoken.INT, Value: "0"},
+			&goast.BasicLit{Kind: gotoken.INT, Value: strconv.Itoa(n)},
+		},
+	}
+}
+
+// Generates methods of the message, whose text is built when they are called.
+// The text is built by AppendX, which appends it to the buffer,
+// while the method that returns it as a string and WriteX,
+// which writes it with a buffer taken from the pool, call AppendX.
+func generateMessage(loc *scope.Localization, ms *scope.MessageScope, decls *[]goast.Decl) {
+	loc.AddImport(ast.GoImport{Import: "io", Package: "io"})
+
+	appendDecl := &goast.FuncDecl{
+		Name: goast.NewIdent(getAppendFuncName(ms)),
+		Recv: getMessageRecv(loc, ms),
+		Type: &goast.FuncType{
+			Params:  getMessageParams(ms, getBufferField()),
+			Results: getAppendResults(),
+		},
+		Body: &goast.BlockStmt{},
+	}
 
 	values := []ast.Value{&ms.Plural, ms.String}
 
 	for _, val := range values {
 		if !val.IsZero() {
-			generateValue(loc, ms, val, builderName, &funcDecl.Body.List)
+			generateValue(loc, ms, val, bufferName, &appendDecl.Body.List)
 			break
 		}
 	}
 
-	funcDecl.Body.List = append(funcDecl.Body.List, &goast.ReturnStmt{
-		Results: []goast.Expr{
-			&goast.CallExpr{
-				Fun: &goast.SelectorExpr{
-					X:   goast.NewIdent(builderName),
-					Sel: goast.NewIdent("String"),
-				},
-			},
-		},
+	appendDecl.Body.List = append(appendDecl.Body.List, &goast.ReturnStmt{
+		Results: []goast.Expr{goast.NewIdent(bufferName)},
 	})
 
 	for i := 0; i < len(ms.Variables); i++ {
 		generateVariableFunc(loc, ms, &ms.Variables[i], decls)
 	}
 
-	*decls = append(*decls, funcDecl)
+	funcDecl := generateMessageMethod(loc, ms, getMessageFuncName(ms),
+		&goast.FuncType{
+			Params:  getMessageParams(ms),
+			Results: getMessageResults(),
+		},
+		&goast.CallExpr{
+			Fun: goast.NewIdent("string"),
+			Args: []goast.Expr{
+				generateMethodCall(loc, getAppendFuncName(ms), getMessageArgs(ms, generateBuffer(ms))),
+			},
+		},
+	)
+
+	// Buffer is taken from the pool and put back after it is written
+	const countName, errName = "n0", "err0"
+
+	buffer := &goast.StarExpr{X: goast.NewIdent(bufferName)}
+
+	writeDecl := &goast.FuncDecl{
+		Name: goast.NewIdent(getWriteFuncName(ms)),
+		Recv: getMessageRecv(loc, ms),
+		Type: &goast.FuncType{
+			Params:  getMessageParams(ms, getWriterField()),
+			Results: getWriteResults(),
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{goast.NewIdent(bufferName)},
+					Tok: gotoken.DEFINE,
+					Rhs: []goast.Expr{
+						&goast.TypeAssertExpr{
+							X: &goast.CallExpr{
+								Fun: &goast.SelectorExpr{
+									X:   goast.NewIdent("buffers"),
+									Sel: goast.NewIdent("Get"),
+								},
+							},
+							Type: &goast.StarExpr{
+								X: &goast.ArrayType{Elt: goast.NewIdent("byte")},
+							},
+						},
+					},
+				},
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{buffer},
+					Tok: gotoken.ASSIGN,
+					Rhs: []goast.Expr{
+						generateMethodCall(loc, getAppendFuncName(ms), getMessageArgs(ms,
+							&goast.SliceExpr{
+								X:    &goast.ParenExpr{X: buffer},
+								High: &goast.BasicLit{Kind: gotoken.INT, Value: "0"},
+							},
+						)),
+					},
+				},
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{goast.NewIdent(countName), goast.NewIdent(errName)},
+					Tok: gotoken.DEFINE,
+					Rhs: []goast.Expr{
+						&goast.CallExpr{
+							Fun: &goast.SelectorExpr{
+								X:   goast.NewIdent(writerName),
+								Sel: goast.NewIdent("Write"),
+							},
+							Args: []goast.Expr{buffer},
+						},
+					},
+				},
+				&goast.ExprStmt{
+					X: &goast.CallExpr{
+						Fun: &goast.SelectorExpr{
+							X:   goast.NewIdent("buffers"),
+							Sel: goast.NewIdent("Put"),
+						},
+						Args: []goast.Expr{goast.NewIdent(bufferName)},
+					},
+				},
+				&goast.ReturnStmt{
+					Results: []goast.Expr{goast.NewIdent(countName), goast.NewIdent(errName)},
+				},
+			},
+		},
+	}
+
+	*decls = append(*decls, funcDecl, appendDecl, writeDecl)
 }
 
+// Generates methods of the message, whose text doesn't depend on arguments
+// or only depends on the plural form. Such texts are constant strings,
+// so AppendX and WriteX use the method that returns them.
 func generateSimpleMessage(loc *scope.Localization, ms *scope.MessageScope, decls *[]goast.Decl) {
+	loc.AddImport(ast.GoImport{Import: "io", Package: "io"})
+
 	funcDecl := &goast.FuncDecl{
 		Name: goast.NewIdent(getMessageFuncName(ms)),
-		Recv: &goast.FieldList{
-			List: []*goast.Field{
-				{
-					Names: []*goast.Ident{goast.NewIdent(getLocalizerName(loc))},
-					Type:  goast.NewIdent(getLocalizerTypeName(loc)),
-				},
-			},
-		},
+		Recv: getMessageRecv(loc, ms),
 		Type: &goast.FuncType{
-			Params: &goast.FieldList{},
-			Results: &goast.FieldList{
-				List: []*goast.Field{
-					{Type: goast.NewIdent("string")},
-				},
-			},
+			Params:  getMessageParams(ms),
+			Results: getMessageResults(),
 		},
 		Body: &goast.BlockStmt{
 			List: []goast.Stmt{},
 		},
 	}
 
-	for i := 0; i < len(ms.Arguments); i++ {
-		funcDecl.Type.Params.List = append(funcDecl.Type.Params.List, &goast.Field{
-			Names: []*goast.Ident{goast.NewIdent(ms.Arguments[i].Name)},
-			Type:  getPackageFieldType(&ms.Arguments[i]),
-		})
-	}
-
 	values := []ast.Value{&ms.Plural, ms.String}
 
 	for _, val := range values {
@@ -465,7 +1419,79 @@ func generateSimpleMessage(loc *scope.Localization, ms *scope.MessageScope, decl
 		generateVariableFunc(loc, ms, &ms.Variables[i], decls)
 	}
 
-	*decls = append(*decls, funcDecl)
+	text := generateMethodCall(loc, getMessageFuncName(ms), getMessageArgs(ms))
+
+	*decls = append(*decls,
+		funcDecl,
+		generateMessageMethod(loc, ms, getAppendFuncName(ms),
+			&goast.FuncType{
+				Params:  getMessageParams(ms, getBufferField()),
+				Results: getAppendResults(),
+			},
+			&goast.CallExpr{
+				Fun:      goast.NewIdent("append"),
+				Args:     []goast.Expr{goast.NewIdent(bufferName), text},
+				Ellipsis: 1,
+			},
+		),
+		generateMessageMethod(loc, ms, getWriteFuncName(ms),
+			&goast.FuncType{
+				Params:  getMessageParams(ms, getWriterField()),
+				Results: getWriteResults(),
+			},
+			&goast.CallExpr{
+				Fun: &goast.SelectorExpr{
+					X:   goast.NewIdent("io"),
+					Sel: goast.NewIdent("WriteString"),
+				},
+				Args: []goast.Expr{goast.NewIdent(writerName), text},
+			},
+		),
+	)
+}
+
+// Generates methods of the message that is missing in the localization,
+// which call the methods of the fallback localization.
+func generateFallbackMessage(loc *scope.Localization, ms *scope.MessageScope, decls *[]goast.Decl) {
+	loc.AddImport(ast.GoImport{Import: "io", Package: "io"})
+
+	fallback := &goast.CompositeLit{
+		Type: goast.NewIdent(getNamespaceTypeName(ms.Fallback, getMessageNamespace(ms))),
+	}
+
+	fallbackCall := func(name string, args []goast.Expr) *goast.CallExpr {
+		return &goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   fallback,
+				Sel: goast.NewIdent(name),
+			},
+			Args: args,
+		}
+	}
+
+	*decls = append(*decls,
+		generateMessageMethod(loc, ms, getMessageFuncName(ms),
+			&goast.FuncType{
+				Params:  getMessageParams(ms),
+				Results: getMessageResults(),
+			},
+			fallbackCall(getMessageFuncName(ms), getMessageArgs(ms)),
+		),
+		generateMessageMethod(loc, ms, getAppendFuncName(ms),
+			&goast.FuncType{
+				Params:  getMessageParams(ms, getBufferField()),
+				Results: getAppendResults(),
+			},
+			fallbackCall(getAppendFuncName(ms), getMessageArgs(ms, goast.NewIdent(bufferName))),
+		),
+		generateMessageMethod(loc, ms, getWriteFuncName(ms),
+			&goast.FuncType{
+				Params:  getMessageParams(ms, getWriterField()),
+				Results: getWriteResults(),
+			},
+			fallbackCall(getWriteFuncName(ms), getMessageArgs(ms, goast.NewIdent(writerName))),
+		),
+	)
 }
 
 func generatePlural(
@@ -475,21 +1501,50 @@ func generatePlural(
 	builderName string,
 	list *[]goast.Stmt,
 ) {
+	const formName = "f0"
+
 	values := []struct {
-		Value  ast.Value
-		Op     gotoken.Token
-		Number string
+		Value ast.Value
+		Name  string
+		Form  string
 	}{
-		{plural.Zero, gotoken.EQL, "0"},
-		{plural.One, gotoken.EQL, "1"},
-		{plural.Many, gotoken.GTR, "1"},
-		{plural.Other, gotoken.ILLEGAL, ""},
+		{plural.Zero, "zero", "Zero"},
+		{plural.One, "one", "One"},
+		{plural.Two, "two", "Two"},
+		{plural.Few, "few", "Few"},
+		{plural.Many, "many", "Many"},
+		{plural.Other, "other", ""},
 	}
 
+	forms := scope.PluralForms(loc.Lang)
+	// Number of cases that match plural form
+	formCases := 0
+
 	switchStmt := &goast.SwitchStmt{
 		Body: &goast.BlockStmt{},
 	}
 
+	// Exact forms go first, as they take precedence over the plural forms
+	for i := 0; i < len(plural.Exact); i++ {
+		form := &plural.Exact[i]
+
+		caseClause := &goast.CaseClause{
+			List: []goast.Expr{
+				&goast.BinaryExpr{
+					X:  goast.NewIdent(plural.Arg),
+					Op: gotoken.EQL,
+					Y: &goast.BasicLit{
+						Kind:  gotoken.INT,
+						Value: strconv.Itoa(form.Number),
+					},
+				},
+			},
+		}
+
+		generateValue(loc, ms, form.Parts, builderName, &caseClause.Body)
+		switchStmt.Body.List = append(switchStmt.Body.List, caseClause)
+	}
+
 	for _, value := range values {
 		if value.Value.IsZero() {
 			continue
@@ -497,23 +1552,108 @@ func generatePlural(
 
 		caseClause := &goast.CaseClause{}
 
-		if value.Op != gotoken.ILLEGAL {
+		switch {
+		case value.Form == "":
+			// Leave "other" as the default case
+		case value.Name == "zero" && !slices.Contains(forms, "zero") && plural.ExactForm(0) != nil:
+			// Zero is matched by the exact form already
+			continue
+		case value.Name == "zero" && !slices.Contains(forms, "zero"):
+			// If the language doesn't use "zero" form, it simply matches zero
 			caseClause.List = []goast.Expr{
 				&goast.BinaryExpr{
 					X:  goast.NewIdent(plural.Arg),
-					Op: value.Op,
+					Op: gotoken.EQL,
 					Y: &goast.BasicLit{
 						Kind:  gotoken.INT,
-						Value: value.Number,
+						Value: "0",
+					},
+				},
+			}
+		default:
+			formCases++
+			loc.AddImport(ast.GoImport{Import: "golang.org/x/text/feature/plural", Package: "plural"})
+			caseClause.List = []goast.Expr{
+				&goast.BinaryExpr{
+					X:  goast.NewIdent(formName),
+					Op: gotoken.EQL,
+					Y: &goast.SelectorExpr{
+						X:   goast.NewIdent("plural"),
+						Sel: goast.NewIdent(value.Form),
 					},
 				},
 			}
+
+			if switchStmt.Init == nil {
+				loc.AddImport(ast.GoImport{Import: "golang.org/x/text/language", Package: "language"})
+				switchStmt.Init = &goast.AssignStmt{
+					Lhs: []goast.Expr{goast.NewIdent(formName)},
+					Tok: gotoken.DEFINE,
+					Rhs: []goast.Expr{
+						&goast.CallExpr{
+							Fun: goast.NewIdent("pluralForm"),
+							Args: []goast.Expr{
+								goast.NewIdent(getLanguageVarName(loc)),
+								goast.NewIdent(plural.Arg),
+							},
+						},
+					},
+				}
+			}
 		}
 
 		generateValue(loc, ms, value.Value, builderName, &caseClause.Body)
 		switchStmt.Body.List = append(switchStmt.Body.List, caseClause)
 	}
 
+	// If "other" is not specified, all the forms used by the language are,
+	// so the last one can safely become the default case
+	if plural.Other == nil {
+		lastClause := switchStmt.Body.List[len(switchStmt.Body.List)-1].(*goast.CaseClause)
+		lastClause.List = nil
+
+		if formCases == 1 {
+			switchStmt.Init = nil
+		}
+	}
+
+	*list = append(*list, switchStmt)
+}
+
+// Generates switch on the argument of the select,
+// whose "other" text is the default case.
+func generateSelect(
+	loc *scope.Localization,
+	ms *scope.MessageScope,
+	sel *ast.Select,
+	builderName string,
+	list *[]goast.Stmt,
+) {
+	switchStmt := &goast.SwitchStmt{
+		Tag:  goast.NewIdent(sel.Arg),
+		Body: &goast.BlockStmt{},
+	}
+
+	for i := 0; i < len(sel.Cases); i++ {
+		c := &sel.Cases[i]
+
+		caseClause := &goast.CaseClause{
+			List: []goast.Expr{
+				&goast.BasicLit{
+					Kind:  gotoken.STRING,
+					Value: strconv.Quote(c.Value),
+				},
+			},
+		}
+
+		generateValue(loc, ms, c.Parts, builderName, &caseClause.Body)
+		switchStmt.Body.List = append(switchStmt.Body.List, caseClause)
+	}
+
+	caseClause := &goast.CaseClause{}
+	generateValue(loc, ms, sel.Other, builderName, &caseClause.Body)
+	switchStmt.Body.List = append(switchStmt.Body.List, caseClause)
+
 	*list = append(*list, switchStmt)
 }
 
@@ -567,6 +1707,24 @@ func generateSimpleFormatParts(
 	})
 }
 
+// Returns statement that appends the result of the expression to the buffer.
+func generateAppendStmt(builderName string, expr goast.Expr) *goast.AssignStmt {
+	return &goast.AssignStmt{
+		Lhs: []goast.Expr{goast.NewIdent(builderName)},
+		Tok: gotoken.ASSIGN,
+		Rhs: []goast.Expr{expr},
+	}
+}
+
+// Returns call of append that appends the string to the buffer.
+func generateAppendString(builderName string, str goast.Expr) *goast.CallExpr {
+	return &goast.CallExpr{
+		Fun:      goast.NewIdent("append"),
+		Args:     []goast.Expr{goast.NewIdent(builderName), str},
+		Ellipsis: 1,
+	}
+}
+
 func generateText(
 	_ *scope.Localization,
 	_ *scope.MessageScope,
@@ -574,20 +1732,12 @@ func generateText(
 	builderName string,
 	list *[]goast.Stmt,
 ) {
-	*list = append(*list, &goast.ExprStmt{
-		X: &goast.CallExpr{
-			Fun: &goast.SelectorExpr{
-				X:   goast.NewIdent(builderName),
-				Sel: goast.NewIdent("WriteString"),
-			},
-			Args: []goast.Expr{
-				&goast.BasicLit{
-					Kind:  gotoken.STRING,
-					Value: strconv.Quote(string(text)),
-				},
-			},
+	*list = append(*list, generateAppendStmt(builderName, generateAppendString(builderName,
+		&goast.BasicLit{
+			Kind:  gotoken.STRING,
+			Value: strconv.Quote(string(text)),
 		},
-	})
+	)))
 }
 
 func generateArgument(
@@ -598,122 +1748,124 @@ func generateArgument(
 	builderName string,
 	list *[]goast.Stmt,
 ) {
-	callExpr := &goast.CallExpr{
-		Fun: &goast.SelectorExpr{
-			X:   goast.NewIdent(builderName),
-			Sel: goast.NewIdent("WriteString"),
-		},
-	}
-
-	*list = append(*list, &goast.ExprStmt{
-		X: callExpr,
-	})
-
-	if info.FmtInfo.HasOptions() {
-		generateArgumentSprintf(loc, arg, info, callExpr)
-		return
-	}
-
-	switch arg.GoType.Type {
-	case "string":
-		callExpr.Args = []goast.Expr{goast.NewIdent(arg.Name)}
-	case "int":
-		generateArgumentItoa(loc, arg, callExpr)
-	case "float64":
-		generateArgumentFormatFloat(loc, arg, callExpr)
-	case "Stringer":
-		generateArgumentStringer(loc, arg, callExpr)
+	var expr goast.Expr
+
+	format, compiled := compileFormat(arg.GoType, &info.FmtInfo)
+
+	switch {
+	case info.FmtInfo.HasOptions() && compiled:
+		expr = generateCompiledFormat(loc, arg, &format, builderName)
+	case info.FmtInfo.HasOptions():
+		expr = generateArgumentAppendf(loc, arg, info, builderName)
+	case arg.GoType.Type == "string":
+		expr = generateAppendString(builderName, goast.NewIdent(arg.Name))
+	case arg.GoType.Type == "int":
+		expr = generateArgumentAppendInt(loc, arg, builderName)
+	case arg.GoType.Type == "float64":
+		expr = generateArgumentAppendFloat(loc, arg, builderName)
+	case arg.GoType.Type == "Stringer":
+		expr = generateArgumentStringer(loc, arg, builderName)
 	default:
-		generateArgumentSprint(loc, arg, callExpr)
+		expr = generateArgumentAppend(loc, arg, builderName)
 	}
+
+	*list = append(*list, generateAppendStmt(builderName, expr))
 }
 
-func generateArgumentStringer(_ *scope.Localization, arg *scope.Argument, callExpr *goast.CallExpr) {
-	callExpr.Args = []goast.Expr{
-		&goast.CallExpr{
-			Fun: &goast.SelectorExpr{
-				X:   goast.NewIdent(arg.Name),
-				Sel: goast.NewIdent("String"),
-			},
+func generateArgumentStringer(_ *scope.Localization, arg *scope.Argument, builderName string) goast.Expr {
+	return generateAppendString(builderName, &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   goast.NewIdent(arg.Name),
+			Sel: goast.NewIdent("String"),
 		},
-	}
+	})
 }
 
-func generateArgumentSprintf(
+func generateArgumentAppendf(
 	loc *scope.Localization,
 	arg *scope.Argument,
 	info *ast.ArgInfo,
-	callExpr *goast.CallExpr,
-) {
+	builderName string,
+) goast.Expr {
 	fmtStr := info.FmtInfo.GoFormat(arg.GoType)
 	loc.AddImport(ast.GoImport{Import: "fmt", Package: "fmt"})
 
-	callExpr.Args = []goast.Expr{
-		&goast.CallExpr{
-			Fun: &goast.SelectorExpr{
-				X:   goast.NewIdent("fmt"),
-				Sel: goast.NewIdent("Sprintf"),
-			},
-			Args: []goast.Expr{
-				&goast.BasicLit{
-					Kind:  gotoken.STRING,
-					Value: strconv.Quote(fmtStr),
-				},
-				goast.NewIdent(arg.Name),
+	return &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   goast.NewIdent("fmt"),
+			Sel: goast.NewIdent("Appendf"),
+		},
+		Args: []goast.Expr{
+			goast.NewIdent(builderName),
+			&goast.BasicLit{
+				Kind:  gotoken.STRING,
+				Value: strconv.Quote(fmtStr),
 			},
+			goast.NewIdent(arg.Name),
 		},
 	}
 }
 
-func generateArgumentItoa(loc *scope.Localization, arg *scope.Argument, callExpr *goast.CallExpr) {
+func generateArgumentAppendInt(loc *scope.Localization, arg *scope.Argument, builderName string) goast.Expr {
 	loc.AddImport(ast.GoImport{Import: "strconv", Package: "strconv"})
-	callExpr.Args = []goast.Expr{
-		&goast.CallExpr{
-			Fun: &goast.SelectorExpr{
-				X:   goast.NewIdent("strconv"),
-				Sel: goast.NewIdent("Itoa"),
+
+	return &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   goast.NewIdent("strconv"),
+			Sel: goast.NewIdent("AppendInt"),
+		},
+		Args: []goast.Expr{
+			goast.NewIdent(builderName),
+			&goast.CallExpr{
+				Fun:  goast.NewIdent("int64"),
+				Args: []goast.Expr{goast.NewIdent(arg.Name)},
+			},
+			&goast.BasicLit{
+				Kind:  gotoken.INT,
+				Value: `10`,
 			},
-			Args: []goast.Expr{goast.NewIdent(arg.Name)},
 		},
 	}
 }
 
-func generateArgumentFormatFloat(loc *scope.Localization, arg *scope.Argument, callExpr *goast.CallExpr) {
+func generateArgumentAppendFloat(loc *scope.Localization, arg *scope.Argument, builderName string) goast.Expr {
 	loc.AddImport(ast.GoImport{Import: "strconv", Package: "strconv"})
-	callExpr.Args = []goast.Expr{
-		&goast.CallExpr{
-			Fun: &goast.SelectorExpr{
-				X:   goast.NewIdent("strconv"),
-				Sel: goast.NewIdent("FormatFloat"),
+
+	return &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   goast.NewIdent("strconv"),
+			Sel: goast.NewIdent("AppendFloat"),
+		},
+		Args: []goast.Expr{
+			goast.NewIdent(builderName),
+			goast.NewIdent(arg.Name),
+			&goast.BasicLit{
+				Kind:  gotoken.CHAR,
+				Value: `'f'`,
 			},
-			Args: []goast.Expr{
-				goast.NewIdent(arg.Name),
-				&goast.BasicLit{
-					Kind:  gotoken.CHAR,
-					Value: `'f'`,
-				},
-				&goast.BasicLit{
-					Kind:  gotoken.INT,
-					Value: `6`,
-				},
-				&goast.BasicLit{
-					Kind:  gotoken.INT,
-					Value: `64`,
-				},
+			&goast.BasicLit{
+				Kind:  gotoken.INT,
+				Value: `6`,
+			},
+			&goast.BasicLit{
+				Kind:  gotoken.INT,
+				Value: `64`,
 			},
 		},
 	}
 }
 
-func generateArgumentSprint(loc *scope.Localization, arg *scope.Argument, callExpr *goast.CallExpr) {
+func generateArgumentAppend(loc *scope.Localization, arg *scope.Argument, builderName string) goast.Expr {
 	loc.AddImport(ast.GoImport{Import: "fmt", Package: "fmt"})
-	callExpr.Args = []goast.Expr{
-		&goast.CallExpr{
-			Fun: &goast.SelectorExpr{
-				X:   goast.NewIdent("fmt"),
-				Sel: goast.NewIdent("Sprint"),
-			},
-			Args: []goast.Expr{goast.NewIdent(arg.Name)},
+
+	return &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   goast.NewIdent("fmt"),
+			Sel: goast.NewIdent("Append"),
+		},
+		Args: []goast.Expr{
+			goast.NewIdent(builderName),
+			goast.NewIdent(arg.Name),
 		},
 	}
 }
@@ -730,62 +1882,36 @@ func generateVariableCall(
 			X:   goast.NewIdent(getLocalizerName(loc)),
 			Sel: goast.NewIdent(getVariableFuncName(ms, variable)),
 		},
-		Args: []goast.Expr{
-			&goast.UnaryExpr{
-				Op: gotoken.AND,
-				X:  goast.NewIdent(builderName),
-			},
-		},
+		Args: []goast.Expr{goast.NewIdent(builderName)},
 	}
 
 	for _, name := range variable.ArgumentNames {
 		callExpr.Args = append(callExpr.Args, goast.NewIdent(name))
 	}
 
-	*list = append(*list, &goast.ExprStmt{
-		X: callExpr,
-	})
+	*list = append(*list, generateAppendStmt(builderName, callExpr))
 }
 
+// Generates method that appends value of the variable to the buffer of the message.
 func generateVariableFunc(
 	loc *scope.Localization,
 	ms *scope.MessageScope,
 	variable *scope.VariableScope,
 	decls *[]goast.Decl,
 ) {
-	const builderName = "b0"
-
-	builderField := &goast.Field{
-		Names: []*goast.Ident{
-			goast.NewIdent(builderName),
-		},
-		Type: &goast.StarExpr{
-			X: &goast.SelectorExpr{
-				X:   goast.NewIdent("strings"),
-				Sel: goast.NewIdent("Builder"),
-			},
-		},
-	}
-
 	funcDecl := &goast.FuncDecl{
 		Name: goast.NewIdent(getVariableFuncName(ms, variable)),
-		Recv: &goast.FieldList{
-			List: []*goast.Field{
-				{
-					Names: []*goast.Ident{goast.NewIdent(getLocalizerName(loc))},
-					Type:  goast.NewIdent(getLocalizerTypeName(loc)),
-				},
-			},
-		},
+		Recv: getMessageRecv(loc, ms),
 		Type: &goast.FuncType{
 			Params: &goast.FieldList{
-				List: []*goast.Field{builderField},
+				List: []*goast.Field{getBufferField()},
 			},
+			Results: getAppendResults(),
 		},
 		Body: &goast.BlockStmt{},
 	}
 
-	values := []ast.Value{&variable.Plural, variable.String}
+	values := []ast.Value{&variable.Plural, &variable.Select, variable.String}
 
 	for _, value := range values {
 		if value.IsZero() {
@@ -800,9 +1926,13 @@ func generateVariableFunc(
 			})
 		}
 
-		generateValue(loc, ms, value, builderName, &funcDecl.Body.List)
+		generateValue(loc, ms, value, bufferName, &funcDecl.Body.List)
 	}
 
+	funcDecl.Body.List = append(funcDecl.Body.List, &goast.ReturnStmt{
+		Results: []goast.Expr{goast.NewIdent(bufferName)},
+	})
+
 	*decls = append(*decls, funcDecl)
 }
 
@@ -817,20 +1947,189 @@ func getPackageFieldType(arg *scope.Argument) goast.Expr {
 	}
 }
 
+// Names of exported identifiers declared in the general file.
+var reservedNames = []string{
+	"Localizer",
+	"Supported",
+	"Matcher",
+	"New",
+	"Match",
+	"FromAcceptLanguage",
+	"Language",
+	"Default",
+	"NewContext",
+	"FromContext",
+	"LanguageFromContext",
+	"Middleware",
+	"MiddlewareOption",
+	"WithQueryParam",
+	"WithCookie",
+	"MessageID",
+	"ParseMessageID",
+	"Translate",
+	"ErrUnknownMessage",
+	"ErrInvalidArgument",
+	"Message",
+}
+
+// Returns language tag of the localization as an identifier.
+// Subtags are separated with underscores: pt_BR, zh_Hant.
+func getLanguageIdent(loc *scope.Localization) string {
+	return strings.ReplaceAll(loc.Lang.String(), "-", "_")
+}
+
+// Returns language tag of the localization as an exported identifier.
+// Subtags are capitalized and joined together: PtBR, ZhHant, Es419.
+func getLanguageExportedIdent(loc *scope.Localization) string {
+	var b strings.Builder
+
+	for _, subtag := range strings.Split(loc.Lang.String(), "-") {
+		b.WriteString(strings.ToUpper(subtag[:1]))
+		b.WriteString(subtag[1:])
+	}
+
+	ident := b.String()
+
+	// Names of the languages like "new" (Newari)
+	// can collide with other exported identifiers
+	if slices.Contains(reservedNames, ident) {
+		ident += "_"
+	}
+
+	return ident
+}
+
 func getLocalizerName(loc *scope.Localization) string {
-	return loc.Lang.String() + "_l"
+	return getLanguageIdent(loc) + "_l"
 }
 
 func getLocalizerTypeName(loc *scope.Localization) string {
-	return loc.Lang.String() + "_Localizer"
+	if common.Config.ExportTypes {
+		return getLanguageExportedIdent(loc)
+	}
+	return getLanguageIdent(loc) + "_Localizer"
+}
+
+func getLanguageVarName(loc *scope.Localization) string {
+	return getLanguageIdent(loc) + "_lang"
 }
 
+// Returns name of the method of the message without its namespace.
 func getMessageFuncName(ms *scope.MessageScope) string {
-	return ms.Name
+	return ms.Name[strings.LastIndexByte(ms.Name, '.')+1:]
+}
+
+// Returns name of the method that appends the message to a buffer.
+func getAppendFuncName(ms *scope.MessageScope) string {
+	return "Append" + getMessageFuncName(ms)
+}
+
+// Returns name of the method that writes the message to a writer.
+func getWriteFuncName(ms *scope.MessageScope) string {
+	return "Write" + getMessageFuncName(ms)
 }
 
 func getVariableFuncName(ms *scope.MessageScope, variable *scope.VariableScope) string {
-	return ms.Name + "_" + variable.Name
+	return getMessageFuncName(ms) + "_" + variable.Name
+}
+
+// Returns namespace of the message, which is empty for top-level messages.
+func getMessageNamespace(ms *scope.MessageScope) string {
+	return getNamespaceParent(ms.Name)
+}
+
+// Returns namespace that contains the given one,
+// which is empty for top-level namespaces.
+func getNamespaceParent(namespace string) string {
+	idx := strings.LastIndexByte(namespace, '.')
+	if idx == -1 {
+		return ""
+	}
+	return namespace[:idx]
+}
+
+// Returns sorted names of all non-empty namespaces of the messages.
+func getNamespaces(msgs []scope.MessageScope) (namespaces []string) {
+	for i := 0; i < len(msgs); i++ {
+		for namespace := getMessageNamespace(&msgs[i]); namespace != ""; namespace = getNamespaceParent(namespace) {
+			if !slices.Contains(namespaces, namespace) {
+				namespaces = append(namespaces, namespace)
+			}
+		}
+	}
+
+	slices.Sort(namespaces)
+
+	return namespaces
+}
+
+// Returns name of the method that returns the namespace.
+func getNamespaceFuncName(namespace string) string {
+	return namespace[strings.LastIndexByte(namespace, '.')+1:]
+}
+
+// Returns name of the interface of the namespace: Localizer_Auth_Login.
+func getNamespaceInterfaceName(namespace string) string {
+	if namespace == "" {
+		return "Localizer"
+	}
+	return "Localizer_" + strings.ReplaceAll(namespace, ".", "_")
+}
+
+// Returns name of the type that implements the namespace in the localization.
+func getNamespaceTypeName(loc *scope.Localization, namespace string) string {
+	if namespace == "" {
+		return getLocalizerTypeName(loc)
+	}
+	return getLocalizerTypeName(loc) + "_" + strings.ReplaceAll(namespace, ".", "_")
+}
+
+// Identifier of the generated package that is made of the name of a namespace or a message.
+type Identifier struct {
+	Name string
+	// Name of the namespace or the message
+	Source string
+	// Location of the message, or of the first message of the namespace
+	Location ast.Location
+}
+
+// Returns identifiers of the generated package that are made of names of namespaces
+// and messages of the localization. They can collide, since dots of the names
+// are replaced with underscores: both "Auth.Login" and "Auth_Login" namespaces
+// have Localizer_Auth_Login interface.
+func GetIdentifiers(loc *scope.Localization) (idents []Identifier) {
+	for _, namespace := range getNamespaces(loc.Scopes) {
+		var location ast.Location
+		for i := 0; i < len(loc.Scopes); i++ {
+			if strings.HasPrefix(loc.Scopes[i].Name, namespace+".") {
+				location = loc.Scopes[i].Location
+				break
+			}
+		}
+
+		names := []string{
+			getNamespaceInterfaceName(namespace),
+			getNamespaceTypeName(loc, namespace),
+		}
+
+		if common.Config.Live {
+			names = append(names, getLiveTypeName(namespace))
+		}
+
+		for _, name := range names {
+			idents = append(idents, Identifier{Name: name, Source: namespace, Location: location})
+		}
+	}
+
+	for i := 0; i < len(loc.Scopes); i++ {
+		ms := &loc.Scopes[i]
+		idents = append(idents,
+			Identifier{Name: getMessageIDName(ms), Source: ms.Name, Location: ms.Location},
+			Identifier{Name: getMessageTypeName(ms), Source: ms.Name, Location: ms.Location},
+		)
+	}
+
+	return idents
 }
 
 func generateValue(
@@ -843,6 +2142,8 @@ func generateValue(
 	switch v := value.(type) {
 	case *ast.Plural:
 		generatePlural(loc, ms, v, builderName, list)
+	case *ast.Select:
+		generateSelect(loc, ms, v, builderName, list)
 	case ast.FormatParts:
 		generateFormatParts(loc, ms, v, builderName, list)
 	}
@@ -0,0 +1,543 @@
+package codegen
+
+import (
+	goast "go/ast"
+	gotoken "go/token"
+	"slices"
+	"strconv"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/scope"
+)
+
+// Argument format that is compiled into strconv calls and padding instead of fmt.Appendf.
+type compiledFormat struct {
+	// Verb of the format with the defaults applied: s, d, x, o, b, e, E, f, g or G
+	Verb rune
+	// Precision of floating-point numbers, -1 for the smallest number of digits
+	Prec int
+	// Sign that is written before non-negative numbers: '+', ' ' or 0 for none
+	Sign byte
+	// Width of the argument or 0 if it isn't padded
+	Width int
+	// Pad on the right with spaces
+	Left bool
+	// Pad numbers with zeros after the sign
+	Zero bool
+}
+
+// Bases of integers for their verbs.
+var intVerbBases = map[rune]int{
+	'd': 10,
+	'x': 16,
+	'o': 8,
+	'b': 2,
+}
+
+// Compiles the format of the argument, if it is one of the common forms.
+// Strings and fmt.Stringers support width and '-' flag,
+// integers and floating-point numbers also support '+', ' ' and '0' flags,
+// and floating-point numbers support precision too.
+// Everything else, like '#' flag, precision of integers or %X verb, is left to fmt.
+func compileFormat(goType ast.GoType, info *ast.FmtInfo) (format compiledFormat, ok bool) {
+	switch goType.String() {
+	case "string", "fmt.Stringer":
+		format.Verb = 's'
+	case "int":
+		format.Verb = 'd'
+	case "float64":
+		format.Verb = 'f'
+	default:
+		return compiledFormat{}, false
+	}
+
+	// Precision of integers and strings means something else
+	if info.Prec.Valid && format.Verb != 'f' {
+		return compiledFormat{}, false
+	}
+
+	if info.Mod.Valid {
+		switch verb := info.Mod.Value; {
+		case verb == 'v' && format.Verb == 'f':
+			format.Verb = 'g'
+		case verb == 'v', verb == format.Verb:
+		case format.Verb == 'd' && intVerbBases[verb] != 0:
+			format.Verb = verb
+		case format.Verb == 'f' && slices.Contains([]rune{'e', 'E', 'g', 'G'}, verb):
+			format.Verb = verb
+		case format.Verb == 'f' && verb == 'F':
+		default:
+			return compiledFormat{}, false
+		}
+	}
+
+	if format.Verb == 'e' || format.Verb == 'E' || format.Verb == 'f' {
+		format.Prec = 6
+	} else {
+		format.Prec = -1
+	}
+
+	if info.Prec.Valid {
+		format.Prec = info.Prec.Value
+	}
+
+	if info.Width.Valid {
+		format.Width = info.Width.Value
+	}
+
+	// fmt uses '+' flag of %v verb for field names of structs, not for signs of numbers
+	plusV := info.Mod.Valid && info.Mod.Value == 'v'
+
+	for _, flag := range info.Flags {
+		switch {
+		case flag == '-':
+			format.Left = true
+		case flag == '+' && !plusV:
+			format.Sign = '+'
+		case flag == ' ' && format.Sign == 0:
+			format.Sign = ' '
+		case flag == '0':
+			format.Zero = true
+		}
+	}
+
+	if format.Verb == 's' && (format.Sign != 0 || format.Zero) {
+		return compiledFormat{}, false
+	}
+
+	if slices.Contains(info.Flags, '#') {
+		return compiledFormat{}, false
+	}
+
+	// Zeros are never written on the right
+	if format.Left {
+		format.Zero = false
+	}
+
+	return format, true
+}
+
+// Returns whether the compiled format needs helpers that sign and pad numbers.
+func (f *compiledFormat) usesHelpers() bool {
+	return f.Sign != 0 || f.Width != 0
+}
+
+// Returns expression that appends the argument to the buffer in the compiled format.
+// Each step of the format takes the buffer returned by the previous one,
+// and since the buffer variable itself doesn't change until the whole expression is assigned,
+// its length is where the argument starts.
+func generateCompiledFormat(loc *scope.Localization, arg *scope.Argument, format *compiledFormat, builderName string) goast.Expr {
+	var expr goast.Expr
+
+	switch format.Verb {
+	case 's':
+		value := goast.Expr(goast.NewIdent(arg.Name))
+		if arg.GoType.Type == "Stringer" {
+			value = &goast.CallExpr{
+				Fun: &goast.SelectorExpr{
+					X:   goast.NewIdent(arg.Name),
+					Sel: goast.NewIdent("String"),
+				},
+			}
+		}
+
+		expr = generateAppendString(builderName, value)
+	case 'd', 'x', 'o', 'b':
+		loc.AddImport(ast.GoImport{Import: "strconv", Package: "strconv"})
+
+		expr = &goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   goast.NewIdent("strconv"),
+				Sel: goast.NewIdent("AppendInt"),
+			},
+			Args: []goast.Expr{
+				goast.NewIdent(builderName),
+				&goast.CallExpr{
+					Fun:  goast.NewIdent("int64"),
+					Args: []goast.Expr{goast.NewIdent(arg.Name)},
+				},
+				&goast.BasicLit{
+					Kind:  gotoken.INT,
+					Value: strconv.Itoa(intVerbBases[format.Verb]),
+				},
+			},
+		}
+	default:
+		loc.AddImport(ast.GoImport{Import: "strconv", Package: "strconv"})
+
+		expr = &goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   goast.NewIdent("strconv"),
+				Sel: goast.NewIdent("AppendFloat"),
+			},
+			Args: []goast.Expr{
+				goast.NewIdent(builderName),
+				goast.NewIdent(arg.Name),
+				&goast.BasicLit{
+					Kind:  gotoken.CHAR,
+					Value: strconv.QuoteRune(format.Verb),
+				},
+				&goast.BasicLit{
+					Kind:  gotoken.INT,
+					Value: strconv.Itoa(format.Prec),
+				},
+				&goast.BasicLit{
+					Kind:  gotoken.INT,
+					Value: `64`,
+				},
+			},
+		}
+	}
+
+	start := &goast.CallExpr{
+		Fun:  goast.NewIdent("len"),
+		Args: []goast.Expr{goast.NewIdent(builderName)},
+	}
+
+	if format.Sign != 0 {
+		expr = &goast.CallExpr{
+			Fun: goast.NewIdent("signNumber"),
+			Args: []goast.Expr{
+				expr,
+				start,
+				&goast.BasicLit{Kind: gotoken.CHAR, Value: strconv.QuoteRune(rune(format.Sign))},
+			},
+		}
+	}
+
+	if format.Width == 0 {
+		return expr
+	}
+
+	width := &goast.BasicLit{Kind: gotoken.INT, Value: strconv.Itoa(format.Width)}
+
+	switch {
+	case format.Left:
+		expr = &goast.CallExpr{
+			Fun:  goast.NewIdent("padRight"),
+			Args: []goast.Expr{expr, start, width},
+		}
+	case format.Zero:
+		expr = &goast.CallExpr{
+			Fun:  goast.NewIdent("padZeros"),
+			Args: []goast.Expr{expr, start, width},
+		}
+	default:
+		expr = &goast.CallExpr{
+			Fun: goast.NewIdent("padLeft"),
+			Args: []goast.Expr{
+				expr,
+				start,
+				width,
+				&goast.BasicLit{Kind: gotoken.CHAR, Value: `' '`},
+			},
+		}
+	}
+
+	return expr
+}
+
+// Calls the function for each argument of the value along with its format.
+func forEachArgInfo(value ast.Value, fn func(info *ast.ArgInfo)) {
+	forEachPart := func(parts ast.FormatParts) {
+		for _, part := range parts {
+			if info, ok := part.(ast.ArgInfo); ok {
+				fn(&info)
+			}
+		}
+	}
+
+	for _, form := range value.Forms() {
+		forEachPart(form)
+	}
+}
+
+// Returns whether any of the arguments of the messages is written in a compiled format
+// that needs helpers to sign or pad it.
+func usesFormatHelpers(locs []scope.Localization) (uses bool) {
+	for i := 0; i < len(locs); i++ {
+		for j := 0; j < len(locs[i].Scopes); j++ {
+			ms := &locs[i].Scopes[j]
+			if ms.Fallback != nil {
+				continue
+			}
+
+			check := func(info *ast.ArgInfo) {
+				if !info.FmtInfo.HasOptions() {
+					return
+				}
+
+				arg := &ms.Arguments[scope.ArgumentIndex(ms.Arguments, info.Name)]
+				if format, ok := compileFormat(arg.GoType, &info.FmtInfo); ok && format.usesHelpers() {
+					uses = true
+				}
+			}
+
+			forEachArgInfo(&ms.Plural, check)
+			forEachArgInfo(ms.String, check)
+
+			for k := 0; k < len(ms.Variables); k++ {
+				forEachArgInfo(&ms.Variables[k].Plural, check)
+				forEachArgInfo(&ms.Variables[k].Select, check)
+				forEachArgInfo(ms.Variables[k].String, check)
+			}
+		}
+	}
+
+	return uses
+}
+
+// Number of bytes that arguments are expected to take if their widths are smaller,
+// which is enough for most numbers and short strings.
+const argLengthEstimate = 16
+
+// Returns number of bytes that the text of the value takes,
+// with each argument taking its width or argLengthEstimate bytes, whichever is larger.
+// Plurals and selects take as much as their longest forms.
+func estimateLength(ms *scope.MessageScope, value ast.Value) (n int) {
+	estimateParts := func(parts ast.FormatParts) (n int) {
+		for _, part := range parts {
+			switch part := part.(type) {
+			case ast.Text:
+				n += len(part)
+			case ast.ArgInfo:
+				n += max(part.FmtInfo.Width.Value, argLengthEstimate)
+			case ast.VarInfo:
+				variable := &ms.Variables[scope.VariableScopeIndex(ms.Variables, part.Name)]
+				n += max(
+					estimateLength(ms, &variable.Plural),
+					estimateLength(ms, &variable.Select),
+					estimateLength(ms, variable.String),
+				)
+			}
+		}
+		return n
+	}
+
+	for _, form := range value.Forms() {
+		n = max(n, estimateParts(form))
+	}
+
+	return n
+}
+
+// Generates functions that sign and pad arguments written in compiled formats.
+// Widths are counted in runes, the same way fmt counts them.
+func generateGeneralFormatHelpers(_ []scope.Localization, decls *[]goast.Decl) {
+	bytesField := func(name string) *goast.Field {
+		return &goast.Field{
+			Names: []*goast.Ident{goast.NewIdent(name)},
+			Type:  &goast.ArrayType{Elt: goast.NewIdent("byte")},
+		}
+	}
+
+	intField := func(names ...string) *goast.Field {
+		field := &goast.Field{Type: goast.NewIdent("int")}
+		for _, name := range names {
+			field.Names = append(field.Names, goast.NewIdent(name))
+		}
+		return field
+	}
+
+	byteField := func(name string) *goast.Field {
+		return &goast.Field{
+			Names: []*goast.Ident{goast.NewIdent(name)},
+			Type:  goast.NewIdent("byte"),
+		}
+	}
+
+	bytesResults := &goast.FieldList{
+		List: []*goast.Field{{Type: &goast.ArrayType{Elt: goast.NewIdent("byte")}}},
+	}
+
+	ident := goast.NewIdent
+	char := func(c rune) *goast.BasicLit {
+		return &goast.BasicLit{Kind: gotoken.CHAR, Value: strconv.QuoteRune(c)}
+	}
+	num := func(n int) *goast.BasicLit {
+		return &goast.BasicLit{Kind: gotoken.INT, Value: strconv.Itoa(n)}
+	}
+	call := func(fun goast.Expr, args ...goast.Expr) *goast.CallExpr {
+		return &goast.CallExpr{Fun: fun, Args: args}
+	}
+	binary := func(x goast.Expr, op gotoken.Token, y goast.Expr) *goast.BinaryExpr {
+		return &goast.BinaryExpr{X: x, Op: op, Y: y}
+	}
+	index := func(x, i goast.Expr) *goast.IndexExpr {
+		return &goast.IndexExpr{X: x, Index: i}
+	}
+	slice := func(x, low goast.Expr) *goast.SliceExpr {
+		return &goast.SliceExpr{X: x, Low: low}
+	}
+	assign := func(lhs goast.Expr, tok gotoken.Token, rhs goast.Expr) *goast.AssignStmt {
+		return &goast.AssignStmt{Lhs: []goast.Expr{lhs}, Tok: tok, Rhs: []goast.Expr{rhs}}
+	}
+	ret := func(results ...goast.Expr) *goast.ReturnStmt {
+		return &goast.ReturnStmt{Results: results}
+	}
+	runeCount := call(
+		&goast.SelectorExpr{X: ident("utf8"), Sel: ident("RuneCount")},
+		slice(ident("b"), ident("start")),
+	)
+	isAny := func(x goast.Expr, chars ...rune) (cond goast.Expr) {
+		for _, c := range chars {
+			eq := binary(x, gotoken.EQL, char(c))
+			if cond == nil {
+				cond = eq
+			} else {
+				cond = binary(cond, gotoken.LOR, eq)
+			}
+		}
+		return cond
+	}
+
+	*decls = append(*decls,
+		// Replaces the sign of the number or puts it before the number, unless it is negative
+		&goast.FuncDecl{
+			Name: ident("signNumber"),
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{
+					List: []*goast.Field{bytesField("b"), intField("start"), byteField("sign")},
+				},
+				Results: bytesResults,
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.SwitchStmt{
+						Tag: index(ident("b"), ident("start")),
+						Body: &goast.BlockStmt{
+							List: []goast.Stmt{
+								&goast.CaseClause{
+									List: []goast.Expr{char('-')},
+									Body: []goast.Stmt{ret(ident("b"))},
+								},
+								&goast.CaseClause{
+									List: []goast.Expr{char('+')},
+									Body: []goast.Stmt{
+										assign(index(ident("b"), ident("start")), gotoken.ASSIGN, ident("sign")),
+										ret(ident("b")),
+									},
+								},
+							},
+						},
+					},
+					assign(ident("b"), gotoken.ASSIGN, call(ident("append"), ident("b"), num(0))),
+					&goast.ExprStmt{
+						X: call(ident("copy"),
+							slice(ident("b"), binary(ident("start"), gotoken.ADD, num(1))),
+							slice(ident("b"), ident("start")),
+						),
+					},
+					assign(index(ident("b"), ident("start")), gotoken.ASSIGN, ident("sign")),
+					ret(ident("b")),
+				},
+			},
+		},
+		&goast.FuncDecl{
+			Name: ident("padLeft"),
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{
+					List: []*goast.Field{bytesField("b"), intField("start", "width"), byteField("c")},
+				},
+				Results: bytesResults,
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					assign(ident("n"), gotoken.DEFINE, binary(ident("width"), gotoken.SUB, runeCount)),
+					&goast.IfStmt{
+						Cond: binary(ident("n"), gotoken.LEQ, num(0)),
+						Body: &goast.BlockStmt{
+							List: []goast.Stmt{ret(ident("b"))},
+						},
+					},
+					assign(ident("b"), gotoken.ASSIGN, &goast.CallExpr{
+						Fun: ident("append"),
+						Args: []goast.Expr{
+							ident("b"),
+							call(ident("make"), &goast.ArrayType{Elt: ident("byte")}, ident("n")),
+						},
+						Ellipsis: 1,
+					}),
+					&goast.ExprStmt{
+						X: call(ident("copy"),
+							slice(ident("b"), binary(ident("start"), gotoken.ADD, ident("n"))),
+							slice(ident("b"), ident("start")),
+						),
+					},
+					&goast.ForStmt{
+						Init: assign(ident("i"), gotoken.DEFINE, ident("start")),
+						Cond: binary(ident("i"), gotoken.LSS, binary(ident("start"), gotoken.ADD, ident("n"))),
+						Post: &goast.IncDecStmt{X: ident("i"), Tok: gotoken.INC},
+						Body: &goast.BlockStmt{
+							List: []goast.Stmt{
+								assign(index(ident("b"), ident("i")), gotoken.ASSIGN, ident("c")),
+							},
+						},
+					},
+					ret(ident("b")),
+				},
+			},
+		},
+		&goast.FuncDecl{
+			Name: ident("padRight"),
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{
+					List: []*goast.Field{bytesField("b"), intField("start", "width")},
+				},
+				Results: bytesResults,
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ForStmt{
+						Init: assign(ident("n"), gotoken.DEFINE, binary(ident("width"), gotoken.SUB, runeCount)),
+						Cond: binary(ident("n"), gotoken.GTR, num(0)),
+						Post: &goast.IncDecStmt{X: ident("n"), Tok: gotoken.DEC},
+						Body: &goast.BlockStmt{
+							List: []goast.Stmt{
+								assign(ident("b"), gotoken.ASSIGN, call(ident("append"), ident("b"), char(' '))),
+							},
+						},
+					},
+					ret(ident("b")),
+				},
+			},
+		},
+		// Zeros go after the sign, and infinities and NaNs are padded with spaces
+		&goast.FuncDecl{
+			Name: ident("padZeros"),
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{
+					List: []*goast.Field{bytesField("b"), intField("start", "width")},
+				},
+				Results: bytesResults,
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					assign(ident("sign"), gotoken.DEFINE, num(0)),
+					&goast.IfStmt{
+						Cond: isAny(index(ident("b"), ident("start")), '+', '-', ' '),
+						Body: &goast.BlockStmt{
+							List: []goast.Stmt{
+								assign(ident("sign"), gotoken.ASSIGN, num(1)),
+							},
+						},
+					},
+					&goast.IfStmt{
+						Cond: isAny(index(ident("b"), binary(ident("start"), gotoken.ADD, ident("sign"))), 'I', 'N'),
+						Body: &goast.BlockStmt{
+							List: []goast.Stmt{
+								ret(call(ident("padLeft"), ident("b"), ident("start"), ident("width"), char(' '))),
+							},
+						},
+					},
+					ret(call(ident("padLeft"),
+						ident("b"),
+						binary(ident("start"), gotoken.ADD, ident("sign")),
+						binary(ident("width"), gotoken.SUB, ident("sign")),
+						char('0'),
+					)),
+				},
+			},
+		},
+	)
+}
@@ -0,0 +1,310 @@
+package codegen
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/infastin/go-l10n/ast"
+)
+
+func TestCompileFormat(t *testing.T) {
+	var (
+		stringType   = ast.GoType{Type: "string"}
+		stringerType = ast.GoType{Import: "fmt", Package: "fmt", Type: "Stringer"}
+		intType      = ast.GoType{Type: "int"}
+		floatType    = ast.GoType{Type: "float64"}
+		anyType      = ast.GoType{Type: "any"}
+	)
+
+	width := func(n int) ast.WidthOpt { return ast.WidthOpt{Value: n, Valid: true} }
+	prec := func(n int) ast.PrecOpt { return ast.PrecOpt{Value: n, Valid: true} }
+	mod := func(c rune) ast.ModOpt { return ast.ModOpt{Value: c, Valid: true} }
+
+	tests := []struct {
+		name   string
+		goType ast.GoType
+		info   ast.FmtInfo
+		want   compiledFormat
+		ok     bool
+	}{
+		{
+			name:   "string",
+			goType: stringType,
+			info:   ast.FmtInfo{Spec: 's'},
+			want:   compiledFormat{Verb: 's', Prec: -1},
+			ok:     true,
+		},
+		{
+			name:   "padded string",
+			goType: stringType,
+			info:   ast.FmtInfo{Spec: 's', Width: width(10), Flags: []rune{'-'}},
+			want:   compiledFormat{Verb: 's', Prec: -1, Width: 10, Left: true},
+			ok:     true,
+		},
+		{
+			name:   "stringer",
+			goType: stringerType,
+			info:   ast.FmtInfo{Spec: 'S', Width: width(4)},
+			want:   compiledFormat{Verb: 's', Prec: -1, Width: 4},
+			ok:     true,
+		},
+		{
+			name:   "signed string",
+			goType: stringType,
+			info:   ast.FmtInfo{Spec: 's', Flags: []rune{'+'}},
+		},
+		{
+			name:   "string precision",
+			goType: stringType,
+			info:   ast.FmtInfo{Spec: 's', Prec: prec(3)},
+		},
+		{
+			name:   "integer",
+			goType: intType,
+			info:   ast.FmtInfo{Spec: 'd'},
+			want:   compiledFormat{Verb: 'd', Prec: -1},
+			ok:     true,
+		},
+		{
+			name:   "hexadecimal integer",
+			goType: intType,
+			info:   ast.FmtInfo{Spec: 'd', Mod: mod('x')},
+			want:   compiledFormat{Verb: 'x', Prec: -1},
+			ok:     true,
+		},
+		{
+			name:   "upper hexadecimal integer",
+			goType: intType,
+			info:   ast.FmtInfo{Spec: 'd', Mod: mod('X')},
+		},
+		{
+			name:   "zero padded integer",
+			goType: intType,
+			info:   ast.FmtInfo{Spec: 'd', Width: width(5), Flags: []rune{'+', '0'}},
+			want:   compiledFormat{Verb: 'd', Prec: -1, Sign: '+', Width: 5, Zero: true},
+			ok:     true,
+		},
+		{
+			name:   "left padded integer",
+			goType: intType,
+			info:   ast.FmtInfo{Spec: 'd', Width: width(5), Flags: []rune{'0', '-'}},
+			want:   compiledFormat{Verb: 'd', Prec: -1, Width: 5, Left: true},
+			ok:     true,
+		},
+		{
+			name:   "integer precision",
+			goType: intType,
+			info:   ast.FmtInfo{Spec: 'd', Prec: prec(3)},
+		},
+		{
+			name:   "alternate integer",
+			goType: intType,
+			info:   ast.FmtInfo{Spec: 'd', Mod: mod('x'), Flags: []rune{'#'}},
+		},
+		{
+			name:   "float",
+			goType: floatType,
+			info:   ast.FmtInfo{Spec: 'f'},
+			want:   compiledFormat{Verb: 'f', Prec: 6},
+			ok:     true,
+		},
+		{
+			name:   "float with precision",
+			goType: floatType,
+			info:   ast.FmtInfo{Spec: 'f', Prec: prec(2), Flags: []rune{' '}},
+			want:   compiledFormat{Verb: 'f', Prec: 2, Sign: ' '},
+			ok:     true,
+		},
+		{
+			name:   "plus flag over space one",
+			goType: floatType,
+			info:   ast.FmtInfo{Spec: 'f', Flags: []rune{' ', '+'}},
+			want:   compiledFormat{Verb: 'f', Prec: 6, Sign: '+'},
+			ok:     true,
+		},
+		{
+			name:   "shortest float",
+			goType: floatType,
+			info:   ast.FmtInfo{Spec: 'f', Mod: mod('v')},
+			want:   compiledFormat{Verb: 'g', Prec: -1},
+			ok:     true,
+		},
+		{
+			name:   "plus flag of shortest float",
+			goType: floatType,
+			info:   ast.FmtInfo{Spec: 'f', Mod: mod('v'), Flags: []rune{'+'}},
+			want:   compiledFormat{Verb: 'g', Prec: -1},
+			ok:     true,
+		},
+		{
+			name:   "plus flag of default integer",
+			goType: intType,
+			info:   ast.FmtInfo{Spec: 'd', Mod: mod('v'), Width: width(12), Flags: []rune{'+', '0'}},
+			want:   compiledFormat{Verb: 'd', Prec: -1, Width: 12, Zero: true},
+			ok:     true,
+		},
+		{
+			name:   "space flag of default integer",
+			goType: intType,
+			info:   ast.FmtInfo{Spec: 'd', Mod: mod('v'), Flags: []rune{'+', ' '}},
+			want:   compiledFormat{Verb: 'd', Prec: -1, Sign: ' '},
+			ok:     true,
+		},
+		{
+			name:   "exponent float",
+			goType: floatType,
+			info:   ast.FmtInfo{Spec: 'f', Mod: mod('E'), Width: width(12)},
+			want:   compiledFormat{Verb: 'E', Prec: 6, Width: 12},
+			ok:     true,
+		},
+		{
+			name:   "hexadecimal float",
+			goType: floatType,
+			info:   ast.FmtInfo{Spec: 'f', Mod: mod('x')},
+		},
+		{
+			name:   "any",
+			goType: anyType,
+			info:   ast.FmtInfo{Spec: 'v'},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := compileFormat(tt.goType, &tt.info)
+			if ok != tt.ok {
+				t.Fatalf("got ok %v, want %v", ok, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Appends the value in the compiled format the same way the generated code does.
+func appendCompiledFormat(b []byte, value any, format *compiledFormat) []byte {
+	start := len(b)
+
+	switch v := value.(type) {
+	case string:
+		b = append(b, v...)
+	case int:
+		b = strconv.AppendInt(b, int64(v), intVerbBases[format.Verb])
+	case float64:
+		b = strconv.AppendFloat(b, v, byte(format.Verb), format.Prec, 64)
+	}
+
+	if format.Sign != 0 {
+		switch b[start] {
+		case '-':
+		case '+':
+			b[start] = format.Sign
+		default:
+			b = append(b[:start], append([]byte{format.Sign}, b[start:]...)...)
+		}
+	}
+
+	padLeft := func(start, width int, c byte) []byte {
+		n := width - utf8.RuneCount(b[start:])
+		if n <= 0 {
+			return b
+		}
+		pad := make([]byte, n)
+		for i := range pad {
+			pad[i] = c
+		}
+		return append(b[:start], append(pad, b[start:]...)...)
+	}
+
+	switch {
+	case format.Width == 0:
+	case format.Left:
+		for n := format.Width - utf8.RuneCount(b[start:]); n > 0; n-- {
+			b = append(b, ' ')
+		}
+	case format.Zero:
+		sign := 0
+		if b[start] == '+' || b[start] == '-' || b[start] == ' ' {
+			sign = 1
+		}
+		if b[start+sign] == 'I' || b[start+sign] == 'N' {
+			b = padLeft(start, format.Width, ' ')
+		} else {
+			b = padLeft(start+sign, format.Width-sign, '0')
+		}
+	default:
+		b = padLeft(start, format.Width, ' ')
+	}
+
+	return b
+}
+
+func TestCompiledFormatMatchesFmt(t *testing.T) {
+	var (
+		intType   = ast.GoType{Type: "int"}
+		floatType = ast.GoType{Type: "float64"}
+	)
+
+	width := func(n int) ast.WidthOpt { return ast.WidthOpt{Value: n, Valid: true} }
+	prec := func(n int) ast.PrecOpt { return ast.PrecOpt{Value: n, Valid: true} }
+	mod := func(c rune) ast.ModOpt { return ast.ModOpt{Value: c, Valid: true} }
+
+	tests := []struct {
+		goType ast.GoType
+		info   ast.FmtInfo
+		values []any
+	}{
+		{
+			goType: intType,
+			info:   ast.FmtInfo{Spec: 'd', Mod: mod('v'), Flags: []rune{'+'}},
+			values: []any{0, 1, -1},
+		},
+		{
+			goType: intType,
+			info:   ast.FmtInfo{Spec: 'd', Mod: mod('v'), Width: width(12), Flags: []rune{'+', '0'}},
+			values: []any{0, 1, -1},
+		},
+		{
+			goType: intType,
+			info:   ast.FmtInfo{Spec: 'd', Mod: mod('v'), Flags: []rune{'+', ' '}},
+			values: []any{0, 1, -1},
+		},
+		{
+			goType: intType,
+			info:   ast.FmtInfo{Spec: 'd', Width: width(6), Flags: []rune{'+', '0'}},
+			values: []any{0, 1, -1},
+		},
+		{
+			goType: floatType,
+			info:   ast.FmtInfo{Spec: 'f', Mod: mod('v'), Flags: []rune{'+'}},
+			values: []any{0.0, 1.5, -1.5, math.NaN(), math.Inf(1)},
+		},
+		{
+			goType: floatType,
+			info:   ast.FmtInfo{Spec: 'f', Prec: prec(2), Width: width(10), Flags: []rune{'+', '0'}},
+			values: []any{0.0, 1.5, -1.5, math.NaN(), math.Inf(-1)},
+		},
+	}
+
+	for _, tt := range tests {
+		goFormat := tt.info.GoFormat(tt.goType)
+
+		format, ok := compileFormat(tt.goType, &tt.info)
+		if !ok {
+			t.Errorf("%s: not compiled", goFormat)
+			continue
+		}
+
+		for _, value := range tt.values {
+			got := string(appendCompiledFormat(nil, value, &format))
+			want := fmt.Sprintf(goFormat, value)
+			if got != want {
+				t.Errorf("%s of %v: got %q, want %q", goFormat, value, got, want)
+			}
+		}
+	}
+}
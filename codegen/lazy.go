@@ -0,0 +1,311 @@
+package codegen
+
+import (
+	goast "go/ast"
+	gotoken "go/token"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/infastin/go-l10n/scope"
+)
+
+// Names of methods of message types, which fields of arguments can't take.
+var messageMethodNames = []string{
+	"MessageID",
+	"Localize",
+	"Error",
+	"LogValue",
+}
+
+// Returns name of the type of the message: YouAreLateMsg, Auth_Login_TitleMsg.
+func getMessageTypeName(ms *scope.MessageScope) string {
+	return strings.ReplaceAll(ms.Name, ".", "_") + "Msg"
+}
+
+// Returns names of the fields that hold arguments of the message.
+// Names of the arguments are capitalized,
+// and those that collide with methods or other fields get an underscore.
+func getMessageFieldNames(ms *scope.MessageScope) (names []string) {
+	for i := 0; i < len(ms.Arguments); i++ {
+		name := ms.Arguments[i].Name
+		name = strings.ToUpper(name[:1]) + name[1:]
+
+		for slices.Contains(messageMethodNames, name) || slices.Contains(names, name) {
+			name += "_"
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// Returns call of the method of the message on the localizer,
+// which is reached through methods of the namespaces of the message.
+func generateLocalizerCall(loc goast.Expr, ms *scope.MessageScope, args []goast.Expr) *goast.CallExpr {
+	fun := loc
+
+	if namespace := getMessageNamespace(ms); namespace != "" {
+		for _, name := range strings.Split(namespace, ".") {
+			fun = &goast.CallExpr{
+				Fun: &goast.SelectorExpr{
+					X:   fun,
+					Sel: goast.NewIdent(name),
+				},
+			}
+		}
+	}
+
+	return &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   fun,
+			Sel: goast.NewIdent(getMessageFuncName(ms)),
+		},
+		Args: args,
+	}
+}
+
+// Generates Message interface and a type for each message that implements it.
+// Values of these types hold arguments of messages
+// and can be localized later, when the language becomes known.
+// They are errors localized with Default, which live localizers replace,
+// and are logged with slog as their IDs along with their arguments.
+func generateGeneralLazyMessages(locs []scope.Localization, decls *[]goast.Decl) {
+	*decls = append(*decls, &goast.GenDecl{
+		Tok: gotoken.TYPE,
+		Specs: []goast.Spec{
+			&goast.TypeSpec{
+				Name: goast.NewIdent("Message"),
+				Type: &goast.InterfaceType{
+					Methods: &goast.FieldList{
+						List: []*goast.Field{
+							{
+								Names: []*goast.Ident{goast.NewIdent("MessageID")},
+								Type: &goast.FuncType{
+									Params: &goast.FieldList{},
+									Results: &goast.FieldList{
+										List: []*goast.Field{{Type: goast.NewIdent("MessageID")}},
+									},
+								},
+							},
+							{
+								Names: []*goast.Ident{goast.NewIdent("Localize")},
+								Type: &goast.FuncType{
+									Params: &goast.FieldList{
+										List: []*goast.Field{
+											{
+												Names: []*goast.Ident{goast.NewIdent("loc")},
+												Type:  goast.NewIdent("Localizer"),
+											},
+										},
+									},
+									Results: &goast.FieldList{
+										List: []*goast.Field{{Type: goast.NewIdent("string")}},
+									},
+								},
+							},
+							{Type: goast.NewIdent("error")},
+							{
+								Type: &goast.SelectorExpr{
+									X:   goast.NewIdent("slog"),
+									Sel: goast.NewIdent("LogValuer"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	for i := 0; i < len(locs[0].Scopes); i++ {
+		generateLazyMessage(&locs[0].Scopes[i], decls)
+	}
+}
+
+func generateLazyMessage(ms *scope.MessageScope, decls *[]goast.Decl) {
+	typeName := getMessageTypeName(ms)
+	fieldNames := getMessageFieldNames(ms)
+
+	recv := &goast.FieldList{
+		List: []*goast.Field{
+			{
+				Names: []*goast.Ident{goast.NewIdent("m")},
+				Type:  goast.NewIdent(typeName),
+			},
+		},
+	}
+
+	structType := &goast.StructType{
+		Fields: &goast.FieldList{},
+	}
+
+	var (
+		fields []goast.Expr
+		attrs  []goast.Expr
+	)
+
+	for i := 0; i < len(ms.Arguments); i++ {
+		structType.Fields.List = append(structType.Fields.List, &goast.Field{
+			Names: []*goast.Ident{goast.NewIdent(fieldNames[i])},
+			Type:  getPackageFieldType(&ms.Arguments[i]),
+		})
+
+		field := &goast.SelectorExpr{
+			X:   goast.NewIdent("m"),
+			Sel: goast.NewIdent(fieldNames[i]),
+		}
+
+		fields = append(fields, field)
+		attrs = append(attrs, &goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   goast.NewIdent("slog"),
+				Sel: goast.NewIdent("Any"),
+			},
+			Args: []goast.Expr{
+				&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(ms.Arguments[i].Name)},
+				field,
+			},
+		})
+	}
+
+	// Arguments are grouped, so that they can't collide with the ID
+	logAttrs := []goast.Expr{
+		&goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   goast.NewIdent("slog"),
+				Sel: goast.NewIdent("String"),
+			},
+			Args: []goast.Expr{
+				&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote("id")},
+				&goast.CallExpr{
+					Fun:  goast.NewIdent("string"),
+					Args: []goast.Expr{goast.NewIdent(getMessageIDName(ms))},
+				},
+			},
+		},
+	}
+
+	if len(attrs) != 0 {
+		logAttrs = append(logAttrs, &goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   goast.NewIdent("slog"),
+				Sel: goast.NewIdent("Group"),
+			},
+			Args: append([]goast.Expr{
+				&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote("args")},
+			}, attrs...),
+		})
+	}
+
+	stringResults := &goast.FieldList{
+		List: []*goast.Field{{Type: goast.NewIdent("string")}},
+	}
+
+	*decls = append(*decls,
+		&goast.GenDecl{
+			Tok: gotoken.TYPE,
+			Specs: []goast.Spec{
+				&goast.TypeSpec{
+					Name: goast.NewIdent(typeName),
+					Type: structType,
+				},
+			},
+		},
+		&goast.FuncDecl{
+			Recv: recv,
+			Name: goast.NewIdent("MessageID"),
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{},
+				Results: &goast.FieldList{
+					List: []*goast.Field{{Type: goast.NewIdent("MessageID")}},
+				},
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ReturnStmt{
+						Results: []goast.Expr{goast.NewIdent(getMessageIDName(ms))},
+					},
+				},
+			},
+		},
+		&goast.FuncDecl{
+			Recv: recv,
+			Name: goast.NewIdent("Localize"),
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{
+					List: []*goast.Field{
+						{
+							Names: []*goast.Ident{goast.NewIdent("loc")},
+							Type:  goast.NewIdent("Localizer"),
+						},
+					},
+				},
+				Results: stringResults,
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ReturnStmt{
+						Results: []goast.Expr{generateLocalizerCall(goast.NewIdent("loc"), ms, fields)},
+					},
+				},
+			},
+		},
+		&goast.FuncDecl{
+			Recv: recv,
+			Name: goast.NewIdent("Error"),
+			Type: &goast.FuncType{
+				Params:  &goast.FieldList{},
+				Results: stringResults,
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ReturnStmt{
+						Results: []goast.Expr{
+							&goast.CallExpr{
+								Fun: &goast.SelectorExpr{
+									X:   goast.NewIdent("m"),
+									Sel: goast.NewIdent("Localize"),
+								},
+								Args: []goast.Expr{goast.NewIdent("Default")},
+							},
+						},
+					},
+				},
+			},
+		},
+		&goast.FuncDecl{
+			Recv: recv,
+			Name: goast.NewIdent("LogValue"),
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{},
+				Results: &goast.FieldList{
+					List: []*goast.Field{
+						{
+							Type: &goast.SelectorExpr{
+								X:   goast.NewIdent("slog"),
+								Sel: goast.NewIdent("Value"),
+							},
+						},
+					},
+				},
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ReturnStmt{
+						Results: []goast.Expr{
+							&goast.CallExpr{
+								Fun: &goast.SelectorExpr{
+									X:   goast.NewIdent("slog"),
+									Sel: goast.NewIdent("GroupValue"),
+								},
+								Args: logAttrs,
+							},
+						},
+					},
+				},
+			},
+		},
+	)
+}
@@ -0,0 +1,753 @@
+package codegen
+
+import (
+	goast "go/ast"
+	gotoken "go/token"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+	"github.com/infastin/go-l10n/scope"
+	"golang.org/x/text/language"
+)
+
+// Name of the receiver of live localizer methods,
+// which can't collide with arguments, since argument names consist of letters only.
+const liveLocalizerName = "live_l"
+
+// Returns build tag of the file of live localizers: l10n_live.
+func getLiveBuildTag() string {
+	return common.Config.PackageName + "_live"
+}
+
+// Returns name of the environment variable that sets the directory
+// with localization files of live localizers: L10N_LIVE_DIR.
+func getLiveDirectoryEnv() string {
+	return strings.ToUpper(common.Config.PackageName) + "_LIVE_DIR"
+}
+
+// Returns name of the live type that implements the namespace: live_Localizer_Auth.
+func getLiveTypeName(namespace string) string {
+	if namespace == "" {
+		return "live_Localizer"
+	}
+	return "live_Localizer_" + strings.ReplaceAll(namespace, ".", "_")
+}
+
+// Returns path to the directory with localization files relative to the output one,
+// so that live localizers find it wherever the program runs from.
+func getLiveDirectory() string {
+	dir, err := filepath.Abs(common.Config.Directory)
+	if err != nil {
+		return filepath.ToSlash(common.Config.Directory)
+	}
+
+	output, err := filepath.Abs(common.Config.Output)
+	if err != nil {
+		return filepath.ToSlash(common.Config.Directory)
+	}
+
+	rel, err := filepath.Rel(output, dir)
+	if err != nil {
+		return filepath.ToSlash(common.Config.Directory)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// Reports whether localization files are matched with a pattern other than the default one,
+// which live localizers have to be given.
+func hasCustomPattern() bool {
+	return common.Config.Pattern.String() != common.DefaultPattern
+}
+
+// Generates variable that live localizers set to wrap the localizers
+// that register themselves after them, since files are initialized in the order of their names.
+func generateGeneralLiveHook(decls *[]goast.Decl) {
+	*decls = append(*decls, &goast.GenDecl{
+		Doc: &goast.CommentGroup{
+			List: []*goast.Comment{
+				{Text: "// Wraps localizers that register themselves, set when live localizers are built"},
+			},
+		},
+		Tok: gotoken.VAR,
+		Specs: []goast.Spec{
+			&goast.ValueSpec{
+				Names: []*goast.Ident{goast.NewIdent("liveLocalizer")},
+				Type: &goast.FuncType{
+					Params: &goast.FieldList{
+						List: []*goast.Field{
+							{
+								Names: []*goast.Ident{goast.NewIdent("lang")},
+								Type:  goast.NewIdent("string"),
+							},
+							{
+								Names: []*goast.Ident{goast.NewIdent("loc")},
+								Type:  goast.NewIdent("Localizer"),
+							},
+						},
+					},
+					Results: &goast.FieldList{
+						List: []*goast.Field{{Type: goast.NewIdent("Localizer")}},
+					},
+				},
+			},
+		},
+	})
+}
+
+// Returns statement of registerLocalizer that wraps the localizer with the live one, if it is built.
+func generateLiveHookCall() goast.Stmt {
+	return &goast.IfStmt{
+		Cond: &goast.BinaryExpr{
+			X:  goast.NewIdent("liveLocalizer"),
+			Op: gotoken.NEQ,
+			Y:  goast.NewIdent("nil"),
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{goast.NewIdent("loc")},
+					Tok: gotoken.ASSIGN,
+					Rhs: []goast.Expr{
+						&goast.CallExpr{
+							Fun:  goast.NewIdent("liveLocalizer"),
+							Args: []goast.Expr{goast.NewIdent("lang"), goast.NewIdent("loc")},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Generates file of live localizers, which is only built with l10n_live tag.
+// Live localizers wrap the compiled ones and take messages from localization files,
+// which are read at runtime and reloaded when they change,
+// falling back to the compiled messages when they can't be read.
+func generateLive(locs []scope.Localization) (file *goast.File) {
+	file = &goast.File{
+		Doc: &goast.CommentGroup{
+			List: []*goast.Comment{
+				{Text: GeneratedComment},
+				{Text: ""},
+				{Text: "//go:build " + getLiveBuildTag()},
+				{Text: ""},
+			},
+		},
+		Name:  goast.NewIdent(common.Config.PackageName),
+		Decls: []goast.Decl{},
+	}
+
+	base := &locs[0]
+
+	imports := []ast.GoImport{
+		{Import: "context", Package: "context"},
+		{Import: "fmt", Package: "fmt"},
+		{Import: "io", Package: "io"},
+		{Import: "os", Package: "os"},
+		{Import: "path/filepath", Package: "filepath"},
+	}
+	if hasCustomPattern() {
+		imports = append(imports, ast.GoImport{Import: "regexp", Package: "regexp"})
+	}
+	imports = append(imports,
+		ast.GoImport{Import: "runtime", Package: "runtime"},
+		ast.GoImport{Import: "time", Package: "time"},
+		ast.GoImport{Import: "github.com/infastin/go-l10n/live", Package: "live"},
+		ast.GoImport{Import: "golang.org/x/text/language", Package: "language"},
+	)
+
+	// Types of the arguments appear in signatures of the methods
+	for i := 0; i < len(base.Scopes); i++ {
+		ms := &base.Scopes[i]
+
+		for j := 0; j < len(ms.Arguments); j++ {
+			goType := &ms.Arguments[j].GoType
+			imp := ast.GoImport{Import: goType.Import, Package: goType.Package}
+
+			if goType.Import != "" && !slices.Contains(imports, imp) {
+				imports = append(imports, imp)
+			}
+		}
+	}
+
+	importDecl := &goast.GenDecl{
+		Tok: gotoken.IMPORT,
+	}
+
+	for _, imp := range imports {
+		importDecl.Specs = append(importDecl.Specs, &goast.ImportSpec{
+			Path: &goast.BasicLit{
+				Kind:  gotoken.STRING,
+				Value: strconv.Quote(imp.Import),
+			},
+		})
+	}
+
+	file.Decls = append(file.Decls,
+		importDecl,
+		&goast.GenDecl{
+			Tok: gotoken.VAR,
+			Specs: []goast.Spec{
+				&goast.ValueSpec{
+					Names: []*goast.Ident{goast.NewIdent("liveCatalog")},
+					Type: &goast.StarExpr{
+						X: &goast.SelectorExpr{
+							X:   goast.NewIdent("live"),
+							Sel: goast.NewIdent("Catalog"),
+						},
+					},
+				},
+			},
+		},
+	)
+
+	generateLiveTypeDecl(base, &file.Decls)
+	generateLiveNamespaceFuncs(base, &file.Decls)
+
+	for i := 0; i < len(base.Scopes); i++ {
+		generateLiveMessage(&base.Scopes[i], &file.Decls)
+	}
+
+	generateLiveInit(base, &file.Decls)
+
+	return file
+}
+
+// Generates live types of the localizer and its namespaces,
+// which contain the language and the compiled localizer or namespace.
+func generateLiveTypeDecl(base *scope.Localization, decls *[]goast.Decl) {
+	typeDecl := &goast.GenDecl{
+		Tok: gotoken.TYPE,
+	}
+
+	namespaces := append([]string{""}, getNamespaces(base.Scopes)...)
+
+	for _, namespace := range namespaces {
+		typeDecl.Specs = append(typeDecl.Specs, &goast.TypeSpec{
+			Name: goast.NewIdent(getLiveTypeName(namespace)),
+			Type: &goast.StructType{
+				Fields: &goast.FieldList{
+					List: []*goast.Field{
+						{
+							Names: []*goast.Ident{goast.NewIdent("lang")},
+							Type:  goast.NewIdent("string"),
+						},
+						{
+							Names: []*goast.Ident{goast.NewIdent("loc")},
+							Type:  goast.NewIdent(getNamespaceInterfaceName(namespace)),
+						},
+					},
+				},
+			},
+		})
+	}
+
+	*decls = append(*decls, typeDecl)
+}
+
+// Returns receiver of live methods of the namespace.
+func getLiveRecv(namespace string) *goast.FieldList {
+	return &goast.FieldList{
+		List: []*goast.Field{
+			{
+				Names: []*goast.Ident{goast.NewIdent(liveLocalizerName)},
+				Type:  goast.NewIdent(getLiveTypeName(namespace)),
+			},
+		},
+	}
+}
+
+// Returns selector of the field of the live receiver.
+func getLiveField(name string) *goast.SelectorExpr {
+	return &goast.SelectorExpr{
+		X:   goast.NewIdent(liveLocalizerName),
+		Sel: goast.NewIdent(name),
+	}
+}
+
+// Generates methods that return live child namespaces of namespaces,
+// which wrap the child namespaces of the compiled ones.
+func generateLiveNamespaceFuncs(base *scope.Localization, decls *[]goast.Decl) {
+	for _, namespace := range getNamespaces(base.Scopes) {
+		*decls = append(*decls, &goast.FuncDecl{
+			Name: goast.NewIdent(getNamespaceFuncName(namespace)),
+			Recv: getLiveRecv(getNamespaceParent(namespace)),
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{},
+				Results: &goast.FieldList{
+					List: []*goast.Field{
+						{Type: goast.NewIdent(getNamespaceInterfaceName(namespace))},
+					},
+				},
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ReturnStmt{
+						Results: []goast.Expr{
+							&goast.CompositeLit{
+								Type: goast.NewIdent(getLiveTypeName(namespace)),
+								Elts: []goast.Expr{
+									&goast.KeyValueExpr{
+										Key:   goast.NewIdent("lang"),
+										Value: getLiveField("lang"),
+									},
+									&goast.KeyValueExpr{
+										Key: goast.NewIdent("loc"),
+										Value: &goast.CallExpr{
+											Fun: &goast.SelectorExpr{
+												X:   getLiveField("loc"),
+												Sel: goast.NewIdent(getNamespaceFuncName(namespace)),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+}
+
+// Generates methods of the message, which take its text from the catalog
+// and call the compiled method if the catalog can't provide it.
+// AppendX and WriteX use the method that returns the text.
+func generateLiveMessage(ms *scope.MessageScope, decls *[]goast.Decl) {
+	var args goast.Expr = goast.NewIdent("nil")
+
+	if len(ms.Arguments) != 0 {
+		argsLit := &goast.CompositeLit{
+			Type: &goast.MapType{
+				Key:   goast.NewIdent("string"),
+				Value: goast.NewIdent("any"),
+			},
+		}
+
+		for i := 0; i < len(ms.Arguments); i++ {
+			argsLit.Elts = append(argsLit.Elts, &goast.KeyValueExpr{
+				Key:   &goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(ms.Arguments[i].Name)},
+				Value: goast.NewIdent(ms.Arguments[i].Name),
+			})
+		}
+
+		args = argsLit
+	}
+
+	recv := getLiveRecv(getMessageNamespace(ms))
+
+	text := &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   goast.NewIdent(liveLocalizerName),
+			Sel: goast.NewIdent(getMessageFuncName(ms)),
+		},
+		Args: getMessageArgs(ms),
+	}
+
+	*decls = append(*decls,
+		&goast.FuncDecl{
+			Name: goast.NewIdent(getMessageFuncName(ms)),
+			Recv: recv,
+			Type: &goast.FuncType{
+				Params:  getMessageParams(ms),
+				Results: getMessageResults(),
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.AssignStmt{
+						Lhs: []goast.Expr{goast.NewIdent("text"), goast.NewIdent("ok")},
+						Tok: gotoken.DEFINE,
+						Rhs: []goast.Expr{
+							&goast.CallExpr{
+								Fun: &goast.SelectorExpr{
+									X:   goast.NewIdent("liveCatalog"),
+									Sel: goast.NewIdent("Message"),
+								},
+								Args: []goast.Expr{
+									getLiveField("lang"),
+									&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(ms.Name)},
+									args,
+								},
+							},
+						},
+					},
+					&goast.IfStmt{
+						Cond: goast.NewIdent("ok"),
+						Body: &goast.BlockStmt{
+							List: []goast.Stmt{
+								&goast.ReturnStmt{Results: []goast.Expr{goast.NewIdent("text")}},
+							},
+						},
+					},
+					&goast.ReturnStmt{
+						Results: []goast.Expr{
+							&goast.CallExpr{
+								Fun: &goast.SelectorExpr{
+									X:   getLiveField("loc"),
+									Sel: goast.NewIdent(getMessageFuncName(ms)),
+								},
+								Args: getMessageArgs(ms),
+							},
+						},
+					},
+				},
+			},
+		},
+		&goast.FuncDecl{
+			Name: goast.NewIdent(getAppendFuncName(ms)),
+			Recv: recv,
+			Type: &goast.FuncType{
+				Params:  getMessageParams(ms, getBufferField()),
+				Results: getAppendResults(),
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ReturnStmt{
+						Results: []goast.Expr{
+							&goast.CallExpr{
+								Fun:      goast.NewIdent("append"),
+								Args:     []goast.Expr{goast.NewIdent(bufferName), text},
+								Ellipsis: 1,
+							},
+						},
+					},
+				},
+			},
+		},
+		&goast.FuncDecl{
+			Name: goast.NewIdent(getWriteFuncName(ms)),
+			Recv: recv,
+			Type: &goast.FuncType{
+				Params:  getMessageParams(ms, getWriterField()),
+				Results: getWriteResults(),
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ReturnStmt{
+						Results: []goast.Expr{
+							&goast.CallExpr{
+								Fun: &goast.SelectorExpr{
+									X:   goast.NewIdent("io"),
+									Sel: goast.NewIdent("WriteString"),
+								},
+								Args: []goast.Expr{goast.NewIdent(writerName), text},
+							},
+						},
+					},
+				},
+			},
+		},
+	)
+}
+
+// Returns statement that prints the error to stderr.
+func generateLivePrintError(errName string) goast.Stmt {
+	return &goast.ExprStmt{
+		X: &goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   goast.NewIdent("fmt"),
+				Sel: goast.NewIdent("Fprintln"),
+			},
+			Args: []goast.Expr{
+				&goast.SelectorExpr{
+					X:   goast.NewIdent("os"),
+					Sel: goast.NewIdent("Stderr"),
+				},
+				goast.NewIdent(errName),
+			},
+		},
+	}
+}
+
+// Returns expression that parses the language tag.
+func generateLanguageTag(lang language.Tag) goast.Expr {
+	return &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   goast.NewIdent("language"),
+			Sel: goast.NewIdent("MustParse"),
+		},
+		Args: []goast.Expr{
+			&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(lang.String())},
+		},
+	}
+}
+
+// Generates init function that loads the catalog, replaces the compiled localizers
+// with the live ones and starts watching localization files.
+// The directory is taken from the environment variable, and if it is not set,
+// it is found relative to the generated file, as the program can run from any directory.
+func generateLiveInit(base *scope.Localization, decls *[]goast.Decl) {
+	const fileName, dirName, catalogName, errName = "file", "dir", "catalog", "err"
+
+	// Directory of the localization files goes before the options
+	args := []goast.Expr{
+		goast.NewIdent(dirName),
+		&goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   goast.NewIdent("live"),
+				Sel: goast.NewIdent("WithBase"),
+			},
+			Args: []goast.Expr{generateLanguageTag(base.Lang)},
+		},
+	}
+
+	if common.Config.Fallback != language.Und {
+		args = append(args, &goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   goast.NewIdent("live"),
+				Sel: goast.NewIdent("WithFallback"),
+			},
+			Args: []goast.Expr{generateLanguageTag(common.Config.Fallback)},
+		})
+	}
+
+	if hasCustomPattern() {
+		args = append(args, &goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   goast.NewIdent("live"),
+				Sel: goast.NewIdent("WithPattern"),
+			},
+			Args: []goast.Expr{
+				&goast.CallExpr{
+					Fun: &goast.SelectorExpr{
+						X:   goast.NewIdent("regexp"),
+						Sel: goast.NewIdent("MustCompile"),
+					},
+					Args: []goast.Expr{
+						&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(common.Config.Pattern.String())},
+					},
+				},
+			},
+		})
+	}
+
+	newLive := func(lang, loc goast.Expr) goast.Expr {
+		return &goast.CompositeLit{
+			Type: goast.NewIdent(getLiveTypeName("")),
+			Elts: []goast.Expr{
+				&goast.KeyValueExpr{Key: goast.NewIdent("lang"), Value: lang},
+				&goast.KeyValueExpr{Key: goast.NewIdent("loc"), Value: loc},
+			},
+		}
+	}
+
+	body := []goast.Stmt{
+		&goast.AssignStmt{
+			Lhs: []goast.Expr{goast.NewIdent(dirName)},
+			Tok: gotoken.DEFINE,
+			Rhs: []goast.Expr{
+				&goast.CallExpr{
+					Fun: &goast.SelectorExpr{
+						X:   goast.NewIdent("os"),
+						Sel: goast.NewIdent("Getenv"),
+					},
+					Args: []goast.Expr{
+						&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(getLiveDirectoryEnv())},
+					},
+				},
+			},
+		},
+		&goast.IfStmt{
+			Cond: &goast.BinaryExpr{
+				X:  goast.NewIdent(dirName),
+				Op: gotoken.EQL,
+				Y:  &goast.BasicLit{Kind: gotoken.STRING, Value: `""`},
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.AssignStmt{
+						Lhs: []goast.Expr{
+							goast.NewIdent("_"),
+							goast.NewIdent(fileName),
+							goast.NewIdent("_"),
+							goast.NewIdent("_"),
+						},
+						Tok: gotoken.DEFINE,
+						Rhs: []goast.Expr{
+							&goast.CallExpr{
+								Fun: &goast.SelectorExpr{
+									X:   goast.NewIdent("runtime"),
+									Sel: goast.NewIdent("Caller"),
+								},
+								Args: []goast.Expr{&goast.BasicLit{Kind: gotoken.INT, Value: "0"}},
+							},
+						},
+					},
+					&goast.AssignStmt{
+						Lhs: []goast.Expr{goast.NewIdent(dirName)},
+						Tok: gotoken.ASSIGN,
+						Rhs: []goast.Expr{
+							&goast.CallExpr{
+								Fun: &goast.SelectorExpr{
+									X:   goast.NewIdent("filepath"),
+									Sel: goast.NewIdent("Join"),
+								},
+								Args: []goast.Expr{
+									&goast.CallExpr{
+										Fun: &goast.SelectorExpr{
+											X:   goast.NewIdent("filepath"),
+											Sel: goast.NewIdent("Dir"),
+										},
+										Args: []goast.Expr{goast.NewIdent(fileName)},
+									},
+									&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(getLiveDirectory())},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		&goast.AssignStmt{
+			Lhs: []goast.Expr{goast.NewIdent(catalogName), goast.NewIdent(errName)},
+			Tok: gotoken.DEFINE,
+			Rhs: []goast.Expr{
+				&goast.CallExpr{
+					Fun: &goast.SelectorExpr{
+						X:   goast.NewIdent("live"),
+						Sel: goast.NewIdent("Load"),
+					},
+					Args: args,
+				},
+			},
+		},
+		&goast.IfStmt{
+			Cond: &goast.BinaryExpr{
+				X:  goast.NewIdent(errName),
+				Op: gotoken.NEQ,
+				Y:  goast.NewIdent("nil"),
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{generateLivePrintError(errName)},
+			},
+		},
+		&goast.AssignStmt{
+			Lhs: []goast.Expr{goast.NewIdent("liveCatalog")},
+			Tok: gotoken.ASSIGN,
+			Rhs: []goast.Expr{goast.NewIdent(catalogName)},
+		},
+		&goast.RangeStmt{
+			Key:   goast.NewIdent("lang"),
+			Value: goast.NewIdent("loc"),
+			Tok:   gotoken.DEFINE,
+			X:     goast.NewIdent("mapLangToLocalizer"),
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.AssignStmt{
+						Lhs: []goast.Expr{
+							&goast.IndexExpr{
+								X:     goast.NewIdent("mapLangToLocalizer"),
+								Index: goast.NewIdent("lang"),
+							},
+						},
+						Tok: gotoken.ASSIGN,
+						Rhs: []goast.Expr{newLive(goast.NewIdent("lang"), goast.NewIdent("loc"))},
+					},
+				},
+			},
+		},
+		&goast.AssignStmt{
+			Lhs: []goast.Expr{goast.NewIdent("Default")},
+			Tok: gotoken.ASSIGN,
+			Rhs: []goast.Expr{
+				&goast.IndexExpr{
+					X:     goast.NewIdent("mapLangToLocalizer"),
+					Index: &goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(base.Lang.String())},
+				},
+			},
+		},
+	}
+
+	// Languages whose files are built with their tags register themselves in their own files,
+	// which may be initialized after this one
+	if common.Config.BuildTags {
+		body = append(body, &goast.AssignStmt{
+			Lhs: []goast.Expr{goast.NewIdent("liveLocalizer")},
+			Tok: gotoken.ASSIGN,
+			Rhs: []goast.Expr{
+				&goast.FuncLit{
+					Type: &goast.FuncType{
+						Params: &goast.FieldList{
+							List: []*goast.Field{
+								{
+									Names: []*goast.Ident{goast.NewIdent("lang")},
+									Type:  goast.NewIdent("string"),
+								},
+								{
+									Names: []*goast.Ident{goast.NewIdent("loc")},
+									Type:  goast.NewIdent("Localizer"),
+								},
+							},
+						},
+						Results: &goast.FieldList{
+							List: []*goast.Field{{Type: goast.NewIdent("Localizer")}},
+						},
+					},
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							&goast.ReturnStmt{
+								Results: []goast.Expr{newLive(goast.NewIdent("lang"), goast.NewIdent("loc"))},
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	body = append(body, &goast.GoStmt{
+		Call: &goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   goast.NewIdent(catalogName),
+				Sel: goast.NewIdent("Watch"),
+			},
+			Args: []goast.Expr{
+				&goast.CallExpr{
+					Fun: &goast.SelectorExpr{
+						X:   goast.NewIdent("context"),
+						Sel: goast.NewIdent("Background"),
+					},
+				},
+				&goast.SelectorExpr{
+					X:   goast.NewIdent("time"),
+					Sel: goast.NewIdent("Second"),
+				},
+				&goast.FuncLit{
+					Type: &goast.FuncType{
+						Params: &goast.FieldList{
+							List: []*goast.Field{
+								{
+									Names: []*goast.Ident{goast.NewIdent(errName)},
+									Type:  goast.NewIdent("error"),
+								},
+							},
+						},
+					},
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{generateLivePrintError(errName)},
+					},
+				},
+			},
+		},
+	})
+
+	*decls = append(*decls, &goast.FuncDecl{
+		Doc: &goast.CommentGroup{
+			List: []*goast.Comment{
+				{Text: "// Localization files are read from the directory set with " + getLiveDirectoryEnv() + " environment variable."},
+				{Text: "// If it is not set, the directory is found relative to this file at the path it has been compiled from,"},
+				{Text: "// which only exists on the machine that has built the program without -trimpath."},
+			},
+		},
+		Name: goast.NewIdent("init"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{},
+		},
+		Body: &goast.BlockStmt{List: body},
+	})
+}
@@ -0,0 +1,590 @@
+package codegen
+
+import (
+	goast "go/ast"
+	gotoken "go/token"
+	"strconv"
+
+	"github.com/infastin/go-l10n/scope"
+)
+
+// Name of the query parameter and the cookie that the middleware takes the language from by default.
+const middlewareDefaultName = "lang"
+
+// Generates net/http middleware that picks the localizer of the request
+// from the query parameter, the cookie and Accept-Language header, in that order,
+// puts it in the context of the request and sets Content-Language header of the response,
+// along with Vary header that names the request headers the language depends on.
+// Names of the query parameter and the cookie are set with options.
+func generateGeneralMiddleware(_ []scope.Localization, decls *[]goast.Decl) {
+	generateMiddlewareOptions(decls)
+	generateMiddlewareFunc(decls)
+	generateMiddlewareFuncRequestLocalizer(decls)
+	generateMiddlewareFuncMatchLanguage(decls)
+}
+
+func generateMiddlewareOptions(decls *[]goast.Decl) {
+	*decls = append(*decls,
+		&goast.GenDecl{
+			Tok: gotoken.TYPE,
+			Specs: []goast.Spec{
+				&goast.TypeSpec{
+					Name: goast.NewIdent("middlewareConfig"),
+					Type: &goast.StructType{
+						Fields: &goast.FieldList{
+							List: []*goast.Field{
+								{
+									Names: []*goast.Ident{goast.NewIdent("queryParam"), goast.NewIdent("cookie")},
+									Type:  goast.NewIdent("string"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		&goast.GenDecl{
+			Tok: gotoken.TYPE,
+			Specs: []goast.Spec{
+				&goast.TypeSpec{
+					Name: goast.NewIdent("MiddlewareOption"),
+					Type: &goast.FuncType{
+						Params: &goast.FieldList{
+							List: []*goast.Field{
+								{
+									Names: []*goast.Ident{goast.NewIdent("c")},
+									Type:  &goast.StarExpr{X: goast.NewIdent("middlewareConfig")},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	)
+
+	// Empty name disables the source of the language
+	for _, option := range []struct{ Func, Field string }{
+		{"WithQueryParam", "queryParam"},
+		{"WithCookie", "cookie"},
+	} {
+		*decls = append(*decls, &goast.FuncDecl{
+			Name: goast.NewIdent(option.Func),
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{
+					List: []*goast.Field{
+						{
+							Names: []*goast.Ident{goast.NewIdent("name")},
+							Type:  goast.NewIdent("string"),
+						},
+					},
+				},
+				Results: &goast.FieldList{
+					List: []*goast.Field{{Type: goast.NewIdent("MiddlewareOption")}},
+				},
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ReturnStmt{
+						Results: []goast.Expr{
+							&goast.FuncLit{
+								Type: &goast.FuncType{
+									Params: &goast.FieldList{
+										List: []*goast.Field{
+											{
+												Names: []*goast.Ident{goast.NewIdent("c")},
+												Type:  &goast.StarExpr{X: goast.NewIdent("middlewareConfig")},
+											},
+										},
+									},
+								},
+								Body: &goast.BlockStmt{
+									List: []goast.Stmt{
+										&goast.AssignStmt{
+											Lhs: []goast.Expr{
+												&goast.SelectorExpr{
+													X:   goast.NewIdent("c"),
+													Sel: goast.NewIdent(option.Field),
+												},
+											},
+											Tok: gotoken.ASSIGN,
+											Rhs: []goast.Expr{goast.NewIdent("name")},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+}
+
+func generateMiddlewareFunc(decls *[]goast.Decl) {
+	defaultName := &goast.BasicLit{
+		Kind:  gotoken.STRING,
+		Value: strconv.Quote(middlewareDefaultName),
+	}
+
+	// Returns statement that calls the method of the header of the response
+	headerStmt := func(method string, key string, value goast.Expr) goast.Stmt {
+		return &goast.ExprStmt{
+			X: &goast.CallExpr{
+				Fun: &goast.SelectorExpr{
+					X: &goast.CallExpr{
+						Fun: &goast.SelectorExpr{
+							X:   goast.NewIdent("w"),
+							Sel: goast.NewIdent("Header"),
+						},
+					},
+					Sel: goast.NewIdent(method),
+				},
+				Args: []goast.Expr{
+					&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(key)},
+					value,
+				},
+			},
+		}
+	}
+
+	handlerFunc := &goast.FuncLit{
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("w")},
+						Type: &goast.SelectorExpr{
+							X:   goast.NewIdent("http"),
+							Sel: goast.NewIdent("ResponseWriter"),
+						},
+					},
+					{
+						Names: []*goast.Ident{goast.NewIdent("r")},
+						Type: &goast.StarExpr{
+							X: &goast.SelectorExpr{
+								X:   goast.NewIdent("http"),
+								Sel: goast.NewIdent("Request"),
+							},
+						},
+					},
+				},
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{goast.NewIdent("loc")},
+					Tok: gotoken.DEFINE,
+					Rhs: []goast.Expr{
+						&goast.CallExpr{
+							Fun: goast.NewIdent("requestLocalizer"),
+							Args: []goast.Expr{
+								goast.NewIdent("r"),
+								&goast.UnaryExpr{Op: gotoken.AND, X: goast.NewIdent("c")},
+							},
+						},
+					},
+				},
+				// The response depends on the headers the language is taken from,
+				// so caches must not serve it to requests with other ones
+				headerStmt("Add", "Vary", &goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote("Accept-Language")}),
+				&goast.IfStmt{
+					Cond: &goast.BinaryExpr{
+						X: &goast.SelectorExpr{
+							X:   goast.NewIdent("c"),
+							Sel: goast.NewIdent("cookie"),
+						},
+						Op: gotoken.NEQ,
+						Y:  &goast.BasicLit{Kind: gotoken.STRING, Value: `""`},
+					},
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							headerStmt("Add", "Vary", &goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote("Cookie")}),
+						},
+					},
+				},
+				// Language of the default localizer is unknown if it has been replaced
+				&goast.IfStmt{
+					Init: &goast.AssignStmt{
+						Lhs: []goast.Expr{goast.NewIdent("lang")},
+						Tok: gotoken.DEFINE,
+						Rhs: []goast.Expr{
+							&goast.CallExpr{
+								Fun:  goast.NewIdent("Language"),
+								Args: []goast.Expr{goast.NewIdent("loc")},
+							},
+						},
+					},
+					Cond: &goast.BinaryExpr{
+						X:  goast.NewIdent("lang"),
+						Op: gotoken.NEQ,
+						Y:  &goast.BasicLit{Kind: gotoken.STRING, Value: `""`},
+					},
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							headerStmt("Set", "Content-Language", goast.NewIdent("lang")),
+						},
+					},
+				},
+				&goast.ExprStmt{
+					X: &goast.CallExpr{
+						Fun: &goast.SelectorExpr{
+							X:   goast.NewIdent("next"),
+							Sel: goast.NewIdent("ServeHTTP"),
+						},
+						Args: []goast.Expr{
+							goast.NewIdent("w"),
+							&goast.CallExpr{
+								Fun: &goast.SelectorExpr{
+									X:   goast.NewIdent("r"),
+									Sel: goast.NewIdent("WithContext"),
+								},
+								Args: []goast.Expr{
+									&goast.CallExpr{
+										Fun: goast.NewIdent("NewContext"),
+										Args: []goast.Expr{
+											&goast.CallExpr{
+												Fun: &goast.SelectorExpr{
+													X:   goast.NewIdent("r"),
+													Sel: goast.NewIdent("Context"),
+												},
+											},
+											goast.NewIdent("loc"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	*decls = append(*decls, &goast.FuncDecl{
+		Name: goast.NewIdent("Middleware"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("next")},
+						Type: &goast.SelectorExpr{
+							X:   goast.NewIdent("http"),
+							Sel: goast.NewIdent("Handler"),
+						},
+					},
+					{
+						Names: []*goast.Ident{goast.NewIdent("opts")},
+						Type:  &goast.Ellipsis{Elt: goast.NewIdent("MiddlewareOption")},
+					},
+				},
+			},
+			Results: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Type: &goast.SelectorExpr{
+							X:   goast.NewIdent("http"),
+							Sel: goast.NewIdent("Handler"),
+						},
+					},
+				},
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{goast.NewIdent("c")},
+					Tok: gotoken.DEFINE,
+					Rhs: []goast.Expr{
+						&goast.CompositeLit{
+							Type: goast.NewIdent("middlewareConfig"),
+							Elts: []goast.Expr{
+								&goast.KeyValueExpr{Key: goast.NewIdent("queryParam"), Value: defaultName},
+								&goast.KeyValueExpr{Key: goast.NewIdent("cookie"), Value: defaultName},
+							},
+						},
+					},
+				},
+				&goast.RangeStmt{
+					Key:   goast.NewIdent("_"),
+					Value: goast.NewIdent("opt"),
+					Tok:   gotoken.DEFINE,
+					X:     goast.NewIdent("opts"),
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							&goast.ExprStmt{
+								X: &goast.CallExpr{
+									Fun: goast.NewIdent("opt"),
+									Args: []goast.Expr{
+										&goast.UnaryExpr{Op: gotoken.AND, X: goast.NewIdent("c")},
+									},
+								},
+							},
+						},
+					},
+				},
+				&goast.ReturnStmt{
+					Results: []goast.Expr{
+						&goast.CallExpr{
+							Fun: &goast.SelectorExpr{
+								X:   goast.NewIdent("http"),
+								Sel: goast.NewIdent("HandlerFunc"),
+							},
+							Args: []goast.Expr{handlerFunc},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+// Generates function that picks the localizer of the request.
+// The first source of the language that matches any of the supported languages wins,
+// and if none of them does, it is the default localizer.
+func generateMiddlewareFuncRequestLocalizer(decls *[]goast.Decl) {
+	// Returns if statement that returns the localizer of the language if it matches
+	matchStmt := func(lang goast.Expr) *goast.IfStmt {
+		return &goast.IfStmt{
+			Init: &goast.AssignStmt{
+				Lhs: []goast.Expr{goast.NewIdent("loc"), goast.NewIdent("ok")},
+				Tok: gotoken.DEFINE,
+				Rhs: []goast.Expr{
+					&goast.CallExpr{
+						Fun:  goast.NewIdent("matchLanguage"),
+						Args: []goast.Expr{lang},
+					},
+				},
+			},
+			Cond: goast.NewIdent("ok"),
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.ReturnStmt{Results: []goast.Expr{goast.NewIdent("loc")}},
+				},
+			},
+		}
+	}
+
+	configField := func(name string) goast.Expr {
+		return &goast.SelectorExpr{X: goast.NewIdent("c"), Sel: goast.NewIdent(name)}
+	}
+
+	notEmpty := func(x goast.Expr) goast.Expr {
+		return &goast.BinaryExpr{
+			X:  x,
+			Op: gotoken.NEQ,
+			Y:  &goast.BasicLit{Kind: gotoken.STRING, Value: `""`},
+		}
+	}
+
+	*decls = append(*decls, &goast.FuncDecl{
+		Name: goast.NewIdent("requestLocalizer"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("r")},
+						Type: &goast.StarExpr{
+							X: &goast.SelectorExpr{
+								X:   goast.NewIdent("http"),
+								Sel: goast.NewIdent("Request"),
+							},
+						},
+					},
+					{
+						Names: []*goast.Ident{goast.NewIdent("c")},
+						Type:  &goast.StarExpr{X: goast.NewIdent("middlewareConfig")},
+					},
+				},
+			},
+			Results: &goast.FieldList{
+				List: []*goast.Field{{Type: goast.NewIdent("Localizer")}},
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.IfStmt{
+					Cond: notEmpty(configField("queryParam")),
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							matchStmt(&goast.CallExpr{
+								Fun: &goast.SelectorExpr{
+									X: &goast.CallExpr{
+										Fun: &goast.SelectorExpr{
+											X: &goast.SelectorExpr{
+												X:   goast.NewIdent("r"),
+												Sel: goast.NewIdent("URL"),
+											},
+											Sel: goast.NewIdent("Query"),
+										},
+									},
+									Sel: goast.NewIdent("Get"),
+								},
+								Args: []goast.Expr{configField("queryParam")},
+							}),
+						},
+					},
+				},
+				&goast.IfStmt{
+					Cond: notEmpty(configField("cookie")),
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							&goast.IfStmt{
+								Init: &goast.AssignStmt{
+									Lhs: []goast.Expr{goast.NewIdent("cookie"), goast.NewIdent("err")},
+									Tok: gotoken.DEFINE,
+									Rhs: []goast.Expr{
+										&goast.CallExpr{
+											Fun: &goast.SelectorExpr{
+												X:   goast.NewIdent("r"),
+												Sel: goast.NewIdent("Cookie"),
+											},
+											Args: []goast.Expr{configField("cookie")},
+										},
+									},
+								},
+								Cond: &goast.BinaryExpr{
+									X:  goast.NewIdent("err"),
+									Op: gotoken.EQL,
+									Y:  goast.NewIdent("nil"),
+								},
+								Body: &goast.BlockStmt{
+									List: []goast.Stmt{
+										matchStmt(&goast.SelectorExpr{
+											X:   goast.NewIdent("cookie"),
+											Sel: goast.NewIdent("Value"),
+										}),
+									},
+								},
+							},
+						},
+					},
+				},
+				&goast.IfStmt{
+					Init: &goast.AssignStmt{
+						Lhs: []goast.Expr{goast.NewIdent("loc"), goast.NewIdent("_"), goast.NewIdent("conf")},
+						Tok: gotoken.DEFINE,
+						Rhs: []goast.Expr{
+							&goast.CallExpr{
+								Fun: goast.NewIdent("FromAcceptLanguage"),
+								Args: []goast.Expr{
+									&goast.CallExpr{
+										Fun: &goast.SelectorExpr{
+											X: &goast.SelectorExpr{
+												X:   goast.NewIdent("r"),
+												Sel: goast.NewIdent("Header"),
+											},
+											Sel: goast.NewIdent("Get"),
+										},
+										Args: []goast.Expr{
+											&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote("Accept-Language")},
+										},
+									},
+								},
+							},
+						},
+					},
+					Cond: &goast.BinaryExpr{
+						X:  goast.NewIdent("conf"),
+						Op: gotoken.NEQ,
+						Y: &goast.SelectorExpr{
+							X:   goast.NewIdent("language"),
+							Sel: goast.NewIdent("No"),
+						},
+					},
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							&goast.ReturnStmt{Results: []goast.Expr{goast.NewIdent("loc")}},
+						},
+					},
+				},
+				&goast.ReturnStmt{Results: []goast.Expr{goast.NewIdent("Default")}},
+			},
+		},
+	})
+}
+
+// Generates function that returns localizer of the best match of the language,
+// unless the language is invalid or doesn't match any of the supported languages.
+func generateMiddlewareFuncMatchLanguage(decls *[]goast.Decl) {
+	*decls = append(*decls, &goast.FuncDecl{
+		Name: goast.NewIdent("matchLanguage"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("lang")},
+						Type:  goast.NewIdent("string"),
+					},
+				},
+			},
+			Results: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("loc")},
+						Type:  goast.NewIdent("Localizer"),
+					},
+					{
+						Names: []*goast.Ident{goast.NewIdent("ok")},
+						Type:  goast.NewIdent("bool"),
+					},
+				},
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{goast.NewIdent("tag"), goast.NewIdent("err")},
+					Tok: gotoken.DEFINE,
+					Rhs: []goast.Expr{
+						&goast.CallExpr{
+							Fun: &goast.SelectorExpr{
+								X:   goast.NewIdent("language"),
+								Sel: goast.NewIdent("Parse"),
+							},
+							Args: []goast.Expr{goast.NewIdent("lang")},
+						},
+					},
+				},
+				&goast.IfStmt{
+					Cond: &goast.BinaryExpr{
+						X:  goast.NewIdent("err"),
+						Op: gotoken.NEQ,
+						Y:  goast.NewIdent("nil"),
+					},
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							&goast.ReturnStmt{
+								Results: []goast.Expr{goast.NewIdent("nil"), goast.NewIdent("false")},
+							},
+						},
+					},
+				},
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{goast.NewIdent("loc"), goast.NewIdent("_"), goast.NewIdent("conf")},
+					Tok: gotoken.DEFINE,
+					Rhs: []goast.Expr{
+						&goast.CallExpr{
+							Fun:  goast.NewIdent("Match"),
+							Args: []goast.Expr{goast.NewIdent("tag")},
+						},
+					},
+				},
+				&goast.ReturnStmt{
+					Results: []goast.Expr{
+						goast.NewIdent("loc"),
+						&goast.BinaryExpr{
+							X:  goast.NewIdent("conf"),
+							Op: gotoken.NEQ,
+							Y: &goast.SelectorExpr{
+								X:   goast.NewIdent("language"),
+								Sel: goast.NewIdent("No"),
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,606 @@
+package codegen
+
+import (
+	goast "go/ast"
+	gotoken "go/token"
+	"strconv"
+	"strings"
+
+	"github.com/infastin/go-l10n/scope"
+)
+
+// Generates MessageID type with constants of all messages and functions
+// that translate messages by their IDs with arguments taken from a map,
+// which is checked against the arguments of the messages.
+func generateGeneralTranslate(locs []scope.Localization, decls *[]goast.Decl) {
+	generateTranslateMessageIDs(locs, decls)
+	generateTranslateErrors(decls)
+	generateTranslateFuncParseMessageID(decls)
+	generateTranslateFunc(locs, decls)
+	generateTranslateFuncCheckArguments(decls)
+	generateTranslateFuncHasArgument(decls)
+	if usesIntArguments(locs) {
+		generateTranslateFuncIntArgument(decls)
+	}
+}
+
+// Reports whether any of the messages has an int argument.
+func usesIntArguments(locs []scope.Localization) bool {
+	for i := 0; i < len(locs[0].Scopes); i++ {
+		ms := &locs[0].Scopes[i]
+		for j := 0; j < len(ms.Arguments); j++ {
+			if ms.Arguments[j].GoType.String() == "int" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns name of the constant of the message ID: MessageID_Auth_Login_Title.
+func getMessageIDName(ms *scope.MessageScope) string {
+	return "MessageID_" + strings.ReplaceAll(ms.Name, ".", "_")
+}
+
+// Returns name of the local variable that holds value of the argument,
+// which can't collide with other variables, since argument names consist of letters only.
+func getTranslateArgumentName(arg *scope.Argument) string {
+	return "arg_" + arg.Name
+}
+
+func generateTranslateMessageIDs(locs []scope.Localization, decls *[]goast.Decl) {
+	constDecl := &goast.GenDecl{
+		Tok: gotoken.CONST,
+	}
+
+	messageIDs := &goast.CompositeLit{
+		Type: &goast.ArrayType{Elt: goast.NewIdent("MessageID")},
+	}
+
+	for i := 0; i < len(locs[0].Scopes); i++ {
+		ms := &locs[0].Scopes[i]
+
+		constDecl.Specs = append(constDecl.Specs, &goast.ValueSpec{
+			Names: []*goast.Ident{goast.NewIdent(getMessageIDName(ms))},
+			Type:  goast.NewIdent("MessageID"),
+			Values: []goast.Expr{
+				&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(ms.Name)},
+			},
+		})
+
+		messageIDs.Elts = append(messageIDs.Elts, goast.NewIdent(getMessageIDName(ms)))
+	}
+
+	*decls = append(*decls,
+		&goast.GenDecl{
+			Tok: gotoken.TYPE,
+			Specs: []goast.Spec{
+				&goast.TypeSpec{
+					Name: goast.NewIdent("MessageID"),
+					Type: goast.NewIdent("string"),
+				},
+			},
+		},
+		constDecl,
+		&goast.GenDecl{
+			Tok: gotoken.VAR,
+			Specs: []goast.Spec{
+				&goast.ValueSpec{
+					Names:  []*goast.Ident{goast.NewIdent("messageIDs")},
+					Values: []goast.Expr{messageIDs},
+				},
+			},
+		},
+	)
+}
+
+func generateTranslateErrors(decls *[]goast.Decl) {
+	errorDecl := &goast.GenDecl{
+		Tok: gotoken.VAR,
+	}
+
+	for _, e := range []struct{ Name, Text string }{
+		{"ErrUnknownMessage", "unknown message"},
+		{"ErrInvalidArgument", "invalid argument"},
+	} {
+		errorDecl.Specs = append(errorDecl.Specs, &goast.ValueSpec{
+			Names: []*goast.Ident{goast.NewIdent(e.Name)},
+			Values: []goast.Expr{
+				&goast.CallExpr{
+					Fun: &goast.SelectorExpr{
+						X:   goast.NewIdent("errors"),
+						Sel: goast.NewIdent("New"),
+					},
+					Args: []goast.Expr{
+						&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(e.Text)},
+					},
+				},
+			},
+		})
+	}
+
+	*decls = append(*decls, errorDecl)
+}
+
+// Returns call of fmt.Errorf with the given format and arguments.
+func generateErrorf(format string, args ...goast.Expr) *goast.CallExpr {
+	return &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   goast.NewIdent("fmt"),
+			Sel: goast.NewIdent("Errorf"),
+		},
+		Args: append([]goast.Expr{
+			&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(format)},
+		}, args...),
+	}
+}
+
+func generateTranslateFuncParseMessageID(decls *[]goast.Decl) {
+	*decls = append(*decls, &goast.FuncDecl{
+		Name: goast.NewIdent("ParseMessageID"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("s")},
+						Type:  goast.NewIdent("string"),
+					},
+				},
+			},
+			Results: &goast.FieldList{
+				List: []*goast.Field{
+					{Type: goast.NewIdent("MessageID")},
+					{Type: goast.NewIdent("error")},
+				},
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.RangeStmt{
+					Key:   goast.NewIdent("_"),
+					Value: goast.NewIdent("id"),
+					Tok:   gotoken.DEFINE,
+					X:     goast.NewIdent("messageIDs"),
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							&goast.IfStmt{
+								Cond: &goast.BinaryExpr{
+									X: &goast.CallExpr{
+										Fun:  goast.NewIdent("string"),
+										Args: []goast.Expr{goast.NewIdent("id")},
+									},
+									Op: gotoken.EQL,
+									Y:  goast.NewIdent("s"),
+								},
+								Body: &goast.BlockStmt{
+									List: []goast.Stmt{
+										&goast.ReturnStmt{
+											Results: []goast.Expr{goast.NewIdent("id"), goast.NewIdent("nil")},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&goast.ReturnStmt{
+					Results: []goast.Expr{
+						&goast.BasicLit{Kind: gotoken.STRING, Value: `""`},
+						generateErrorf("%w: %q", goast.NewIdent("ErrUnknownMessage"), goast.NewIdent("s")),
+					},
+				},
+			},
+		},
+	})
+}
+
+// Generates function that translates the message with the given ID.
+// Each message gets its own case, which checks that the map contains
+// exactly the arguments of the message and that their values are of the right types.
+func generateTranslateFunc(locs []scope.Localization, decls *[]goast.Decl) {
+	switchStmt := &goast.SwitchStmt{
+		Tag:  goast.NewIdent("id"),
+		Body: &goast.BlockStmt{},
+	}
+
+	for i := 0; i < len(locs[0].Scopes); i++ {
+		ms := &locs[0].Scopes[i]
+
+		checkCall := &goast.CallExpr{
+			Fun:  goast.NewIdent("checkArguments"),
+			Args: []goast.Expr{goast.NewIdent("id"), goast.NewIdent("args")},
+		}
+
+		body := []goast.Stmt{
+			&goast.IfStmt{
+				Init: &goast.AssignStmt{
+					Lhs: []goast.Expr{goast.NewIdent("err")},
+					Tok: gotoken.DEFINE,
+					Rhs: []goast.Expr{checkCall},
+				},
+				Cond: &goast.BinaryExpr{
+					X:  goast.NewIdent("err"),
+					Op: gotoken.NEQ,
+					Y:  goast.NewIdent("nil"),
+				},
+				Body: &goast.BlockStmt{
+					List: []goast.Stmt{
+						&goast.ReturnStmt{
+							Results: []goast.Expr{
+								&goast.BasicLit{Kind: gotoken.STRING, Value: `""`},
+								goast.NewIdent("err"),
+							},
+						},
+					},
+				},
+			},
+		}
+
+		messageCall := generateLocalizerCall(goast.NewIdent("loc"), ms, nil)
+
+		for j := 0; j < len(ms.Arguments); j++ {
+			arg := &ms.Arguments[j]
+			argName := &goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(arg.Name)}
+			argValue := &goast.IndexExpr{X: goast.NewIdent("args"), Index: argName}
+
+			checkCall.Args = append(checkCall.Args, argName)
+
+			body = append(body,
+				&goast.AssignStmt{
+					Lhs: []goast.Expr{
+						goast.NewIdent(getTranslateArgumentName(arg)),
+						goast.NewIdent("ok"),
+					},
+					Tok: gotoken.DEFINE,
+					Rhs: []goast.Expr{generateTranslateArgument(arg, argValue)},
+				},
+				&goast.IfStmt{
+					Cond: &goast.UnaryExpr{Op: gotoken.NOT, X: goast.NewIdent("ok")},
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							&goast.ReturnStmt{
+								Results: []goast.Expr{
+									&goast.BasicLit{Kind: gotoken.STRING, Value: `""`},
+									generateErrorf("%s: %w: %q must be %s, not %T",
+										goast.NewIdent("id"),
+										goast.NewIdent("ErrInvalidArgument"),
+										argName,
+										&goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(arg.GoType.String())},
+										argValue,
+									),
+								},
+							},
+						},
+					},
+				},
+			)
+
+			messageCall.Args = append(messageCall.Args, goast.NewIdent(getTranslateArgumentName(arg)))
+		}
+
+		body = append(body, &goast.ReturnStmt{
+			Results: []goast.Expr{messageCall, goast.NewIdent("nil")},
+		})
+
+		switchStmt.Body.List = append(switchStmt.Body.List, &goast.CaseClause{
+			List: []goast.Expr{goast.NewIdent(getMessageIDName(ms))},
+			Body: body,
+		})
+	}
+
+	*decls = append(*decls, &goast.FuncDecl{
+		Doc: &goast.CommentGroup{
+			List: []*goast.Comment{
+				{Text: "// Translate returns the message with the given ID in the language of loc."},
+				{Text: "// args must contain exactly the arguments of the message, each of its Go type,"},
+				{Text: "// except that int arguments may also be given as integral float64 values."},
+			},
+		},
+		Name: goast.NewIdent("Translate"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("loc")},
+						Type:  goast.NewIdent("Localizer"),
+					},
+					{
+						Names: []*goast.Ident{goast.NewIdent("id")},
+						Type:  goast.NewIdent("MessageID"),
+					},
+					{
+						Names: []*goast.Ident{goast.NewIdent("args")},
+						Type: &goast.MapType{
+							Key:   goast.NewIdent("string"),
+							Value: goast.NewIdent("any"),
+						},
+					},
+				},
+			},
+			Results: &goast.FieldList{
+				List: []*goast.Field{
+					{Type: goast.NewIdent("string")},
+					{Type: goast.NewIdent("error")},
+				},
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				switchStmt,
+				&goast.ReturnStmt{
+					Results: []goast.Expr{
+						&goast.BasicLit{Kind: gotoken.STRING, Value: `""`},
+						generateErrorf("%w: %q", goast.NewIdent("ErrUnknownMessage"), goast.NewIdent("id")),
+					},
+				},
+			},
+		},
+	})
+}
+
+// Returns expression that takes value of the argument from the map along with whether it has the right type.
+// Integers are also taken from integral float64 values, which is what encoding/json decodes numbers into.
+func generateTranslateArgument(arg *scope.Argument, argValue goast.Expr) goast.Expr {
+	if arg.GoType.String() == "int" {
+		return &goast.CallExpr{
+			Fun:  goast.NewIdent("intArgument"),
+			Args: []goast.Expr{argValue},
+		}
+	}
+
+	return &goast.TypeAssertExpr{
+		X:    argValue,
+		Type: getPackageFieldType(arg),
+	}
+}
+
+// Generates function that checks that the map contains all of the arguments
+// with the given names and nothing else.
+func generateTranslateFuncCheckArguments(decls *[]goast.Decl) {
+	*decls = append(*decls, &goast.FuncDecl{
+		Name: goast.NewIdent("checkArguments"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("id")},
+						Type:  goast.NewIdent("MessageID"),
+					},
+					{
+						Names: []*goast.Ident{goast.NewIdent("args")},
+						Type: &goast.MapType{
+							Key:   goast.NewIdent("string"),
+							Value: goast.NewIdent("any"),
+						},
+					},
+					{
+						Names: []*goast.Ident{goast.NewIdent("names")},
+						Type:  &goast.Ellipsis{Elt: goast.NewIdent("string")},
+					},
+				},
+			},
+			Results: &goast.FieldList{
+				List: []*goast.Field{{Type: goast.NewIdent("error")}},
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.RangeStmt{
+					Key:   goast.NewIdent("_"),
+					Value: goast.NewIdent("name"),
+					Tok:   gotoken.DEFINE,
+					X:     goast.NewIdent("names"),
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							&goast.IfStmt{
+								Init: &goast.AssignStmt{
+									Lhs: []goast.Expr{goast.NewIdent("_"), goast.NewIdent("ok")},
+									Tok: gotoken.DEFINE,
+									Rhs: []goast.Expr{
+										&goast.IndexExpr{X: goast.NewIdent("args"), Index: goast.NewIdent("name")},
+									},
+								},
+								Cond: &goast.UnaryExpr{Op: gotoken.NOT, X: goast.NewIdent("ok")},
+								Body: &goast.BlockStmt{
+									List: []goast.Stmt{
+										&goast.ReturnStmt{
+											Results: []goast.Expr{
+												generateErrorf("%s: %w: %q is missing",
+													goast.NewIdent("id"),
+													goast.NewIdent("ErrInvalidArgument"),
+													goast.NewIdent("name"),
+												),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&goast.RangeStmt{
+					Key: goast.NewIdent("name"),
+					Tok: gotoken.DEFINE,
+					X:   goast.NewIdent("args"),
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							&goast.IfStmt{
+								Cond: &goast.UnaryExpr{
+									Op: gotoken.NOT,
+									X: &goast.CallExpr{
+										Fun:  goast.NewIdent("hasArgument"),
+										Args: []goast.Expr{goast.NewIdent("names"), goast.NewIdent("name")},
+									},
+								},
+								Body: &goast.BlockStmt{
+									List: []goast.Stmt{
+										&goast.ReturnStmt{
+											Results: []goast.Expr{
+												generateErrorf("%s: %w: %q is unknown",
+													goast.NewIdent("id"),
+													goast.NewIdent("ErrInvalidArgument"),
+													goast.NewIdent("name"),
+												),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&goast.ReturnStmt{Results: []goast.Expr{goast.NewIdent("nil")}},
+			},
+		},
+	})
+}
+
+func generateTranslateFuncHasArgument(decls *[]goast.Decl) {
+	*decls = append(*decls, &goast.FuncDecl{
+		Name: goast.NewIdent("hasArgument"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("names")},
+						Type:  &goast.ArrayType{Elt: goast.NewIdent("string")},
+					},
+					{
+						Names: []*goast.Ident{goast.NewIdent("name")},
+						Type:  goast.NewIdent("string"),
+					},
+				},
+			},
+			Results: &goast.FieldList{
+				List: []*goast.Field{{Type: goast.NewIdent("bool")}},
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.RangeStmt{
+					Key:   goast.NewIdent("_"),
+					Value: goast.NewIdent("n"),
+					Tok:   gotoken.DEFINE,
+					X:     goast.NewIdent("names"),
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							&goast.IfStmt{
+								Cond: &goast.BinaryExpr{
+									X:  goast.NewIdent("n"),
+									Op: gotoken.EQL,
+									Y:  goast.NewIdent("name"),
+								},
+								Body: &goast.BlockStmt{
+									List: []goast.Stmt{
+										&goast.ReturnStmt{Results: []goast.Expr{goast.NewIdent("true")}},
+									},
+								},
+							},
+						},
+					},
+				},
+				&goast.ReturnStmt{Results: []goast.Expr{goast.NewIdent("false")}},
+			},
+		},
+	})
+}
+
+// Generates function that converts the value of an int argument,
+// which is either int or float64 without fractional part that fits into int.
+func generateTranslateFuncIntArgument(decls *[]goast.Decl) {
+	minInt := &goast.SelectorExpr{X: goast.NewIdent("math"), Sel: goast.NewIdent("MinInt")}
+
+	*decls = append(*decls, &goast.FuncDecl{
+		Name: goast.NewIdent("intArgument"),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: []*goast.Field{
+					{
+						Names: []*goast.Ident{goast.NewIdent("value")},
+						Type:  goast.NewIdent("any"),
+					},
+				},
+			},
+			Results: &goast.FieldList{
+				List: []*goast.Field{
+					{Type: goast.NewIdent("int")},
+					{Type: goast.NewIdent("bool")},
+				},
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: []goast.Stmt{
+				&goast.TypeSwitchStmt{
+					Assign: &goast.AssignStmt{
+						Lhs: []goast.Expr{goast.NewIdent("value")},
+						Tok: gotoken.DEFINE,
+						Rhs: []goast.Expr{&goast.TypeAssertExpr{X: goast.NewIdent("value")}},
+					},
+					Body: &goast.BlockStmt{
+						List: []goast.Stmt{
+							&goast.CaseClause{
+								List: []goast.Expr{goast.NewIdent("int")},
+								Body: []goast.Stmt{
+									&goast.ReturnStmt{
+										Results: []goast.Expr{goast.NewIdent("value"), goast.NewIdent("true")},
+									},
+								},
+							},
+							&goast.CaseClause{
+								List: []goast.Expr{goast.NewIdent("float64")},
+								Body: []goast.Stmt{
+									// -math.MinInt is one more than math.MaxInt, and it is exact as float64
+									&goast.IfStmt{
+										Cond: &goast.BinaryExpr{
+											X: &goast.BinaryExpr{
+												X: &goast.BinaryExpr{
+													X:  goast.NewIdent("value"),
+													Op: gotoken.EQL,
+													Y: &goast.CallExpr{
+														Fun:  &goast.SelectorExpr{X: goast.NewIdent("math"), Sel: goast.NewIdent("Trunc")},
+														Args: []goast.Expr{goast.NewIdent("value")},
+													},
+												},
+												Op: gotoken.LAND,
+												Y: &goast.BinaryExpr{
+													X:  goast.NewIdent("value"),
+													Op: gotoken.GEQ,
+													Y:  minInt,
+												},
+											},
+											Op: gotoken.LAND,
+											Y: &goast.BinaryExpr{
+												X:  goast.NewIdent("value"),
+												Op: gotoken.LSS,
+												Y:  &goast.UnaryExpr{Op: gotoken.SUB, X: minInt},
+											},
+										},
+										Body: &goast.BlockStmt{
+											List: []goast.Stmt{
+												&goast.ReturnStmt{
+													Results: []goast.Expr{
+														&goast.CallExpr{
+															Fun:  goast.NewIdent("int"),
+															Args: []goast.Expr{goast.NewIdent("value")},
+														},
+														goast.NewIdent("true"),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&goast.ReturnStmt{
+					Results: []goast.Expr{
+						&goast.BasicLit{Kind: gotoken.INT, Value: "0"},
+						goast.NewIdent("false"),
+					},
+				},
+			},
+		},
+	})
+}
@@ -1,57 +1,175 @@
 package common
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
 	"regexp"
 
 	"github.com/alecthomas/kong"
 	"github.com/infastin/go-l10n/ast"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
 )
 
 const cliVersion = "v1.0.6"
 
 var Config struct {
+	// Name of the command: generate, check, export or import
+	Command           string
 	Directory         string
 	PackageName       string
 	Output            string
+	Input             string
+	ExchangeFormat    string
 	Pattern           regexp.Regexp
+	Base              language.Tag
+	Fallback          language.Tag
+	Strict            bool
+	ExportTypes       bool
+	Middleware        bool
+	BuildTags         bool
+	Live              bool
+	MaxErrors         int
 	FormatSpecifiers  []rune
 	SpecifierToGoType map[rune]ast.GoType
 	Imports           []ast.GoImport
 }
 
+// Format specifiers are set regardless of the command line,
+// since localization files can also be read at runtime.
+func init() {
+	Config.FormatSpecifiers = []rune{'s', 'd', 'f', 'S', 'F', 'M'}
+	Config.SpecifierToGoType = map[rune]ast.GoType{
+		's': {Type: "string"},
+		'd': {Type: "int"},
+		'f': {Type: "float64"},
+		'v': {Type: "any"},
+		'S': {
+			Import:  "fmt",
+			Package: "fmt",
+			Type:    "Stringer",
+		},
+	}
+}
+
+// Default pattern of localization file names: <name>.<lang>.<ext>.
+const DefaultPattern = `([a-z_]+)\.([a-zA-Z0-9_-]+)\.(yaml|yml|json|toml|arb|ftl|xml|strings|stringsdict)`
+
 var cli struct {
-	Dir     string           `required:"" short:"d" type:"existingdir" placeholder:"DIR" help:"Path to the directory with localization files."`
-	Pattern string           `optional:"" short:"p" default:"${pattern}" placeholder:"PATTERN" help:"Localization file regexp pattern."`
-	Package string           `optional:"" short:"P" default:"${package}" help:"Package name."`
-	Output  string           `required:"" short:"o" placeholder:"DIR" help:"Path to output directory."`
-	Version kong.VersionFlag `optional:"" short:"v" help:"Print version number."`
+	Generate       struct{} `cmd:"" default:"withargs" help:"Generate localization code (default command)."`
+	Check          struct{} `cmd:"" help:"Check localization files and whether the generated code is up to date, without writing anything."`
+	ExportMessages struct {
+		Format string `required:"" enum:"po,xliff,arb" placeholder:"FORMAT" help:"Format of exported files: po, xliff or arb."`
+	} `cmd:"" name:"export" help:"Export messages for translators into the output directory."`
+	ImportMessages struct {
+		Format string `required:"" enum:"po,xliff" placeholder:"FORMAT" help:"Format of imported files: po or xliff."`
+		Input  string `required:"" short:"i" type:"existingdir" placeholder:"DIR" help:"Path to the directory with translated files."`
+	} `cmd:"" name:"import" help:"Import translated messages into localization files."`
+
+	Dir        string           `required:"" short:"d" type:"existingdir" placeholder:"DIR" help:"Path to the directory with localization files."`
+	Pattern    string           `optional:"" short:"p" default:"${pattern}" placeholder:"PATTERN" help:"Localization file regexp pattern."`
+	Package    string           `optional:"" short:"P" default:"${package}" help:"Package name."`
+	Output     string           `optional:"" short:"o" placeholder:"DIR" help:"Path to output directory, required by all commands but import."`
+	Base       string           `optional:"" short:"b" placeholder:"LANG" help:"Base language that defines messages, their arguments and documentation (en if there are its files, otherwise the first language alphabetically)."`
+	Fallback   string           `optional:"" short:"f" placeholder:"LANG" help:"Language to fall back to when a message is missing (base language by default)."`
+	Strict     bool             `optional:"" short:"s" help:"Fail when a message is missing instead of falling back to another language."`
+	Export     bool             `optional:"" short:"e" help:"Export localizer types with idiomatic names like PtBR."`
+	Middleware bool             `optional:"" help:"Generate net/http middleware that picks the language of the request."`
+	BuildTags  bool             `optional:"" help:"Put files of all languages but the base one under build tags, so that they can be left out of the binary."`
+	Live       bool             `optional:"" help:"Generate localizers for development builds that read localization files at runtime and reload them when they change."`
+	MaxErrors  int              `optional:"" placeholder:"N" help:"Maximum number of errors to report, 0 means no limit."`
+	Config     kong.ConfigFlag  `optional:"" short:"c" placeholder:"FILE" help:"Path to configuration file."`
+	Version    kong.VersionFlag `optional:"" short:"v" help:"Print version number."`
 }
 
-func InitConfig() {
-	kong.Parse(&cli,
+// Configuration files that are looked up in the current directory, in this order.
+var configPaths = []string{".go-l10n.yaml", ".go-l10n.yml", ".go-l10n.json"}
+
+// Returns the first of the configuration files that exists, if there is any,
+// so that the others don't override it.
+func findConfig() (paths []string) {
+	for _, path := range configPaths {
+		if _, err := os.Stat(path); err == nil {
+			return []string{path}
+		}
+	}
+	return nil
+}
+
+// Loads configuration file in YAML or JSON format.
+// Keys of the configuration are the same as the names of the flags.
+func loadConfig(r io.Reader) (kong.Resolver, error) {
+	values := make(map[string]any)
+
+	err := yaml.NewDecoder(r).Decode(&values)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	return kong.JSON(bytes.NewReader(data))
+}
+
+// Returns options of the command line parser.
+// Flags that are not set are taken from the configuration file
+// found in the current directory or, if it is specified, from the one of --config flag.
+func cliOptions() []kong.Option {
+	return []kong.Option{
 		kong.Description("Simple command-line utility to localize your Golang applications."),
 		kong.Vars{
-			"pattern": `([a-z_]+)\.([a-z_]+)\.(yaml|yml|json|toml)`,
+			"pattern": DefaultPattern,
 			"package": "l10n",
 			"version": cliVersion,
 		},
-	)
+		kong.Configuration(loadConfig, findConfig()...),
+	}
+}
+
+func InitConfig() {
+	ctx := kong.Parse(&cli, cliOptions()...)
+
+	Config.Command = ctx.Command()
+
+	switch Config.Command {
+	case "export":
+		Config.ExchangeFormat = cli.ExportMessages.Format
+	case "import":
+		Config.ExchangeFormat = cli.ImportMessages.Format
+		Config.Input = cli.ImportMessages.Input
+	}
+
+	if cli.Output == "" && Config.Command != "import" {
+		ctx.Fatalf("missing flags: --output=DIR")
+	}
 
 	Config.Directory = cli.Dir
 	Config.Pattern = *regexp.MustCompile(cli.Pattern)
 	Config.PackageName = cli.Package
 	Config.Output = cli.Output
+	Config.Strict = cli.Strict
+	Config.ExportTypes = cli.Export
+	Config.Middleware = cli.Middleware
+	Config.BuildTags = cli.BuildTags
+	Config.Live = cli.Live
+	Config.MaxErrors = cli.MaxErrors
 
-	Config.FormatSpecifiers = []rune{'s', 'd', 'f', 'S', 'F', 'M'}
-	Config.SpecifierToGoType = map[rune]ast.GoType{
-		's': {Type: "string"},
-		'd': {Type: "int"},
-		'f': {Type: "float64"},
-		'v': {Type: "any"},
-		'S': {
-			Import:  "fmt",
-			Package: "fmt",
-			Type:    "Stringer",
-		},
+	// Otherwise the base language is chosen once the languages of the files are known
+	if cli.Base != "" {
+		base, err := language.Parse(cli.Base)
+		ctx.FatalIfErrorf(err)
+		Config.Base = base
+	}
+
+	if cli.Fallback != "" {
+		fallback, err := language.Parse(cli.Fallback)
+		ctx.FatalIfErrorf(err)
+		Config.Fallback = fallback
 	}
 }
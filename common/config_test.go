@@ -0,0 +1,118 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestConfigFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		// Configuration files in the current directory by their names
+		files map[string]string
+		args  []string
+		// Values of the flags that are expected
+		base   string
+		output string
+	}{
+		{
+			name:   "yaml",
+			files:  map[string]string{".go-l10n.yaml": "base: de\noutput: l10n\n"},
+			base:   "de",
+			output: "l10n",
+		},
+		{
+			name:  "yml",
+			files: map[string]string{".go-l10n.yml": "base: de\n"},
+			base:  "de",
+		},
+		{
+			name:  "json",
+			files: map[string]string{".go-l10n.json": `{"base": "de"}`},
+			base:  "de",
+		},
+		{
+			name: "yaml goes before yml and json",
+			files: map[string]string{
+				".go-l10n.yaml": "base: de\n",
+				".go-l10n.yml":  "base: fr\noutput: yml\n",
+				".go-l10n.json": `{"base": "ja", "output": "json"}`,
+			},
+			base: "de",
+		},
+		{
+			name: "yml goes before json",
+			files: map[string]string{
+				".go-l10n.yml":  "base: fr\n",
+				".go-l10n.json": `{"base": "ja"}`,
+			},
+			base: "fr",
+		},
+		{
+			name: "config flag",
+			files: map[string]string{
+				".go-l10n.yaml": "base: de\noutput: l10n\n",
+				"config.yaml":   "base: ja\n",
+			},
+			args:   []string{"-c", "config.yaml"},
+			base:   "ja",
+			output: "l10n",
+		},
+		{
+			name: "command line",
+			files: map[string]string{
+				".go-l10n.yaml": "base: de\noutput: l10n\n",
+				"config.json":   `{"base": "ja", "output": "json"}`,
+			},
+			args:   []string{"--config=config.json", "-b", "fr"},
+			base:   "fr",
+			output: "json",
+		},
+		{
+			// The file that is found first is the only one loaded, even if it is empty
+			name: "empty file",
+			files: map[string]string{
+				".go-l10n.yaml": "",
+				".go-l10n.json": `{"base": "ja"}`,
+			},
+		},
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+					t.Fatalf("could not write %s: %v", name, err)
+				}
+			}
+
+			if err := os.Chdir(dir); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			parser, err := kong.New(&cli, cliOptions()...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err = parser.Parse(append([]string{"-d", "."}, tt.args...))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if cli.Base != tt.base || cli.Output != tt.output {
+				t.Errorf("got base %q and output %q, want %q and %q", cli.Base, cli.Output, tt.base, tt.output)
+			}
+		})
+	}
+}
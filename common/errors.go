@@ -3,8 +3,11 @@ package common
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/infastin/go-l10n/ast"
 )
 
 var (
@@ -24,6 +27,7 @@ var (
 	ErrUnknownField                 = errors.New("unknown field")
 	ErrTypesDontMatch               = errors.New("types don't match")
 	ErrCouldNotUnmarshal            = errors.New("could not unmarshal")
+	ErrCouldNotMarshal              = errors.New("could not marshal")
 	ErrCouldNotProcess              = errors.New("could not process")
 	ErrFieldNotSpecified            = errors.New("field not specified")
 	ErrFieldsSpecifiedAtTheSameTime = errors.New("fields can't be specified at the same time")
@@ -42,6 +46,37 @@ var (
 	ErrCouldNotCreateDirectory      = errors.New("could not create directory")
 	ErrCouldNotWriteToFile          = errors.New("could not write to file")
 	ErrNoLocalizationsFound         = errors.New("no localizations found")
+	ErrPluralFormNotUsed            = errors.New("plural form is not used by language")
+	ErrPluralFormRequired           = errors.New("plural form is required by language")
+	ErrArgumentNotDeclared          = errors.New("argument not declared")
+	ErrMissingArgument              = errors.New("missing argument")
+	ErrUnknownArgument              = errors.New("unknown argument")
+	ErrArgumentTypesDontMatch       = errors.New("argument types don't match")
+	ErrDuplicateArgument            = errors.New("duplicate argument")
+	ErrFallbackNotFound             = errors.New("fallback localization not found")
+	ErrBaseNotFound                 = errors.New("base localization not found")
+	ErrDuplicateField               = errors.New("duplicate field")
+	ErrInvalidSyntax                = errors.New("invalid syntax")
+	ErrCouldNotReadDirectory        = errors.New("could not read directory")
+	ErrInvalidMessageName           = errors.New("invalid message name")
+	ErrNamespaceCollision           = errors.New("namespace collides with message")
+	ErrMethodCollision              = errors.New("message collides with method of another message")
+	ErrGeneratedCodeOutOfDate       = errors.New("generated code is out of date")
+	ErrIncompleteTranslation        = errors.New("translation is incomplete and doesn't match the existing message")
+	ErrUnknownPlaceholder           = errors.New("unknown placeholder")
+	ErrUnsupportedPluralRules       = errors.New("plural rules of the language are not supported")
+	ErrTranslationDoesNotMatch      = errors.New("translation doesn't match the base message")
+	ErrCouldNotImport               = errors.New("could not import")
+	ErrCouldNotExport               = errors.New("could not export")
+	ErrPlaceholdersDontMatch        = errors.New("placeholders don't match the source")
+	ErrUnknownSyntax                = errors.New("unknown syntax")
+	ErrUnsupportedArgumentType      = errors.New("unsupported argument type")
+	ErrUnsupportedArgumentStyle     = errors.New("unsupported argument style")
+	ErrNestedPlural                 = errors.New("plural can't be nested in another nested plural")
+	ErrReadOnlyFileFormat           = errors.New("files of this format can only be read")
+	ErrUnknownReference             = errors.New("unknown message or term")
+	ErrCyclicReference              = errors.New("message or term references itself")
+	ErrCyclicVariable               = errors.New("variable references itself")
 )
 
 type ErrorValue struct {
@@ -102,6 +137,8 @@ func ErrorExpectedAnyChar(chars ...rune) ErrorExpected {
 
 type ErrorPosition int
 
+type ErrorLocation ast.Location
+
 type ErrorWrapped error
 
 type Error struct {
@@ -109,6 +146,7 @@ type Error struct {
 	Value    ErrorValue
 	Expected ErrorExpected
 	Pos      ErrorPosition
+	Location ast.Location
 	Wrapped  ErrorWrapped
 }
 
@@ -126,6 +164,8 @@ func NewError(errKind error, options ...any) error {
 			e.Expected = p
 		case ErrorPosition:
 			e.Pos = p
+		case ErrorLocation:
+			e.Location = ast.Location(p)
 		case ErrorWrapped:
 			e.Wrapped = p
 		}
@@ -150,7 +190,9 @@ func (e *Error) Error() string {
 		}
 	}
 
-	if e.Pos != -1 {
+	// Location points to the exact character,
+	// so there is no need in position
+	if e.Pos != -1 && !e.Location.IsValid() {
 		b.WriteString(" at position ")
 		b.WriteString(strconv.Itoa(int(e.Pos)))
 	}
@@ -196,6 +238,16 @@ func (e *Error) Unwrap() error {
 	return e.Wrapped
 }
 
+// Returns location of the innermost error in the chain that has it.
+func ErrorLocationOf(err error) (loc ast.Location, ok bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, isError := err.(*Error); isError && e.Location.IsValid() {
+			loc, ok = e.Location, true
+		}
+	}
+	return loc, ok
+}
+
 type FieldError struct {
 	ErrKind error
 	Field   string
@@ -228,16 +280,34 @@ func (e *FieldError) Unwrap() error {
 
 type MessageNotSpecifiedError struct {
 	Message string
+	Base    string
 }
 
-func NewMessageNotSpecifiedError(message string) error {
+func NewMessageNotSpecifiedError(message, base string) error {
 	return &MessageNotSpecifiedError{
 		Message: message,
+		Base:    base,
 	}
 }
 
 func (e *MessageNotSpecifiedError) Error() string {
-	return "message \"" + e.Message + "\" not specified"
+	return "message \"" + e.Message + "\" of base localization \"" + e.Base + "\" not specified"
+}
+
+type MessageNotInBaseError struct {
+	Message string
+	Base    string
+}
+
+func NewMessageNotInBaseError(message, base string) error {
+	return &MessageNotInBaseError{
+		Message: message,
+		Base:    base,
+	}
+}
+
+func (e *MessageNotInBaseError) Error() string {
+	return "message \"" + e.Message + "\" not specified in base localization \"" + e.Base + "\""
 }
 
 type DuplicateMessageError struct {
@@ -253,3 +323,239 @@ func NewDuplicateMessageError(message string) error {
 func (e *DuplicateMessageError) Error() string {
 	return "duplicate message \"" + e.Message + "\""
 }
+
+// Two namespaces or messages whose names are made into the same identifier.
+type IdentifierCollisionError struct {
+	Name       string
+	Other      string
+	Identifier string
+}
+
+func NewIdentifierCollisionError(name, other, identifier string) error {
+	return &IdentifierCollisionError{
+		Name:       name,
+		Other:      other,
+		Identifier: identifier,
+	}
+}
+
+func (e *IdentifierCollisionError) Error() string {
+	return "\"" + e.Name + "\" collides with \"" + e.Other + "\", both of them generate \"" + e.Identifier + "\""
+}
+
+type ArgumentsMismatchError struct {
+	Message string
+	Wrapped error
+}
+
+func NewArgumentsMismatchError(message string, err error) error {
+	return &ArgumentsMismatchError{
+		Message: message,
+		Wrapped: err,
+	}
+}
+
+func (e *ArgumentsMismatchError) Error() string {
+	return "arguments of message \"" + e.Message + "\" don't match: " + e.Wrapped.Error()
+}
+
+func (e *ArgumentsMismatchError) Unwrap() error {
+	return e.Wrapped
+}
+
+// Translation of a segment whose placeholders are not the ones of its source.
+type PlaceholdersMismatchError struct {
+	Segment string
+	// Placeholders of the source that the translation doesn't use
+	Missing []string
+	// Placeholders of the translation that the source doesn't use
+	Unknown []string
+}
+
+func NewPlaceholdersMismatchError(segment string, missing, unknown []string) error {
+	return &PlaceholdersMismatchError{
+		Segment: segment,
+		Missing: missing,
+		Unknown: unknown,
+	}
+}
+
+func (e *PlaceholdersMismatchError) Error() string {
+	var b strings.Builder
+
+	b.WriteString("placeholders of segment \"" + e.Segment + "\" don't match the source")
+
+	for i, group := range [][]string{e.Missing, e.Unknown} {
+		if len(group) == 0 {
+			continue
+		}
+
+		if i == 0 {
+			b.WriteString(": missing ")
+		} else if len(e.Missing) != 0 {
+			b.WriteString(", unknown ")
+		} else {
+			b.WriteString(": unknown ")
+		}
+
+		for j, name := range group {
+			if j != 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(strconv.Quote(name))
+		}
+	}
+
+	return b.String()
+}
+
+func (e *PlaceholdersMismatchError) Unwrap() error {
+	return ErrPlaceholdersDontMatch
+}
+
+// Error that has occurred in the message.
+// It doesn't change the text of the wrapped error
+// and is used to find out which messages are invalid.
+type MessageError struct {
+	Message string
+	Wrapped error
+}
+
+func NewMessageError(message string, err error) error {
+	return &MessageError{
+		Message: message,
+		Wrapped: err,
+	}
+}
+
+func (e *MessageError) Error() string {
+	return e.Wrapped.Error()
+}
+
+func (e *MessageError) Unwrap() error {
+	return e.Wrapped
+}
+
+// Returns name of the message the error has occurred in.
+func ErrorMessageOf(err error) (message string, ok bool) {
+	var msgErr *MessageError
+	if errors.As(err, &msgErr) {
+		return msgErr.Message, true
+	}
+	return "", false
+}
+
+// List of errors that is used to report all of them at once.
+type ErrorList struct {
+	Errors []error
+	// Maximum number of errors in the list or 0 if there is no limit
+	Max int
+	// Whether errors have been dropped because of the limit
+	Truncated bool
+}
+
+// Adds the error to the list, unless it is nil.
+// If the error is a list itself, all of its errors are added.
+func (l *ErrorList) Add(err error) {
+	if err == nil {
+		return
+	}
+
+	if list, ok := err.(*ErrorList); ok {
+		for _, err := range list.Errors {
+			l.Add(err)
+		}
+		l.Truncated = l.Truncated || list.Truncated
+		return
+	}
+
+	if l.Max > 0 && len(l.Errors) >= l.Max {
+		l.Truncated = true
+		return
+	}
+
+	l.Errors = append(l.Errors, err)
+}
+
+func (l *ErrorList) Len() int {
+	return len(l.Errors)
+}
+
+// Returns the list if it contains any errors, otherwise nil.
+func (l *ErrorList) Err() error {
+	if len(l.Errors) == 0 && !l.Truncated {
+		return nil
+	}
+	return l
+}
+
+// Returns errors one per line, grouped by file and sorted by location.
+// Errors without location go last.
+func (l *ErrorList) Error() string {
+	errs := slices.Clone(l.Errors)
+
+	slices.SortStableFunc(errs, func(a, b error) int {
+		aLoc, aOk := ErrorLocationOf(a)
+		bLoc, bOk := ErrorLocationOf(b)
+
+		switch {
+		case aOk && !bOk:
+			return -1
+		case !aOk && bOk:
+			return 1
+		case !aOk && !bOk:
+			return 0
+		}
+
+		if c := strings.Compare(aLoc.File, bLoc.File); c != 0 {
+			return c
+		}
+		if aLoc.Line != bLoc.Line {
+			return aLoc.Line - bLoc.Line
+		}
+		return aLoc.Column - bLoc.Column
+	})
+
+	var b strings.Builder
+
+	for i, err := range errs {
+		if i != 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(FormatError(err))
+	}
+
+	if l.Truncated {
+		if len(errs) != 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("too many errors")
+	}
+
+	return b.String()
+}
+
+func (l *ErrorList) Unwrap() []error {
+	return l.Errors
+}
+
+// Returns errors of the list or the error itself, if it is not a list.
+// Returns nil if there is no error.
+func Errors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if list, ok := err.(*ErrorList); ok {
+		return list.Errors
+	}
+	return []error{err}
+}
+
+// Returns text of the error prefixed with the location
+// it has occurred at, if it is known.
+func FormatError(err error) string {
+	if loc, ok := ErrorLocationOf(err); ok {
+		return loc.String() + ": " + err.Error()
+	}
+	return err.Error()
+}
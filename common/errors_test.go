@@ -0,0 +1,107 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/infastin/go-l10n/ast"
+)
+
+func locatedError(file string, line, column int) error {
+	return NewError(ErrUnknownField,
+		ErrorValueStr("strong"),
+		ErrorLocation(ast.Location{File: file, Line: line, Column: column}),
+	)
+}
+
+func TestErrorListAdd(t *testing.T) {
+	var first, second ErrorList
+
+	first.Add(nil)
+	if first.Err() != nil {
+		t.Fatalf("got error %v of empty list", first.Err())
+	}
+
+	// Errors of lists from different files are added one by one
+	first.Add(locatedError("loc.en.yaml", 1, 1))
+	second.Add(locatedError("loc.ru.yaml", 1, 1))
+	second.Add(locatedError("loc.ru.yaml", 2, 1))
+	first.Add(second.Err())
+
+	if first.Len() != 3 {
+		t.Fatalf("got %d errors, want 3", first.Len())
+	}
+	if first.Truncated {
+		t.Errorf("list is truncated")
+	}
+	for _, err := range Errors(first.Err()) {
+		if _, ok := err.(*ErrorList); ok {
+			t.Errorf("got nested list %v", err)
+		}
+	}
+
+	var e *Error
+	if !errors.As(first.Err(), &e) || e.ErrKind != ErrUnknownField {
+		t.Errorf("errors of the list are not reachable with errors.As")
+	}
+}
+
+func TestErrorListMax(t *testing.T) {
+	list := ErrorList{Max: 2}
+
+	list.Add(locatedError("loc.en.yaml", 3, 1))
+	list.Add(locatedError("loc.en.yaml", 1, 1))
+	list.Add(locatedError("loc.en.yaml", 2, 1))
+
+	if list.Len() != 2 || !list.Truncated {
+		t.Fatalf("got %d errors, truncated: %t, want 2 of truncated list", list.Len(), list.Truncated)
+	}
+
+	// The errors that are kept are the first ones added
+	want := `loc.en.yaml:1:1: unknown field "strong"
+loc.en.yaml:3:1: unknown field "strong"
+too many errors`
+	if got := list.Error(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Truncation of the added list is kept, even if all of its errors fit
+	var other ErrorList
+	other.Add(&ErrorList{Truncated: true})
+	if !other.Truncated || other.Err() == nil {
+		t.Errorf("truncation of the added list is lost")
+	}
+	if got := other.Error(); got != "too many errors" {
+		t.Errorf("got %q, want %q", got, "too many errors")
+	}
+}
+
+func TestErrorListError(t *testing.T) {
+	var list ErrorList
+
+	list.Add(NewError(ErrNoLocalizationsFound))
+	list.Add(locatedError("loc.ru.yaml", 1, 5))
+	list.Add(locatedError("loc.en.yaml", 10, 1))
+	list.Add(locatedError("loc.en.yaml", 2, 7))
+	list.Add(locatedError("loc.en.yaml", 2, 3))
+	list.Add(NewFieldError(ErrCouldNotUnmarshal, "Hello", locatedError("loc.en.yaml", 5, 3)))
+	list.Add(NewError(ErrInvalidFilename, ErrorLocation{File: "loc.yaml"}))
+
+	// Errors are grouped by file and sorted by location,
+	// errors without location go last in the order they have been added
+	want := `loc.en.yaml:2:3: unknown field "strong"
+loc.en.yaml:2:7: unknown field "strong"
+loc.en.yaml:5:3: could not unmarshal Hello: unknown field "strong"
+loc.en.yaml:10:1: unknown field "strong"
+loc.ru.yaml:1:5: unknown field "strong"
+loc.yaml: invalid filename
+no localizations found`
+	if got := list.Error(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Sorting doesn't change the list itself
+	if loc, _ := ErrorLocationOf(list.Errors[1]); loc.File != "loc.ru.yaml" {
+		t.Errorf("list has been reordered: %v", list.Errors)
+	}
+}
@@ -0,0 +1,263 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Number of unchanged lines around changes
+const contextLines = 3
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	Kind opKind
+	Line string
+}
+
+// Returns unified diff between two texts with the given names.
+// Returns empty string if the texts are equal.
+func Unified(oldName, newName string, oldText, newText []byte) string {
+	if string(oldText) == string(newText) {
+		return ""
+	}
+
+	ops := editScript(splitLines(string(oldText)), splitLines(string(newText)))
+
+	var b strings.Builder
+
+	b.WriteString("--- " + oldName + "\n")
+	b.WriteString("+++ " + newName + "\n")
+
+	// Whether an operation goes into a hunk,
+	// which is so if it is close enough to a change
+	included := make([]bool, len(ops))
+
+	for i := 0; i < len(ops); i++ {
+		if ops[i].Kind == opEqual {
+			continue
+		}
+		for j := max(i-contextLines, 0); j <= min(i+contextLines, len(ops)-1); j++ {
+			included[j] = true
+		}
+	}
+
+	oldLine, newLine := 0, 0
+
+	for i := 0; i < len(ops); {
+		if !included[i] {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		end := i
+		for end < len(ops) && included[end] {
+			end++
+		}
+
+		writeHunk(&b, ops[i:end], oldLine, newLine)
+
+		for _, op := range ops[i:end] {
+			if op.Kind != opInsert {
+				oldLine++
+			}
+			if op.Kind != opDelete {
+				newLine++
+			}
+		}
+
+		i = end
+	}
+
+	return b.String()
+}
+
+func writeHunk(b *strings.Builder, ops []op, oldLine, newLine int) {
+	oldCount, newCount := 0, 0
+
+	for _, op := range ops {
+		if op.Kind != opInsert {
+			oldCount++
+		}
+		if op.Kind != opDelete {
+			newCount++
+		}
+	}
+
+	b.WriteString("@@ -")
+	writeRange(b, oldLine, oldCount)
+	b.WriteString(" +")
+	writeRange(b, newLine, newCount)
+	b.WriteString(" @@\n")
+
+	for _, op := range ops {
+		b.WriteByte(byte(op.Kind))
+		b.WriteString(op.Line)
+
+		if !strings.HasSuffix(op.Line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// Writes range of lines, that starts after the given line.
+func writeRange(b *strings.Builder, line, count int) {
+	// Empty range refers to the line before it
+	if count != 0 {
+		line++
+	}
+
+	b.WriteString(strconv.Itoa(line))
+
+	if count != 1 {
+		b.WriteByte(',')
+		b.WriteString(strconv.Itoa(count))
+	}
+}
+
+// Splits the text into lines keeping line endings.
+func splitLines(text string) (lines []string) {
+	for text != "" {
+		idx := strings.IndexByte(text, '\n')
+		if idx == -1 {
+			lines = append(lines, text)
+			break
+		}
+
+		lines = append(lines, text[:idx+1])
+		text = text[idx+1:]
+	}
+
+	return lines
+}
+
+// Returns the shortest sequence of operations
+// that turns one sequence of lines into another one.
+// Uses the linear space variation of Myers' algorithm,
+// which splits the sequences at the middle snake of the edit script
+// and finds edit scripts of both halves the same way.
+func editScript(a, b []string) (ops []op) {
+	return appendEditScript(nil, a, b)
+}
+
+func appendEditScript(ops []op, a, b []string) []op {
+	// Common prefix and suffix are trimmed
+	// to reduce the amount of work
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, line := range a[:prefix] {
+		ops = append(ops, op{opEqual, line})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	switch {
+	case len(midA) == 0:
+		for _, line := range midB {
+			ops = append(ops, op{opInsert, line})
+		}
+	case len(midB) == 0:
+		for _, line := range midA {
+			ops = append(ops, op{opDelete, line})
+		}
+	default:
+		x, y, u, v := middleSnake(midA, midB)
+
+		ops = appendEditScript(ops, midA[:x], midB[:y])
+		for _, line := range midA[x:u] {
+			ops = append(ops, op{opEqual, line})
+		}
+		ops = appendEditScript(ops, midA[u:], midB[v:])
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, op{opEqual, line})
+	}
+
+	return ops
+}
+
+// Returns the middle snake of the shortest edit script of the sequences,
+// that is the run of equal lines the script goes through halfway,
+// which starts at a[x] and b[y] and ends right before a[u] and b[v].
+// Searches for the script from both ends at once, keeping only
+// the furthest reaching points of the current step.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+
+	// Furthest reaching x of each diagonal k = x - y of the forward search,
+	// and the same of the backward one, which goes through the reversed sequences
+	vf := make([]int, 2*offset+1)
+	vb := make([]int, 2*offset+1)
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y = x - k
+
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+
+			vf[offset+k] = u
+
+			// Paths of the same length overlap only if the difference of lengths is odd,
+			// and the backward diagonal that matches this one is delta - k
+			if kb := delta - k; odd && kb >= -(d-1) && kb <= d-1 && u+vb[offset+kb] >= n {
+				return x, y, u, v
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && vb[offset+k-1] < vb[offset+k+1]) {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+			y = x - k
+
+			u, v = x, y
+			for u < n && v < m && a[n-1-u] == b[m-1-v] {
+				u++
+				v++
+			}
+
+			vb[offset+k] = u
+
+			if kf := delta - k; !odd && kf >= -d && kf <= d && u+vf[offset+kf] >= n {
+				// Snake of the backward search goes from the end
+				return n - u, m - v, n - x, m - y
+			}
+		}
+	}
+
+	// Paths always overlap by the time they reach the middle
+	panic("unreachable")
+}
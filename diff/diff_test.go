@@ -0,0 +1,205 @@
+package diff
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name: "insert",
+			old:  "a\nb\nc\n",
+			new:  "a\nb\nx\nc\n",
+			want: "@@ -1,3 +1,4 @@\n" +
+				" a\n" +
+				" b\n" +
+				"+x\n" +
+				" c\n",
+		},
+		{
+			name: "insert into empty",
+			old:  "",
+			new:  "a\n",
+			want: "@@ -0,0 +1 @@\n" +
+				"+a\n",
+		},
+		{
+			name: "delete",
+			old:  "a\nb\nc\n",
+			new:  "a\nc\n",
+			want: "@@ -1,3 +1,2 @@\n" +
+				" a\n" +
+				"-b\n" +
+				" c\n",
+		},
+		{
+			name: "delete everything",
+			old:  "a\n",
+			new:  "",
+			want: "@@ -1 +0,0 @@\n" +
+				"-a\n",
+		},
+		{
+			name: "no newline in old",
+			old:  "a\nb",
+			new:  "a\nb\n",
+			want: "@@ -1,2 +1,2 @@\n" +
+				" a\n" +
+				"-b\n" +
+				"\\ No newline at end of file\n" +
+				"+b\n",
+		},
+		{
+			name: "no newline in new",
+			old:  "a\n",
+			new:  "a",
+			want: "@@ -1 +1 @@\n" +
+				"-a\n" +
+				"+a\n" +
+				"\\ No newline at end of file\n",
+		},
+		{
+			name: "close changes share hunk",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			new:  "1\nX\n3\n4\n5\n6\n7\n8\nY\n10\n11\n12\n",
+			want: "@@ -1,12 +1,12 @@\n" +
+				" 1\n" +
+				"-2\n" +
+				"+X\n" +
+				" 3\n" +
+				" 4\n" +
+				" 5\n" +
+				" 6\n" +
+				" 7\n" +
+				" 8\n" +
+				"-9\n" +
+				"+Y\n" +
+				" 10\n" +
+				" 11\n" +
+				" 12\n",
+		},
+		{
+			name: "distant changes split hunks",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n",
+			new:  "1\nX\n3\n4\n5\n6\n7\n8\n9\nY\n11\n12\n13\n14\n",
+			want: "@@ -1,5 +1,5 @@\n" +
+				" 1\n" +
+				"-2\n" +
+				"+X\n" +
+				" 3\n" +
+				" 4\n" +
+				" 5\n" +
+				"@@ -7,7 +7,7 @@\n" +
+				" 7\n" +
+				" 8\n" +
+				" 9\n" +
+				"-10\n" +
+				"+Y\n" +
+				" 11\n" +
+				" 12\n" +
+				" 13\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want
+			if want != "" {
+				want = "--- old\n+++ new\n" + want
+			}
+
+			got := Unified("old", "new", []byte(tt.old), []byte(tt.new))
+			if got != want {
+				t.Errorf("Unified() = \n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+// Checks that the operations turn one sequence into another one
+// and returns the number of lines they change.
+func checkEditScript(t *testing.T, a, b []string, ops []op) (edits int) {
+	t.Helper()
+
+	var gotA, gotB []string
+	for _, op := range ops {
+		if op.Kind != opInsert {
+			gotA = append(gotA, op.Line)
+		}
+		if op.Kind != opDelete {
+			gotB = append(gotB, op.Line)
+		}
+		if op.Kind != opEqual {
+			edits++
+		}
+	}
+
+	if !slices.Equal(gotA, a) {
+		t.Fatalf("operations don't start from the old sequence")
+	}
+	if !slices.Equal(gotB, b) {
+		t.Fatalf("operations don't result in the new sequence")
+	}
+
+	return edits
+}
+
+func TestEditScriptLarge(t *testing.T) {
+	const n = 5000
+
+	tests := []struct {
+		name  string
+		newFn func(i int) string
+		edits int
+	}{
+		{
+			// The script is as long as both sequences,
+			// which would take quadratic memory to backtrack
+			name:  "everything replaced",
+			newFn: func(i int) string { return "new " + strconv.Itoa(i) + "\n" },
+			edits: 2 * n,
+		},
+		{
+			name: "every tenth line replaced",
+			newFn: func(i int) string {
+				if i%10 == 0 {
+					return "new " + strconv.Itoa(i) + "\n"
+				}
+				return strconv.Itoa(i) + "\n"
+			},
+			edits: 2 * n / 10,
+		},
+	}
+
+	a := make([]string, n)
+	for i := range a {
+		a[i] = strconv.Itoa(i) + "\n"
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := make([]string, n)
+			for i := range b {
+				b[i] = tt.newFn(i)
+			}
+
+			edits := checkEditScript(t, a, b, editScript(a, b))
+			if edits != tt.edits {
+				t.Errorf("got %d changed lines, want %d", edits, tt.edits)
+			}
+		})
+	}
+}
@@ -2,8 +2,37 @@
 
 package l10n
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
 type Localizer interface {
+	// BankAccount returns "You have $$${+.3f:money} dollars in your bank account."
 	BankAccount(money float64) string
+
+	// AppendBankAccount appends BankAccount to b0 and returns the extended buffer.
+	AppendBankAccount(b0 []byte, money float64) []byte
+
+	// WriteBankAccount writes BankAccount to w0.
+	WriteBankAccount(w0 io.Writer, money float64) (int, error)
+
+	// Transfer returns "${-12s:name}|${6d:count}|${+010.2f:amount}|${dx:id}"
+	Transfer(name string, count int, amount float64, id int) string
+
+	// AppendTransfer appends Transfer to b0 and returns the extended buffer.
+	AppendTransfer(b0 []byte, name string, count int, amount float64, id int) []byte
+
+	// WriteTransfer writes Transfer to w0.
+	WriteTransfer(w0 io.Writer, name string, count int, amount float64, id int) (int, error)
 }
 
 var mapLangToLocalizer = map[string]Localizer{
@@ -11,16 +40,37 @@ var mapLangToLocalizer = map[string]Localizer{
 	"ru": ru_Localizer{},
 }
 
+var buffers = sync.Pool{
+	New: func() any {
+		return new([]byte)
+	},
+}
+
 var Supported = []string{
 	"en",
 	"ru",
 }
 
+var Matcher = language.NewMatcher([]language.Tag{
+	language.MustParse("en"),
+	language.MustParse("ru"),
+})
+
 func New(lang string) (loc Localizer, ok bool) {
 	loc, ok = mapLangToLocalizer[lang]
 	return loc, ok
 }
 
+func Match(tags ...language.Tag) (loc Localizer, tag language.Tag, conf language.Confidence) {
+	tag, idx, conf := Matcher.Match(tags...)
+	return mapLangToLocalizer[Supported[idx]], tag, conf
+}
+
+func FromAcceptLanguage(header string) (loc Localizer, tag language.Tag, conf language.Confidence) {
+	tags, _, _ := language.ParseAcceptLanguage(header)
+	return Match(tags...)
+}
+
 func Language(loc Localizer) string {
 	switch loc.(type) {
 	case en_Localizer:
@@ -30,4 +80,222 @@ func Language(loc Localizer) string {
 	default:
 		return ""
 	}
-}
\ No newline at end of file
+}
+
+type contextKey struct{}
+
+var Default Localizer = en_Localizer{}
+
+func NewContext(ctx context.Context, loc Localizer) context.Context {
+	return context.WithValue(ctx, contextKey{}, loc)
+}
+
+func FromContext(ctx context.Context) Localizer {
+	if loc, ok := ctx.Value(contextKey{}).(Localizer); ok {
+		return loc
+	}
+	return Default
+}
+
+func LanguageFromContext(ctx context.Context) string {
+	return Language(FromContext(ctx))
+}
+
+type MessageID string
+
+const (
+	MessageID_BankAccount MessageID = "BankAccount"
+	MessageID_Transfer    MessageID = "Transfer"
+)
+
+var messageIDs = []MessageID{
+	MessageID_BankAccount,
+	MessageID_Transfer,
+}
+
+var (
+	ErrUnknownMessage  = errors.New("unknown message")
+	ErrInvalidArgument = errors.New("invalid argument")
+)
+
+func ParseMessageID(s string) (MessageID, error) {
+	for _, id := range messageIDs {
+		if string(id) == s {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownMessage, s)
+}
+
+// Translate returns the message with the given ID in the language of loc.
+// args must contain exactly the arguments of the message, each of its Go type,
+// except that int arguments may also be given as integral float64 values.
+func Translate(loc Localizer, id MessageID, args map[string]any) (string, error) {
+	switch id {
+	case MessageID_BankAccount:
+		if err := checkArguments(id, args, "money"); err != nil {
+			return "", err
+		}
+		arg_money, ok := args["money"].(float64)
+		if !ok {
+			return "", fmt.Errorf("%s: %w: %q must be %s, not %T", id, ErrInvalidArgument, "money", "float64", args["money"])
+		}
+		return loc.BankAccount(arg_money), nil
+	case MessageID_Transfer:
+		if err := checkArguments(id, args, "name", "count", "amount", "id"); err != nil {
+			return "", err
+		}
+		arg_name, ok := args["name"].(string)
+		if !ok {
+			return "", fmt.Errorf("%s: %w: %q must be %s, not %T", id, ErrInvalidArgument, "name", "string", args["name"])
+		}
+		arg_count, ok := intArgument(args["count"])
+		if !ok {
+			return "", fmt.Errorf("%s: %w: %q must be %s, not %T", id, ErrInvalidArgument, "count", "int", args["count"])
+		}
+		arg_amount, ok := args["amount"].(float64)
+		if !ok {
+			return "", fmt.Errorf("%s: %w: %q must be %s, not %T", id, ErrInvalidArgument, "amount", "float64", args["amount"])
+		}
+		arg_id, ok := intArgument(args["id"])
+		if !ok {
+			return "", fmt.Errorf("%s: %w: %q must be %s, not %T", id, ErrInvalidArgument, "id", "int", args["id"])
+		}
+		return loc.Transfer(arg_name, arg_count, arg_amount, arg_id), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownMessage, id)
+}
+
+func checkArguments(id MessageID, args map[string]any, names ...string) error {
+	for _, name := range names {
+		if _, ok := args[name]; !ok {
+			return fmt.Errorf("%s: %w: %q is missing", id, ErrInvalidArgument, name)
+		}
+	}
+	for name := range args {
+		if !hasArgument(names, name) {
+			return fmt.Errorf("%s: %w: %q is unknown", id, ErrInvalidArgument, name)
+		}
+	}
+
+	return nil
+}
+
+func hasArgument(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func intArgument(value any) (int, bool) {
+	switch value := value.(type) {
+	case int:
+		return value, true
+	case float64:
+		if value == math.Trunc(value) && value >= math.MinInt && value < -math.MinInt {
+			return int(value), true
+		}
+	}
+	return 0, false
+}
+
+type Message interface {
+	MessageID() MessageID
+	Localize(loc Localizer) string
+	error
+	slog.LogValuer
+}
+
+type BankAccountMsg struct {
+	Money float64
+}
+
+func (m BankAccountMsg) MessageID() MessageID {
+	return MessageID_BankAccount
+}
+
+func (m BankAccountMsg) Localize(loc Localizer) string {
+	return loc.BankAccount(m.Money)
+}
+
+func (m BankAccountMsg) Error() string {
+	return m.Localize(Default)
+}
+
+func (m BankAccountMsg) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", string(MessageID_BankAccount)), slog.Group("args", slog.Any("money", m.Money)))
+}
+
+type TransferMsg struct {
+	Name   string
+	Count  int
+	Amount float64
+	Id     int
+}
+
+func (m TransferMsg) MessageID() MessageID {
+	return MessageID_Transfer
+}
+
+func (m TransferMsg) Localize(loc Localizer) string {
+	return loc.Transfer(m.Name, m.Count, m.Amount, m.Id)
+}
+
+func (m TransferMsg) Error() string {
+	return m.Localize(Default)
+}
+
+func (m TransferMsg) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", string(MessageID_Transfer)), slog.Group("args", slog.Any("name", m.Name), slog.Any("count", m.Count), slog.Any("amount", m.Amount), slog.Any("id", m.Id)))
+}
+
+func signNumber(b []byte, start int, sign byte) []byte {
+	switch b[start] {
+	case '-':
+		return b
+	case '+':
+		b[start] = sign
+		return b
+	}
+	b = append(b, 0)
+	copy(b[start+1:], b[start:])
+	b[start] = sign
+
+	return b
+}
+
+func padLeft(b []byte, start, width int, c byte) []byte {
+	n := width - utf8.RuneCount(b[start:])
+	if n <= 0 {
+		return b
+	}
+	b = append(b, make([]byte, n)...)
+	copy(b[start+n:], b[start:])
+	for i := start; i < start+n; i++ {
+		b[i] = c
+	}
+
+	return b
+}
+
+func padRight(b []byte, start, width int) []byte {
+	for n := width - utf8.RuneCount(b[start:]); n > 0; n-- {
+		b = append(b, ' ')
+	}
+	return b
+}
+
+func padZeros(b []byte, start, width int) []byte {
+	sign := 0
+	if b[start] == '+' || b[start] == '-' || b[start] == ' ' {
+		sign = 1
+	}
+	if b[start+sign] == 'I' || b[start+sign] == 'N' {
+		return padLeft(b, start, width, ' ')
+	}
+
+	return padLeft(b, start+sign, width-sign, '0')
+}
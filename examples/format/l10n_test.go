@@ -0,0 +1,307 @@
+package l10n
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFormatHelpers(t *testing.T) {
+	const prefix = "prefix|"
+
+	floats := []float64{0, math.Copysign(0, -1), 1.5, -1.5, 12345.678, math.Inf(1), math.Inf(-1), math.NaN()}
+	ints := []int{0, 7, -7, 123456, math.MinInt64}
+	strs := []string{"", "abc", "héllo", "日本語", "long string"}
+
+	t.Run("signNumber", func(t *testing.T) {
+		for _, sign := range []byte{'+', ' '} {
+			for _, f := range floats {
+				b := []byte(prefix)
+				got := string(signNumber(strconv.AppendFloat(b, f, 'f', 2, 64), len(b), sign))
+				want := prefix + fmt.Sprintf("%"+string(sign)+".2f", f)
+				if got != want {
+					t.Errorf("sign %q of %v: got %q, want %q", sign, f, got, want)
+				}
+			}
+
+			for _, n := range ints {
+				b := []byte(prefix)
+				got := string(signNumber(strconv.AppendInt(b, int64(n), 10), len(b), sign))
+				want := prefix + fmt.Sprintf("%"+string(sign)+"d", n)
+				if got != want {
+					t.Errorf("sign %q of %v: got %q, want %q", sign, n, got, want)
+				}
+			}
+		}
+	})
+
+	t.Run("padLeft", func(t *testing.T) {
+		for _, s := range strs {
+			b := []byte(prefix)
+			got := string(padLeft(append(b, s...), len(b), 8, ' '))
+			want := prefix + fmt.Sprintf("%8s", s)
+			if got != want {
+				t.Errorf("%q: got %q, want %q", s, got, want)
+			}
+		}
+	})
+
+	t.Run("padRight", func(t *testing.T) {
+		for _, s := range strs {
+			b := []byte(prefix)
+			got := string(padRight(append(b, s...), len(b), 8))
+			want := prefix + fmt.Sprintf("%-8s", s)
+			if got != want {
+				t.Errorf("%q: got %q, want %q", s, got, want)
+			}
+		}
+	})
+
+	t.Run("padZeros", func(t *testing.T) {
+		for _, f := range floats {
+			b := []byte(prefix)
+			got := string(padZeros(signNumber(strconv.AppendFloat(b, f, 'f', 2, 64), len(b), '+'), len(b), 10))
+			want := prefix + fmt.Sprintf("%+010.2f", f)
+			if got != want {
+				t.Errorf("%v: got %q, want %q", f, got, want)
+			}
+		}
+
+		for _, n := range ints {
+			b := []byte(prefix)
+			got := string(padZeros(strconv.AppendInt(b, int64(n), 10), len(b), 10))
+			want := prefix + fmt.Sprintf("%010d", n)
+			if got != want {
+				t.Errorf("%v: got %q, want %q", n, got, want)
+			}
+		}
+	})
+}
+
+// Format of Transfer message written for fmt.
+const transferFormat = "%-12s|%6d|%+010.2f|%x"
+
+func TestTransfer(t *testing.T) {
+	tests := []struct {
+		name   string
+		count  int
+		amount float64
+		id     int
+	}{
+		{"Alice", 3, 1234.5, 255},
+		{"Jörg", -42, -0.004, 0},
+		{"Very long name", 1234567, math.Inf(1), -255},
+		{"", 0, math.NaN(), math.MaxInt64},
+	}
+
+	for _, lang := range []string{"en", "ru"} {
+		loc, _ := New(lang)
+
+		for _, tt := range tests {
+			got := loc.Transfer(tt.name, tt.count, tt.amount, tt.id)
+			want := fmt.Sprintf(transferFormat, tt.name, tt.count, tt.amount, tt.id)
+			if got != want {
+				t.Errorf("%s: got %q, want %q", lang, got, want)
+			}
+		}
+	}
+}
+
+// Checks that Append method keeps the prefix of the buffer it appends to
+// and that Write method writes the same text as the string one.
+func checkAppendWrite(t *testing.T, want string, appendFunc func(b []byte) []byte, writeFunc func(w io.Writer) (int, error)) {
+	t.Helper()
+
+	const prefix = "prefix|"
+
+	if got := string(appendFunc(nil)); got != want {
+		t.Errorf("append to nil: got %q, want %q", got, want)
+	}
+
+	// The prefix must be kept even if the buffer has spare capacity
+	b := append(make([]byte, 0, 256), prefix...)
+	if got := string(appendFunc(b)); got != prefix+want {
+		t.Errorf("append: got %q, want %q", got, prefix+want)
+	}
+
+	var w bytes.Buffer
+	n, err := writeFunc(&w)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.String() != want || n != len(want) {
+		t.Errorf("write: got %q of %d bytes, want %q of %d bytes", w.String(), n, want, len(want))
+	}
+}
+
+func TestAppendWrite(t *testing.T) {
+	transfers := []struct {
+		name   string
+		count  int
+		amount float64
+		id     int
+	}{
+		{"Alice", 3, 1234.5, 255},
+		{"Jörg", -42, -0.004, 0},
+		{"Very long name", 1234567, math.Inf(-1), -255},
+	}
+
+	moneys := []float64{0, 1234.5678, -0.0005, math.NaN()}
+
+	for _, lang := range []string{"en", "ru"} {
+		loc, _ := New(lang)
+
+		for _, tt := range transfers {
+			checkAppendWrite(t, loc.Transfer(tt.name, tt.count, tt.amount, tt.id),
+				func(b []byte) []byte { return loc.AppendTransfer(b, tt.name, tt.count, tt.amount, tt.id) },
+				func(w io.Writer) (int, error) { return loc.WriteTransfer(w, tt.name, tt.count, tt.amount, tt.id) },
+			)
+		}
+
+		for _, money := range moneys {
+			checkAppendWrite(t, loc.BankAccount(money),
+				func(b []byte) []byte { return loc.AppendBankAccount(b, money) },
+				func(w io.Writer) (int, error) { return loc.WriteBankAccount(w, money) },
+			)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	loc, _ := New("en")
+
+	tests := []struct {
+		name string
+		args string
+		want string
+		err  error
+	}{
+		{
+			name: "numbers decoded from JSON",
+			args: `{"name": "Alice", "count": 3, "amount": 1234.5, "id": 255}`,
+			want: fmt.Sprintf(transferFormat, "Alice", 3, 1234.5, 255),
+		},
+		{
+			name: "integral float of int argument",
+			args: `{"name": "Alice", "count": -42.0, "amount": 0, "id": 1e3}`,
+			want: fmt.Sprintf(transferFormat, "Alice", -42, 0.0, 1000),
+		},
+		{
+			name: "fractional float of int argument",
+			args: `{"name": "Alice", "count": 3.5, "amount": 0, "id": 255}`,
+			err:  ErrInvalidArgument,
+		},
+		{
+			name: "int argument out of range",
+			args: `{"name": "Alice", "count": 1e19, "amount": 0, "id": 255}`,
+			err:  ErrInvalidArgument,
+		},
+		{
+			name: "string of int argument",
+			args: `{"name": "Alice", "count": "3", "amount": 0, "id": 255}`,
+			err:  ErrInvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args map[string]any
+			if err := json.Unmarshal([]byte(tt.args), &args); err != nil {
+				t.Fatalf("could not unmarshal arguments: %v", err)
+			}
+
+			got, err := Translate(loc, MessageID_Transfer, args)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkTransfer(b *testing.B) {
+	loc, _ := New("en")
+
+	const (
+		name   = "Alice"
+		count  = 42
+		amount = -1234.5
+		id     = 0xbeef
+	)
+
+	b.Run("compiled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = loc.Transfer(name, count, amount, id)
+		}
+	})
+
+	b.Run("append", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf []byte
+		for i := 0; i < b.N; i++ {
+			buf = loc.AppendTransfer(buf[:0], name, count, amount, id)
+		}
+	})
+
+	b.Run("fmt.Sprintf", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = fmt.Sprintf(transferFormat, name, count, amount, id)
+		}
+	})
+
+	b.Run("strings.Builder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var sb strings.Builder
+			fmt.Fprintf(&sb, "%-12s", name)
+			sb.WriteString("|")
+			fmt.Fprintf(&sb, "%6d", count)
+			sb.WriteString("|")
+			fmt.Fprintf(&sb, "%+010.2f", amount)
+			sb.WriteString("|")
+			fmt.Fprintf(&sb, "%x", id)
+			_ = sb.String()
+		}
+	})
+}
+
+func BenchmarkBankAccount(b *testing.B) {
+	loc, _ := New("en")
+
+	const money = 1234.5678
+
+	b.Run("compiled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = loc.BankAccount(money)
+		}
+	})
+
+	b.Run("fmt.Sprintf", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = fmt.Sprintf("You have $%+.3f dollars in your bank account.", money)
+		}
+	})
+
+	b.Run("strings.Builder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var sb strings.Builder
+			sb.WriteString("You have $")
+			fmt.Fprintf(&sb, "%+.3f", money)
+			sb.WriteString(" dollars in your bank account.")
+			_ = sb.String()
+		}
+	})
+}
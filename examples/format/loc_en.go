@@ -3,18 +3,54 @@
 package l10n
 
 import (
-	"strings"
-	"fmt"
+	"io"
+	"strconv"
 )
 
 type en_Localizer struct{}
 
 func (en_l en_Localizer) BankAccount(money float64) string {
-	var b0 strings.Builder
+	return string(en_l.AppendBankAccount(make([]byte, 0, 56), money))
+}
 
-	b0.WriteString("You have $")
-	b0.WriteString(fmt.Sprintf("%+.3f", money))
-	b0.WriteString(" dollars in your bank account.")
+func (en_l en_Localizer) AppendBankAccount(b0 []byte, money float64) []byte {
+	b0 = append(b0, "You have $"...)
+	b0 = signNumber(strconv.AppendFloat(b0, money, 'f', 3, 64), len(b0), '+')
+	b0 = append(b0, " dollars in your bank account."...)
 
-	return b0.String()
-}
\ No newline at end of file
+	return b0
+}
+
+func (en_l en_Localizer) WriteBankAccount(w0 io.Writer, money float64) (int, error) {
+	b0 := buffers.Get().(*[]byte)
+	*b0 = en_l.AppendBankAccount((*b0)[:0], money)
+	n0, err0 := w0.Write(*b0)
+	buffers.Put(b0)
+
+	return n0, err0
+}
+
+func (en_l en_Localizer) Transfer(name string, count int, amount float64, id int) string {
+	return string(en_l.AppendTransfer(make([]byte, 0, 67), name, count, amount, id))
+}
+
+func (en_l en_Localizer) AppendTransfer(b0 []byte, name string, count int, amount float64, id int) []byte {
+	b0 = padRight(append(b0, name...), len(b0), 12)
+	b0 = append(b0, "|"...)
+	b0 = padLeft(strconv.AppendInt(b0, int64(count), 10), len(b0), 6, ' ')
+	b0 = append(b0, "|"...)
+	b0 = padZeros(signNumber(strconv.AppendFloat(b0, amount, 'f', 2, 64), len(b0), '+'), len(b0), 10)
+	b0 = append(b0, "|"...)
+	b0 = strconv.AppendInt(b0, int64(id), 16)
+
+	return b0
+}
+
+func (en_l en_Localizer) WriteTransfer(w0 io.Writer, name string, count int, amount float64, id int) (int, error) {
+	b0 := buffers.Get().(*[]byte)
+	*b0 = en_l.AppendTransfer((*b0)[:0], name, count, amount, id)
+	n0, err0 := w0.Write(*b0)
+	buffers.Put(b0)
+
+	return n0, err0
+}
@@ -2,8 +2,27 @@
 
 package l10n
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
 type Localizer interface {
+	// Hello returns "Hello, ${name}!"
 	Hello(name string) string
+
+	// AppendHello appends Hello to b0 and returns the extended buffer.
+	AppendHello(b0 []byte, name string) []byte
+
+	// WriteHello writes Hello to w0.
+	WriteHello(w0 io.Writer, name string) (int, error)
 }
 
 var mapLangToLocalizer = map[string]Localizer{
@@ -11,16 +30,37 @@ var mapLangToLocalizer = map[string]Localizer{
 	"ru": ru_Localizer{},
 }
 
+var buffers = sync.Pool{
+	New: func() any {
+		return new([]byte)
+	},
+}
+
 var Supported = []string{
 	"en",
 	"ru",
 }
 
+var Matcher = language.NewMatcher([]language.Tag{
+	language.MustParse("en"),
+	language.MustParse("ru"),
+})
+
 func New(lang string) (loc Localizer, ok bool) {
 	loc, ok = mapLangToLocalizer[lang]
 	return loc, ok
 }
 
+func Match(tags ...language.Tag) (loc Localizer, tag language.Tag, conf language.Confidence) {
+	tag, idx, conf := Matcher.Match(tags...)
+	return mapLangToLocalizer[Supported[idx]], tag, conf
+}
+
+func FromAcceptLanguage(header string) (loc Localizer, tag language.Tag, conf language.Confidence) {
+	tags, _, _ := language.ParseAcceptLanguage(header)
+	return Match(tags...)
+}
+
 func Language(loc Localizer) string {
 	switch loc.(type) {
 	case en_Localizer:
@@ -30,4 +70,184 @@ func Language(loc Localizer) string {
 	default:
 		return ""
 	}
-}
\ No newline at end of file
+}
+
+type contextKey struct{}
+
+var Default Localizer = en_Localizer{}
+
+func NewContext(ctx context.Context, loc Localizer) context.Context {
+	return context.WithValue(ctx, contextKey{}, loc)
+}
+
+func FromContext(ctx context.Context) Localizer {
+	if loc, ok := ctx.Value(contextKey{}).(Localizer); ok {
+		return loc
+	}
+	return Default
+}
+
+func LanguageFromContext(ctx context.Context) string {
+	return Language(FromContext(ctx))
+}
+
+type MessageID string
+
+const MessageID_Hello MessageID = "Hello"
+
+var messageIDs = []MessageID{
+	MessageID_Hello,
+}
+
+var (
+	ErrUnknownMessage  = errors.New("unknown message")
+	ErrInvalidArgument = errors.New("invalid argument")
+)
+
+func ParseMessageID(s string) (MessageID, error) {
+	for _, id := range messageIDs {
+		if string(id) == s {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownMessage, s)
+}
+
+// Translate returns the message with the given ID in the language of loc.
+// args must contain exactly the arguments of the message, each of its Go type,
+// except that int arguments may also be given as integral float64 values.
+func Translate(loc Localizer, id MessageID, args map[string]any) (string, error) {
+	switch id {
+	case MessageID_Hello:
+		if err := checkArguments(id, args, "name"); err != nil {
+			return "", err
+		}
+		arg_name, ok := args["name"].(string)
+		if !ok {
+			return "", fmt.Errorf("%s: %w: %q must be %s, not %T", id, ErrInvalidArgument, "name", "string", args["name"])
+		}
+		return loc.Hello(arg_name), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownMessage, id)
+}
+
+func checkArguments(id MessageID, args map[string]any, names ...string) error {
+	for _, name := range names {
+		if _, ok := args[name]; !ok {
+			return fmt.Errorf("%s: %w: %q is missing", id, ErrInvalidArgument, name)
+		}
+	}
+	for name := range args {
+		if !hasArgument(names, name) {
+			return fmt.Errorf("%s: %w: %q is unknown", id, ErrInvalidArgument, name)
+		}
+	}
+
+	return nil
+}
+
+func hasArgument(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+type Message interface {
+	MessageID() MessageID
+	Localize(loc Localizer) string
+	error
+	slog.LogValuer
+}
+
+type HelloMsg struct {
+	Name string
+}
+
+func (m HelloMsg) MessageID() MessageID {
+	return MessageID_Hello
+}
+
+func (m HelloMsg) Localize(loc Localizer) string {
+	return loc.Hello(m.Name)
+}
+
+func (m HelloMsg) Error() string {
+	return m.Localize(Default)
+}
+
+func (m HelloMsg) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", string(MessageID_Hello)), slog.Group("args", slog.Any("name", m.Name)))
+}
+
+type middlewareConfig struct {
+	queryParam, cookie string
+}
+
+type MiddlewareOption func(c *middlewareConfig)
+
+func WithQueryParam(name string) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.queryParam = name
+	}
+}
+
+func WithCookie(name string) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.cookie = name
+	}
+}
+
+func Middleware(next http.Handler, opts ...MiddlewareOption) http.Handler {
+	c := middlewareConfig{
+		queryParam: "lang",
+		cookie:     "lang",
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loc := requestLocalizer(r, &c)
+		w.Header().Add("Vary", "Accept-Language")
+		if c.cookie != "" {
+			w.Header().Add("Vary", "Cookie")
+		}
+		if lang := Language(loc); lang != "" {
+			w.Header().Set("Content-Language", lang)
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), loc)))
+	})
+}
+
+func requestLocalizer(r *http.Request, c *middlewareConfig) Localizer {
+	if c.queryParam != "" {
+		if loc, ok := matchLanguage(r.URL.Query().Get(c.queryParam)); ok {
+			return loc
+		}
+	}
+	if c.cookie != "" {
+		if cookie, err := r.Cookie(c.cookie); err == nil {
+			if loc, ok := matchLanguage(cookie.Value); ok {
+				return loc
+			}
+		}
+	}
+	if loc, _, conf := FromAcceptLanguage(r.Header.Get("Accept-Language")); conf != language.No {
+		return loc
+	}
+
+	return Default
+}
+
+func matchLanguage(lang string) (loc Localizer, ok bool) {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return nil, false
+	}
+	loc, _, conf := Match(tag)
+
+	return loc, conf != language.No
+}
@@ -0,0 +1,316 @@
+package l10n
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	ru, _ := New("ru")
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"empty context", context.Background(), "Hello, Alice!"},
+		{"context with localizer", NewContext(context.Background(), ru), "Привет, Alice!"},
+		{"nil localizer", NewContext(context.Background(), nil), "Hello, Alice!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromContext(tt.ctx).Hello("Alice"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := LanguageFromContext(NewContext(context.Background(), ru)); got != "ru" {
+		t.Errorf("got language %q, want %q", got, "ru")
+	}
+
+	// Default can be replaced with any localizer,
+	// even with one whose language is unknown
+	t.Run("default", func(t *testing.T) {
+		def := Default
+		t.Cleanup(func() { Default = def })
+
+		Default = ru
+		if got := LanguageFromContext(context.Background()); got != "ru" {
+			t.Errorf("got language %q, want %q", got, "ru")
+		}
+
+		Default = struct{ Localizer }{ru}
+		if got := LanguageFromContext(context.Background()); got != "" {
+			t.Errorf("got language %q of custom localizer", got)
+		}
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []MiddlewareOption
+		target string
+		cookie string
+		header string
+		want   string
+		vary   []string
+	}{
+		{
+			name:   "no preferences",
+			target: "/",
+			want:   "en",
+			vary:   []string{"Accept-Language", "Cookie"},
+		},
+		{
+			name:   "header",
+			target: "/",
+			header: "de-DE, ru;q=0.8, en;q=0.5",
+			want:   "ru",
+			vary:   []string{"Accept-Language", "Cookie"},
+		},
+		{
+			name:   "unsupported header",
+			target: "/",
+			header: "de-DE",
+			want:   "en",
+			vary:   []string{"Accept-Language", "Cookie"},
+		},
+		{
+			name:   "cookie before header",
+			target: "/",
+			cookie: "ru",
+			header: "en",
+			want:   "ru",
+			vary:   []string{"Accept-Language", "Cookie"},
+		},
+		{
+			name:   "query parameter before cookie",
+			target: "/?lang=ru",
+			cookie: "en",
+			want:   "ru",
+			vary:   []string{"Accept-Language", "Cookie"},
+		},
+		{
+			name:   "unsupported query parameter",
+			target: "/?lang=de",
+			cookie: "ru",
+			want:   "ru",
+			vary:   []string{"Accept-Language", "Cookie"},
+		},
+		{
+			name:   "renamed query parameter",
+			opts:   []MiddlewareOption{WithQueryParam("locale")},
+			target: "/?lang=en&locale=ru",
+			want:   "ru",
+			vary:   []string{"Accept-Language", "Cookie"},
+		},
+		{
+			name:   "disabled cookie",
+			opts:   []MiddlewareOption{WithCookie("")},
+			target: "/",
+			cookie: "ru",
+			want:   "en",
+			vary:   []string{"Accept-Language"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = LanguageFromContext(r.Context())
+				io.WriteString(w, FromContext(r.Context()).Hello("Alice"))
+			}), tt.opts...)
+
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+			}
+			if tt.header != "" {
+				r.Header.Set("Accept-Language", tt.header)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got != tt.want {
+				t.Errorf("got language %q, want %q", got, tt.want)
+			}
+
+			loc, _ := New(tt.want)
+			if body := w.Body.String(); body != loc.Hello("Alice") {
+				t.Errorf("got body %q", body)
+			}
+
+			if lang := w.Header().Get("Content-Language"); lang != tt.want {
+				t.Errorf("got Content-Language %q, want %q", lang, tt.want)
+			}
+
+			if vary := w.Header().Values("Vary"); !slices.Equal(vary, tt.vary) {
+				t.Errorf("got Vary %q, want %q", vary, tt.vary)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	ru, _ := New("ru")
+
+	tests := []struct {
+		name string
+		id   MessageID
+		args map[string]any
+		want string
+		err  error
+	}{
+		{
+			name: "message",
+			id:   MessageID_Hello,
+			args: map[string]any{"name": "Alice"},
+			want: "Привет, Alice!",
+		},
+		{
+			name: "unknown message",
+			id:   "Goodbye",
+			args: map[string]any{"name": "Alice"},
+			err:  ErrUnknownMessage,
+		},
+		{
+			name: "missing argument",
+			id:   MessageID_Hello,
+			args: map[string]any{},
+			err:  ErrInvalidArgument,
+		},
+		{
+			name: "unknown argument",
+			id:   MessageID_Hello,
+			args: map[string]any{"name": "Alice", "surname": "Smith"},
+			err:  ErrInvalidArgument,
+		},
+		{
+			name: "argument of wrong type",
+			id:   MessageID_Hello,
+			args: map[string]any{"name": 42},
+			err:  ErrInvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Translate(ru, tt.id, tt.args)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMessageID(t *testing.T) {
+	id, err := ParseMessageID("Hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != MessageID_Hello {
+		t.Errorf("got %q, want %q", id, MessageID_Hello)
+	}
+
+	for _, s := range []string{"", "hello", "Goodbye"} {
+		if _, err := ParseMessageID(s); !errors.Is(err, ErrUnknownMessage) {
+			t.Errorf("%q: got error %v, want %v", s, err, ErrUnknownMessage)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	ru, _ := New("ru")
+
+	var msg Message = HelloMsg{Name: "Alice"}
+
+	if id := msg.MessageID(); id != MessageID_Hello {
+		t.Errorf("got id %q, want %q", id, MessageID_Hello)
+	}
+	if got, want := msg.Localize(ru), ru.Hello("Alice"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Errors are written in the base language
+	err := fmt.Errorf("greeting: %w", msg)
+	if got, want := err.Error(), "greeting: Hello, Alice!"; got != want {
+		t.Errorf("got error %q, want %q", got, want)
+	}
+
+	var hello HelloMsg
+	if !errors.As(err, &hello) || hello.Name != "Alice" {
+		t.Errorf("got message %+v from error", hello)
+	}
+
+	var b strings.Builder
+	logger := slog.New(slog.NewJSONHandler(&b, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("greeted", "greeting", msg)
+
+	want := `{"level":"INFO","msg":"greeted","greeting":{"id":"Hello","args":{"name":"Alice"}}}` + "\n"
+	if got := b.String(); got != want {
+		t.Errorf("got log\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGeneratedFilesFormatted(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var generated int
+
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.HasPrefix(src, []byte("// Code generated by go-l10n; DO NOT EDIT.")) {
+			continue
+		}
+
+		generated++
+
+		formatted, err := format.Source(src)
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+
+		if !bytes.Equal(formatted, src) {
+			t.Errorf("%s is not formatted", file)
+		}
+	}
+
+	if generated == 0 {
+		t.Error("no generated files")
+	}
+}
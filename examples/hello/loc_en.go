@@ -2,16 +2,27 @@
 
 package l10n
 
-import "strings"
+import "io"
 
 type en_Localizer struct{}
 
 func (en_l en_Localizer) Hello(name string) string {
-	var b0 strings.Builder
+	return string(en_l.AppendHello(make([]byte, 0, 24), name))
+}
 
-	b0.WriteString("Hello, ")
-	b0.WriteString(name)
-	b0.WriteString("!")
+func (en_l en_Localizer) AppendHello(b0 []byte, name string) []byte {
+	b0 = append(b0, "Hello, "...)
+	b0 = append(b0, name...)
+	b0 = append(b0, "!"...)
 
-	return b0.String()
-}
\ No newline at end of file
+	return b0
+}
+
+func (en_l en_Localizer) WriteHello(w0 io.Writer, name string) (int, error) {
+	b0 := buffers.Get().(*[]byte)
+	*b0 = en_l.AppendHello((*b0)[:0], name)
+	n0, err0 := w0.Write(*b0)
+	buffers.Put(b0)
+
+	return n0, err0
+}
@@ -2,16 +2,27 @@
 
 package l10n
 
-import "strings"
+import "io"
 
 type ru_Localizer struct{}
 
 func (ru_l ru_Localizer) Hello(name string) string {
-	var b0 strings.Builder
+	return string(ru_l.AppendHello(make([]byte, 0, 31), name))
+}
 
-	b0.WriteString("Привет, ")
-	b0.WriteString(name)
-	b0.WriteString("!")
+func (ru_l ru_Localizer) AppendHello(b0 []byte, name string) []byte {
+	b0 = append(b0, "Привет, "...)
+	b0 = append(b0, name...)
+	b0 = append(b0, "!"...)
 
-	return b0.String()
-}
\ No newline at end of file
+	return b0
+}
+
+func (ru_l ru_Localizer) WriteHello(w0 io.Writer, name string) (int, error) {
+	b0 := buffers.Get().(*[]byte)
+	*b0 = ru_l.AppendHello((*b0)[:0], name)
+	n0, err0 := w0.Write(*b0)
+	buffers.Put(b0)
+
+	return n0, err0
+}
@@ -0,0 +1,37 @@
+//go:build l10n_all || l10n_es_ar
+
+package l10n
+
+import (
+	"slices"
+	"testing"
+)
+
+// Run with -tags l10n_es_ar alone to check that the languages
+// es-AR falls back to through es-419 are built along with it.
+func TestFallbackChain(t *testing.T) {
+	for _, lang := range []string{"es", "es-419", "es-AR"} {
+		if !slices.Contains(Supported, lang) {
+			t.Errorf("%s is not supported", lang)
+		}
+	}
+
+	esAR, ok := New("es-AR")
+	if !ok {
+		t.Fatal("es-AR is not supported")
+	}
+
+	// es-AR has its own Goodbye, and YouAreLate is taken from es-419
+	if got, want := esAR.Goodbye(), "¡Chau!"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := esAR.YouAreLate(5), "Llegaste 5 minutos tarde."; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// es-419 takes Goodbye from es
+	es419, _ := New("es-419")
+	if got, want := es419.Goodbye(), "¡Adiós!"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -2,18 +2,68 @@
 
 package l10n
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"sync"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
 type Localizer interface {
+	// Goodbye returns "Goodbye!"
+	Goodbye() string
+
+	// AppendGoodbye appends Goodbye to b0 and returns the extended buffer.
+	AppendGoodbye(b0 []byte) []byte
+
+	// WriteGoodbye writes Goodbye to w0.
+	WriteGoodbye(w0 io.Writer) (int, error)
+
+	// YouAreLate returns "You are &{minutes} late."
+	//
+	// &{minutes} is, depending on count:
+	//   - one: "1 minute"
+	//   - other: "${count} minutes"
 	YouAreLate(count int) string
+
+	// AppendYouAreLate appends YouAreLate to b0 and returns the extended buffer.
+	AppendYouAreLate(b0 []byte, count int) []byte
+
+	// WriteYouAreLate writes YouAreLate to w0.
+	WriteYouAreLate(w0 io.Writer, count int) (int, error)
 }
 
 var mapLangToLocalizer = map[string]Localizer{
 	"en": en_Localizer{},
-	"ru": ru_Localizer{},
+}
+
+var buffers = sync.Pool{
+	New: func() any {
+		return new([]byte)
+	},
 }
 
 var Supported = []string{
 	"en",
-	"ru",
+}
+
+var matcherTags = []language.Tag{
+	language.MustParse("en"),
+}
+
+var Matcher = language.NewMatcher(matcherTags)
+
+func registerLocalizer(lang string, loc Localizer) {
+	mapLangToLocalizer[lang] = loc
+	Supported = append(Supported, lang)
+	matcherTags = append(matcherTags, language.MustParse(lang))
+	Matcher = language.NewMatcher(matcherTags)
 }
 
 func New(lang string) (loc Localizer, ok bool) {
@@ -21,13 +71,177 @@ func New(lang string) (loc Localizer, ok bool) {
 	return loc, ok
 }
 
+func Match(tags ...language.Tag) (loc Localizer, tag language.Tag, conf language.Confidence) {
+	tag, idx, conf := Matcher.Match(tags...)
+	return mapLangToLocalizer[Supported[idx]], tag, conf
+}
+
+func FromAcceptLanguage(header string) (loc Localizer, tag language.Tag, conf language.Confidence) {
+	tags, _, _ := language.ParseAcceptLanguage(header)
+	return Match(tags...)
+}
+
 func Language(loc Localizer) string {
-	switch loc.(type) {
-	case en_Localizer:
-		return "en"
-	case ru_Localizer:
-		return "ru"
-	default:
-		return ""
-	}
-}
\ No newline at end of file
+	for _, lang := range Supported {
+		if mapLangToLocalizer[lang] == loc {
+			return lang
+		}
+	}
+	return ""
+}
+
+type contextKey struct{}
+
+var Default Localizer = en_Localizer{}
+
+func NewContext(ctx context.Context, loc Localizer) context.Context {
+	return context.WithValue(ctx, contextKey{}, loc)
+}
+
+func FromContext(ctx context.Context) Localizer {
+	if loc, ok := ctx.Value(contextKey{}).(Localizer); ok {
+		return loc
+	}
+	return Default
+}
+
+func LanguageFromContext(ctx context.Context) string {
+	return Language(FromContext(ctx))
+}
+
+type MessageID string
+
+const (
+	MessageID_Goodbye    MessageID = "Goodbye"
+	MessageID_YouAreLate MessageID = "YouAreLate"
+)
+
+var messageIDs = []MessageID{
+	MessageID_Goodbye,
+	MessageID_YouAreLate,
+}
+
+var (
+	ErrUnknownMessage  = errors.New("unknown message")
+	ErrInvalidArgument = errors.New("invalid argument")
+)
+
+func ParseMessageID(s string) (MessageID, error) {
+	for _, id := range messageIDs {
+		if string(id) == s {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownMessage, s)
+}
+
+// Translate returns the message with the given ID in the language of loc.
+// args must contain exactly the arguments of the message, each of its Go type,
+// except that int arguments may also be given as integral float64 values.
+func Translate(loc Localizer, id MessageID, args map[string]any) (string, error) {
+	switch id {
+	case MessageID_Goodbye:
+		if err := checkArguments(id, args); err != nil {
+			return "", err
+		}
+		return loc.Goodbye(), nil
+	case MessageID_YouAreLate:
+		if err := checkArguments(id, args, "count"); err != nil {
+			return "", err
+		}
+		arg_count, ok := intArgument(args["count"])
+		if !ok {
+			return "", fmt.Errorf("%s: %w: %q must be %s, not %T", id, ErrInvalidArgument, "count", "int", args["count"])
+		}
+		return loc.YouAreLate(arg_count), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownMessage, id)
+}
+
+func checkArguments(id MessageID, args map[string]any, names ...string) error {
+	for _, name := range names {
+		if _, ok := args[name]; !ok {
+			return fmt.Errorf("%s: %w: %q is missing", id, ErrInvalidArgument, name)
+		}
+	}
+	for name := range args {
+		if !hasArgument(names, name) {
+			return fmt.Errorf("%s: %w: %q is unknown", id, ErrInvalidArgument, name)
+		}
+	}
+
+	return nil
+}
+
+func hasArgument(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func intArgument(value any) (int, bool) {
+	switch value := value.(type) {
+	case int:
+		return value, true
+	case float64:
+		if value == math.Trunc(value) && value >= math.MinInt && value < -math.MinInt {
+			return int(value), true
+		}
+	}
+	return 0, false
+}
+
+type Message interface {
+	MessageID() MessageID
+	Localize(loc Localizer) string
+	error
+	slog.LogValuer
+}
+
+type GoodbyeMsg struct{}
+
+func (m GoodbyeMsg) MessageID() MessageID {
+	return MessageID_Goodbye
+}
+
+func (m GoodbyeMsg) Localize(loc Localizer) string {
+	return loc.Goodbye()
+}
+
+func (m GoodbyeMsg) Error() string {
+	return m.Localize(Default)
+}
+
+func (m GoodbyeMsg) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", string(MessageID_Goodbye)))
+}
+
+type YouAreLateMsg struct {
+	Count int
+}
+
+func (m YouAreLateMsg) MessageID() MessageID {
+	return MessageID_YouAreLate
+}
+
+func (m YouAreLateMsg) Localize(loc Localizer) string {
+	return loc.YouAreLate(m.Count)
+}
+
+func (m YouAreLateMsg) Error() string {
+	return m.Localize(Default)
+}
+
+func (m YouAreLateMsg) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", string(MessageID_YouAreLate)), slog.Group("args", slog.Any("count", m.Count)))
+}
+
+func pluralForm(lang language.Tag, n int) plural.Form {
+	if n < 0 {
+		n = -(n % 10000000)
+	}
+	return plural.Cardinal.MatchPlural(lang, n, 0, 0, 0, 0)
+}
@@ -0,0 +1,108 @@
+package l10n
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/infastin/go-l10n/internal/pluraltest"
+	"golang.org/x/text/language"
+)
+
+// Run with -tags l10n_ru or -tags l10n_all to test the package with Russian.
+func TestRegistration(t *testing.T) {
+	// Spanish languages are tested separately
+	supported := slices.DeleteFunc(slices.Clone(Supported), func(lang string) bool {
+		return strings.HasPrefix(lang, "es")
+	})
+
+	want := []string{"en"}
+	if withRussian {
+		want = append(want, "ru")
+	}
+
+	if !slices.Equal(supported, want) {
+		t.Errorf("got supported languages %v, want %v", supported, want)
+	}
+
+	ru, ok := New("ru")
+	if ok != withRussian {
+		t.Fatalf("got Russian %v, want %v", ok, withRussian)
+	}
+
+	// The base language is always there
+	en, ok := New("en")
+	if !ok {
+		t.Fatal("English is not supported")
+	}
+	if lang := Language(en); lang != "en" {
+		t.Errorf("got language %q, want %q", lang, "en")
+	}
+
+	_, tag, _ := Match(language.Russian)
+	if base, _ := tag.Base(); (base.String() == "ru") != withRussian {
+		t.Errorf("got Russian matched to %v", tag)
+	}
+
+	if !withRussian {
+		return
+	}
+
+	if lang := Language(ru); lang != "ru" {
+		t.Errorf("got language %q, want %q", lang, "ru")
+	}
+
+	tests := []struct {
+		count int
+		want  string
+	}{
+		{1, "Вы опоздали на 1 минуту."},
+		{2, "Вы опоздали на 2 минуты."},
+		{5, "Вы опоздали на 5 минут."},
+		{21, "Вы опоздали на 21 минуту."},
+	}
+
+	for _, tt := range tests {
+		if got := ru.YouAreLate(tt.count); got != tt.want {
+			t.Errorf("%d: got %q, want %q", tt.count, got, tt.want)
+		}
+	}
+}
+
+// The generated helper has to choose the same forms as live catalogs.
+func TestPluralForm(t *testing.T) {
+	for _, tt := range pluraltest.Cases {
+		if got := pluralForm(tt.Lang, tt.N); got != tt.Form {
+			t.Errorf("%v of %d: got %v, want %v", tt.Lang, tt.N, got, tt.Form)
+		}
+	}
+}
+
+// Append and Write methods have to produce the same text as the string one,
+// whichever plural form it has.
+func TestAppendWrite(t *testing.T) {
+	const prefix = "prefix|"
+
+	for _, lang := range Supported {
+		loc, _ := New(lang)
+
+		for _, count := range []int{0, 1, 2, 5, 21, -1} {
+			want := loc.YouAreLate(count)
+
+			b := append(make([]byte, 0, 256), prefix...)
+			if got := string(loc.AppendYouAreLate(b, count)); got != prefix+want {
+				t.Errorf("%s, %d: got appended %q, want %q", lang, count, got, prefix+want)
+			}
+
+			var w bytes.Buffer
+			n, err := loc.WriteYouAreLate(&w, count)
+			if err != nil {
+				t.Fatalf("%s, %d: unexpected error: %v", lang, count, err)
+			}
+			if w.String() != want || n != len(want) {
+				t.Errorf("%s, %d: got written %q of %d bytes, want %q of %d bytes", lang, count, w.String(), n, want, len(want))
+			}
+		}
+	}
+}
@@ -3,28 +3,57 @@
 package l10n
 
 import (
-	"strings"
+	"io"
 	"strconv"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
 )
 
 type en_Localizer struct{}
 
-func (en_l en_Localizer) YouAreLate_minutes(b0 *strings.Builder, count int)  {
-	switch {
-	case count == 1:
-		b0.WriteString("1 minute")
+var en_lang = language.MustParse("en")
+
+func (en_l en_Localizer) Goodbye() string {
+	return "Goodbye!"
+}
+
+func (en_l en_Localizer) AppendGoodbye(b0 []byte) []byte {
+	return append(b0, en_l.Goodbye()...)
+}
+
+func (en_l en_Localizer) WriteGoodbye(w0 io.Writer) (int, error) {
+	return io.WriteString(w0, en_l.Goodbye())
+}
+
+func (en_l en_Localizer) YouAreLate_minutes(b0 []byte, count int) []byte {
+	switch f0 := pluralForm(en_lang, count); {
+	case f0 == plural.One:
+		b0 = append(b0, "1 minute"...)
 	default:
-		b0.WriteString(strconv.Itoa(count))
-		b0.WriteString(" minutes")
+		b0 = strconv.AppendInt(b0, int64(count), 10)
+		b0 = append(b0, " minutes"...)
 	}
+	return b0
 }
 
 func (en_l en_Localizer) YouAreLate(count int) string {
-	var b0 strings.Builder
+	return string(en_l.AppendYouAreLate(make([]byte, 0, 38), count))
+}
+
+func (en_l en_Localizer) AppendYouAreLate(b0 []byte, count int) []byte {
+	b0 = append(b0, "You are "...)
+	b0 = en_l.YouAreLate_minutes(b0, count)
+	b0 = append(b0, " late."...)
 
-	b0.WriteString("You are ")
-	en_l.YouAreLate_minutes(&b0, count)
-	b0.WriteString(" late.")
+	return b0
+}
+
+func (en_l en_Localizer) WriteYouAreLate(w0 io.Writer, count int) (int, error) {
+	b0 := buffers.Get().(*[]byte)
+	*b0 = en_l.AppendYouAreLate((*b0)[:0], count)
+	n0, err0 := w0.Write(*b0)
+	buffers.Put(b0)
 
-	return b0.String()
-}
\ No newline at end of file
+	return n0, err0
+}
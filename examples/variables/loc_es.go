@@ -0,0 +1,65 @@
+// Code generated by go-l10n; DO NOT EDIT.
+
+//go:build l10n_all || l10n_es || l10n_es_419 || l10n_es_ar
+
+package l10n
+
+import (
+	"io"
+	"strconv"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+type es_Localizer struct{}
+
+var es_lang = language.MustParse("es")
+
+func init() {
+	registerLocalizer("es", es_Localizer{})
+}
+
+func (es_l es_Localizer) Goodbye() string {
+	return "¡Adiós!"
+}
+
+func (es_l es_Localizer) AppendGoodbye(b0 []byte) []byte {
+	return append(b0, es_l.Goodbye()...)
+}
+
+func (es_l es_Localizer) WriteGoodbye(w0 io.Writer) (int, error) {
+	return io.WriteString(w0, es_l.Goodbye())
+}
+
+func (es_l es_Localizer) YouAreLate_minutes(b0 []byte, count int) []byte {
+	switch f0 := pluralForm(es_lang, count); {
+	case f0 == plural.One:
+		b0 = append(b0, "1 minuto"...)
+	default:
+		b0 = strconv.AppendInt(b0, int64(count), 10)
+		b0 = append(b0, " minutos"...)
+	}
+	return b0
+}
+
+func (es_l es_Localizer) YouAreLate(count int) string {
+	return string(es_l.AppendYouAreLate(make([]byte, 0, 38), count))
+}
+
+func (es_l es_Localizer) AppendYouAreLate(b0 []byte, count int) []byte {
+	b0 = append(b0, "Llegas "...)
+	b0 = es_l.YouAreLate_minutes(b0, count)
+	b0 = append(b0, " tarde."...)
+
+	return b0
+}
+
+func (es_l es_Localizer) WriteYouAreLate(w0 io.Writer, count int) (int, error) {
+	b0 := buffers.Get().(*[]byte)
+	*b0 = es_l.AppendYouAreLate((*b0)[:0], count)
+	n0, err0 := w0.Write(*b0)
+	buffers.Put(b0)
+
+	return n0, err0
+}
@@ -0,0 +1,37 @@
+// Code generated by go-l10n; DO NOT EDIT.
+
+//go:build l10n_all || l10n_es_ar
+
+package l10n
+
+import "io"
+
+type es_AR_Localizer struct{}
+
+func init() {
+	registerLocalizer("es-AR", es_AR_Localizer{})
+}
+
+func (es_AR_l es_AR_Localizer) Goodbye() string {
+	return "¡Chau!"
+}
+
+func (es_AR_l es_AR_Localizer) AppendGoodbye(b0 []byte) []byte {
+	return append(b0, es_AR_l.Goodbye()...)
+}
+
+func (es_AR_l es_AR_Localizer) WriteGoodbye(w0 io.Writer) (int, error) {
+	return io.WriteString(w0, es_AR_l.Goodbye())
+}
+
+func (es_AR_l es_AR_Localizer) YouAreLate(count int) string {
+	return es_419_Localizer{}.YouAreLate(count)
+}
+
+func (es_AR_l es_AR_Localizer) AppendYouAreLate(b0 []byte, count int) []byte {
+	return es_419_Localizer{}.AppendYouAreLate(b0, count)
+}
+
+func (es_AR_l es_AR_Localizer) WriteYouAreLate(w0 io.Writer, count int) (int, error) {
+	return es_419_Localizer{}.WriteYouAreLate(w0, count)
+}
@@ -1,30 +1,69 @@
 // Code generated by go-l10n; DO NOT EDIT.
 
+//go:build l10n_all || l10n_ru
+
 package l10n
 
 import (
-	"strings"
+	"io"
 	"strconv"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
 )
 
 type ru_Localizer struct{}
 
-func (ru_l ru_Localizer) YouAreLate_minutes(b0 *strings.Builder, count int)  {
-	switch {
-	case count == 1:
-		b0.WriteString("1 минуту")
+var ru_lang = language.MustParse("ru")
+
+func init() {
+	registerLocalizer("ru", ru_Localizer{})
+}
+
+func (ru_l ru_Localizer) YouAreLate_minutes(b0 []byte, count int) []byte {
+	switch f0 := pluralForm(ru_lang, count); {
+	case f0 == plural.One:
+		b0 = strconv.AppendInt(b0, int64(count), 10)
+		b0 = append(b0, " минуту"...)
+	case f0 == plural.Few:
+		b0 = strconv.AppendInt(b0, int64(count), 10)
+		b0 = append(b0, " минуты"...)
 	default:
-		b0.WriteString(strconv.Itoa(count))
-		b0.WriteString(" минут")
+		b0 = strconv.AppendInt(b0, int64(count), 10)
+		b0 = append(b0, " минут"...)
 	}
+	return b0
 }
 
 func (ru_l ru_Localizer) YouAreLate(count int) string {
-	var b0 strings.Builder
+	return string(ru_l.AppendYouAreLate(make([]byte, 0, 57), count))
+}
+
+func (ru_l ru_Localizer) AppendYouAreLate(b0 []byte, count int) []byte {
+	b0 = append(b0, "Вы опоздали на "...)
+	b0 = ru_l.YouAreLate_minutes(b0, count)
+	b0 = append(b0, "."...)
+
+	return b0
+}
+
+func (ru_l ru_Localizer) WriteYouAreLate(w0 io.Writer, count int) (int, error) {
+	b0 := buffers.Get().(*[]byte)
+	*b0 = ru_l.AppendYouAreLate((*b0)[:0], count)
+	n0, err0 := w0.Write(*b0)
+	buffers.Put(b0)
+
+	return n0, err0
+}
 
-	b0.WriteString("Вы опоздали на ")
-	ru_l.YouAreLate_minutes(&b0, count)
-	b0.WriteString(".")
+func (ru_l ru_Localizer) Goodbye() string {
+	return en_Localizer{}.Goodbye()
+}
+
+func (ru_l ru_Localizer) AppendGoodbye(b0 []byte) []byte {
+	return en_Localizer{}.AppendGoodbye(b0)
+}
 
-	return b0.String()
-}
\ No newline at end of file
+func (ru_l ru_Localizer) WriteGoodbye(w0 io.Writer) (int, error) {
+	return en_Localizer{}.WriteGoodbye(w0)
+}
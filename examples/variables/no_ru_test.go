@@ -0,0 +1,6 @@
+//go:build !(l10n_all || l10n_ru)
+
+package l10n
+
+// Whether Russian is built into the package.
+const withRussian = false
@@ -0,0 +1,131 @@
+package exchange
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+	"github.com/infastin/go-l10n/parse"
+	"github.com/infastin/go-l10n/scope"
+)
+
+// Returns key of the message in ARB file: the name of the message in lowerCamelCase,
+// whose namespaces are joined with it, since keys must be valid Dart identifiers.
+// For example, Auth.Login.Title becomes authLoginTitle.
+func arbKey(name string) string {
+	name = strings.ReplaceAll(name, ".", "")
+
+	c, size := utf8.DecodeRuneInString(name)
+
+	return string(unicode.ToLower(c)) + name[size:]
+}
+
+// Returns type of the ARB placeholder of the argument.
+func arbType(goType ast.GoType) string {
+	switch specifierOf(goType) {
+	case 0:
+		return "String"
+	case 'd':
+		return "int"
+	case 'f':
+		return "double"
+	default:
+		return "Object"
+	}
+}
+
+// Returns ARB file of the localization, whose strings are written in ICU MessageFormat.
+// Metadata of messages, which declares their placeholders and descriptions,
+// is only written to the file of the base localization,
+// since Flutter takes it from the template file.
+// Messages that are missing in the localization are not written at all.
+func ExportARB(base, loc *scope.Localization) (data []byte, err error) {
+	root := parse.NewTableNode()
+	root.Set("@@locale", parse.NewStringNode(strings.ReplaceAll(loc.Lang.String(), "-", "_")))
+
+	useZero := usesZeroForm(loc.Lang)
+
+	for i := 0; i < len(base.Scopes); i++ {
+		baseMs := &base.Scopes[i]
+
+		ms := findMessage(loc, baseMs.Name)
+		if ms == nil {
+			continue
+		}
+
+		variables := make([]ast.Variable, 0, len(ms.Variables))
+		for j := 0; j < len(ms.Variables); j++ {
+			variables = append(variables, ms.Variables[j].Variable)
+		}
+
+		var str string
+		if ms.Plural.IsZero() {
+			str, err = parse.FormatARB(ms.String, variables, useZero)
+		} else {
+			str, err = parse.FormatARBPlural(&ms.Plural, variables, useZero)
+		}
+		if err != nil {
+			return nil, common.NewMessageError(ms.Name, err)
+		}
+
+		key := arbKey(ms.Name)
+		root.Set(key, parse.NewStringNode(str))
+
+		if loc == base {
+			if meta := arbMetadata(ms); meta != nil {
+				root.Set("@"+key, meta)
+			}
+		}
+	}
+
+	return parse.EncodeJSON(root)
+}
+
+// Returns metadata of the message or nil if it has neither arguments nor description.
+// Floating-point arguments written with a precision get the decimal pattern with as many digits.
+func arbMetadata(ms *scope.MessageScope) *parse.Node {
+	if len(ms.Arguments) == 0 && ms.Description == "" {
+		return nil
+	}
+
+	meta := parse.NewTableNode()
+
+	if ms.Description != "" {
+		meta.Set("description", parse.NewStringNode(ms.Description))
+	}
+
+	if len(ms.Arguments) == 0 {
+		return meta
+	}
+
+	formats := placeholders(ms, nil)
+
+	nodes := parse.NewTableNode()
+
+	for i := 0; i < len(ms.Arguments); i++ {
+		arg := &ms.Arguments[i]
+
+		placeholder := parse.NewTableNode()
+		placeholder.Set("type", parse.NewStringNode(arbType(arg.GoType)))
+
+		if part, ok := formats[arg.Name].(ast.ArgInfo); ok && specifierOf(arg.GoType) == 'f' && part.FmtInfo.Prec.Valid {
+			params := parse.NewTableNode()
+			params.Set("decimalDigits", &parse.Node{
+				Kind: parse.OtherNode,
+				Str:  strconv.Itoa(part.FmtInfo.Prec.Value),
+			})
+
+			placeholder.Set("format", parse.NewStringNode("decimalPatternDigits"))
+			placeholder.Set("optionalParameters", params)
+		}
+
+		nodes.Set(arg.Name, placeholder)
+	}
+
+	meta.Set("placeholders", nodes)
+
+	return meta
+}
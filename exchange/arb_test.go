@@ -0,0 +1,132 @@
+package exchange
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/infastin/go-l10n/parse"
+	"github.com/infastin/go-l10n/process"
+	"github.com/infastin/go-l10n/scope"
+)
+
+// The same messages translated into Latvian, which uses zero form.
+const testLatvianYAML = `
+Route: "${from} ← ${to}"
+Quote: "Sakiet \"{sveiki}\" <b>&&</b>\n\tun \\ ${name}"
+Files:
+  plural:
+    arg: n
+    "=0": "Nav failu"
+    zero: "${n} failu mapē ${dir}"
+    one: "${n} fails mapē ${dir}"
+    other: "${n} faili mapē ${dir}"
+Invitation:
+  variables:
+    pronoun:
+      select:
+        arg: gender
+        male: "viņa"
+        other: "savu"
+  string: "${host} uzaicināja jūs uz &{pronoun} ballīti."
+`
+
+// Exports the localization to ARB file and reads it back,
+// taking metadata from the template if it is given.
+func exportImportARB(t *testing.T, base, loc *scope.Localization, template []byte) (data []byte, imported scope.Localization) {
+	t.Helper()
+
+	data, err := ExportARB(base, loc)
+	if err != nil {
+		t.Fatalf("%v: unexpected error: %v", loc.Lang, err)
+	}
+
+	var arbTemplate *parse.ARBTemplate
+	if template != nil {
+		arbTemplate = &parse.ARBTemplate{Filename: "app_en.arb", Data: template}
+	}
+
+	filename := "app_" + strings.ReplaceAll(loc.Lang.String(), "-", "_") + ".arb"
+
+	msgs, err := parse.UnmarshalARBMessages(filename, data, arbTemplate, parse.DecodeJSON)
+	if err != nil {
+		t.Fatalf("%v: could not read exported file: %v\n%s", loc.Lang, err, data)
+	}
+
+	mss, err := process.ProcessMessages(msgs, loc.Lang)
+	if err != nil {
+		t.Fatalf("%v: could not process exported messages: %v", loc.Lang, err)
+	}
+
+	return data, scope.Localization{Name: loc.Name, Lang: loc.Lang, Scopes: mss}
+}
+
+// Returns texts of the localization with its variables renamed the way they are read from ARB files:
+// variables are written in place of their references in ICU MessageFormat,
+// so they are read back under names made of their arguments.
+func arbTexts(loc *scope.Localization, names map[string]string) map[SegmentID]string {
+	texts := make(map[SegmentID]string)
+
+	for id, text := range localizationTexts(loc) {
+		for name, arbName := range names {
+			text = strings.ReplaceAll(text, "&{"+name+"}", "&{"+arbName+"}")
+			if id.Variable == name {
+				id.Variable = arbName
+			}
+		}
+		texts[id] = text
+	}
+
+	return texts
+}
+
+func TestExportImportARB(t *testing.T) {
+	names := map[string]string{"pronoun": "gender_select"}
+
+	base := testLocalization(t, "en", testBaseYAML)
+	lv := testLocalization(t, "lv", testLatvianYAML)
+
+	baseData, baseImported := exportImportARB(t, &base, &base, nil)
+
+	for _, want := range []string{
+		`"@@locale": "en"`,
+		`"files": "{n, plural, =0 {No files} one {{n} file in {dir}} other {{n} files in {dir}}}"`,
+		`"@files": {`,
+		`"n": {`,
+		`"type": "int"`,
+	} {
+		if !strings.Contains(string(baseData), want) {
+			t.Errorf("exported base file doesn't contain\n%s\nfile:\n%s", want, baseData)
+		}
+	}
+
+	checkTexts(t, localizationTexts(&baseImported), arbTexts(&base, names))
+
+	// Arguments of the base messages are declared by the metadata
+	for i := 0; i < len(base.Scopes); i++ {
+		ms, imported := &base.Scopes[i], &baseImported.Scopes[i]
+		for j := 0; j < len(ms.Arguments); j++ {
+			if j >= len(imported.Arguments) || imported.Arguments[j].GoType != ms.Arguments[j].GoType {
+				t.Errorf("%s: got arguments %v, want %v", ms.Name, imported.Arguments, ms.Arguments)
+				break
+			}
+		}
+	}
+
+	lvData, lvImported := exportImportARB(t, &base, &lv, baseData)
+
+	for _, want := range []string{
+		`"@@locale": "lv"`,
+		// Latvian uses zero form, so it is written as it is along with =0 one
+		`=0 {Nav failu} zero {{n} failu mapē {dir}}`,
+	} {
+		if !strings.Contains(string(lvData), want) {
+			t.Errorf("exported file doesn't contain\n%s\nfile:\n%s", want, lvData)
+		}
+	}
+
+	if strings.Contains(string(lvData), `"@files"`) {
+		t.Errorf("metadata is written to the file of a language other than the base one:\n%s", lvData)
+	}
+
+	checkTexts(t, localizationTexts(&lvImported), arbTexts(&lv, names))
+}
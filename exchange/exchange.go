@@ -0,0 +1,939 @@
+package exchange
+
+import (
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+	"github.com/infastin/go-l10n/parse"
+	"github.com/infastin/go-l10n/scope"
+	"golang.org/x/text/language"
+)
+
+// Identifies translatable text of a message.
+type SegmentID struct {
+	Message string
+	// Name of the variable, empty for the message itself
+	Variable string
+	// Name of the plural form, empty if the text is not plural
+	Form string
+	// Exact form of the plural, like "=1", which is not one of the plural forms,
+	// or value of the argument of the select, which is "other" for the rest of the values
+	Case string
+}
+
+// Translatable text of a message: the string of the message or a variable,
+// or one of the forms of their plural or select.
+type Segment struct {
+	SegmentID
+	// Location of the text in the file it has been read from
+	Location ast.Location
+	Text     ast.FormatParts
+}
+
+// Returns segments of the message: the ones of the message itself go first,
+// followed by the ones of its variables.
+// Plural forms are the ones used by the language, preceded by the exact forms.
+func Segments(ms *scope.MessageScope, lang language.Tag) (segs []Segment) {
+	segs = valueSegments(SegmentID{Message: ms.Name}, ms.Location, &ms.Plural, &ast.Select{}, ms.String, lang)
+
+	for i := 0; i < len(ms.Variables); i++ {
+		variable := &ms.Variables[i]
+		id := SegmentID{Message: ms.Name, Variable: variable.Name}
+		segs = append(segs, valueSegments(id, variable.Location, &variable.Plural, &variable.Select, variable.String, lang)...)
+	}
+
+	return segs
+}
+
+func valueSegments(
+	id SegmentID,
+	loc ast.Location,
+	plural *ast.Plural,
+	sel *ast.Select,
+	str ast.FormatParts,
+	lang language.Tag,
+) (segs []Segment) {
+	if !sel.IsZero() {
+		for i := 0; i < len(sel.Cases); i++ {
+			id.Case = sel.Cases[i].Value
+			segs = append(segs, Segment{SegmentID: id, Location: sel.Location, Text: sel.Cases[i].Parts})
+		}
+
+		id.Case = "other"
+		return append(segs, Segment{SegmentID: id, Location: sel.Location, Text: sel.Other})
+	}
+
+	if plural.IsZero() {
+		return []Segment{{SegmentID: id, Location: loc, Text: str}}
+	}
+
+	for i := 0; i < len(plural.Exact); i++ {
+		id.Case = plural.Exact[i].Name()
+		segs = append(segs, Segment{SegmentID: id, Location: plural.Location, Text: plural.Exact[i].Parts})
+	}
+
+	// Zero form of a language that doesn't use it matches zero exactly,
+	// so it goes along with the exact forms, unless there is "=0" form already
+	if hasExactZero(plural, lang) {
+		id.Case = "zero"
+		segs = append(segs, Segment{SegmentID: id, Location: plural.Location, Text: plural.Zero})
+	}
+
+	id.Case = ""
+
+	for _, form := range scope.PluralForms(lang) {
+		text := pluralForm(plural, form)
+		if text == nil {
+			text = plural.Other
+		}
+
+		id.Form = form
+		segs = append(segs, Segment{SegmentID: id, Location: plural.Location, Text: text})
+	}
+
+	return segs
+}
+
+// Reports whether the plural has zero form that the language doesn't use,
+// which matches zero exactly and is translated on its own.
+func hasExactZero(plural *ast.Plural, lang language.Tag) bool {
+	return plural.Zero != nil && !usesZeroForm(lang) && plural.ExactForm(0) == nil
+}
+
+func pluralForm(plural *ast.Plural, form string) ast.FormatParts {
+	switch form {
+	case "zero":
+		return plural.Zero
+	case "one":
+		return plural.One
+	case "two":
+		return plural.Two
+	case "few":
+		return plural.Few
+	case "many":
+		return plural.Many
+	default:
+		return plural.Other
+	}
+}
+
+// Returns name of the argument the plural of the segment depends on.
+func pluralArg(ms *scope.MessageScope, id SegmentID) string {
+	if id.Variable == "" {
+		return ms.Plural.Arg
+	}
+
+	for i := 0; i < len(ms.Variables); i++ {
+		if ms.Variables[i].Name == id.Variable {
+			return ms.Variables[i].Plural.Arg
+		}
+	}
+
+	return ""
+}
+
+// Returns name of the argument the select of the segment depends on,
+// or empty string if the segment is not a form of a select.
+func selectArg(ms *scope.MessageScope, id SegmentID) string {
+	for i := 0; i < len(ms.Variables); i++ {
+		if ms.Variables[i].Name == id.Variable {
+			return ms.Variables[i].Select.Arg
+		}
+	}
+
+	return ""
+}
+
+// Returns message scope of the localization with the given name,
+// or nil if the localization doesn't have it and takes it from the fallback one.
+func findMessage(loc *scope.Localization, name string) *scope.MessageScope {
+	if loc == nil {
+		return nil
+	}
+
+	for i := 0; i < len(loc.Scopes); i++ {
+		ms := &loc.Scopes[i]
+		if ms.Name == name && ms.Fallback == nil {
+			return ms
+		}
+	}
+
+	return nil
+}
+
+// Returns reference to the location in the source file,
+// whose path is relative to the directory of the exported file.
+func sourceReference(loc ast.Location, refDir string) string {
+	file := loc.File
+
+	absFile, fileErr := filepath.Abs(loc.File)
+	absDir, dirErr := filepath.Abs(refDir)
+
+	if fileErr == nil && dirErr == nil {
+		if rel, err := filepath.Rel(absDir, absFile); err == nil {
+			file = rel
+		}
+	}
+
+	return filepath.ToSlash(file) + ":" + strconv.Itoa(loc.Line)
+}
+
+// Returns placeholders that can be used in translations of the message:
+// its variables and arguments.
+// Arguments are formatted the same way the translated message formats them,
+// or the same way as the base message, if it is not translated yet.
+func placeholders(baseMs, ms *scope.MessageScope) (parts map[string]ast.FormatPart) {
+	parts = make(map[string]ast.FormatPart)
+
+	for i := 0; i < len(baseMs.Arguments); i++ {
+		arg := &baseMs.Arguments[i]
+		parts[arg.Name] = ast.ArgInfo{
+			Name:    arg.Name,
+			FmtInfo: ast.FmtInfo{Spec: specifierOf(arg.GoType)},
+		}
+	}
+
+	for _, ms := range []*scope.MessageScope{baseMs, ms} {
+		if ms == nil {
+			continue
+		}
+
+		addValueFormats(parts, ms.String)
+		addValueFormats(parts, &ms.Plural)
+
+		for i := 0; i < len(ms.Variables); i++ {
+			addValueFormats(parts, ms.Variables[i].String)
+			addValueFormats(parts, &ms.Variables[i].Plural)
+			addValueFormats(parts, &ms.Variables[i].Select)
+		}
+	}
+
+	for _, ms := range []*scope.MessageScope{baseMs, ms} {
+		if ms == nil {
+			continue
+		}
+
+		for i := 0; i < len(ms.Variables); i++ {
+			name := ms.Variables[i].Name
+			parts[name] = ast.VarInfo{Name: name}
+		}
+	}
+
+	return parts
+}
+
+// Returns names of the arguments and variables the text refers to.
+func textPlaceholders(text ast.FormatParts) (names []string) {
+	for _, part := range text {
+		if name := placeholderName(part); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Returns names of the placeholders of the segment of the message.
+// Reports false if the message is nil or doesn't have the segment.
+func segmentPlaceholders(ms *scope.MessageScope, id SegmentID, lang language.Tag) (names []string, ok bool) {
+	if ms == nil {
+		return nil, false
+	}
+
+	for _, seg := range Segments(ms, lang) {
+		if seg.SegmentID == id {
+			return textPlaceholders(seg.Text), true
+		}
+	}
+
+	return nil, false
+}
+
+// Checks that the translation of the segment uses the same placeholders as the base message,
+// or the same ones as the current translation of the segment, if there is one.
+// The argument of the plural may be missing from the forms of the plural or added to them,
+// since the number doesn't have to be written in every form.
+// The name of the segment is the one used in the error.
+func checkPlaceholders(
+	baseMs, ms *scope.MessageScope,
+	id SegmentID,
+	lang language.Tag,
+	name string,
+	text ast.FormatParts,
+) error {
+	var ignored string
+	if id.Form != "" || id.Case != "" {
+		ignored = pluralArg(baseMs, id)
+	}
+
+	diff := func(a, b []string) (names []string) {
+		for _, name := range a {
+			if name != ignored && !slices.Contains(b, name) {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+
+	target := textPlaceholders(text)
+
+	if current, ok := segmentPlaceholders(ms, id, lang); ok {
+		if diff(current, target) == nil && diff(target, current) == nil {
+			return nil
+		}
+	}
+
+	source, _ := segmentPlaceholders(baseMs, id, lang)
+
+	missing, unknown := diff(source, target), diff(target, source)
+	if missing == nil && unknown == nil {
+		return nil
+	}
+
+	return common.NewPlaceholdersMismatchError(name, missing, unknown)
+}
+
+func addValueFormats(parts map[string]ast.FormatPart, value ast.Value) {
+	for _, form := range value.Forms() {
+		addArgumentFormats(parts, form)
+	}
+}
+
+// Remembers formats of the arguments, the ones added later take precedence.
+// Arguments that are not known already are ignored.
+func addArgumentFormats(parts map[string]ast.FormatPart, text ast.FormatParts) {
+	for _, part := range text {
+		arg, ok := part.(ast.ArgInfo)
+		if !ok {
+			continue
+		}
+
+		if _, known := parts[arg.Name].(ast.ArgInfo); known {
+			parts[arg.Name] = ast.ArgInfo{Name: arg.Name, FmtInfo: arg.FmtInfo}
+		}
+	}
+}
+
+// Returns format specifier that results in the Go type.
+// Strings don't need a specifier.
+func specifierOf(goType ast.GoType) rune {
+	if goType == common.Config.SpecifierToGoType['s'] {
+		return 0
+	}
+
+	for spec, specType := range common.Config.SpecifierToGoType {
+		if specType == goType {
+			return spec
+		}
+	}
+
+	return 0
+}
+
+// Merges translated segments of the base message into the tree of a localization file.
+// Texts of the message that is already in the file are replaced,
+// and everything else is kept intact.
+// If the message is not in the file, or it is structured differently
+// than the base message, it is added with the structure of the base message,
+// but only if all of its segments are translated.
+// Texts are written in the syntax of the message.
+func MergeMessage(
+	root *parse.Node,
+	baseMs *scope.MessageScope,
+	lang language.Tag,
+	segs map[SegmentID]Segment,
+) (err error) {
+	existing := parse.FindMessage(root, baseMs.Name)
+
+	fileSyntax := parse.FileSyntax(root)
+
+	syntax := fileSyntax
+	if existing != nil {
+		syntax = parse.MessageSyntax(syntax, existing)
+	}
+
+	if existing != nil {
+		node := existing.Clone()
+		if updateMessage(node, baseMs, lang, segs, syntax) {
+			parse.SetMessage(root, baseMs.Name, node)
+			return nil
+		}
+	}
+
+	var (
+		node     *parse.Node
+		complete bool
+	)
+
+	if syntax == parse.SyntaxICU {
+		node, complete, err = buildICUMessage(baseMs, lang, segs, stringStyle(root))
+		if err != nil {
+			// Formats that can't be written in ICU MessageFormat
+			// are written in the default syntax
+			node, complete = buildMessage(baseMs, lang, segs, stringStyle(root))
+			syntax = parse.SyntaxDefault
+		}
+	} else {
+		node, complete = buildMessage(baseMs, lang, segs, stringStyle(root))
+	}
+
+	if complete {
+		// Message keeps its own syntax if it is not the one of the file
+		if syntax != fileSyntax {
+			node = withSyntax(node, syntax)
+		}
+
+		parse.SetMessage(root, baseMs.Name, node)
+		return nil
+	}
+
+	// Partially translated new message is just left out,
+	// but the existing one can't be replaced
+	if existing != nil {
+		return common.NewMessageError(baseMs.Name, common.NewError(common.ErrIncompleteTranslation,
+			common.ErrorValueStr(baseMs.Name),
+		))
+	}
+
+	return nil
+}
+
+// Replaces texts of the message with the translated ones.
+// Returns false if some of the segments have nowhere to go.
+func updateMessage(
+	node *parse.Node,
+	baseMs *scope.MessageScope,
+	lang language.Tag,
+	segs map[SegmentID]Segment,
+	syntax parse.Syntax,
+) bool {
+	// Arguments that are used by translated texts
+	var used []string
+
+	for _, baseSeg := range Segments(baseMs, lang) {
+		seg, ok := segs[baseSeg.SegmentID]
+		if !ok {
+			continue
+		}
+
+		value := node
+		if seg.Variable != "" {
+			value = node.Get("variables")
+			if value == nil || value.Kind != parse.TableNode {
+				return false
+			}
+
+			value = value.Get(seg.Variable)
+			if value == nil {
+				return false
+			}
+		}
+
+		var arg string
+		if seg.Form != "" || seg.Case != "" {
+			arg = pluralArg(baseMs, seg.SegmentID)
+		}
+
+		key := "plural"
+		if selectArg(baseMs, seg.SegmentID) != "" {
+			key = "select"
+		}
+
+		str, err := formatText(seg.Text, arg, lang, syntax)
+		if err != nil {
+			return false
+		}
+
+		if seg.Form == "" && seg.Case == "" {
+			if value.Kind == parse.TableNode {
+				value = value.Get("string")
+			}
+			if value == nil || value.Kind != parse.StringNode {
+				return false
+			}
+
+			value.Str = str
+		} else {
+			if value.Kind == parse.TableNode {
+				value = value.Get(key)
+			}
+			if value == nil || value.Kind != parse.TableNode {
+				return false
+			}
+
+			form := seg.Form
+			if seg.Case != "" {
+				form = seg.Case
+			}
+
+			setPluralForm(value, form, str)
+		}
+
+		used = append(used, seg.Text.GetArgumentNames()...)
+	}
+
+	// Declared arguments must include all of the used ones
+	if args := node.Get("arguments"); args != nil && args.Kind == parse.ArrayNode {
+		declared := make(map[string]struct{})
+		for _, arg := range args.Array {
+			declared[argumentName(arg.Str)] = struct{}{}
+		}
+
+		for _, name := range used {
+			if _, ok := declared[name]; !ok {
+				declared[name] = struct{}{}
+				args.Array = append(args.Array, parse.NewStringNode(name))
+			}
+		}
+	}
+
+	return true
+}
+
+// Returns name of the argument declared the same way it is written inside of ${...} block.
+func argumentName(decl string) string {
+	for i := len(decl) - 1; i >= 0; i-- {
+		if decl[i] == ':' {
+			return decl[i+1:]
+		}
+	}
+	return decl
+}
+
+// Returns style of the first quoted string of the tree,
+// so that new strings look the same as the existing ones.
+func stringStyle(node *parse.Node) (style parse.StringStyle) {
+	switch node.Kind {
+	case parse.StringNode:
+		if node.Style.Quote != "" {
+			return node.Style
+		}
+	case parse.TableNode:
+		for i := 0; i < len(node.Table); i++ {
+			if value := node.Table[i].Value; value.Kind != parse.ArrayNode {
+				if style = stringStyle(value); style != (parse.StringStyle{}) {
+					return style
+				}
+			}
+		}
+	}
+	return parse.StringStyle{}
+}
+
+// Returns the text written in the syntax.
+// Texts of the forms of plurals are given the argument of the plural.
+func formatText(text ast.FormatParts, pluralArg string, lang language.Tag, syntax parse.Syntax) (str string, err error) {
+	if syntax == parse.SyntaxICU {
+		return parse.FormatICU(text, nil, pluralArg, usesZeroForm(lang))
+	}
+	return text.String(), nil
+}
+
+func usesZeroForm(lang language.Tag) bool {
+	return slices.Contains(scope.PluralForms(lang), "zero")
+}
+
+// Sets the form of the plural or select table,
+// whose style is the same as the one of the other forms.
+func setPluralForm(plural *parse.Node, form string, str string) {
+	value := parse.NewStringNode(str)
+
+	if existing := plural.Get(form); existing != nil {
+		value.Style = existing.Style
+	} else if other := plural.Get("other"); other != nil {
+		value.Style = other.Style
+	}
+
+	plural.Set(form, value)
+}
+
+// Builds the message with the structure of the base message out of translated segments.
+// Returns false if some of the segments are not translated.
+// Strings are written in the given style.
+func buildMessage(
+	baseMs *scope.MessageScope,
+	lang language.Tag,
+	segs map[SegmentID]Segment,
+	style parse.StringStyle,
+) (node *parse.Node, complete bool) {
+	newString := func(str string) *parse.Node {
+		node := parse.NewStringNode(str)
+		node.Style = style
+		return node
+	}
+
+	// Arguments that are used by translated texts
+	used := make(map[string]struct{})
+
+	value := func(id SegmentID, plural *ast.Plural) *parse.Node {
+		if plural.IsZero() {
+			seg, ok := segs[id]
+			if !ok {
+				return nil
+			}
+
+			for _, name := range seg.Text.GetArgumentNames() {
+				used[name] = struct{}{}
+			}
+
+			return newString(seg.Text.String())
+		}
+
+		table := parse.NewTableNode()
+		table.Set("arg", newString(plural.Arg))
+		used[plural.Arg] = struct{}{}
+
+		for i := 0; i < len(plural.Exact); i++ {
+			id.Case = plural.Exact[i].Name()
+
+			seg, ok := segs[id]
+			if !ok {
+				return nil
+			}
+
+			for _, name := range seg.Text.GetArgumentNames() {
+				used[name] = struct{}{}
+			}
+
+			table.Set(id.Case, newString(seg.Text.String()))
+		}
+
+		// Zero form that matches zero exactly may be left untranslated
+		if hasExactZero(plural, lang) {
+			id.Case = "zero"
+
+			if seg, ok := segs[id]; ok {
+				for _, name := range seg.Text.GetArgumentNames() {
+					used[name] = struct{}{}
+				}
+
+				table.Set("zero", newString(seg.Text.String()))
+			}
+		}
+
+		id.Case = ""
+
+		for _, form := range scope.PluralForms(lang) {
+			id.Form = form
+
+			seg, ok := segs[id]
+			if !ok {
+				return nil
+			}
+
+			for _, name := range seg.Text.GetArgumentNames() {
+				used[name] = struct{}{}
+			}
+
+			table.Set(form, newString(seg.Text.String()))
+		}
+
+		return table
+	}
+
+	selectValue := func(id SegmentID, sel *ast.Select) *parse.Node {
+		table := parse.NewTableNode()
+		table.Set("arg", newString(sel.Arg))
+		used[sel.Arg] = struct{}{}
+
+		for _, baseSeg := range valueSegments(id, ast.Location{}, &ast.Plural{}, sel, nil, lang) {
+			seg, ok := segs[baseSeg.SegmentID]
+			if !ok {
+				return nil
+			}
+
+			for _, name := range seg.Text.GetArgumentNames() {
+				used[name] = struct{}{}
+			}
+
+			table.Set(seg.Case, newString(seg.Text.String()))
+		}
+
+		wrapper := parse.NewTableNode()
+		wrapper.Set("select", table)
+
+		return wrapper
+	}
+
+	msg := value(SegmentID{Message: baseMs.Name}, &baseMs.Plural)
+	if msg == nil {
+		return nil, false
+	}
+
+	node = parse.NewTableNode()
+
+	if len(baseMs.Variables) != 0 {
+		variables := parse.NewTableNode()
+
+		for i := 0; i < len(baseMs.Variables); i++ {
+			variable := &baseMs.Variables[i]
+
+			id := SegmentID{Message: baseMs.Name, Variable: variable.Name}
+
+			if !variable.Select.IsZero() {
+				selValue := selectValue(id, &variable.Select)
+				if selValue == nil {
+					return nil, false
+				}
+
+				variables.Set(variable.Name, selValue)
+				continue
+			}
+
+			varValue := value(id, &variable.Plural)
+			if varValue == nil {
+				return nil, false
+			}
+
+			if varValue.Kind == parse.TableNode {
+				table := parse.NewTableNode()
+				table.Set("plural", varValue)
+				varValue = table
+			}
+
+			variables.Set(variable.Name, varValue)
+		}
+
+		node.Set("variables", variables)
+	}
+
+	if args := declareArguments(baseMs, used, newString); args != nil {
+		node.Table = append([]parse.NodeEntry{{Key: "arguments", KeyOffset: -1, Value: args}}, node.Table...)
+	}
+
+	if msg.Kind == parse.TableNode {
+		node.Set("plural", msg)
+	} else if len(node.Table) != 0 {
+		node.Set("string", msg)
+	} else {
+		node = msg
+	}
+
+	return node, true
+}
+
+// Returns declaration of the arguments of the base message,
+// or nil if all of them are used and don't have to be declared.
+// Arguments that are not used have to be declared,
+// otherwise the message would have less arguments than the base one.
+func declareArguments(
+	baseMs *scope.MessageScope,
+	used map[string]struct{},
+	newString func(str string) *parse.Node,
+) (args *parse.Node) {
+	for i := 0; i < len(baseMs.Arguments); i++ {
+		if _, ok := used[baseMs.Arguments[i].Name]; ok {
+			continue
+		}
+
+		args = &parse.Node{Kind: parse.ArrayNode, Offset: -1}
+
+		for j := 0; j < len(baseMs.Arguments); j++ {
+			arg := &baseMs.Arguments[j]
+
+			decl := arg.Name
+			if spec := specifierOf(arg.GoType); spec != 0 {
+				decl = string(spec) + ":" + decl
+			}
+
+			args.Array = append(args.Array, newString(decl))
+		}
+
+		return args
+	}
+
+	return nil
+}
+
+// Builds the message out of translated segments as a single string written in ICU MessageFormat,
+// which contains variables of the message in place of their references.
+// Returns false if some of the segments are not translated,
+// and an error if some of the arguments can't be written in ICU MessageFormat.
+func buildICUMessage(
+	baseMs *scope.MessageScope,
+	lang language.Tag,
+	segs map[SegmentID]Segment,
+	style parse.StringStyle,
+) (node *parse.Node, complete bool, err error) {
+	newString := func(str string) *parse.Node {
+		node := parse.NewStringNode(str)
+		node.Style = style
+		return node
+	}
+
+	// Arguments that are used by translated texts
+	used := make(map[string]struct{})
+
+	value := func(id SegmentID, basePlural *ast.Plural) (str ast.FormatParts, plural ast.Plural, ok bool) {
+		if basePlural.IsZero() {
+			seg, ok := segs[id]
+			if !ok {
+				return nil, ast.Plural{}, false
+			}
+
+			for _, name := range seg.Text.GetArgumentNames() {
+				used[name] = struct{}{}
+			}
+
+			return seg.Text, ast.Plural{}, true
+		}
+
+		plural.Arg = basePlural.Arg
+		used[plural.Arg] = struct{}{}
+
+		for i := 0; i < len(basePlural.Exact); i++ {
+			id.Case = basePlural.Exact[i].Name()
+
+			seg, ok := segs[id]
+			if !ok {
+				return nil, ast.Plural{}, false
+			}
+
+			for _, name := range seg.Text.GetArgumentNames() {
+				used[name] = struct{}{}
+			}
+
+			plural.Exact = append(plural.Exact, ast.ExactForm{Number: basePlural.Exact[i].Number, Parts: seg.Text})
+		}
+
+		// Zero form that matches zero exactly may be left untranslated
+		if hasExactZero(basePlural, lang) {
+			id.Case = "zero"
+
+			if seg, ok := segs[id]; ok {
+				for _, name := range seg.Text.GetArgumentNames() {
+					used[name] = struct{}{}
+				}
+
+				plural.Zero = seg.Text
+			}
+		}
+
+		id.Case = ""
+
+		for _, form := range scope.PluralForms(lang) {
+			id.Form = form
+
+			seg, ok := segs[id]
+			if !ok {
+				return nil, ast.Plural{}, false
+			}
+
+			for _, name := range seg.Text.GetArgumentNames() {
+				used[name] = struct{}{}
+			}
+
+			setForm(&plural, form, seg.Text)
+		}
+
+		return nil, plural, true
+	}
+
+	selectValue := func(id SegmentID, baseSelect *ast.Select) (sel ast.Select, ok bool) {
+		sel.Arg = baseSelect.Arg
+		used[sel.Arg] = struct{}{}
+
+		for _, baseSeg := range valueSegments(id, ast.Location{}, &ast.Plural{}, baseSelect, nil, lang) {
+			seg, ok := segs[baseSeg.SegmentID]
+			if !ok {
+				return ast.Select{}, false
+			}
+
+			for _, name := range seg.Text.GetArgumentNames() {
+				used[name] = struct{}{}
+			}
+
+			if seg.Case == "other" {
+				sel.Other = seg.Text
+			} else {
+				sel.Cases = append(sel.Cases, ast.SelectCase{Value: seg.Case, Parts: seg.Text})
+			}
+		}
+
+		return sel, true
+	}
+
+	var variables []ast.Variable
+
+	for i := 0; i < len(baseMs.Variables); i++ {
+		baseVariable := &baseMs.Variables[i]
+		id := SegmentID{Message: baseMs.Name, Variable: baseVariable.Name}
+
+		if !baseVariable.Select.IsZero() {
+			sel, ok := selectValue(id, &baseVariable.Select)
+			if !ok {
+				return nil, false, nil
+			}
+
+			variables = append(variables, ast.Variable{Name: baseVariable.Name, Select: sel})
+			continue
+		}
+
+		str, plural, ok := value(id, &baseVariable.Plural)
+		if !ok {
+			return nil, false, nil
+		}
+
+		variables = append(variables, ast.Variable{Name: baseVariable.Name, Plural: plural, String: str})
+	}
+
+	str, plural, ok := value(SegmentID{Message: baseMs.Name}, &baseMs.Plural)
+	if !ok {
+		return nil, false, nil
+	}
+
+	var icu string
+	if plural.IsZero() {
+		icu, err = parse.FormatICU(str, variables, "", usesZeroForm(lang))
+	} else {
+		icu, err = parse.FormatICUPlural(&plural, variables, usesZeroForm(lang))
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	node = newString(icu)
+
+	if args := declareArguments(baseMs, used, newString); args != nil {
+		table := parse.NewTableNode()
+		table.Set("arguments", args)
+		table.Set("string", node)
+		node = table
+	}
+
+	return node, true, nil
+}
+
+func setForm(plural *ast.Plural, form string, text ast.FormatParts) {
+	switch form {
+	case "zero":
+		plural.Zero = text
+	case "one":
+		plural.One = text
+	case "two":
+		plural.Two = text
+	case "few":
+		plural.Few = text
+	case "many":
+		plural.Many = text
+	default:
+		plural.Other = text
+	}
+}
+
+// Returns the message that specifies its own syntax.
+func withSyntax(node *parse.Node, syntax parse.Syntax) *parse.Node {
+	if node.Kind != parse.TableNode {
+		table := parse.NewTableNode()
+		table.Set("string", node)
+		node = table
+	}
+
+	value := parse.NewStringNode(string(syntax))
+	node.Table = append([]parse.NodeEntry{{Key: "syntax", KeyOffset: -1, Value: value}}, node.Table...)
+
+	return node
+}
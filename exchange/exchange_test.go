@@ -0,0 +1,150 @@
+package exchange
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/infastin/go-l10n/common"
+	"github.com/infastin/go-l10n/parse"
+	"github.com/infastin/go-l10n/process"
+	"github.com/infastin/go-l10n/scope"
+	"golang.org/x/text/language"
+)
+
+// Messages of the base localization used by the tests.
+const testBaseYAML = `
+Route: "${from} → ${to}"
+Quote: "Say \"{hi}\" <b>&&</b>\n\tand \\ ${name}"
+Files:
+  plural:
+    arg: n
+    "=0": "No files"
+    one: "${n} file in ${dir}"
+    other: "${n} files in ${dir}"
+Invitation:
+  variables:
+    pronoun:
+      select:
+        arg: gender
+        male: "his"
+        other: "their"
+  string: "${host} invited you to &{pronoun} party."
+`
+
+// The same messages translated into Russian.
+const testRussianYAML = `
+Route: "${from} ← ${to}"
+Quote: "Скажите \"{привет}\" <b>&&</b>\n\tи \\ ${name}"
+Files:
+  plural:
+    arg: n
+    "=0": "Нет файлов"
+    one: "${n} файл в ${dir}"
+    few: "${n} файла в ${dir}"
+    many: "${n} файлов в ${dir}"
+    other: "${n} файла в ${dir}"
+Invitation:
+  variables:
+    pronoun:
+      select:
+        arg: gender
+        male: "его"
+        other: "свою"
+  string: "${host} пригласил вас на &{pronoun} вечеринку."
+`
+
+// Returns localization of the messages written in YAML.
+func testLocalization(t *testing.T, lang, data string) scope.Localization {
+	t.Helper()
+
+	tag := language.MustParse(lang)
+
+	msgs, err := parse.UnmarshalMessages("loc."+lang+".yaml", []byte(data), parse.DecodeYAML)
+	if err != nil {
+		t.Fatalf("could not unmarshal %s messages: %v", lang, err)
+	}
+
+	mss, err := process.ProcessMessages(msgs, tag)
+	if err != nil {
+		t.Fatalf("could not process %s messages: %v", lang, err)
+	}
+
+	return scope.Localization{Name: "loc", Lang: tag, Scopes: mss}
+}
+
+// Returns texts of the segments by their ids.
+func segmentTexts(segs []Segment) map[SegmentID]string {
+	texts := make(map[SegmentID]string)
+	for _, seg := range segs {
+		texts[seg.SegmentID] = seg.Text.String()
+	}
+	return texts
+}
+
+// Returns texts of all segments of the localization by their ids.
+func localizationTexts(loc *scope.Localization) map[SegmentID]string {
+	var segs []Segment
+	for i := 0; i < len(loc.Scopes); i++ {
+		segs = append(segs, Segments(&loc.Scopes[i], loc.Lang)...)
+	}
+	return segmentTexts(segs)
+}
+
+func checkTexts(t *testing.T, got, want map[SegmentID]string) {
+	t.Helper()
+
+	for id, text := range want {
+		if got[id] != text {
+			t.Errorf("segment %+v:\ngot:  %q\nwant: %q", id, got[id], text)
+		}
+	}
+
+	for id := range got {
+		if _, ok := want[id]; !ok {
+			t.Errorf("unexpected segment %+v: %q", id, got[id])
+		}
+	}
+}
+
+// Returns the mismatch of placeholders the error is caused by.
+func placeholdersMismatch(t *testing.T, err error) *common.PlaceholdersMismatchError {
+	t.Helper()
+
+	var mismatch *common.PlaceholdersMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("got error %v, want placeholders mismatch", err)
+	}
+
+	return mismatch
+}
+
+func TestSegments(t *testing.T) {
+	base := testLocalization(t, "en", testBaseYAML)
+
+	got := localizationTexts(&base)
+	want := map[SegmentID]string{
+		{Message: "Route"}:                                          "${from} → ${to}",
+		{Message: "Quote"}:                                          "Say \"{hi}\" <b>&&</b>\n\tand \\ ${name}",
+		{Message: "Files", Case: "=0"}:                              "No files",
+		{Message: "Files", Form: "one"}:                             "${n} file in ${dir}",
+		{Message: "Files", Form: "other"}:                           "${n} files in ${dir}",
+		{Message: "Invitation"}:                                     "${host} invited you to &{pronoun} party.",
+		{Message: "Invitation", Variable: "pronoun", Case: "male"}:  "his",
+		{Message: "Invitation", Variable: "pronoun", Case: "other"}: "their",
+	}
+
+	checkTexts(t, got, want)
+
+	// Forms of the plural are the ones of the language
+	ms := findMessage(&base, "Files")
+
+	var forms []string
+	for _, seg := range Segments(ms, language.Russian) {
+		forms = append(forms, seg.Case+seg.Form)
+	}
+
+	if want := []string{"=0", "one", "few", "many"}; !slices.Equal(forms, want) {
+		t.Errorf("got russian forms %v, want %v", forms, want)
+	}
+}
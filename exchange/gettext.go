@@ -0,0 +1,257 @@
+package exchange
+
+import (
+	"strconv"
+
+	"github.com/infastin/go-l10n/scope"
+	"golang.org/x/text/language"
+)
+
+// Plural rules in the form of gettext's Plural-Forms header.
+// Indexes returned by the formula must match plural forms in CLDR order.
+type gettextPluralRule struct {
+	Formula string
+	Index   func(n int) int
+}
+
+// Plural rules of gettext that are known to match CLDR rules of some languages.
+var gettextPluralRules = []gettextPluralRule{
+	{"0", func(n int) int {
+		return 0
+	}},
+	{"(n != 1)", func(n int) int {
+		return b2i(n != 1)
+	}},
+	{"(n > 1)", func(n int) int {
+		return b2i(n > 1)
+	}},
+	{"(n%10 != 1 || n%100 == 11)", func(n int) int {
+		return b2i(n%10 != 1 || n%100 == 11)
+	}},
+	{"(n%10 != 1)", func(n int) int {
+		return b2i(n%10 != 1)
+	}},
+	{"(n%10 == 4 || n%10 == 6 || n%10 == 9)", func(n int) int {
+		return b2i(n%10 == 4 || n%10 == 6 || n%10 == 9)
+	}},
+	{"(n == 0 || n == 1 ? 0 : n != 0 && n%1000000 == 0 ? 1 : 2)", func(n int) int {
+		switch {
+		case n == 0 || n == 1:
+			return 0
+		case n != 0 && n%1000000 == 0:
+			return 1
+		default:
+			return 2
+		}
+	}},
+	{"(n == 1 ? 0 : n != 0 && n%1000000 == 0 ? 1 : 2)", func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case n != 0 && n%1000000 == 0:
+			return 1
+		default:
+			return 2
+		}
+	}},
+	{"(n%10 == 1 && n%100 != 11 ? 0 : n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14) ? 1 : 2)", func(n int) int {
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		default:
+			return 2
+		}
+	}},
+	{"(n == 1 ? 0 : n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14) ? 1 : 2)", func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		default:
+			return 2
+		}
+	}},
+	{"(n == 1 ? 0 : n >= 2 && n <= 4 ? 1 : 2)", func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case n >= 2 && n <= 4:
+			return 1
+		default:
+			return 2
+		}
+	}},
+	{"(n%10 == 1 && (n%100 < 11 || n%100 > 19) ? 0 : n%10 >= 2 && (n%100 < 11 || n%100 > 19) ? 1 : 2)", func(n int) int {
+		switch {
+		case n%10 == 1 && (n%100 < 11 || n%100 > 19):
+			return 0
+		case n%10 >= 2 && (n%100 < 11 || n%100 > 19):
+			return 1
+		default:
+			return 2
+		}
+	}},
+	{"(n%10 == 0 || n%100 >= 11 && n%100 <= 19 ? 0 : n%10 == 1 && n%100 != 11 ? 1 : 2)", func(n int) int {
+		switch {
+		case n%10 == 0 || n%100 >= 11 && n%100 <= 19:
+			return 0
+		case n%10 == 1 && n%100 != 11:
+			return 1
+		default:
+			return 2
+		}
+	}},
+	{"(n == 1 ? 0 : n == 0 || n%100 >= 2 && n%100 <= 19 ? 1 : 2)", func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case n == 0 || n%100 >= 2 && n%100 <= 19:
+			return 1
+		default:
+			return 2
+		}
+	}},
+	{"(n == 1 ? 0 : n == 0 || n%100 >= 1 && n%100 <= 19 ? 1 : 2)", func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case n == 0 || n%100 >= 1 && n%100 <= 19:
+			return 1
+		default:
+			return 2
+		}
+	}},
+	{"(n%100 == 1 ? 0 : n%100 == 2 ? 1 : n%100 == 3 || n%100 == 4 ? 2 : 3)", func(n int) int {
+		switch {
+		case n%100 == 1:
+			return 0
+		case n%100 == 2:
+			return 1
+		case n%100 == 3 || n%100 == 4:
+			return 2
+		default:
+			return 3
+		}
+	}},
+	{"(n == 1 ? 0 : n == 2 ? 1 : 2)", func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case n == 2:
+			return 1
+		default:
+			return 2
+		}
+	}},
+	{"(n == 1 ? 0 : n == 2 ? 1 : n > 10 && n%10 == 0 ? 2 : 3)", func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case n == 2:
+			return 1
+		case n > 10 && n%10 == 0:
+			return 2
+		default:
+			return 3
+		}
+	}},
+	{"(n == 1 ? 0 : n == 2 ? 1 : n >= 3 && n <= 6 ? 2 : n >= 7 && n <= 10 ? 3 : 4)", func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case n == 2:
+			return 1
+		case n >= 3 && n <= 6:
+			return 2
+		case n >= 7 && n <= 10:
+			return 3
+		default:
+			return 4
+		}
+	}},
+	{"(n == 1 || n == 11 ? 0 : n == 2 || n == 12 ? 1 : n > 2 && n < 20 ? 2 : 3)", func(n int) int {
+		switch {
+		case n == 1 || n == 11:
+			return 0
+		case n == 2 || n == 12:
+			return 1
+		case n > 2 && n < 20:
+			return 2
+		default:
+			return 3
+		}
+	}},
+	{"(n == 1 ? 0 : n == 0 || n%100 > 1 && n%100 < 11 ? 1 : n%100 > 10 && n%100 < 20 ? 2 : 3)", func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case n == 0 || n%100 > 1 && n%100 < 11:
+			return 1
+		case n%100 > 10 && n%100 < 20:
+			return 2
+		default:
+			return 3
+		}
+	}},
+	{"(n == 0 ? 0 : n == 1 ? 1 : n == 2 ? 2 : n%100 >= 3 && n%100 <= 10 ? 3 : n%100 >= 11 ? 4 : 5)", func(n int) int {
+		switch {
+		case n == 0:
+			return 0
+		case n == 1:
+			return 1
+		case n == 2:
+			return 2
+		case n%100 >= 3 && n%100 <= 10:
+			return 3
+		case n%100 >= 11:
+			return 4
+		default:
+			return 5
+		}
+	}},
+	{"(n == 0 ? 0 : n == 1 ? 1 : n == 2 ? 2 : n == 3 ? 3 : n == 6 ? 4 : 5)", func(n int) int {
+		switch n {
+		case 0, 1, 2, 3:
+			return n
+		case 6:
+			return 4
+		default:
+			return 5
+		}
+	}},
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Returns value of gettext's Plural-Forms header for the given language,
+// whose formula chooses plural forms in CLDR order.
+// Returns false if none of the known formulas matches the language's rules.
+func gettextPluralForms(lang language.Tag) (header string, ok bool) {
+	forms := scope.PluralForms(lang)
+
+	for _, rule := range gettextPluralRules {
+		if ruleMatches(rule, lang, forms) {
+			return "nplurals=" + strconv.Itoa(len(forms)) + "; plural=" + rule.Formula + ";", true
+		}
+	}
+
+	return "", false
+}
+
+func ruleMatches(rule gettextPluralRule, lang language.Tag, forms []string) bool {
+	for _, n := range scope.PluralFormSamples() {
+		idx := rule.Index(n)
+		if idx >= len(forms) || forms[idx] != scope.PluralFormOf(lang, n) {
+			return false
+		}
+	}
+	return true
+}
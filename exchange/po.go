@@ -0,0 +1,715 @@
+package exchange
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+	"github.com/infastin/go-l10n/scope"
+	"golang.org/x/text/language"
+)
+
+// Entry of a gettext PO file.
+type poEntry struct {
+	Location ast.Location
+	// Comments that are written by the generator, without the leading "#"
+	Comments    []string
+	Fuzzy       bool
+	Context     string
+	HasContext  bool
+	ID          string
+	IDPlural    string
+	HasPlural   bool
+	Translation []string
+	// Line of the translation, which is used in errors
+	TranslationLine int
+}
+
+// Returns context of the entry of the message or its variable.
+// Variables are referred to the same way they are written in messages,
+// and exact forms of plurals and forms of selects follow them in square brackets:
+// "Message&{variable}[=1]" or "Message&{variable}[male]".
+func poContext(id SegmentID) (ctxt string) {
+	ctxt = id.Message
+	if id.Variable != "" {
+		ctxt += "&{" + id.Variable + "}"
+	}
+	if id.Case != "" {
+		ctxt += "[" + id.Case + "]"
+	}
+	return ctxt
+}
+
+func parsePOContext(ctxt string) (message, variable, cas string) {
+	if idx := strings.LastIndexByte(ctxt, '['); idx != -1 && strings.HasSuffix(ctxt, "]") {
+		ctxt, cas = ctxt[:idx], ctxt[idx+1:len(ctxt)-1]
+	}
+
+	idx := strings.Index(ctxt, "&{")
+	if idx == -1 || !strings.HasSuffix(ctxt, "}") {
+		return ctxt, "", cas
+	}
+
+	return ctxt[:idx], ctxt[idx+2 : len(ctxt)-1], cas
+}
+
+// Returns the text with arguments and variables written as {name}.
+// Braces of the text itself are doubled.
+func poText(text ast.FormatParts) string {
+	var b strings.Builder
+
+	for _, part := range text {
+		switch part := part.(type) {
+		case ast.Text:
+			str := strings.ReplaceAll(string(part), "{", "{{")
+			str = strings.ReplaceAll(str, "}", "}}")
+			b.WriteString(str)
+		case ast.ArgInfo:
+			b.WriteString("{" + part.Name + "}")
+		case ast.VarInfo:
+			b.WriteString("{" + part.Name + "}")
+		}
+	}
+
+	return b.String()
+}
+
+// Parses the text with placeholders written as {name}.
+func parsePOText(str string, placeholders map[string]ast.FormatPart) (text ast.FormatParts, err error) {
+	var b strings.Builder
+
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+
+		if c == '}' && i+1 < len(str) && str[i+1] == '}' {
+			b.WriteByte('}')
+			i++
+			continue
+		}
+
+		if c != '{' {
+			b.WriteByte(c)
+			continue
+		}
+
+		if i+1 < len(str) && str[i+1] == '{' {
+			b.WriteByte('{')
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(str[i:], '}')
+		if end == -1 {
+			return nil, common.NewError(common.ErrNoClosingBracket, common.ErrorPosition(i))
+		}
+
+		name := str[i+1 : i+end]
+
+		part, ok := placeholders[name]
+		if !ok {
+			return nil, common.NewError(common.ErrUnknownPlaceholder,
+				common.ErrorValueStr(name),
+				common.ErrorPosition(i),
+			)
+		}
+
+		if b.Len() != 0 {
+			text = append(text, ast.Text(b.String()))
+			b.Reset()
+		}
+
+		text = append(text, part)
+		i += end
+	}
+
+	if b.Len() != 0 {
+		text = append(text, ast.Text(b.String()))
+	}
+
+	return text, nil
+}
+
+// Returns PO template that contains messages of the base localization.
+// References to the source files are relative to the given directory,
+// which is the one the template is written to.
+func ExportPOT(base *scope.Localization, refDir string) (data []byte) {
+	var b strings.Builder
+
+	writePOHeader(&b, true, map[string]string{
+		"Plural-Forms":      "nplurals=INTEGER; plural=EXPRESSION;",
+		"X-Source-Language": base.Lang.String(),
+	})
+
+	for i := 0; i < len(base.Scopes); i++ {
+		for _, entry := range poEntries(&base.Scopes[i], base.Lang, nil, language.Und, refDir) {
+			b.WriteByte('\n')
+			writePOEntry(&b, &entry)
+		}
+	}
+
+	return []byte(b.String())
+}
+
+// Returns PO file that contains messages of the base localization
+// along with their translations.
+// Messages that are missing in the localization are left untranslated.
+// References to the source files are relative to the given directory.
+func ExportPO(base, loc *scope.Localization, refDir string) (data []byte, err error) {
+	pluralForms, ok := gettextPluralForms(loc.Lang)
+	if !ok {
+		return nil, common.NewError(common.ErrUnsupportedPluralRules, common.ErrorValueStr(loc.Lang.String()))
+	}
+
+	var b strings.Builder
+
+	writePOHeader(&b, false, map[string]string{
+		"Language":          loc.Lang.String(),
+		"Plural-Forms":      pluralForms,
+		"X-Source-Language": base.Lang.String(),
+	})
+
+	for i := 0; i < len(base.Scopes); i++ {
+		baseMs := &base.Scopes[i]
+		ms := findMessage(loc, baseMs.Name)
+
+		for _, entry := range poEntries(baseMs, base.Lang, ms, loc.Lang, refDir) {
+			b.WriteByte('\n')
+			writePOEntry(&b, &entry)
+		}
+	}
+
+	return []byte(b.String()), nil
+}
+
+// Returns entries of the base message, which can be translated
+// with the given message of another language.
+// Template entries are returned if there is no language.
+func poEntries(
+	baseMs *scope.MessageScope,
+	baseLang language.Tag,
+	ms *scope.MessageScope,
+	lang language.Tag,
+	refDir string,
+) (entries []poEntry) {
+	translations := make(map[SegmentID]ast.FormatParts)
+	if ms != nil {
+		for _, seg := range Segments(ms, lang) {
+			translations[seg.SegmentID] = seg.Text
+		}
+	}
+
+	baseSegs := Segments(baseMs, baseLang)
+
+	for len(baseSegs) != 0 {
+		// Segments of the same plural go in a row,
+		// and exact forms and forms of selects are separate entries
+		n := 1
+		for n < len(baseSegs) && baseSegs[0].Form != "" &&
+			baseSegs[n].Form != "" && baseSegs[n].Variable == baseSegs[0].Variable {
+			n++
+		}
+
+		group := baseSegs[:n]
+		baseSegs = baseSegs[n:]
+
+		id := group[0].SegmentID
+		id.Form = ""
+
+		entry := poEntry{
+			Context:    poContext(id),
+			HasContext: true,
+		}
+
+		if id.Variable != "" {
+			entry.Comments = append(entry.Comments, ". Variable "+strconv.Quote(id.Variable)+" of "+strconv.Quote(id.Message))
+		}
+		if loc := group[0].Location; loc.IsValid() {
+			entry.Comments = append(entry.Comments, ": "+sourceReference(loc, refDir))
+		}
+
+		if group[0].Form == "" {
+			entry.ID = poText(group[0].Text)
+
+			text, ok := translations[id]
+			if ok {
+				entry.Translation = []string{poText(text)}
+			} else {
+				entry.Translation = []string{""}
+			}
+
+			entries = append(entries, entry)
+			continue
+		}
+
+		// Source text of the singular is the "one" form if there is such
+		entry.HasPlural = true
+		entry.ID = poText(group[0].Text)
+		entry.IDPlural = poText(group[len(group)-1].Text)
+
+		for _, seg := range group {
+			if seg.Form == "one" {
+				entry.ID = poText(seg.Text)
+			}
+		}
+
+		if lang == language.Und {
+			entry.Translation = []string{"", ""}
+			entries = append(entries, entry)
+			continue
+		}
+
+		for _, form := range scope.PluralForms(lang) {
+			id.Form = form
+
+			text, ok := translations[id]
+			if !ok {
+				// Translation of the plural is either complete or missing
+				entry.Translation = make([]string, len(scope.PluralForms(lang)))
+				break
+			}
+
+			entry.Translation = append(entry.Translation, poText(text))
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// Keys of the header in the order they are written.
+var poHeaderKeys = []string{
+	"Language",
+	"MIME-Version",
+	"Content-Type",
+	"Content-Transfer-Encoding",
+	"Plural-Forms",
+	"X-Source-Language",
+	"X-Generator",
+}
+
+func writePOHeader(b *strings.Builder, template bool, fields map[string]string) {
+	fields["MIME-Version"] = "1.0"
+	fields["Content-Type"] = "text/plain; charset=UTF-8"
+	fields["Content-Transfer-Encoding"] = "8bit"
+	fields["X-Generator"] = "go-l10n"
+
+	var header strings.Builder
+
+	for _, key := range poHeaderKeys {
+		value, ok := fields[key]
+		if ok || key == "Language" {
+			header.WriteString(key + ": " + value + "\n")
+		}
+	}
+
+	entry := poEntry{
+		Fuzzy:       template,
+		Translation: []string{header.String()},
+	}
+
+	writePOEntry(b, &entry)
+}
+
+func writePOEntry(b *strings.Builder, entry *poEntry) {
+	for _, comment := range entry.Comments {
+		b.WriteString("#" + comment + "\n")
+	}
+
+	if entry.Fuzzy {
+		b.WriteString("#, fuzzy\n")
+	}
+
+	if entry.HasContext {
+		writePOString(b, "msgctxt", entry.Context)
+	}
+
+	writePOString(b, "msgid", entry.ID)
+
+	if !entry.HasPlural {
+		writePOString(b, "msgstr", entry.Translation[0])
+		return
+	}
+
+	writePOString(b, "msgid_plural", entry.IDPlural)
+
+	for i, translation := range entry.Translation {
+		writePOString(b, "msgstr["+strconv.Itoa(i)+"]", translation)
+	}
+}
+
+// Writes the keyword with the string,
+// which is split into several lines after each newline.
+func writePOString(b *strings.Builder, keyword, str string) {
+	b.WriteString(keyword + " ")
+
+	lines := strings.SplitAfter(str, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	if len(lines) > 1 {
+		b.WriteString(`""` + "\n")
+	}
+
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+
+	for _, line := range lines {
+		b.WriteByte('"')
+
+		for _, c := range line {
+			switch c {
+			case '"':
+				b.WriteString(`\"`)
+			case '\\':
+				b.WriteString(`\\`)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\t':
+				b.WriteString(`\t`)
+			case '\r':
+				b.WriteString(`\r`)
+			default:
+				b.WriteRune(c)
+			}
+		}
+
+		b.WriteString("\"\n")
+	}
+}
+
+// Reads translations from the PO file.
+// Returns language of the file along with the translated segments.
+// Fuzzy and untranslated entries are skipped.
+// The first localization is the base one, whose messages are translated.
+func ImportPO(filename string, data []byte, locs []scope.Localization) (lang language.Tag, segs []Segment, err error) {
+	entries, err := readPO(filename, data)
+	if err != nil {
+		return language.Und, nil, err
+	}
+
+	var header map[string]string
+
+	for i := 0; i < len(entries); i++ {
+		if entry := &entries[i]; !entry.HasContext && entry.ID == "" {
+			header = parsePOHeader(entry.Translation[0])
+			break
+		}
+	}
+
+	langStr := header["Language"]
+	if langStr == "" {
+		return language.Und, nil, common.NewError(common.ErrFieldNotSpecified,
+			common.ErrorValueStr("Language"),
+			common.ErrorLocation{File: filename},
+		)
+	}
+
+	lang, err = language.Parse(langStr)
+	if err != nil {
+		return language.Und, nil, common.NewError(common.ErrInvalidLanguage,
+			common.ErrorValueStr(langStr),
+			common.ErrorLocation{File: filename},
+			common.ErrorWrapped(err),
+		)
+	}
+
+	base := &locs[0]
+
+	var loc *scope.Localization
+	if idx := scope.LocalizationIndex(locs, lang); idx != -1 {
+		loc = &locs[idx]
+	}
+
+	forms := scope.PluralForms(lang)
+
+	var errs common.ErrorList
+
+	for i := 0; i < len(entries); i++ {
+		entry := &entries[i]
+
+		if !entry.HasContext && entry.ID == "" {
+			continue
+		}
+
+		if entry.Fuzzy || isUntranslated(entry) {
+			continue
+		}
+
+		if !entry.HasContext {
+			errs.Add(common.NewError(common.ErrFieldNotSpecified,
+				common.ErrorValueStr("msgctxt"),
+				common.ErrorLocation(entry.Location),
+			))
+			continue
+		}
+
+		name, variable, cas := parsePOContext(entry.Context)
+
+		baseMs := findMessage(base, name)
+		if baseMs == nil {
+			errs.Add(common.NewError(common.ErrCouldNotImport,
+				common.ErrorValueStr(entry.Context),
+				common.ErrorLocation(entry.Location),
+				common.ErrorWrapped(common.NewMessageNotInBaseError(name, base.Lang.String())),
+			))
+			continue
+		}
+
+		// Form names of the segments of the base message, in the language of the file
+		var (
+			expected    []string
+			hasVariable bool
+		)
+		for _, seg := range Segments(baseMs, lang) {
+			if seg.Variable != variable {
+				continue
+			}
+
+			hasVariable = true
+			if seg.Case == cas {
+				expected = append(expected, seg.Form)
+			}
+		}
+
+		if !hasVariable {
+			errs.Add(common.NewError(common.ErrCouldNotImport,
+				common.ErrorValueStr(entry.Context),
+				common.ErrorLocation(entry.Location),
+				common.ErrorWrapped(common.NewError(common.ErrVariableNotSpecified, common.ErrorValueStr(variable))),
+			))
+			continue
+		}
+
+		if len(expected) == 0 || entry.HasPlural != (expected[0] != "") || (entry.HasPlural && len(entry.Translation) != len(forms)) {
+			errs.Add(common.NewError(common.ErrCouldNotImport,
+				common.ErrorValueStr(entry.Context),
+				common.ErrorLocation(entry.Location),
+				common.ErrorWrapped(common.ErrTranslationDoesNotMatch),
+			))
+			continue
+		}
+
+		ms := findMessage(loc, name)
+		parts := placeholders(baseMs, ms)
+
+		for j, translation := range entry.Translation {
+			// Partially translated plural
+			if translation == "" {
+				continue
+			}
+
+			text, err := parsePOText(translation, parts)
+			if err != nil {
+				errs.Add(common.NewError(common.ErrCouldNotImport,
+					common.ErrorValueStr(entry.Context),
+					common.ErrorLocation{File: filename, Line: entry.TranslationLine},
+					common.ErrorWrapped(err),
+				))
+				break
+			}
+
+			id := SegmentID{Message: name, Variable: variable, Case: cas}
+			segName := entry.Context
+			if entry.HasPlural {
+				id.Form = forms[j]
+				segName += " msgstr[" + strconv.Itoa(j) + "]"
+			}
+
+			err = checkPlaceholders(baseMs, ms, id, lang, segName, text)
+			if err != nil {
+				errs.Add(common.NewError(common.ErrCouldNotImport,
+					common.ErrorValueStr(entry.Context),
+					common.ErrorLocation{File: filename, Line: entry.TranslationLine},
+					common.ErrorWrapped(err),
+				))
+				break
+			}
+
+			segs = append(segs, Segment{
+				SegmentID: id,
+				Location:  entry.Location,
+				Text:      text,
+			})
+		}
+	}
+
+	return lang, segs, errs.Err()
+}
+
+func isUntranslated(entry *poEntry) bool {
+	for _, translation := range entry.Translation {
+		if translation != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func parsePOHeader(str string) (header map[string]string) {
+	header = make(map[string]string)
+
+	for _, line := range strings.Split(str, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok {
+			header[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	return header
+}
+
+// Reads entries of the PO file.
+// Obsolete entries and comments other than flags are skipped.
+func readPO(filename string, data []byte) (entries []poEntry, err error) {
+	var (
+		entry poEntry
+		// String the continuation lines are appended to
+		current *string
+		// Whether there is an entry being read
+		started bool
+		// Whether translation of the entry has been read
+		translated bool
+	)
+
+	flush := func() {
+		if started {
+			entries = append(entries, entry)
+		}
+		entry, current, started, translated = poEntry{}, nil, false, false
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		lineNum := i + 1
+		line = strings.TrimSpace(line)
+
+		invalidSyntax := func(err error) error {
+			return common.NewError(common.ErrInvalidSyntax,
+				common.ErrorLocation{File: filename, Line: lineNum},
+				common.ErrorWrapped(err),
+			)
+		}
+
+		switch {
+		case line == "":
+			flush()
+			continue
+		case strings.HasPrefix(line, "#,"):
+			if translated {
+				flush()
+			}
+			for _, flag := range strings.Split(line[2:], ",") {
+				if strings.TrimSpace(flag) == "fuzzy" {
+					entry.Fuzzy = true
+				}
+			}
+			continue
+		case strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, `"`):
+			if current == nil {
+				return nil, invalidSyntax(common.NewError(common.ErrUnexpectedText))
+			}
+
+			str, err := unquotePOString(line)
+			if err != nil {
+				return nil, invalidSyntax(err)
+			}
+
+			*current += str
+			continue
+		}
+
+		keyword, rest, _ := strings.Cut(line, " ")
+
+		str, err := unquotePOString(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, invalidSyntax(err)
+		}
+
+		// Entries don't have to be separated with blank lines
+		if translated && (keyword == "msgctxt" || keyword == "msgid") {
+			flush()
+		}
+
+		if !started {
+			entry.Location = ast.Location{File: filename, Line: lineNum}
+			started = true
+		}
+
+		switch {
+		case keyword == "msgctxt":
+			entry.Context, entry.HasContext = str, true
+			current = &entry.Context
+		case keyword == "msgid":
+			entry.ID = str
+			current = &entry.ID
+		case keyword == "msgid_plural":
+			entry.IDPlural, entry.HasPlural = str, true
+			current = &entry.IDPlural
+		case keyword == "msgstr" || strings.HasPrefix(keyword, "msgstr["):
+			if !translated {
+				entry.TranslationLine = lineNum
+			}
+			entry.Translation = append(entry.Translation, str)
+			current = &entry.Translation[len(entry.Translation)-1]
+			translated = true
+		default:
+			return nil, invalidSyntax(common.NewError(common.ErrUnknownField, common.ErrorValueStr(keyword)))
+		}
+	}
+
+	flush()
+
+	return entries, nil
+}
+
+func unquotePOString(str string) (unquoted string, err error) {
+	if len(str) < 2 || str[0] != '"' || str[len(str)-1] != '"' {
+		return "", common.NewError(common.ErrUnexpectedText, common.ErrorValueStr(str))
+	}
+
+	str = str[1 : len(str)-1]
+
+	var b strings.Builder
+
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i == len(str) {
+			return "", common.NewError(common.ErrUnexpectedEndOfFormat)
+		}
+
+		switch str[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '"', '\\', '\'', '?':
+			b.WriteByte(str[i])
+		default:
+			return "", common.NewError(common.ErrInvalidChar, common.ErrorValueChar(rune(str[i])))
+		}
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,376 @@
+package exchange
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/parse"
+	"github.com/infastin/go-l10n/scope"
+)
+
+func TestPOString(t *testing.T) {
+	strs := []string{
+		"",
+		"plain",
+		`quote " and \ backslash`,
+		"tab\t and carriage return\r",
+		"two\nlines\n",
+		"no newline\nat the end",
+		"юникод",
+	}
+
+	for _, str := range strs {
+		var b strings.Builder
+		writePOEntry(&b, &poEntry{
+			Context:     str,
+			HasContext:  true,
+			ID:          str,
+			Translation: []string{str},
+		})
+
+		entries, err := readPO("test.po", []byte(b.String()))
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v\n%s", str, err, b.String())
+		}
+
+		if len(entries) != 1 {
+			t.Fatalf("%q: got %d entries, want 1", str, len(entries))
+		}
+
+		entry := &entries[0]
+		if entry.Context != str || entry.ID != str || entry.Translation[0] != str {
+			t.Errorf("%q: got context %q, id %q, translation %q", str, entry.Context, entry.ID, entry.Translation[0])
+		}
+	}
+}
+
+func TestPOText(t *testing.T) {
+	placeholders := map[string]ast.FormatPart{
+		"name":    ast.ArgInfo{Name: "name"},
+		"pronoun": ast.VarInfo{Name: "pronoun"},
+	}
+
+	tests := []struct {
+		name string
+		text ast.FormatParts
+		want string
+	}{
+		{
+			name: "text",
+			text: ast.FormatParts{ast.Text("Hello")},
+			want: "Hello",
+		},
+		{
+			name: "braces",
+			text: ast.FormatParts{ast.Text("Use {braces} and }}")},
+			want: "Use {{braces}} and }}}}",
+		},
+		{
+			name: "placeholders",
+			text: ast.FormatParts{ast.ArgInfo{Name: "name"}, ast.Text(" has {"), ast.VarInfo{Name: "pronoun"}, ast.Text("}")},
+			want: "{name} has {{{pronoun}}}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := poText(tt.text)
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+
+			text, err := parsePOText(got, placeholders)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if text.String() != tt.text.String() {
+				t.Errorf("parsed %q, want %q", text.String(), tt.text.String())
+			}
+		})
+	}
+
+	if _, err := parsePOText("{unknown}", placeholders); err == nil {
+		t.Error("unknown placeholder is parsed")
+	}
+	if _, err := parsePOText("{name", placeholders); err == nil {
+		t.Error("placeholder without closing bracket is parsed")
+	}
+}
+
+func TestPOContext(t *testing.T) {
+	tests := []struct {
+		id   SegmentID
+		want string
+	}{
+		{SegmentID{Message: "Route"}, "Route"},
+		{SegmentID{Message: "Auth.Login"}, "Auth.Login"},
+		{SegmentID{Message: "Files", Case: "=0"}, "Files[=0]"},
+		{SegmentID{Message: "Invitation", Variable: "pronoun"}, "Invitation&{pronoun}"},
+		{SegmentID{Message: "Invitation", Variable: "pronoun", Case: "male"}, "Invitation&{pronoun}[male]"},
+	}
+
+	for _, tt := range tests {
+		got := poContext(tt.id)
+		if got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.id, got, tt.want)
+		}
+
+		message, variable, cas := parsePOContext(got)
+		if message != tt.id.Message || variable != tt.id.Variable || cas != tt.id.Case {
+			t.Errorf("%q: got %q, %q, %q", got, message, variable, cas)
+		}
+	}
+}
+
+func TestExportImportPO(t *testing.T) {
+	base := testLocalization(t, "en", testBaseYAML)
+	ru := testLocalization(t, "ru", testRussianYAML)
+	locs := []scope.Localization{base, ru}
+
+	data, err := ExportPO(&base, &ru, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`msgctxt "Files[=0]"` + "\n" + `msgid "No files"` + "\n" + `msgstr "Нет файлов"`,
+		`msgctxt "Files"` + "\n" + `msgid "{n} file in {dir}"` + "\n" + `msgid_plural "{n} files in {dir}"` + "\n" +
+			`msgstr[0] "{n} файл в {dir}"` + "\n" + `msgstr[1] "{n} файла в {dir}"` + "\n" + `msgstr[2] "{n} файлов в {dir}"`,
+		`msgctxt "Invitation&{pronoun}[male]"` + "\n" + `msgid "his"` + "\n" + `msgstr "его"`,
+		`msgid ""` + "\n" + `"Say \"{{hi}}\" <b>&</b>\n"` + "\n" + `"\tand \\ {name}"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("exported file doesn't contain\n%s\nfile:\n%s", want, data)
+		}
+	}
+
+	lang, segs, err := ImportPO("loc.ru.po", data, locs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if lang != ru.Lang {
+		t.Errorf("got language %v, want %v", lang, ru.Lang)
+	}
+
+	checkTexts(t, segmentTexts(segs), localizationTexts(&ru))
+}
+
+func TestExportImportPOZero(t *testing.T) {
+	// Neither English nor Russian uses zero form, so it matches zero exactly
+	base := testLocalization(t, "en", `
+Files:
+  plural:
+    arg: n
+    zero: "No files"
+    one: "${n} file"
+    other: "${n} files"
+`)
+	ru := testLocalization(t, "ru", `
+Files:
+  plural:
+    arg: n
+    zero: "Нет файлов"
+    one: "${n} файл"
+    few: "${n} файла"
+    many: "${n} файлов"
+    other: "${n} файла"
+`)
+	locs := []scope.Localization{base, ru}
+
+	template := string(ExportPOT(&base, ""))
+	if want := `msgctxt "Files[zero]"` + "\n" + `msgid "No files"` + "\n" + `msgstr ""`; !strings.Contains(template, want) {
+		t.Errorf("exported template doesn't contain\n%s\ntemplate:\n%s", want, template)
+	}
+
+	data, err := ExportPO(&base, &ru, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`msgctxt "Files[zero]"` + "\n" + `msgid "No files"` + "\n" + `msgstr "Нет файлов"`,
+		`msgctxt "Files"` + "\n" + `msgid "{n} file"` + "\n" + `msgid_plural "{n} files"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("exported file doesn't contain\n%s\nfile:\n%s", want, data)
+		}
+	}
+
+	_, segs, err := ImportPO("loc.ru.po", data, locs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkTexts(t, segmentTexts(segs), localizationTexts(&ru))
+
+	// Zero form is written back to the file where it has been taken from
+	translated := make(map[SegmentID]Segment)
+	for _, seg := range segs {
+		translated[seg.SegmentID] = seg
+	}
+
+	root := parse.NewTableNode()
+	if err := MergeMessage(root, &base.Scopes[0], ru.Lang, translated); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zero := root.Get("Files").Get("plural").Get("zero")
+	if zero == nil || zero.Str != "Нет файлов" {
+		t.Errorf("got zero form %v, want %q", zero, "Нет файлов")
+	}
+}
+
+func TestImportPOSkipped(t *testing.T) {
+	base := testLocalization(t, "en", testBaseYAML)
+	ru := testLocalization(t, "ru", "")
+	locs := []scope.Localization{base, ru}
+
+	// Nothing is translated yet
+	data, err := ExportPO(&base, &ru, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, segs, err := ImportPO("loc.ru.po", data, locs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(segs) != 0 {
+		t.Errorf("got segments of untranslated file: %v", segmentTexts(segs))
+	}
+
+	in := poTestHeader +
+		"#, fuzzy\n" +
+		"msgctxt \"Route\"\n" +
+		"msgid \"{from} → {to}\"\n" +
+		"msgstr \"{from} ← {to}\"\n" +
+		"\n" +
+		"msgctxt \"Files\"\n" +
+		"msgid \"{n} file in {dir}\"\n" +
+		"msgid_plural \"{n} files in {dir}\"\n" +
+		"msgstr[0] \"{n} файл в {dir}\"\n" +
+		"msgstr[1] \"\"\n" +
+		"msgstr[2] \"{n} файлов в {dir}\"\n"
+
+	_, segs, err = ImportPO("loc.ru.po", []byte(in), locs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Fuzzy entries are skipped, and so are untranslated forms of plurals
+	checkTexts(t, segmentTexts(segs), map[SegmentID]string{
+		{Message: "Files", Form: "one"}:  "${n} файл в ${dir}",
+		{Message: "Files", Form: "many"}: "${n} файлов в ${dir}",
+	})
+}
+
+const poTestHeader = "msgid \"\"\n" +
+	"msgstr \"Language: ru\\n\"\n" +
+	"\n"
+
+func TestImportPOPlaceholders(t *testing.T) {
+	base := testLocalization(t, "en", testBaseYAML)
+	ru := testLocalization(t, "ru", "")
+	locs := []scope.Localization{base, ru}
+
+	tests := []struct {
+		name    string
+		context string
+		// Translations of the plural forms if there are more than one
+		translations []string
+		missing      []string
+		unknown      []string
+	}{
+		{
+			name:         "same placeholders in other order",
+			context:      "Route",
+			translations: []string{"{to} ← {from}"},
+		},
+		{
+			name:         "missing placeholder",
+			context:      "Route",
+			translations: []string{"{to} ←"},
+			missing:      []string{"from"},
+		},
+		{
+			name:         "placeholder of another segment",
+			context:      "Invitation",
+			translations: []string{"{host} пригласил вас на {pronoun} вечеринку {gender}."},
+			unknown:      []string{"gender"},
+		},
+		{
+			name:         "missing variable",
+			context:      "Invitation",
+			translations: []string{"{host} пригласил вас."},
+			missing:      []string{"pronoun"},
+		},
+		{
+			name:         "placeholder in form of select",
+			context:      "Invitation&{pronoun}[male]",
+			translations: []string{"его {host}"},
+			unknown:      []string{"host"},
+		},
+		{
+			name:         "plural argument missing from form",
+			context:      "Files",
+			translations: []string{"один файл в {dir}", "{n} файла в {dir}", "{n} файлов в {dir}"},
+		},
+		{
+			name:         "plural argument added to exact form",
+			context:      "Files[=0]",
+			translations: []string{"{n} файлов"},
+		},
+		{
+			name:         "missing placeholder in form",
+			context:      "Files",
+			translations: []string{"{n} файл в {dir}", "{n} файла", "{n} файлов в {dir}"},
+			missing:      []string{"dir"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+
+			b.WriteString(poTestHeader)
+
+			entry := poEntry{
+				Context:     tt.context,
+				HasContext:  true,
+				ID:          "source",
+				Translation: tt.translations,
+			}
+			if len(tt.translations) > 1 {
+				entry.HasPlural = true
+				entry.IDPlural = "source"
+			}
+
+			writePOEntry(&b, &entry)
+
+			_, segs, err := ImportPO("loc.ru.po", []byte(b.String()), locs)
+			if tt.missing == nil && tt.unknown == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(segs) != len(tt.translations) {
+					t.Fatalf("got %d segments, want %d", len(segs), len(tt.translations))
+				}
+				return
+			}
+
+			mismatch := placeholdersMismatch(t, err)
+			if !slices.Equal(mismatch.Missing, tt.missing) || !slices.Equal(mismatch.Unknown, tt.unknown) {
+				t.Errorf("got missing %v and unknown %v, want %v and %v",
+					mismatch.Missing, mismatch.Unknown, tt.missing, tt.unknown)
+			}
+			if !strings.HasPrefix(mismatch.Segment, tt.context) {
+				t.Errorf("got segment %q, want %q", mismatch.Segment, tt.context)
+			}
+		})
+	}
+}
@@ -0,0 +1,604 @@
+package exchange
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+	"github.com/infastin/go-l10n/scope"
+	"golang.org/x/text/language"
+)
+
+const xliffNamespace = "urn:oasis:names:tc:xliff:document:2.0"
+
+// Returns id of the unit of the segment.
+// Names of messages and variables are Go identifiers,
+// so they are separated with characters that can't occur in them:
+// "Message", "Message:variable", "Message-form" or "Message:variable-form",
+// and exact forms of plurals and forms of selects go after "#":
+// "Message#=1", "Message:variable#=1" or "Message:variable#male".
+func xliffUnitID(id SegmentID) string {
+	unitID := id.Message
+	if id.Variable != "" {
+		unitID += ":" + id.Variable
+	}
+	if id.Form != "" {
+		unitID += "-" + id.Form
+	}
+	if id.Case != "" {
+		unitID += "#" + id.Case
+	}
+	return unitID
+}
+
+func parseXLIFFUnitID(unitID string) (id SegmentID) {
+	unitID, id.Case, _ = strings.Cut(unitID, "#")
+	if idx := strings.LastIndexByte(unitID, '-'); idx != -1 {
+		unitID, id.Form = unitID[:idx], unitID[idx+1:]
+	}
+	id.Message, id.Variable, _ = strings.Cut(unitID, ":")
+	return id
+}
+
+// Returns XLIFF 2.0 document that contains messages of the base localization
+// along with their translations.
+// Each segment of a message, including each of its plural forms, is a separate unit,
+// and arguments and variables are written as placeholders,
+// whose original data is the way they are formatted.
+// Messages that are missing in the localization are left untranslated.
+// References to the source files are relative to the given directory.
+func ExportXLIFF(base, loc *scope.Localization, refDir string) (data []byte) {
+	var b strings.Builder
+
+	b.WriteString(xml.Header)
+	b.WriteString(`<xliff xmlns="` + xliffNamespace + `" version="2.0"`)
+	b.WriteString(` srcLang="` + xliffEscape(base.Lang.String(), true) + `"`)
+	b.WriteString(` trgLang="` + xliffEscape(loc.Lang.String(), true) + `">` + "\n")
+	b.WriteString(`  <file id="` + xliffEscape(base.Name, true) + `" xml:space="preserve">` + "\n")
+
+	for i := 0; i < len(base.Scopes); i++ {
+		baseMs := &base.Scopes[i]
+		ms := findMessage(loc, baseMs.Name)
+
+		translations := make(map[SegmentID]ast.FormatParts)
+		if ms != nil {
+			for _, seg := range Segments(ms, loc.Lang) {
+				translations[seg.SegmentID] = seg.Text
+			}
+		}
+
+		parts := placeholders(baseMs, ms)
+
+		for _, seg := range Segments(baseMs, loc.Lang) {
+			text, translated := translations[seg.SegmentID]
+			writeXLIFFUnit(&b, &seg, text, translated, pluralArg(baseMs, seg.SegmentID), parts, refDir)
+		}
+	}
+
+	b.WriteString("  </file>\n")
+	b.WriteString("</xliff>\n")
+
+	return []byte(b.String())
+}
+
+func writeXLIFFUnit(
+	b *strings.Builder,
+	seg *Segment,
+	translation ast.FormatParts,
+	translated bool,
+	pluralArg string,
+	parts map[string]ast.FormatPart,
+	refDir string,
+) {
+	name := poContext(seg.SegmentID)
+
+	b.WriteString(`    <unit id="` + xliffEscape(xliffUnitID(seg.SegmentID), true) + `"`)
+	b.WriteString(` name="` + xliffEscape(name, true) + `">` + "\n")
+
+	var notes []string
+	if seg.Variable != "" {
+		notes = append(notes, `<note category="variable">`+xliffEscape(seg.Variable, false)+`</note>`)
+	}
+	if seg.Form != "" {
+		notes = append(notes, `<note category="plural">`+xliffEscape(seg.Form, false)+`</note>`)
+	}
+	if seg.Case != "" {
+		category := "plural"
+		if pluralArg == "" {
+			category = "select"
+		}
+		notes = append(notes, `<note category="`+category+`">`+xliffEscape(seg.Case, false)+`</note>`)
+	}
+	if seg.Location.IsValid() {
+		notes = append(notes, `<note category="location">`+xliffEscape(sourceReference(seg.Location, refDir), false)+`</note>`)
+	}
+
+	if len(notes) != 0 {
+		b.WriteString("      <notes>\n")
+		for _, note := range notes {
+			b.WriteString("        " + note + "\n")
+		}
+		b.WriteString("      </notes>\n")
+	}
+
+	// Placeholders that can be used in the translation
+	var names []string
+	for _, text := range []ast.FormatParts{seg.Text, translation} {
+		for _, part := range text {
+			if name := placeholderName(part); name != "" && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	if (seg.Form != "" || seg.Case != "") && pluralArg != "" && !slices.Contains(names, pluralArg) {
+		names = append(names, pluralArg)
+	}
+
+	if len(names) != 0 {
+		b.WriteString("      <originalData>\n")
+		for _, name := range names {
+			b.WriteString(`        <data id="` + xliffEscape(name, true) + `">`)
+			b.WriteString(xliffEscape(ast.FormatParts{parts[name]}.String(), false))
+			b.WriteString("</data>\n")
+		}
+		b.WriteString("      </originalData>\n")
+	}
+
+	state := "initial"
+	if translated {
+		state = "translated"
+	}
+
+	b.WriteString(`      <segment state="` + state + `">` + "\n")
+
+	ids := make(map[string][]string)
+	var lastID int
+
+	b.WriteString("        <source>")
+	writeXLIFFText(b, seg.Text, ids, &lastID, true)
+	b.WriteString("</source>\n")
+
+	if translated {
+		b.WriteString("        <target>")
+		writeXLIFFText(b, translation, ids, &lastID, false)
+		b.WriteString("</target>\n")
+	}
+
+	b.WriteString("      </segment>\n")
+	b.WriteString("    </unit>\n")
+}
+
+func placeholderName(part ast.FormatPart) string {
+	switch part := part.(type) {
+	case ast.ArgInfo:
+		return part.Name
+	case ast.VarInfo:
+		return part.Name
+	default:
+		return ""
+	}
+}
+
+// Writes inline content of the source or the target.
+// Placeholders of the source get new ids, that are remembered,
+// so that the same placeholders of the target get the same ids.
+func writeXLIFFText(b *strings.Builder, text ast.FormatParts, ids map[string][]string, lastID *int, source bool) {
+	// Ids of the source that are not used by the target yet
+	unused := make(map[string][]string)
+	for name, nameIDs := range ids {
+		unused[name] = nameIDs
+	}
+
+	for _, part := range text {
+		name := placeholderName(part)
+		if name == "" {
+			writeXLIFFString(b, string(part.(ast.Text)))
+			continue
+		}
+
+		var id string
+		if nameIDs := unused[name]; !source && len(nameIDs) != 0 {
+			id, unused[name] = nameIDs[0], nameIDs[1:]
+		} else {
+			*lastID++
+			id = strconv.Itoa(*lastID)
+		}
+
+		if source {
+			ids[name] = append(ids[name], id)
+		}
+
+		b.WriteString(`<ph id="` + id + `" dataRef="` + xliffEscape(name, true) + `"`)
+		b.WriteString(` disp="{` + xliffEscape(name, true) + `}"/>`)
+	}
+}
+
+// Writes the text, whose characters that are not allowed in XML are written as <cp/> elements.
+func writeXLIFFString(b *strings.Builder, str string) {
+	for _, c := range str {
+		if c == '\t' || c == '\n' || c == '\r' || (c >= 0x20 && c != 0xfffe && c != 0xffff) {
+			b.WriteString(xliffEscape(string(c), false))
+			continue
+		}
+		b.WriteString(`<cp hex="` + strings.ToUpper(strconv.FormatInt(int64(c), 16)) + `"/>`)
+	}
+}
+
+func xliffEscape(str string, attr bool) string {
+	var b strings.Builder
+
+	for _, c := range str {
+		switch {
+		case c == '&':
+			b.WriteString("&amp;")
+		case c == '<':
+			b.WriteString("&lt;")
+		case c == '>':
+			b.WriteString("&gt;")
+		case c == '"' && attr:
+			b.WriteString("&quot;")
+		case c == '\n' && attr:
+			b.WriteString("&#xA;")
+		case c == '\t' && attr:
+			b.WriteString("&#x9;")
+		case c == '\r':
+			// Parsers turn carriage returns into newlines
+			b.WriteString("&#xD;")
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	return b.String()
+}
+
+type xliffUnit struct {
+	ID           string         `xml:"id,attr"`
+	OriginalData []xliffData    `xml:"originalData>data"`
+	Content      []xliffSegment `xml:",any"`
+}
+
+type xliffData struct {
+	ID    string `xml:"id,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Segment or ignorable content of the unit.
+type xliffSegment struct {
+	XMLName xml.Name
+	Source  xliffText  `xml:"source"`
+	Target  *xliffText `xml:"target"`
+}
+
+// Inline content of the source or the target.
+type xliffText struct {
+	Parts []xliffPart
+}
+
+// Either text or placeholder.
+type xliffPart struct {
+	Text        string
+	Placeholder bool
+	ID          string
+	DataRef     string
+	CopyOf      string
+}
+
+func (t *xliffText) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
+	for depth := 0; ; {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tok := tok.(type) {
+		case xml.CharData:
+			t.Parts = append(t.Parts, xliffPart{Text: string(tok)})
+		case xml.StartElement:
+			switch tok.Name.Local {
+			case "ph":
+				part := xliffPart{Placeholder: true}
+				for _, attr := range tok.Attr {
+					switch attr.Name.Local {
+					case "id":
+						part.ID = attr.Value
+					case "dataRef":
+						part.DataRef = attr.Value
+					case "copyOf":
+						part.CopyOf = attr.Value
+					}
+				}
+				t.Parts = append(t.Parts, part)
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			case "cp":
+				for _, attr := range tok.Attr {
+					if attr.Name.Local != "hex" {
+						continue
+					}
+					c, err := strconv.ParseUint(attr.Value, 16, 32)
+					if err != nil {
+						return common.NewError(common.ErrInvalidChar, common.ErrorValueStr(attr.Value))
+					}
+					t.Parts = append(t.Parts, xliffPart{Text: string(rune(c))})
+				}
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			case "mrk":
+				// Annotations only mark the text
+				depth++
+			case "sm", "em":
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			default:
+				return common.NewError(common.ErrUnknownField, common.ErrorValueStr(tok.Name.Local))
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				return nil
+			}
+			depth--
+		}
+	}
+}
+
+// Reads translations from the XLIFF 2.0 document.
+// Returns target language of the document along with the translated segments.
+// Units without target are skipped.
+// Each target may contain only the placeholders of its source or of the current translation,
+// though forms of plurals may contain the argument the plural depends on.
+// The first localization is the base one, whose messages are translated.
+func ImportXLIFF(filename string, data []byte, locs []scope.Localization) (lang language.Tag, segs []Segment, err error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	invalidSyntax := func(err error) error {
+		loc := common.ErrorLocation{File: filename}
+
+		var syntaxErr *xml.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			loc.Line = syntaxErr.Line
+		} else {
+			loc.Line, loc.Column = dec.InputPos()
+		}
+
+		return common.NewError(common.ErrInvalidSyntax, loc, common.ErrorWrapped(err))
+	}
+
+	noTargetLanguage := common.NewError(common.ErrFieldNotSpecified,
+		common.ErrorValueStr("trgLang"),
+		common.ErrorLocation{File: filename},
+	)
+
+	base := &locs[0]
+
+	var (
+		loc  *scope.Localization
+		errs common.ErrorList
+	)
+
+	lang = language.Und
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return lang, nil, invalidSyntax(err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "xliff":
+			if start.Name.Space != xliffNamespace {
+				return lang, nil, common.NewError(common.ErrInvalidSyntax,
+					common.ErrorExpectedStr(xliffNamespace),
+					common.ErrorValueStr(start.Name.Space),
+					common.ErrorLocation{File: filename},
+				)
+			}
+
+			var langStr string
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "trgLang" {
+					langStr = attr.Value
+				}
+			}
+
+			if langStr == "" {
+				return lang, nil, noTargetLanguage
+			}
+
+			lang, err = language.Parse(langStr)
+			if err != nil {
+				return language.Und, nil, common.NewError(common.ErrInvalidLanguage,
+					common.ErrorValueStr(langStr),
+					common.ErrorLocation{File: filename},
+					common.ErrorWrapped(err),
+				)
+			}
+
+			if idx := scope.LocalizationIndex(locs, lang); idx != -1 {
+				loc = &locs[idx]
+			}
+		case "unit":
+			line, _ := dec.InputPos()
+
+			var unit xliffUnit
+			if err := dec.DecodeElement(&unit, &start); err != nil {
+				return lang, nil, invalidSyntax(err)
+			}
+
+			if lang == language.Und {
+				return lang, nil, noTargetLanguage
+			}
+
+			seg, ok, err := importXLIFFUnit(&unit, base, loc, lang)
+			if err != nil {
+				errs.Add(common.NewError(common.ErrCouldNotImport,
+					common.ErrorValueStr(unit.ID),
+					common.ErrorLocation{File: filename, Line: line},
+					common.ErrorWrapped(err),
+				))
+				continue
+			}
+
+			if ok {
+				seg.Location = ast.Location{File: filename, Line: line}
+				segs = append(segs, seg)
+			}
+		}
+	}
+
+	if lang == language.Und {
+		return lang, nil, noTargetLanguage
+	}
+
+	return lang, segs, errs.Err()
+}
+
+func isEmptyXLIFFText(parts []xliffPart) bool {
+	for _, part := range parts {
+		if part.Placeholder || part.Text != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns translated segment of the unit.
+// Returns false if the unit is not translated.
+func importXLIFFUnit(
+	unit *xliffUnit,
+	base, loc *scope.Localization,
+	lang language.Tag,
+) (seg Segment, ok bool, err error) {
+	var (
+		source []xliffPart
+		target []xliffPart
+		// Whether any of the segments has target
+		translated bool
+	)
+
+	for i := 0; i < len(unit.Content); i++ {
+		content := &unit.Content[i]
+
+		switch content.XMLName.Local {
+		case "segment", "ignorable":
+			source = append(source, content.Source.Parts...)
+			if content.Target != nil {
+				target = append(target, content.Target.Parts...)
+				translated = translated || content.XMLName.Local == "segment"
+			} else {
+				// Ignorable content is the same in the target if it is omitted
+				target = append(target, content.Source.Parts...)
+			}
+		}
+	}
+
+	if !translated || isEmptyXLIFFText(target) {
+		return Segment{}, false, nil
+	}
+
+	id := parseXLIFFUnitID(unit.ID)
+
+	baseMs := findMessage(base, id.Message)
+	if baseMs == nil {
+		return Segment{}, false, common.NewMessageNotInBaseError(id.Message, base.Lang.String())
+	}
+
+	var known bool
+	for _, baseSeg := range Segments(baseMs, lang) {
+		if baseSeg.SegmentID == id {
+			known = true
+			break
+		}
+	}
+
+	if !known {
+		return Segment{}, false, common.ErrTranslationDoesNotMatch
+	}
+
+	// Names of the placeholders referred to by ids of the source
+	sourceIDs := make(map[string]string)
+	for _, part := range source {
+		if part.Placeholder {
+			sourceIDs[part.ID] = part.DataRef
+		}
+	}
+
+	placeholderNames := func(parts []xliffPart) (names []string) {
+		for _, part := range parts {
+			if !part.Placeholder {
+				continue
+			}
+
+			name := part.DataRef
+			if name == "" && part.CopyOf != "" {
+				name = sourceIDs[part.CopyOf]
+			}
+			if name == "" {
+				name = sourceIDs[part.ID]
+			}
+
+			names = append(names, name)
+		}
+		return names
+	}
+
+	targetNames := placeholderNames(target)
+
+	ms := findMessage(loc, id.Message)
+	parts := placeholders(baseMs, ms)
+
+	var (
+		text ast.FormatParts
+		b    strings.Builder
+	)
+
+	for i, name := 0, 0; i < len(target); i++ {
+		part := &target[i]
+
+		if !part.Placeholder {
+			b.WriteString(part.Text)
+			continue
+		}
+
+		placeholder, ok := parts[targetNames[name]]
+		if !ok {
+			return Segment{}, false, common.NewError(common.ErrUnknownPlaceholder, common.ErrorValueStr(targetNames[name]))
+		}
+		name++
+
+		if b.Len() != 0 {
+			text = append(text, ast.Text(b.String()))
+			b.Reset()
+		}
+
+		text = append(text, placeholder)
+	}
+
+	if b.Len() != 0 {
+		text = append(text, ast.Text(b.String()))
+	}
+
+	err = checkPlaceholders(baseMs, ms, id, lang, unit.ID, text)
+	if err != nil {
+		return Segment{}, false, err
+	}
+
+	return Segment{SegmentID: id, Text: text}, true, nil
+}
@@ -0,0 +1,203 @@
+package exchange
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/infastin/go-l10n/common"
+	"github.com/infastin/go-l10n/scope"
+)
+
+// Replaces the target of the unit in the XLIFF document.
+func withXLIFFTarget(t *testing.T, data []byte, unitID, target string) []byte {
+	t.Helper()
+
+	doc := string(data)
+
+	unit := strings.Index(doc, `<unit id="`+unitID+`"`)
+	if unit == -1 {
+		t.Fatalf("unit %s is missing", unitID)
+	}
+
+	start := unit + strings.Index(doc[unit:], "<target>") + len("<target>")
+	end := start + strings.Index(doc[start:], "</target>")
+
+	return []byte(doc[:start] + target + doc[end:])
+}
+
+func TestXLIFFUnitID(t *testing.T) {
+	tests := []struct {
+		id   SegmentID
+		want string
+	}{
+		{SegmentID{Message: "Route"}, "Route"},
+		{SegmentID{Message: "Auth.Login"}, "Auth.Login"},
+		{SegmentID{Message: "Files", Form: "one"}, "Files-one"},
+		{SegmentID{Message: "Files", Case: "=0"}, "Files#=0"},
+		{SegmentID{Message: "Late", Variable: "minutes", Form: "few"}, "Late:minutes-few"},
+		{SegmentID{Message: "Invitation", Variable: "pronoun", Case: "male"}, "Invitation:pronoun#male"},
+	}
+
+	for _, tt := range tests {
+		got := xliffUnitID(tt.id)
+		if got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.id, got, tt.want)
+		}
+
+		if id := parseXLIFFUnitID(got); id != tt.id {
+			t.Errorf("%q: got %+v", got, id)
+		}
+	}
+}
+
+func TestExportImportXLIFF(t *testing.T) {
+	base := testLocalization(t, "en", testBaseYAML)
+	ru := testLocalization(t, "ru", testRussianYAML)
+	locs := []scope.Localization{base, ru}
+
+	data := ExportXLIFF(&base, &ru, "")
+
+	for _, want := range []string{
+		`<unit id="Files#=0" name="Files[=0]">`,
+		`<note category="plural">few</note>`,
+		`<unit id="Invitation:pronoun#male" name="Invitation&amp;{pronoun}[male]">`,
+		`<data id="pronoun">&amp;{pronoun}</data>`,
+		`<source>Say "{hi}" &lt;b&gt;&amp;&lt;/b&gt;`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("exported document doesn't contain\n%s\ndocument:\n%s", want, data)
+		}
+	}
+
+	lang, segs, err := ImportXLIFF("loc.ru.xlf", data, locs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if lang != ru.Lang {
+		t.Errorf("got language %v, want %v", lang, ru.Lang)
+	}
+
+	checkTexts(t, segmentTexts(segs), localizationTexts(&ru))
+}
+
+func TestImportXLIFFUntranslated(t *testing.T) {
+	base := testLocalization(t, "en", testBaseYAML)
+	ru := testLocalization(t, "ru", "")
+	locs := []scope.Localization{base, ru}
+
+	_, segs, err := ImportXLIFF("loc.ru.xlf", ExportXLIFF(&base, &ru, ""), locs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(segs) != 0 {
+		t.Errorf("got segments of untranslated document: %v", segmentTexts(segs))
+	}
+}
+
+func TestImportXLIFFPlaceholders(t *testing.T) {
+	base := testLocalization(t, "en", testBaseYAML)
+	ru := testLocalization(t, "ru", testRussianYAML)
+	locs := []scope.Localization{base, ru}
+
+	data := ExportXLIFF(&base, &ru, "")
+
+	tests := []struct {
+		name    string
+		unit    string
+		target  string
+		want    string
+		missing []string
+		unknown []string
+	}{
+		{
+			name:   "same placeholders in other order",
+			unit:   "Route",
+			target: `<ph id="2" dataRef="to" disp="{to}"/> ← <ph id="1" dataRef="from" disp="{from}"/>`,
+			want:   "${to} ← ${from}",
+		},
+		{
+			name:    "missing placeholder",
+			unit:    "Route",
+			target:  `<ph id="2" dataRef="to" disp="{to}"/> ←`,
+			missing: []string{"from"},
+		},
+		{
+			name:    "placeholder of another segment",
+			unit:    "Invitation",
+			target:  `<ph id="1" dataRef="host"/> пригласил <ph id="3" dataRef="gender"/> на <ph id="2" dataRef="pronoun"/> вечеринку.`,
+			unknown: []string{"gender"},
+		},
+		{
+			name:   "plural argument missing from form",
+			unit:   "Files-one",
+			target: `один файл в <ph id="2" dataRef="dir" disp="{dir}"/>`,
+			want:   "один файл в ${dir}",
+		},
+		{
+			name:    "missing placeholder in form",
+			unit:    "Files-few",
+			target:  `<ph id="1" dataRef="n" disp="{n}"/> файла`,
+			missing: []string{"dir"},
+		},
+		{
+			name:   "placeholder that copies the source",
+			unit:   "Quote",
+			target: `Цитата <ph id="9" copyOf="1"/>`,
+			want:   "Цитата ${name}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, segs, err := ImportXLIFF("loc.ru.xlf", withXLIFFTarget(t, data, tt.unit, tt.target), locs)
+
+			if tt.missing == nil && tt.unknown == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				id := parseXLIFFUnitID(tt.unit)
+				if got := segmentTexts(segs)[id]; got != tt.want {
+					t.Errorf("got %q, want %q", got, tt.want)
+				}
+				return
+			}
+
+			mismatch := placeholdersMismatch(t, err)
+			if !slices.Equal(mismatch.Missing, tt.missing) || !slices.Equal(mismatch.Unknown, tt.unknown) {
+				t.Errorf("got missing %v and unknown %v, want %v and %v",
+					mismatch.Missing, mismatch.Unknown, tt.missing, tt.unknown)
+			}
+			if mismatch.Segment != tt.unit {
+				t.Errorf("got segment %q, want %q", mismatch.Segment, tt.unit)
+			}
+		})
+	}
+}
+
+func TestImportXLIFFUnknownPlaceholder(t *testing.T) {
+	base := testLocalization(t, "en", testBaseYAML)
+	ru := testLocalization(t, "ru", testRussianYAML)
+	locs := []scope.Localization{base, ru}
+
+	data := withXLIFFTarget(t, ExportXLIFF(&base, &ru, ""), "Route", `<ph id="1" dataRef="zzz"/>`)
+
+	_, _, err := ImportXLIFF("loc.ru.xlf", data, locs)
+
+	var e *common.Error
+	for _, err := range common.Errors(err) {
+		for ; err != nil; err = errors.Unwrap(err) {
+			if ce, ok := err.(*common.Error); ok && ce.ErrKind == common.ErrUnknownPlaceholder {
+				e = ce
+			}
+		}
+	}
+
+	if e == nil {
+		t.Fatalf("got error %v, want unknown placeholder", err)
+	}
+}
@@ -0,0 +1,41 @@
+// Package pluraltest contains plural forms of numbers that both
+// the generated localizers and the live catalogs are tested against,
+// so that they never disagree on which form to use.
+package pluraltest
+
+import (
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+type Case struct {
+	Lang language.Tag
+	N    int
+	Form plural.Form
+}
+
+// Plural forms of numbers in languages with different plural rules,
+// including negative numbers and numbers that exceed 10,000,000.
+var Cases = []Case{
+	{language.English, 0, plural.Other},
+	{language.English, 1, plural.One},
+	{language.English, -1, plural.One},
+	{language.English, 2, plural.Other},
+	{language.English, 10000001, plural.Other},
+	{language.Russian, 1, plural.One},
+	{language.Russian, 2, plural.Few},
+	{language.Russian, 5, plural.Many},
+	{language.Russian, 11, plural.Many},
+	{language.Russian, 21, plural.One},
+	{language.Russian, -22, plural.Few},
+	{language.Russian, 10000021, plural.One},
+	{language.Spanish, 1, plural.One},
+	{language.Spanish, 0, plural.Other},
+	{language.Spanish, 2, plural.Other},
+	{language.Arabic, 0, plural.Zero},
+	{language.Arabic, 2, plural.Two},
+	{language.Arabic, 3, plural.Few},
+	{language.Arabic, 11, plural.Many},
+	{language.Arabic, 100, plural.Other},
+	{language.Japanese, 1, plural.Other},
+}
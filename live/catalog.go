@@ -0,0 +1,255 @@
+// Package live reads localization files at runtime, so that texts of messages
+// can be changed without regenerating and recompiling the code.
+//
+// Localizers generated with --live flag read messages from Catalog
+// and fall back to the compiled ones when a message can't be read.
+package live
+
+import (
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/infastin/go-l10n/common"
+	"github.com/infastin/go-l10n/parse"
+	"github.com/infastin/go-l10n/process"
+	"github.com/infastin/go-l10n/scope"
+	"golang.org/x/text/language"
+)
+
+// Messages of the language read from localization files.
+type localization struct {
+	lang language.Tag
+	msgs map[string]*scope.MessageScope
+	// Whether the language uses "zero" plural form,
+	// otherwise the form simply matches zero
+	usesZero bool
+}
+
+// Size and modification time of the file,
+// which tell whether it has changed since it was read.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func (s fileStamp) equal(other fileStamp) bool {
+	return s.size == other.size && s.modTime.Equal(other.modTime)
+}
+
+// Messages read from localization files of the directory.
+// Catalog is safe for concurrent use, including while it is reloaded.
+type Catalog struct {
+	dir      string
+	pattern  *regexp.Regexp
+	base     language.Tag
+	fallback language.Tag
+
+	mu   sync.RWMutex
+	locs map[string]*localization
+	// Stamps of the files the localizations have been read from
+	stamps map[string]fileStamp
+}
+
+type Option func(c *Catalog)
+
+// Sets the base language, which is the last one to fall back to, English by default.
+func WithBase(lang language.Tag) Option {
+	return func(c *Catalog) {
+		c.base = lang
+	}
+}
+
+// Sets the language to fall back to before the base one.
+func WithFallback(lang language.Tag) Option {
+	return func(c *Catalog) {
+		c.fallback = lang
+	}
+}
+
+// Sets pattern of localization file names,
+// whose groups match name, language and extension of the file.
+func WithPattern(pattern *regexp.Regexp) Option {
+	return func(c *Catalog) {
+		c.pattern = pattern
+	}
+}
+
+// Reads localization files of the directory.
+// If some of the files or messages are invalid, the catalog contains the rest of them
+// and is returned along with the list of errors.
+func Load(dir string, opts ...Option) (c *Catalog, err error) {
+	c = &Catalog{
+		dir:     dir,
+		pattern: regexp.MustCompile(common.DefaultPattern),
+		base:    language.English,
+		locs:    make(map[string]*localization),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, c.Reload()
+}
+
+// Reads localization files of the directory again.
+// Messages that can't be read are removed from the catalog.
+func (c *Catalog) Reload() (err error) {
+	var errs common.ErrorList
+
+	stamps, err := c.stat()
+	errs.Add(err)
+
+	locs := make(map[string]*localization)
+
+	// Files are read in the same order as by go-l10n
+	names := make([]string, 0, len(stamps))
+	for name := range stamps {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		matches := c.pattern.FindStringSubmatch(name)
+		filePath := path.Join(c.dir, name)
+
+		lang, err := language.Parse(matches[2])
+		if err != nil {
+			errs.Add(common.NewError(common.ErrInvalidLanguage,
+				common.ErrorValueStr(matches[2]),
+				common.ErrorLocation{File: filePath},
+				common.ErrorWrapped(err),
+			))
+			continue
+		}
+
+		loc, ok := locs[lang.String()]
+		if !ok {
+			loc = &localization{
+				lang:     lang,
+				msgs:     make(map[string]*scope.MessageScope),
+				usesZero: slices.Contains(scope.PluralForms(lang), "zero"),
+			}
+			locs[lang.String()] = loc
+		}
+
+		mss, err := readFile(filePath, matches[3], lang)
+		errs.Add(err)
+
+		for i := 0; i < len(mss); i++ {
+			ms := &mss[i]
+
+			if _, ok := loc.msgs[ms.Name]; ok {
+				errs.Add(common.NewError(common.ErrInvalidLocalization,
+					common.ErrorValueStr(lang.String()),
+					common.ErrorLocation(ms.Location),
+					common.NewDuplicateMessageError(ms.Name),
+				))
+				continue
+			}
+
+			loc.msgs[ms.Name] = ms
+		}
+	}
+
+	if _, ok := locs[c.base.String()]; !ok {
+		errs.Add(common.NewError(common.ErrBaseNotFound, common.ErrorValueStr(c.base.String())))
+	}
+
+	c.mu.Lock()
+	c.locs = locs
+	c.stamps = stamps
+	c.mu.Unlock()
+
+	return errs.Err()
+}
+
+// Returns stamps of the localization files of the directory by their names.
+// Files that match the pattern but can't be read at runtime are skipped,
+// as their messages are only available in the compiled code.
+func (c *Catalog) stat() (stamps map[string]fileStamp, err error) {
+	stamps = make(map[string]fileStamp)
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return stamps, common.NewError(common.ErrCouldNotReadDirectory,
+			common.ErrorValueStr(c.dir),
+			common.ErrorWrapped(err),
+		)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		matches := c.pattern.FindStringSubmatch(entry.Name())
+		if len(matches) != 4 || getDecoder(matches[3]) == nil {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// The file has been removed since the directory was read
+			continue
+		}
+
+		stamps[entry.Name()] = fileStamp{
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		}
+	}
+
+	return stamps, nil
+}
+
+func getDecoder(ext string) parse.Decoder {
+	switch ext {
+	case "json":
+		return parse.DecodeJSON
+	case "yaml", "yml":
+		return parse.DecodeYAML
+	case "toml":
+		return parse.DecodeTOML
+	default:
+		return nil
+	}
+}
+
+// Reads messages of the file the same way go-l10n does.
+// If some of the messages are invalid, the rest of them are returned
+// along with the list of errors.
+func readFile(filePath, ext string, lang language.Tag) (mss []scope.MessageScope, err error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, common.NewError(common.ErrCouldNotReadFile,
+			common.ErrorValueStr(path.Base(filePath)),
+			common.ErrorLocation{File: filePath},
+			common.ErrorWrapped(err),
+		)
+	}
+
+	var errs common.ErrorList
+
+	msgs, err := parse.UnmarshalMessages(filePath, data, getDecoder(ext))
+	for _, err := range common.Errors(err) {
+		errs.Add(common.NewError(common.ErrCouldNotUnmarshalFile,
+			common.ErrorValueStr(path.Base(filePath)),
+			common.ErrorWrapped(err),
+		))
+	}
+
+	mss, err = process.ProcessMessages(msgs, lang)
+	for _, err := range common.Errors(err) {
+		errs.Add(common.NewError(common.ErrCouldNotParseFile,
+			common.ErrorValueStr(path.Base(filePath)),
+			common.ErrorWrapped(err),
+		))
+	}
+
+	return mss, errs.Err()
+}
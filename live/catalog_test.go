@@ -0,0 +1,191 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/infastin/go-l10n/common"
+	"github.com/infastin/go-l10n/internal/pluraltest"
+)
+
+// Writes the localization file to the directory.
+func writeTestFile(t *testing.T, dir, name, data string) {
+	t.Helper()
+
+	if err := os.WriteFile(path.Join(dir, name), []byte(data), 0o644); err != nil {
+		t.Fatalf("could not write %s: %v", name, err)
+	}
+}
+
+func checkMessage(t *testing.T, c *Catalog, lang, name string, args map[string]any, want string) {
+	t.Helper()
+
+	got, ok := c.Message(lang, name, args)
+	if !ok {
+		t.Fatalf("%s: message %s is missing", lang, name)
+	}
+	if got != want {
+		t.Errorf("%s: got %q, want %q", lang, got, want)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFile(t, dir, "loc.en.yaml", `
+Hello: "Hello, ${name}!"
+Files:
+  plural:
+    arg: n
+    one: "${n} file"
+    other: "${n} files"
+Bye: "Bye!"
+`)
+	writeTestFile(t, dir, "loc.ru.yaml", `
+Hello: "Привет, ${name}!"
+Files:
+  plural:
+    arg: n
+    one: "${n} файл"
+    few: "${n} файла"
+    many: "${n} файлов"
+`)
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkMessage(t, c, "en", "Hello", map[string]any{"name": "Alice"}, "Hello, Alice!")
+	checkMessage(t, c, "ru", "Hello", map[string]any{"name": "Alice"}, "Привет, Alice!")
+	checkMessage(t, c, "ru", "Files", map[string]any{"n": 3}, "3 файла")
+	checkMessage(t, c, "ru", "Files", map[string]any{"n": 5}, "5 файлов")
+
+	// Missing message is taken from the base language
+	checkMessage(t, c, "ru", "Bye", nil, "Bye!")
+	checkMessage(t, c, "ru-RU", "Hello", map[string]any{"name": "Alice"}, "Привет, Alice!")
+
+	if _, ok := c.Message("en", "Hello", map[string]any{"name": 42}); ok {
+		t.Error("got message with argument of wrong type")
+	}
+	if _, ok := c.Message("en", "Unknown", nil); ok {
+		t.Error("got unknown message")
+	}
+
+	writeTestFile(t, dir, "loc.ru.yaml", `
+Hello: "Здравствуйте, ${name}!"
+Bye: "Пока!"
+`)
+
+	if err := c.Reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkMessage(t, c, "ru", "Hello", map[string]any{"name": "Alice"}, "Здравствуйте, Alice!")
+	checkMessage(t, c, "ru", "Bye", nil, "Пока!")
+	// Messages removed from the file are taken from the base language again
+	checkMessage(t, c, "ru", "Files", map[string]any{"n": 5}, "5 files")
+
+	// Broken file drops its messages, but not the ones of the other files
+	writeTestFile(t, dir, "loc.ru.yaml", `Hello: "Привет, ${name"`)
+
+	if err := c.Reload(); err == nil {
+		t.Fatal("broken file is reloaded without errors")
+	}
+
+	checkMessage(t, c, "ru", "Hello", map[string]any{"name": "Alice"}, "Hello, Alice!")
+}
+
+func TestWatch(t *testing.T) {
+	dir := path.Join(t.TempDir(), "loc")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, dir, "loc.en.yaml", `Hello: "Hello!"`)
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		c.Watch(ctx, time.Millisecond, func(err error) {
+			// Errors are reported every tick, but only the first one is checked
+			select {
+			case errs <- err:
+			default:
+			}
+		})
+	}()
+
+	// Waits until the message has the text
+	waitMessage := func(want string) {
+		t.Helper()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			if got, _ := c.Message("en", "Hello", nil); got == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("message hasn't been reloaded with %q", want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	writeTestFile(t, dir, "loc.en.yaml", `Hello: "Hello again!"`)
+	waitMessage("Hello again!")
+
+	// The file could have been read while it was written
+	select {
+	case <-errs:
+	default:
+	}
+
+	// The catalog is kept while the directory can't be read
+	if err := os.Rename(dir, dir+".old"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-errs:
+		var e *common.Error
+		if !errors.As(err, &e) || e.ErrKind != common.ErrCouldNotReadDirectory {
+			t.Errorf("got error %v, want %v", err, common.ErrCouldNotReadDirectory)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("error of reading the directory hasn't been reported")
+	}
+
+	checkMessage(t, c, "en", "Hello", nil, "Hello again!")
+
+	if err := os.Rename(dir+".old", dir); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "loc.en.yaml", `Hello: "Hello once more!"`)
+	waitMessage("Hello once more!")
+
+	cancel()
+	<-done
+}
+
+func TestPluralForm(t *testing.T) {
+	for _, tt := range pluraltest.Cases {
+		if got := pluralForm(tt.Lang, tt.N); got != tt.Form {
+			t.Errorf("%v of %d: got %v, want %v", tt.Lang, tt.N, got, tt.Form)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package live
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/scope"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// Returns text of the message in the language with the given arguments.
+// If the message is missing in the language, it is taken from the fallback chain
+// the same way go-l10n does: parent languages, the fallback language and the base one.
+// Reports false if none of them has the message, or the arguments don't match
+// the ones the message uses, e.g. since the message has been changed after the code was generated.
+func (c *Catalog) Message(lang, name string, args map[string]any) (text string, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	loc, ms := c.findMessage(lang, name)
+	if ms == nil {
+		return "", false
+	}
+
+	r := renderer{loc: loc, ms: ms, args: args}
+
+	values := []ast.Value{&ms.Plural, ms.String}
+
+	for _, val := range values {
+		if !val.IsZero() {
+			b, ok := r.appendValue(nil, val)
+			return string(b), ok
+		}
+	}
+
+	return "", true
+}
+
+// Returns the first localization of the fallback chain of the language
+// that has the message, along with the message.
+func (c *Catalog) findMessage(lang, name string) (loc *localization, ms *scope.MessageScope) {
+	chain := []language.Tag{}
+
+	if tag, err := language.Parse(lang); err == nil {
+		for ; tag != language.Und; tag = tag.Parent() {
+			chain = append(chain, tag)
+		}
+	}
+
+	if c.fallback != language.Und {
+		chain = append(chain, c.fallback)
+	}
+
+	chain = append(chain, c.base)
+
+	for _, tag := range chain {
+		loc := c.locs[tag.String()]
+		if loc == nil {
+			continue
+		}
+
+		if ms := loc.msgs[name]; ms != nil {
+			return loc, ms
+		}
+	}
+
+	return nil, nil
+}
+
+// Interprets value of the message the same way its generated code does.
+type renderer struct {
+	loc  *localization
+	ms   *scope.MessageScope
+	args map[string]any
+}
+
+func (r *renderer) appendValue(b []byte, value ast.Value) ([]byte, bool) {
+	switch v := value.(type) {
+	case *ast.Plural:
+		return r.appendPlural(b, v)
+	case *ast.Select:
+		return r.appendSelect(b, v)
+	case ast.FormatParts:
+		return r.appendFormatParts(b, v)
+	}
+
+	return b, true
+}
+
+func (r *renderer) appendPlural(b []byte, p *ast.Plural) ([]byte, bool) {
+	n, ok := r.args[p.Arg].(int)
+	if !ok {
+		return nil, false
+	}
+
+	// Exact forms take precedence over the plural forms
+	if exact := p.ExactForm(n); exact != nil {
+		return r.appendFormatParts(b, exact.Parts)
+	}
+
+	form := pluralForm(r.loc.lang, n)
+
+	values := []struct {
+		Parts ast.FormatParts
+		Form  plural.Form
+	}{
+		{p.Zero, plural.Zero},
+		{p.One, plural.One},
+		{p.Two, plural.Two},
+		{p.Few, plural.Few},
+		{p.Many, plural.Many},
+		{p.Other, plural.Other},
+	}
+
+	// If "other" is not specified, all the forms used by the language are,
+	// so the last one matches the rest of the numbers
+	var parts ast.FormatParts
+
+	for _, value := range values {
+		if value.Parts == nil {
+			continue
+		}
+
+		parts = value.Parts
+
+		switch {
+		case value.Form == plural.Other:
+			return r.appendFormatParts(b, parts)
+		case value.Form == plural.Zero && !r.loc.usesZero:
+			// If the language doesn't use "zero" form, it simply matches zero
+			if n == 0 {
+				return r.appendFormatParts(b, parts)
+			}
+		case value.Form == form:
+			return r.appendFormatParts(b, parts)
+		}
+	}
+
+	return r.appendFormatParts(b, parts)
+}
+
+func (r *renderer) appendSelect(b []byte, s *ast.Select) ([]byte, bool) {
+	value, ok := r.args[s.Arg].(string)
+	if !ok {
+		return nil, false
+	}
+
+	for i := 0; i < len(s.Cases); i++ {
+		if s.Cases[i].Value == value {
+			return r.appendFormatParts(b, s.Cases[i].Parts)
+		}
+	}
+
+	return r.appendFormatParts(b, s.Other)
+}
+
+// Returns plural form of the integer in the language.
+// Plural operands must be non-negative, and it is okay
+// to pass them modulo 10,000,000.
+// It chooses the same forms as pluralForm of the generated code,
+// and both of them are tested against pluraltest.Cases.
+func pluralForm(lang language.Tag, n int) plural.Form {
+	if n < 0 {
+		n = -(n % 10000000)
+	}
+	return plural.Cardinal.MatchPlural(lang, n, 0, 0, 0, 0)
+}
+
+func (r *renderer) appendFormatParts(b []byte, parts ast.FormatParts) ([]byte, bool) {
+	ok := true
+
+	for _, part := range parts {
+		switch part := part.(type) {
+		case ast.Text:
+			b = append(b, part...)
+		case ast.ArgInfo:
+			b, ok = r.appendArgument(b, &part)
+		case ast.VarInfo:
+			b, ok = r.appendVariable(b, &part)
+		}
+
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return b, true
+}
+
+func (r *renderer) appendVariable(b []byte, info *ast.VarInfo) ([]byte, bool) {
+	idx := scope.VariableScopeIndex(r.ms.Variables, info.Name)
+	variable := &r.ms.Variables[idx]
+
+	switch {
+	case !variable.Plural.IsZero():
+		return r.appendPlural(b, &variable.Plural)
+	case !variable.Select.IsZero():
+		return r.appendSelect(b, &variable.Select)
+	default:
+		return r.appendFormatParts(b, variable.String)
+	}
+}
+
+// Appends the argument, formatted the same way as by the generated code.
+// Reports false if the argument is missing or its type doesn't match the one of the message.
+func (r *renderer) appendArgument(b []byte, info *ast.ArgInfo) ([]byte, bool) {
+	arg := &r.ms.Arguments[scope.ArgumentIndex(r.ms.Arguments, info.Name)]
+
+	value, ok := r.args[arg.Name]
+	if !ok || !hasGoType(value, arg.GoType) {
+		return nil, false
+	}
+
+	if info.FmtInfo.HasOptions() {
+		return fmt.Appendf(b, info.FmtInfo.GoFormat(arg.GoType), value), true
+	}
+
+	switch arg.GoType.Type {
+	case "string":
+		return append(b, value.(string)...), true
+	case "int":
+		return strconv.AppendInt(b, int64(value.(int)), 10), true
+	case "float64":
+		return strconv.AppendFloat(b, value.(float64), 'f', 6, 64), true
+	case "Stringer":
+		return append(b, value.(fmt.Stringer).String()...), true
+	default:
+		return fmt.Append(b, value), true
+	}
+}
+
+// Reports whether the value can be passed as an argument of the type.
+func hasGoType(value any, goType ast.GoType) (ok bool) {
+	switch goType.String() {
+	case "string":
+		_, ok = value.(string)
+	case "int":
+		_, ok = value.(int)
+	case "float64":
+		_, ok = value.(float64)
+	case "fmt.Stringer":
+		_, ok = value.(fmt.Stringer)
+	default:
+		ok = true
+	}
+
+	return ok
+}
@@ -0,0 +1,48 @@
+package live
+
+import (
+	"context"
+	"maps"
+	"time"
+)
+
+// Checks localization files of the directory every interval and reloads the catalog
+// when some of them have been changed, added or removed, until the context is done.
+// Errors of reading the directory and of reloading are passed to onError, if it is not nil.
+//
+// Files are polled, since the catalog is meant for development
+// and watching them with the API of the operating system requires a dependency.
+func (c *Catalog) Watch(ctx context.Context, interval time.Duration, onError func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stamps, err := c.stat()
+		if err != nil {
+			// The directory may be unavailable for a moment, for example while it is replaced,
+			// so the catalog is kept as it is until it can be read again
+			if onError != nil {
+				onError(err)
+			}
+			continue
+		}
+
+		c.mu.RLock()
+		changed := !maps.EqualFunc(stamps, c.stamps, fileStamp.equal)
+		c.mu.RUnlock()
+
+		if !changed {
+			continue
+		}
+
+		if err := c.Reload(); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
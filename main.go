@@ -1,21 +1,25 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"errors"
 	"fmt"
-	"go/ast"
+	"io/fs"
 	"os"
 	"path"
+	"regexp"
+	"strings"
 
-	"github.com/BurntSushi/toml"
+	"github.com/infastin/go-l10n/ast"
 	"github.com/infastin/go-l10n/codegen"
 	"github.com/infastin/go-l10n/common"
+	"github.com/infastin/go-l10n/diff"
+	"github.com/infastin/go-l10n/exchange"
 	"github.com/infastin/go-l10n/parse"
 	"github.com/infastin/go-l10n/printer"
 	"github.com/infastin/go-l10n/process"
 	"github.com/infastin/go-l10n/scope"
 	"golang.org/x/text/language"
-	"gopkg.in/yaml.v3"
 )
 
 type LocalizationFile struct {
@@ -24,27 +28,45 @@ type LocalizationFile struct {
 	Name     string
 	Lang     language.Tag
 	Ext      string
+	// Path to the ARB file of the base language with the same name,
+	// whose metadata applies to this one, empty if there is none
+	Template string
 }
 
+// Returns localization files of the directory.
+// If names of some of the files are invalid, the rest of them are returned
+// along with the list of errors.
 func GetLocalizationFiles() (files []LocalizationFile, err error) {
 	entries, err := os.ReadDir(common.Config.Directory)
 	if err != nil {
 		return nil, err
 	}
 
+	var errs common.ErrorList
+
 	for _, entry := range entries {
 		if entry.IsDir() {
+			platformFiles, err := getPlatformLocalizationFiles(entry.Name())
+			errs.Add(err)
+			files = append(files, platformFiles...)
 			continue
 		}
 
 		name := entry.Name()
+		filePath := path.Join(common.Config.Directory, name)
 
 		matches := common.Config.Pattern.FindStringSubmatch(name)
 		if len(matches) == 0 {
-			return nil, common.NewError(common.ErrInvalidFilename,
+			matches = matchARBFilename(name)
+		}
+
+		if len(matches) == 0 {
+			errs.Add(common.NewError(common.ErrInvalidFilename,
 				common.ErrorValueStr(name),
+				common.ErrorLocation{File: filePath},
 				common.ErrorWrapped(common.ErrFilenameDoesNotMatch),
-			)
+			))
+			continue
 		}
 
 		if len(matches) != 4 {
@@ -55,14 +77,16 @@ func GetLocalizationFiles() (files []LocalizationFile, err error) {
 
 		lang, err := language.Parse(matches[2])
 		if err != nil {
-			return nil, common.NewError(common.ErrInvalidLanguage,
-				common.ErrorValueStr(matches[1]),
+			errs.Add(common.NewError(common.ErrInvalidLanguage,
+				common.ErrorValueStr(matches[2]),
+				common.ErrorLocation{File: filePath},
 				common.ErrorWrapped(err),
-			)
+			))
+			continue
 		}
 
 		files = append(files, LocalizationFile{
-			Path:     path.Join(common.Config.Directory, name),
+			Path:     filePath,
 			Filename: name,
 			Name:     matches[1],
 			Lang:     lang,
@@ -70,118 +94,449 @@ func GetLocalizationFiles() (files []LocalizationFile, err error) {
 		})
 	}
 
-	return files, nil
+	if common.Config.Base == language.Und {
+		common.Config.Base = defaultBase(files)
+	}
+
+	setARBTemplates(files)
+
+	return files, errs.Err()
 }
 
-func ReadLocalizationFiles(files []LocalizationFile) (locs []scope.Localization, err error) {
-	// Slice of sets of scope names
-	// Each set corresponds to the localization at the same index
-	var locsScopeNames []map[string]struct{}
+// Returns the base language used when it is not set:
+// English if there are files of it, otherwise the language that goes first alphabetically,
+// so that it doesn't depend on the order of the files.
+func defaultBase(files []LocalizationFile) (base language.Tag) {
+	for i := 0; i < len(files); i++ {
+		lang := files[i].Lang
+
+		if lang == language.English {
+			return lang
+		}
 
+		if base == language.Und || lang.String() < base.String() {
+			base = lang
+		}
+	}
+
+	if base == language.Und {
+		return language.English
+	}
+
+	return base
+}
+
+// Sets the template of each ARB file of a language other than the base one.
+func setARBTemplates(files []LocalizationFile) {
 	for i := 0; i < len(files); i++ {
 		file := &files[i]
+		if file.Ext != "arb" || file.Lang == common.Config.Base {
+			continue
+		}
 
-		data, err := os.ReadFile(file.Path)
-		if err != nil {
-			return nil, common.NewError(common.ErrCouldNotReadFile,
-				common.ErrorValueStr(file.Filename),
-				common.ErrorWrapped(err),
-			)
+		for j := 0; j < len(files); j++ {
+			template := &files[j]
+			if template.Ext == "arb" && template.Name == file.Name && template.Lang == common.Config.Base {
+				file.Template = template.Path
+				break
+			}
 		}
+	}
+}
 
-		var unmarshaler func([]byte, any) error
+// Flutter ARB file of a language: app_en.arb or app_pt_BR.arb.
+var arbFilename = regexp.MustCompile(`^(.+?)_([a-z]{2,3}(?:_[A-Z][a-z]{3})?(?:_(?:[A-Z]{2}|[0-9]{3}))?)\.(arb)$`)
 
-		switch file.Ext {
-		case "json":
-			unmarshaler = json.Unmarshal
-		case "yaml", "yml":
-			unmarshaler = yaml.Unmarshal
-		case "toml":
-			unmarshaler = toml.Unmarshal
-		default:
-			return nil, common.NewError(common.ErrUnsupportedFileExtension, common.ErrorValueStr(file.Ext))
+// Matches the name of the file against Flutter naming of ARB files
+// and returns the same submatches as the pattern does,
+// with the language written the way language.Parse accepts it.
+func matchARBFilename(name string) []string {
+	matches := arbFilename.FindStringSubmatch(name)
+	if matches == nil {
+		return nil
+	}
+
+	matches[2] = strings.ReplaceAll(matches[2], "_", "-")
+
+	return matches
+}
+
+// Android resource directory of a language: values-pt-rBR or values-b+sr+Latn.
+var androidValuesDir = regexp.MustCompile(`^values-(?:([a-z]{2,3})(?:-r([A-Z]{2}))?|b\+([a-zA-Z0-9+]+))$`)
+
+// Returns localization files of the subdirectory, if it is
+// an Android resource directory, like values or values-pt-rBR,
+// which contains strings.xml file,
+// or an Apple localization directory, like Base.lproj or pt-BR.lproj,
+// which contains Localizable.strings and Localizable.stringsdict files.
+// Files of the directories without a language qualifier are of the base language.
+// Other directories, like values-night, are skipped.
+func getPlatformLocalizationFiles(dir string) (files []LocalizationFile, err error) {
+	var (
+		langStr   string
+		filenames []string
+	)
+
+	switch {
+	case dir == "values" || dir == "Base.lproj":
+		// The base language is English, unless it is set
+		langStr = language.English.String()
+		if common.Config.Base != language.Und {
+			langStr = common.Config.Base.String()
+		}
+	case strings.HasSuffix(dir, ".lproj"):
+		langStr = strings.TrimSuffix(dir, ".lproj")
+	default:
+		matches := androidValuesDir.FindStringSubmatch(dir)
+		if matches == nil {
+			return nil, nil
 		}
 
-		msgs, err := parse.UnmarshalMessages(data, unmarshaler)
-		if err != nil {
-			return nil, common.NewError(common.ErrCouldNotUnmarshalFile,
-				common.ErrorValueStr(file.Filename),
-				common.ErrorWrapped(err),
-			)
+		switch {
+		case matches[3] != "":
+			langStr = strings.ReplaceAll(matches[3], "+", "-")
+		case matches[2] != "":
+			langStr = matches[1] + "-" + matches[2]
+		default:
+			langStr = matches[1]
 		}
+	}
 
-		mss, err := process.ProcessMessages(msgs)
-		if err != nil {
-			return nil, common.NewError(common.ErrCouldNotParseFile,
-				common.ErrorValueStr(file.Filename),
-				common.ErrorWrapped(err),
-			)
+	if strings.HasSuffix(dir, ".lproj") {
+		filenames = []string{"Localizable.strings", "Localizable.stringsdict"}
+	} else {
+		filenames = []string{"strings.xml"}
+	}
+
+	lang, err := language.Parse(langStr)
+	if err != nil {
+		return nil, common.NewError(common.ErrInvalidLanguage,
+			common.ErrorValueStr(langStr),
+			common.ErrorLocation{File: path.Join(common.Config.Directory, dir)},
+			common.ErrorWrapped(err),
+		)
+	}
+
+	for _, filename := range filenames {
+		name := path.Join(dir, filename)
+		filePath := path.Join(common.Config.Directory, name)
+
+		if _, err := os.Stat(filePath); err != nil {
+			continue
 		}
 
+		ext := path.Ext(filename)
+
+		files = append(files, LocalizationFile{
+			Path:     filePath,
+			Filename: name,
+			Name:     strings.ToLower(strings.TrimSuffix(filename, ext)),
+			Lang:     lang,
+			Ext:      ext[1:],
+		})
+	}
+
+	return files, nil
+}
+
+// Reads localization files and merges files of the same language.
+// Errors of all the files are collected and returned as a list
+// along with the messages that have been read successfully.
+func ReadLocalizationFiles(files []LocalizationFile) (locs []scope.Localization, err error) {
+	// Slice of sets of scope names
+	// Each set corresponds to the localization at the same index
+	var locsScopeNames []map[string]struct{}
+
+	var errs common.ErrorList
+
+	for i := 0; i < len(files); i++ {
+		file := &files[i]
+
 		locIdx := scope.LocalizationIndex(locs, file.Lang)
 
-		// If localization is not found, create it and add scopes to it
+		// If localization is not found, create it
 		if locIdx == -1 {
 			locs = append(locs, scope.Localization{
-				Name:   file.Name,
-				Lang:   file.Lang,
-				Scopes: mss,
+				Name:            file.Name,
+				Lang:            file.Lang,
+				InvalidMessages: make(map[string]struct{}),
 			})
-
 			locsScopeNames = append(locsScopeNames, make(map[string]struct{}))
-			locScopeNames := locsScopeNames[len(locsScopeNames)-1]
-
-			// Add scope names to the corresponding set so it is possible
-			// to quickly check for duplicate messages
-			for i := 0; i < len(mss); i++ {
-				locScopeNames[mss[i].Name] = struct{}{}
-			}
-
-			continue
+			locIdx = len(locs) - 1
 		}
 
-		// If localization is found, check for duplicate messages
-		// and add new messages
-
 		loc := &locs[locIdx]
-		locScopeName := locsScopeNames[locIdx]
+		locScopeNames := locsScopeNames[locIdx]
+
+		mss, err := readLocalizationFile(file)
+		if err != nil {
+			for _, err := range common.Errors(err) {
+				if msg, ok := common.ErrorMessageOf(err); ok {
+					loc.InvalidMessages[msg] = struct{}{}
+				} else {
+					loc.Incomplete = true
+				}
+
+				errs.Add(err)
+			}
+		}
 
+		// Check for duplicate messages and add new messages
 		for i := 0; i < len(mss); i++ {
 			ms := &mss[i]
 
-			if _, ok := locScopeName[ms.Name]; ok {
-				return nil, common.NewError(common.ErrInvalidLocalization,
+			if _, ok := locScopeNames[ms.Name]; ok {
+				errs.Add(common.NewError(common.ErrInvalidLocalization,
 					common.ErrorValueStr(loc.Lang.String()),
+					common.ErrorLocation(ms.Location),
 					common.NewDuplicateMessageError(ms.Name),
-				)
+				))
+				continue
+			}
+
+			locScopeNames[ms.Name] = struct{}{}
+			loc.Scopes = append(loc.Scopes, *ms)
+		}
+	}
+
+	for i := 0; i < len(locs); i++ {
+		errs.Add(checkNamespaces(&locs[i], locsScopeNames[i]))
+		errs.Add(checkMethodNames(&locs[i], locsScopeNames[i]))
+		errs.Add(checkIdentifiers(&locs[i]))
+	}
+
+	return locs, errs.Err()
+}
+
+// Checks that names of messages don't collide with names of namespaces,
+// e.g. there is no "Auth" message if there is "Auth.Login" message.
+func checkNamespaces(loc *scope.Localization, names map[string]struct{}) (err error) {
+	var errs common.ErrorList
+
+	for i := 0; i < len(loc.Scopes); i++ {
+		ms := &loc.Scopes[i]
+
+		for namespace := ms.Name; ; {
+			idx := strings.LastIndexByte(namespace, '.')
+			if idx == -1 {
+				break
+			}
+
+			namespace = namespace[:idx]
+
+			if _, ok := names[namespace]; ok {
+				errs.Add(common.NewError(common.ErrInvalidLocalization,
+					common.ErrorValueStr(loc.Lang.String()),
+					common.ErrorLocation(ms.Location),
+					common.ErrorWrapped(common.NewError(common.ErrNamespaceCollision, common.ErrorValueStr(namespace))),
+				))
+				break
+			}
+		}
+	}
+
+	return errs.Err()
+}
+
+// Checks that names of messages don't collide with names of methods
+// that append and write other messages, e.g. there is no "AppendTitle" message
+// if there is "Title" message in the same namespace.
+func checkMethodNames(loc *scope.Localization, names map[string]struct{}) (err error) {
+	var errs common.ErrorList
+
+	for i := 0; i < len(loc.Scopes); i++ {
+		ms := &loc.Scopes[i]
+
+		idx := strings.LastIndexByte(ms.Name, '.')
+		namespace, name := ms.Name[:idx+1], ms.Name[idx+1:]
+
+		for _, prefix := range []string{"Append", "Write"} {
+			if _, ok := names[namespace+strings.TrimPrefix(name, prefix)]; ok && strings.HasPrefix(name, prefix) {
+				errs.Add(common.NewError(common.ErrInvalidLocalization,
+					common.ErrorValueStr(loc.Lang.String()),
+					common.ErrorLocation(ms.Location),
+					common.ErrorWrapped(common.NewError(common.ErrMethodCollision, common.ErrorValueStr(ms.Name))),
+				))
+				break
 			}
+		}
+	}
+
+	return errs.Err()
+}
+
+// Checks that identifiers made of names of namespaces and messages don't collide,
+// e.g. there are no "Auth.Login" and "Auth_Login" namespaces,
+// which would both have Localizer_Auth_Login interface.
+func checkIdentifiers(loc *scope.Localization) (err error) {
+	var errs common.ErrorList
+
+	// Names of namespaces and messages by the identifiers they are made into
+	sources := make(map[string]string)
+	// Pairs of names that have been reported already,
+	// since a namespace is made into several identifiers
+	reported := make(map[[2]string]struct{})
+
+	for _, ident := range codegen.GetIdentifiers(loc) {
+		other, ok := sources[ident.Name]
+		if !ok {
+			sources[ident.Name] = ident.Source
+			continue
+		}
+
+		if _, ok := reported[[2]string{other, ident.Source}]; ok {
+			continue
+		}
 
-			locScopeName[ms.Name] = struct{}{}
+		reported[[2]string{other, ident.Source}] = struct{}{}
+
+		errs.Add(common.NewError(common.ErrInvalidLocalization,
+			common.ErrorValueStr(loc.Lang.String()),
+			common.ErrorLocation(ident.Location),
+			common.NewIdentifierCollisionError(ident.Source, other, ident.Name),
+		))
+	}
+
+	return errs.Err()
+}
+
+// Reads messages of the localization file.
+// If some of the messages are invalid, the rest of them are returned
+// along with the list of errors.
+func readLocalizationFile(file *LocalizationFile) (mss []scope.MessageScope, err error) {
+	data, err := os.ReadFile(file.Path)
+	if err != nil {
+		return nil, common.NewError(common.ErrCouldNotReadFile,
+			common.ErrorValueStr(file.Filename),
+			common.ErrorLocation{File: file.Path},
+			common.ErrorWrapped(err),
+		)
+	}
+
+	decoder, _, err := getCodec(file)
+	if err != nil {
+		return nil, err
+	}
+
+	unmarshal := parse.UnmarshalMessages
+	switch {
+	case isPlatformFile(file):
+		unmarshal = parse.UnmarshalPrintfMessages
+	case file.Ext == "arb":
+		unmarshal = func(filename string, in []byte, decode parse.Decoder) ([]ast.Message, error) {
+			return parse.UnmarshalARBMessages(filename, in, readARBTemplate(file), decode)
+		}
+	case file.Ext == "ftl":
+		unmarshal = func(filename string, in []byte, _ parse.Decoder) ([]ast.Message, error) {
+			return parse.UnmarshalFluentMessages(filename, in)
 		}
+	}
+
+	var errs common.ErrorList
+
+	msgs, err := unmarshal(file.Path, data, decoder)
+	for _, err := range common.Errors(err) {
+		errs.Add(common.NewError(common.ErrCouldNotUnmarshalFile,
+			common.ErrorValueStr(file.Filename),
+			common.ErrorWrapped(err),
+		))
+	}
+
+	mss, err = process.ProcessMessages(msgs, file.Lang)
+	for _, err := range common.Errors(err) {
+		errs.Add(common.NewError(common.ErrCouldNotParseFile,
+			common.ErrorValueStr(file.Filename),
+			common.ErrorWrapped(err),
+		))
+	}
+
+	return mss, errs.Err()
+}
+
+// Reads the template of the ARB file, if it has one and it can be read.
+// Errors are not reported, since the template is read on its own anyway.
+func readARBTemplate(file *LocalizationFile) *parse.ARBTemplate {
+	if file.Template == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(file.Template)
+	if err != nil {
+		return nil
+	}
 
-		loc.Scopes = append(loc.Scopes, mss...)
+	return &parse.ARBTemplate{Filename: file.Template, Data: data}
+}
+
+// Returns decoder and encoder of the localization file.
+// Files of mobile platforms and ARB files can only be read, so they don't have an encoder,
+// and Fluent files are parsed on their own, so they don't have either of them.
+func getCodec(file *LocalizationFile) (decoder parse.Decoder, encoder parse.Encoder, err error) {
+	switch file.Ext {
+	case "json":
+		return parse.DecodeJSON, parse.EncodeJSON, nil
+	case "yaml", "yml":
+		return parse.DecodeYAML, parse.EncodeYAML, nil
+	case "toml":
+		return parse.DecodeTOML, parse.EncodeTOML, nil
+	case "arb":
+		return parse.DecodeJSON, nil, nil
+	case "ftl":
+		return nil, nil, nil
+	case "xml":
+		return parse.DecodeAndroidStrings, nil, nil
+	case "strings":
+		return parse.DecodeAppleStrings, nil, nil
+	case "stringsdict":
+		return parse.DecodeStringsdict, nil, nil
+	default:
+		return nil, nil, common.NewError(common.ErrUnsupportedFileExtension,
+			common.ErrorValueStr(file.Ext),
+			common.ErrorLocation{File: file.Path},
+		)
 	}
+}
 
-	return locs, nil
+// Reports whether the file is a localization file of a mobile platform,
+// whose strings are written in printf style.
+func isPlatformFile(file *LocalizationFile) bool {
+	switch file.Ext {
+	case "xml", "strings", "stringsdict":
+		return true
+	default:
+		return false
+	}
 }
 
-// Checks whether different localizations contain all the same messages.
+// Checks whether different localizations contain all the same messages
+// with the same arguments as the base localization, which must be the first one,
+// and reorders arguments of messages so that they go in the same order
+// as in the base localization.
+// Messages missing in a localization are taken from the fallback one,
+// unless strict mode is enabled.
+// Messages that couldn't be read are not checked.
 // Also checks if there are any localizations at all.
 func CheckLocalizations(locs []scope.Localization) (err error) {
 	if len(locs) == 0 {
 		return common.NewError(common.ErrNoLocalizationsFound)
 	}
 
-	// We consider the first localization as the "base" one
+	var errs common.ErrorList
+
+	// The base localization goes first
 	baseLoc := &locs[0]
-	// Set of localization messages of the base localization
-	baseMsgs := make(map[string]struct{})
+	// Map of localization messages of the base localization
+	baseMsgs := make(map[string]*scope.MessageScope)
 
 	for i := 0; i < len(baseLoc.Scopes); i++ {
 		ms := &locs[0].Scopes[i]
-		baseMsgs[ms.Name] = struct{}{}
+		baseMsgs[ms.Name] = ms
 	}
 
+	// Slice of sets of message names
+	// Each set corresponds to the localization at the same index
+	locsMsgs := make([]map[string]struct{}, len(locs))
+
 	for i := 1; i < len(locs); i++ {
 		loc := &locs[i]
 		// Set of localization messages
@@ -192,103 +547,704 @@ func CheckLocalizations(locs []scope.Localization) (err error) {
 		for j := 0; j < len(loc.Scopes); j++ {
 			ms := &loc.Scopes[j]
 
-			if _, ok := baseMsgs[ms.Name]; !ok {
-				return common.NewError(common.ErrInvalidLocalization,
-					common.ErrorValueStr(baseLoc.Lang.String()),
-					common.NewMessageNotSpecifiedError(ms.Name),
-				)
+			baseMs, ok := baseMsgs[ms.Name]
+			if !ok {
+				if _, invalid := baseLoc.InvalidMessages[ms.Name]; !invalid && !baseLoc.Incomplete {
+					errs.Add(common.NewError(common.ErrInvalidLocalization,
+						common.ErrorValueStr(loc.Lang.String()),
+						common.ErrorLocation(ms.Location),
+						common.NewMessageNotInBaseError(ms.Name, baseLoc.Lang.String()),
+					))
+				}
+				continue
 			}
 
 			msgs[ms.Name] = struct{}{}
+
+			err = alignArguments(baseMs, ms)
+			if err != nil {
+				errs.Add(common.NewError(common.ErrInvalidLocalization,
+					common.ErrorValueStr(loc.Lang.String()),
+					common.NewArgumentsMismatchError(ms.Name, err),
+				))
+			}
 		}
 
-		// Check for unspecified messages in localization
-		for msg := range baseMsgs {
-			if _, ok := msgs[msg]; !ok {
-				return common.NewError(common.ErrInvalidLocalization,
+		locsMsgs[i] = msgs
+	}
+
+	fallbackIdx := 0
+	if common.Config.Fallback != language.Und {
+		fallbackIdx = scope.LocalizationIndex(locs, common.Config.Fallback)
+		if fallbackIdx == -1 {
+			errs.Add(common.NewError(common.ErrFallbackNotFound, common.ErrorValueStr(common.Config.Fallback.String())))
+			return errs.Err()
+		}
+	}
+
+	// Check for unspecified messages in localizations
+	for i := 1; i < len(locs); i++ {
+		loc := &locs[i]
+		msgs := locsMsgs[i]
+
+		for j := 0; j < len(baseLoc.Scopes); j++ {
+			baseMs := &baseLoc.Scopes[j]
+
+			if _, ok := msgs[baseMs.Name]; ok {
+				continue
+			}
+
+			if _, invalid := loc.InvalidMessages[baseMs.Name]; invalid || loc.Incomplete {
+				continue
+			}
+
+			if common.Config.Strict {
+				errs.Add(common.NewError(common.ErrInvalidLocalization,
 					common.ErrorValueStr(loc.Lang.String()),
-					common.NewMessageNotSpecifiedError(msg),
-				)
+					common.NewMessageNotSpecifiedError(baseMs.Name, baseLoc.Lang.String()),
+				))
+				continue
 			}
+
+			loc.Scopes = append(loc.Scopes, scope.MessageScope{
+				Name:      baseMs.Name,
+				Arguments: baseMs.Arguments,
+				Fallback:  findFallback(locs, locsMsgs, i, fallbackIdx, baseMs.Name),
+			})
+		}
+	}
+
+	return errs.Err()
+}
+
+// Finds the localization to take the missing message from.
+// The fallback chain consists of parent languages (pt-BR falls back to pt),
+// then the fallback language, and finally the base language.
+func findFallback(
+	locs []scope.Localization,
+	locsMsgs []map[string]struct{},
+	locIdx, fallbackIdx int,
+	msg string,
+) (fallback *scope.Localization) {
+	hasMessage := func(idx int) bool {
+		// The base localization contains all the messages
+		if idx == 0 {
+			return true
+		}
+		_, ok := locsMsgs[idx][msg]
+		return ok
+	}
+
+	for lang := locs[locIdx].Lang.Parent(); lang != language.Und; lang = lang.Parent() {
+		idx := scope.LocalizationIndex(locs, lang)
+		if idx != -1 && hasMessage(idx) {
+			return &locs[idx]
+		}
+	}
+
+	if hasMessage(fallbackIdx) {
+		return &locs[fallbackIdx]
+	}
+
+	return &locs[0]
+}
+
+// Checks whether the message has the same arguments as the base message
+// and reorders them so that they go in the same order.
+func alignArguments(baseMs, ms *scope.MessageScope) (err error) {
+	for i := 0; i < len(ms.Arguments); i++ {
+		arg := &ms.Arguments[i]
+
+		if scope.ArgumentIndex(baseMs.Arguments, arg.Name) == -1 {
+			return common.NewError(common.ErrUnknownArgument,
+				common.ErrorValueStr(arg.Name),
+				common.ErrorLocation(arg.Location),
+			)
 		}
 	}
 
+	args := make([]scope.Argument, 0, len(baseMs.Arguments))
+
+	for i := 0; i < len(baseMs.Arguments); i++ {
+		baseArg := &baseMs.Arguments[i]
+
+		idx := scope.ArgumentIndex(ms.Arguments, baseArg.Name)
+		if idx == -1 {
+			return common.NewError(common.ErrMissingArgument,
+				common.ErrorValueStr(baseArg.Name),
+				common.ErrorLocation(ms.Location),
+			)
+		}
+
+		arg := &ms.Arguments[idx]
+
+		if arg.GoType != baseArg.GoType {
+			return common.NewError(common.ErrArgumentTypesDontMatch,
+				common.ErrorValueStr(arg.Name),
+				common.ErrorExpectedStr(baseArg.GoType.String()),
+				common.ErrorLocation(arg.Location),
+			)
+		}
+
+		args = append(args, *arg)
+	}
+
+	ms.Arguments = args
+
 	return nil
 }
 
-func generateFile(locFile *ast.File, filename string) (err error) {
-	file, err := os.Create(filename)
+// Moves the base localization to the beginning of the slice.
+func SelectBaseLocalization(locs []scope.Localization) (err error) {
+	if len(locs) == 0 {
+		return common.NewError(common.ErrNoLocalizationsFound)
+	}
+
+	baseIdx := scope.LocalizationIndex(locs, common.Config.Base)
+	if baseIdx == -1 {
+		return common.NewError(common.ErrBaseNotFound, common.ErrorValueStr(common.Config.Base.String()))
+	}
+
+	baseLoc := locs[baseIdx]
+	copy(locs[1:baseIdx+1], locs[:baseIdx])
+	locs[0] = baseLoc
+
+	return nil
+}
+
+// Reports messages that are missing in localizations
+// and have been taken from fallback localizations.
+func ReportFallbacks(locs []scope.Localization) {
+	for i := 0; i < len(locs); i++ {
+		loc := &locs[i]
+
+		for j := 0; j < len(loc.Scopes); j++ {
+			ms := &loc.Scopes[j]
+
+			if ms.Fallback != nil {
+				fmt.Fprintf(os.Stderr, "%s: message %q is missing, falling back to %s\n",
+					loc.Lang, ms.Name, ms.Fallback.Lang)
+			}
+		}
+	}
+}
+
+// Returns name of the generated file of the localization.
+// Language tag is lowercased and its subtags are separated with underscores,
+// so that pt-BR, pt_BR and pt_br all result in the same name.
+func getLocalizationFilename(loc *scope.Localization) string {
+	lang := strings.ToLower(strings.ReplaceAll(loc.Lang.String(), "-", "_"))
+	return loc.Name + "_" + lang + ".go"
+}
+
+// Generated file along with its contents.
+type GeneratedFile struct {
+	Path string
+	Data []byte
+}
+
+// Generates code of localizations in memory.
+func RenderLocalizations(locs []scope.Localization) (files []GeneratedFile, err error) {
+	locFiles := codegen.GenerateLocalizations(locs)
+
+	filenames := []string{path.Join(common.Config.Output, "l10n.go")}
+
+	for i := 1; i <= len(locs); i++ {
+		filename := getLocalizationFilename(&locs[i-1])
+		filenames = append(filenames, path.Join(common.Config.Output, filename))
+	}
+
+	if common.Config.Live {
+		filenames = append(filenames, path.Join(common.Config.Output, "l10n_live.go"))
+	}
+
+	for i, locFile := range locFiles {
+		var b bytes.Buffer
+
+		err = printer.FprintAstFile(&b, locFile)
+		if err != nil {
+			return nil, common.NewError(common.ErrCouldNotWriteToFile,
+				common.ErrorValueStr(filenames[i]),
+				common.ErrorWrapped(err),
+			)
+		}
+
+		files = append(files, GeneratedFile{
+			Path: filenames[i],
+			Data: b.Bytes(),
+		})
+	}
+
+	return files, nil
+}
+
+func GenerateLocalizations(locs []scope.Localization) (err error) {
+	files, err := RenderLocalizations(locs)
+	if err != nil {
+		return err
+	}
+
+	return writeFiles(common.Config.Output, files)
+}
+
+// Writes the files, creating the directory if it doesn't exist.
+func writeFiles(dir string, files []GeneratedFile) (err error) {
+	err = os.MkdirAll(dir, 0755)
 	if err != nil {
-		return common.NewError(common.ErrCouldNotCreateFile,
-			common.ErrorValueStr(filename),
+		return common.NewError(common.ErrCouldNotCreateDirectory,
+			common.ErrorValueStr(dir),
 			common.ErrorWrapped(err),
 		)
 	}
-	defer file.Close()
 
-	err = printer.FprintAstFile(file, locFile)
+	for i := 0; i < len(files); i++ {
+		file := &files[i]
+
+		err = os.WriteFile(file.Path, file.Data, 0644)
+		if err != nil {
+			return common.NewError(common.ErrCouldNotWriteToFile,
+				common.ErrorValueStr(file.Path),
+				common.ErrorWrapped(err),
+			)
+		}
+	}
+
+	return nil
+}
+
+// Checks whether the code in the output directory is the same as the generated one.
+// Returns unified diff between them, which is empty if they are the same.
+// Files generated earlier that wouldn't be generated now are considered stale
+// and are reported as deleted.
+func CheckGeneratedLocalizations(locs []scope.Localization) (diffs string, err error) {
+	files, err := RenderLocalizations(locs)
 	if err != nil {
-		return common.NewError(common.ErrCouldNotWriteToFile,
-			common.ErrorValueStr(filename),
+		return "", err
+	}
+
+	var b strings.Builder
+
+	generated := make(map[string]struct{})
+
+	for i := 0; i < len(files); i++ {
+		file := &files[i]
+		generated[file.Path] = struct{}{}
+
+		data, err := os.ReadFile(file.Path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", common.NewError(common.ErrCouldNotReadFile,
+				common.ErrorValueStr(file.Path),
+				common.ErrorWrapped(err),
+			)
+		}
+
+		oldName := file.Path
+		if err != nil {
+			oldName = "/dev/null"
+		}
+
+		b.WriteString(diff.Unified(oldName, file.Path, data, file.Data))
+	}
+
+	entries, err := os.ReadDir(common.Config.Output)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", common.NewError(common.ErrCouldNotReadDirectory,
+			common.ErrorValueStr(common.Config.Output),
 			common.ErrorWrapped(err),
 		)
 	}
 
-	return nil
+	for _, entry := range entries {
+		filePath := path.Join(common.Config.Output, entry.Name())
+
+		if _, ok := generated[filePath]; ok || entry.IsDir() || path.Ext(filePath) != ".go" {
+			continue
+		}
+
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return "", common.NewError(common.ErrCouldNotReadFile,
+				common.ErrorValueStr(filePath),
+				common.ErrorWrapped(err),
+			)
+		}
+
+		if bytes.HasPrefix(data, []byte(codegen.GeneratedComment)) {
+			b.WriteString(diff.Unified(filePath, "/dev/null", data, nil))
+		}
+	}
+
+	return b.String(), nil
 }
 
-func GenerateLocalizations(locs []scope.Localization) (err error) {
-	locFiles := codegen.GenerateLocalizations(locs)
+// Exports messages for translators into the output directory.
+// PO format results in PO template made of the base localization
+// and PO file of each of the other localizations,
+// XLIFF format results in XLIFF file of each of the other localizations,
+// ARB format results in ARB file of each of the localizations, the base one included,
+// which is named the way Flutter expects, like app_pt_BR.arb.
+func ExportLocalizations(locs []scope.Localization) (err error) {
+	base := &locs[0]
+
+	var files []GeneratedFile
 
-	err = os.MkdirAll(common.Config.Output, 0755)
+	if common.Config.ExchangeFormat == "arb" {
+		for i := 0; i < len(locs); i++ {
+			loc := &locs[i]
+
+			data, err := exchange.ExportARB(base, loc)
+			if err != nil {
+				return common.NewError(common.ErrCouldNotExport,
+					common.ErrorValueStr(loc.Lang.String()),
+					common.ErrorWrapped(err),
+				)
+			}
+
+			files = append(files, GeneratedFile{
+				Path: path.Join(common.Config.Output, loc.Name+"_"+strings.ReplaceAll(loc.Lang.String(), "-", "_")+".arb"),
+				Data: data,
+			})
+		}
+
+		return writeFiles(common.Config.Output, files)
+	}
+
+	if common.Config.ExchangeFormat == "po" {
+		files = append(files, GeneratedFile{
+			Path: path.Join(common.Config.Output, base.Name+".pot"),
+			Data: exchange.ExportPOT(base, common.Config.Output),
+		})
+	}
+
+	for i := 1; i < len(locs); i++ {
+		loc := &locs[i]
+
+		var (
+			data []byte
+			ext  string
+		)
+
+		switch common.Config.ExchangeFormat {
+		case "po":
+			data, err = exchange.ExportPO(base, loc, common.Config.Output)
+			if err != nil {
+				return common.NewError(common.ErrCouldNotExport,
+					common.ErrorValueStr(loc.Lang.String()),
+					common.ErrorWrapped(err),
+				)
+			}
+			ext = ".po"
+		case "xliff":
+			data = exchange.ExportXLIFF(base, loc, common.Config.Output)
+			ext = ".xlf"
+		}
+
+		files = append(files, GeneratedFile{
+			Path: path.Join(common.Config.Output, loc.Name+"."+loc.Lang.String()+ext),
+			Data: data,
+		})
+	}
+
+	return writeFiles(common.Config.Output, files)
+}
+
+// Imports translated files from the input directory into localization files.
+// Nothing is written if any of the translations are invalid.
+func ImportLocalizations(files []LocalizationFile, locs []scope.Localization) (err error) {
+	entries, err := os.ReadDir(common.Config.Input)
 	if err != nil {
-		return common.NewError(common.ErrCouldNotCreateDirectory,
-			common.ErrorValueStr(common.Config.Output),
+		return common.NewError(common.ErrCouldNotReadDirectory,
+			common.ErrorValueStr(common.Config.Input),
 			common.ErrorWrapped(err),
 		)
 	}
 
-	filenames := []string{path.Join(common.Config.Output, "l10n.go")}
+	var errs common.ErrorList
 
-	for i := 1; i < len(locFiles); i++ {
-		filename := locs[i-1].Name + "_" + locs[i-1].Lang.String() + ".go"
-		filenames = append(filenames, path.Join(common.Config.Output, filename))
+	// Languages in the order their files are read
+	var langs []language.Tag
+	// Translated segments of each language
+	langSegs := make(map[string]map[exchange.SegmentID]exchange.Segment)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		var importFile func(filename string, data []byte, locs []scope.Localization) (language.Tag, []exchange.Segment, error)
+
+		switch ext := path.Ext(entry.Name()); {
+		case common.Config.ExchangeFormat == "po" && ext == ".po":
+			importFile = exchange.ImportPO
+		case common.Config.ExchangeFormat == "xliff" && (ext == ".xlf" || ext == ".xliff"):
+			importFile = exchange.ImportXLIFF
+		default:
+			continue
+		}
+
+		filePath := path.Join(common.Config.Input, entry.Name())
+
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			errs.Add(common.NewError(common.ErrCouldNotReadFile,
+				common.ErrorValueStr(entry.Name()),
+				common.ErrorLocation{File: filePath},
+				common.ErrorWrapped(err),
+			))
+			continue
+		}
+
+		lang, segs, err := importFile(filePath, data, locs)
+		errs.Add(err)
+
+		if lang == language.Und {
+			continue
+		}
+
+		if _, ok := langSegs[lang.String()]; !ok {
+			langs = append(langs, lang)
+			langSegs[lang.String()] = make(map[exchange.SegmentID]exchange.Segment)
+		}
+
+		for _, seg := range segs {
+			langSegs[lang.String()][seg.SegmentID] = seg
+		}
 	}
 
-	for i, locFile := range locFiles {
-		err = generateFile(locFile, filenames[i])
+	if err := errs.Err(); err != nil {
+		return err
+	}
+
+	var changed []GeneratedFile
+
+	for _, lang := range langs {
+		langFiles, err := mergeTranslations(files, locs, lang, langSegs[lang.String()])
+		errs.Add(err)
+		changed = append(changed, langFiles...)
+	}
+
+	if err := errs.Err(); err != nil {
+		return err
+	}
+
+	return writeFiles(common.Config.Directory, changed)
+}
+
+// Localization file along with its contents that can be changed.
+type localizationTree struct {
+	File    LocalizationFile
+	Root    *parse.Node
+	Encoder parse.Encoder
+	Changed bool
+}
+
+// Merges translated segments into the files of the language.
+// Messages are merged into the files they are in,
+// new ones are added to the first file of the language.
+// If the language has no files, a new file is created next to the ones of the base language.
+// Returns the files that have been changed.
+func mergeTranslations(
+	files []LocalizationFile,
+	locs []scope.Localization,
+	lang language.Tag,
+	segs map[exchange.SegmentID]exchange.Segment,
+) (changed []GeneratedFile, err error) {
+	var (
+		trees    []localizationTree
+		baseFile *LocalizationFile
+	)
+
+	for i := 0; i < len(files); i++ {
+		file := &files[i]
+
+		if file.Lang.String() == locs[0].Lang.String() && baseFile == nil {
+			baseFile = file
+		}
+
+		if file.Lang.String() != lang.String() {
+			continue
+		}
+
+		decoder, encoder, err := getCodec(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if encoder == nil {
+			return nil, common.NewError(common.ErrCouldNotImport,
+				common.ErrorValueStr(file.Filename),
+				common.ErrorLocation{File: file.Path},
+				common.ErrorWrapped(common.ErrReadOnlyFileFormat),
+			)
+		}
+
+		data, err := os.ReadFile(file.Path)
 		if err != nil {
-			return err
+			return nil, common.NewError(common.ErrCouldNotReadFile,
+				common.ErrorValueStr(file.Filename),
+				common.ErrorLocation{File: file.Path},
+				common.ErrorWrapped(err),
+			)
+		}
+
+		root, err := decoder(data)
+		if err != nil {
+			return nil, common.NewError(common.ErrCouldNotUnmarshalFile,
+				common.ErrorValueStr(file.Filename),
+				common.ErrorLocation{File: file.Path},
+				common.ErrorWrapped(err),
+			)
 		}
+
+		trees = append(trees, localizationTree{
+			File:    *file,
+			Root:    root,
+			Encoder: encoder,
+		})
 	}
 
-	return nil
+	if len(trees) == 0 {
+		file := LocalizationFile{
+			Name: baseFile.Name,
+			Lang: lang,
+			Ext:  baseFile.Ext,
+		}
+		file.Filename = file.Name + "." + lang.String() + "." + file.Ext
+		file.Path = path.Join(common.Config.Directory, file.Filename)
+
+		_, encoder, err := getCodec(&file)
+		if err != nil {
+			return nil, err
+		}
+
+		if encoder == nil {
+			return nil, common.NewError(common.ErrCouldNotImport,
+				common.ErrorValueStr(baseFile.Filename),
+				common.ErrorLocation{File: baseFile.Path},
+				common.ErrorWrapped(common.ErrReadOnlyFileFormat),
+			)
+		}
+
+		trees = append(trees, localizationTree{
+			File:    file,
+			Root:    parse.NewTableNode(),
+			Encoder: encoder,
+		})
+	}
+
+	var errs common.ErrorList
+
+	for i := 0; i < len(locs[0].Scopes); i++ {
+		baseMs := &locs[0].Scopes[i]
+
+		translated := false
+		for _, seg := range exchange.Segments(baseMs, lang) {
+			if _, ok := segs[seg.SegmentID]; ok {
+				translated = true
+				break
+			}
+		}
+
+		if !translated {
+			continue
+		}
+
+		tree := &trees[0]
+		for j := 0; j < len(trees); j++ {
+			if parse.FindMessage(trees[j].Root, baseMs.Name) != nil {
+				tree = &trees[j]
+				break
+			}
+		}
+
+		err = exchange.MergeMessage(tree.Root, baseMs, lang, segs)
+		if err != nil {
+			errs.Add(common.NewError(common.ErrCouldNotImport,
+				common.ErrorValueStr(tree.File.Filename),
+				common.ErrorLocation{File: tree.File.Path},
+				common.ErrorWrapped(err),
+			))
+			continue
+		}
+
+		tree.Changed = true
+	}
+
+	for i := 0; i < len(trees); i++ {
+		tree := &trees[i]
+
+		if !tree.Changed {
+			continue
+		}
+
+		data, err := tree.Encoder(tree.Root)
+		if err != nil {
+			errs.Add(common.NewError(common.ErrCouldNotMarshal,
+				common.ErrorValueStr(tree.File.Filename),
+				common.ErrorWrapped(err),
+			))
+			continue
+		}
+
+		changed = append(changed, GeneratedFile{
+			Path: tree.File.Path,
+			Data: data,
+		})
+	}
+
+	return changed, errs.Err()
 }
 
 func main() {
 	common.InitConfig()
 
+	errs := common.ErrorList{Max: common.Config.MaxErrors}
+
 	locFiles, err := GetLocalizationFiles()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return
-	}
+	errs.Add(err)
 
 	locs, err := ReadLocalizationFiles(locFiles)
+	errs.Add(err)
+
+	// Localizations can only be checked against the base one
+	err = SelectBaseLocalization(locs)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return
+		errs.Add(err)
+	} else {
+		errs.Add(CheckLocalizations(locs))
 	}
 
-	err = CheckLocalizations(locs)
-	if err != nil {
+	if err := errs.Err(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		return
+		os.Exit(1)
+	}
+
+	switch common.Config.Command {
+	case "check":
+		ReportFallbacks(locs)
+
+		diffs, err := CheckGeneratedLocalizations(locs)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, common.FormatError(err))
+			os.Exit(1)
+		}
+
+		if diffs != "" {
+			fmt.Print(diffs)
+			fmt.Fprintln(os.Stderr, common.ErrGeneratedCodeOutOfDate)
+			os.Exit(1)
+		}
+	case "export":
+		err = ExportLocalizations(locs)
+	case "import":
+		err = ImportLocalizations(locFiles, locs)
+	default:
+		ReportFallbacks(locs)
+		err = GenerateLocalizations(locs)
 	}
 
-	err = GenerateLocalizations(locs)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return
+		fmt.Fprintln(os.Stderr, common.FormatError(err))
+		os.Exit(1)
 	}
 }
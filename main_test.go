@@ -0,0 +1,805 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/infastin/go-l10n/common"
+	"github.com/infastin/go-l10n/scope"
+	"golang.org/x/text/language"
+)
+
+// Localization file written in YAML.
+type testFile struct {
+	lang string
+	data string
+}
+
+// Writes localization files to a temporary directory and reads them.
+// The first file is the one of the base language.
+func readTestLocalizations(t *testing.T, files ...testFile) (locs []scope.Localization, err error) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	var locFiles []LocalizationFile
+
+	for _, file := range files {
+		filename := "loc." + file.lang + ".yaml"
+		filePath := path.Join(dir, filename)
+
+		if err := os.WriteFile(filePath, []byte(file.data), 0o644); err != nil {
+			t.Fatalf("could not write %s: %v", filename, err)
+		}
+
+		locFiles = append(locFiles, LocalizationFile{
+			Path:     filePath,
+			Filename: filename,
+			Name:     "loc",
+			Lang:     language.MustParse(file.lang),
+			Ext:      "yaml",
+		})
+	}
+
+	return ReadLocalizationFiles(locFiles)
+}
+
+// Returns kinds of all errors in the chain of the error,
+// including the ones of error lists.
+func errorKinds(err error) (kinds []error) {
+	if err == nil {
+		return nil
+	}
+
+	if list, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range list.Unwrap() {
+			kinds = append(kinds, errorKinds(err)...)
+		}
+		return kinds
+	}
+
+	if e, ok := err.(*common.Error); ok {
+		kinds = append(kinds, e.ErrKind)
+	}
+
+	return append(kinds, errorKinds(errors.Unwrap(err))...)
+}
+
+// Returns YAML of Files message, which is a plural with the given forms.
+func pluralYAML(forms ...string) string {
+	var b strings.Builder
+
+	b.WriteString("Files:\n  plural:\n    arg: n\n")
+	for _, form := range forms {
+		b.WriteString("    " + form + `: "${n} files"` + "\n")
+	}
+
+	return b.String()
+}
+
+func TestPluralForms(t *testing.T) {
+	tests := []struct {
+		name  string
+		lang  string
+		forms []string
+		err   error
+	}{
+		{
+			name:  "english",
+			lang:  "en",
+			forms: []string{"one", "other"},
+		},
+		{
+			name:  "english without other",
+			lang:  "en",
+			forms: []string{"one"},
+			err:   common.ErrPluralFormRequired,
+		},
+		{
+			name:  "zero is always allowed",
+			lang:  "en",
+			forms: []string{"zero", "one", "other"},
+		},
+		{
+			name:  "english with few",
+			lang:  "en",
+			forms: []string{"one", "few", "other"},
+			err:   common.ErrPluralFormNotUsed,
+		},
+		{
+			name:  "russian",
+			lang:  "ru",
+			forms: []string{"one", "few", "many"},
+		},
+		{
+			name:  "other stands for the rest of the forms",
+			lang:  "ru",
+			forms: []string{"one", "other"},
+		},
+		{
+			name:  "russian without many",
+			lang:  "ru",
+			forms: []string{"one", "few"},
+			err:   common.ErrPluralFormRequired,
+		},
+		{
+			name:  "russian with two",
+			lang:  "ru",
+			forms: []string{"one", "two", "few", "many"},
+			err:   common.ErrPluralFormNotUsed,
+		},
+		{
+			name:  "polish",
+			lang:  "pl",
+			forms: []string{"one", "few", "many"},
+		},
+		{
+			name:  "czech",
+			lang:  "cs",
+			forms: []string{"one", "few", "other"},
+		},
+		{
+			// Czech uses "many" only for fractions
+			name:  "czech with many",
+			lang:  "cs",
+			forms: []string{"one", "few", "many", "other"},
+			err:   common.ErrPluralFormNotUsed,
+		},
+		{
+			name:  "arabic",
+			lang:  "ar",
+			forms: []string{"zero", "one", "two", "few", "many", "other"},
+		},
+		{
+			name:  "arabic without other",
+			lang:  "ar",
+			forms: []string{"zero", "one", "two", "few", "many"},
+			err:   common.ErrPluralFormRequired,
+		},
+		{
+			name:  "japanese",
+			lang:  "ja",
+			forms: []string{"other"},
+		},
+		{
+			name:  "japanese with one",
+			lang:  "ja",
+			forms: []string{"one", "other"},
+			err:   common.ErrPluralFormNotUsed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := []testFile{{lang: tt.lang, data: pluralYAML(tt.forms...)}}
+			if tt.lang != "en" {
+				files = slices.Insert(files, 0, testFile{lang: "en", data: pluralYAML("one", "other")})
+			}
+
+			locs, err := readTestLocalizations(t, files...)
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			} else if !slices.Contains(errorKinds(err), tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+
+			// Invalid message is neither reported as missing nor taken from the base localization
+			if err := CheckLocalizations(locs); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			loc := &locs[len(locs)-1]
+			if tt.err != nil && len(loc.Scopes) != 0 {
+				t.Errorf("got messages of invalid localization: %v", loc.Scopes)
+			}
+		})
+	}
+}
+
+func TestAlignArguments(t *testing.T) {
+	tests := []struct {
+		name        string
+		base        string
+		translation string
+		want        []string
+		err         error
+	}{
+		{
+			name:        "same order",
+			base:        "${from} → ${to}",
+			translation: "${from} → ${to}",
+			want:        []string{"from", "to"},
+		},
+		{
+			name:        "reordered",
+			base:        "${from} → ${to}",
+			translation: "${to} ← ${from}",
+			want:        []string{"from", "to"},
+		},
+		{
+			name:        "reordered with types",
+			base:        "${d:n} files of ${owner} weigh ${f:size}",
+			translation: "${f:size} весят ${d:n} файлов ${owner}",
+			want:        []string{"n", "owner", "size"},
+		},
+		{
+			name:        "types don't match",
+			base:        "${d:n} files",
+			translation: "${n} файлов",
+			err:         common.ErrArgumentTypesDontMatch,
+		},
+		{
+			name:        "unknown argument",
+			base:        "${from} → ${to}",
+			translation: "${from} → ${to} через ${via}",
+			err:         common.ErrUnknownArgument,
+		},
+		{
+			name:        "missing argument",
+			base:        "${from} → ${to}",
+			translation: "→ ${to}",
+			err:         common.ErrMissingArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locs, err := readTestLocalizations(t,
+				testFile{lang: "en", data: "Route: " + strconv.Quote(tt.base)},
+				testFile{lang: "ru", data: "Route: " + strconv.Quote(tt.translation)},
+			)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			baseMs, ms := &locs[0].Scopes[0], &locs[1].Scopes[0]
+
+			err = alignArguments(baseMs, ms)
+			if tt.err != nil {
+				if !slices.Contains(errorKinds(err), tt.err) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+
+				var mismatch *common.ArgumentsMismatchError
+				if err := CheckLocalizations(locs); !errors.As(err, &mismatch) || mismatch.Message != "Route" {
+					t.Errorf("got error %v, want arguments mismatch of Route", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for i := 0; i < len(ms.Arguments); i++ {
+				got = append(got, ms.Arguments[i].Name)
+
+				if ms.Arguments[i].GoType != baseMs.Arguments[i].GoType {
+					t.Errorf("argument %s: got type %v, want %v",
+						ms.Arguments[i].Name, ms.Arguments[i].GoType, baseMs.Arguments[i].GoType)
+				}
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got arguments %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectBaseLocalization(t *testing.T) {
+	config := common.Config
+	t.Cleanup(func() { common.Config = config })
+
+	tests := []struct {
+		name string
+		// Languages of the files
+		langs []string
+		// Base language specified with --base flag
+		base string
+		want string
+		err  error
+	}{
+		{
+			name:  "english is present",
+			langs: []string{"de", "en", "fr"},
+			want:  "en",
+		},
+		{
+			name:  "first language alphabetically",
+			langs: []string{"ru", "de", "fr"},
+			want:  "de",
+		},
+		{
+			// Regions go after the languages they belong to
+			name:  "first language with region",
+			langs: []string{"pt_BR", "pt"},
+			want:  "pt",
+		},
+		{
+			name:  "explicit base",
+			langs: []string{"de", "en", "fr"},
+			base:  "fr",
+			want:  "fr",
+		},
+		{
+			name:  "explicit base is missing",
+			langs: []string{"de", "en", "fr"},
+			base:  "ja",
+			err:   common.ErrBaseNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, lang := range tt.langs {
+				if err := os.WriteFile(path.Join(dir, "loc."+lang+".yaml"), []byte("Hello: Hello\n"), 0o644); err != nil {
+					t.Fatalf("could not write file of %s: %v", lang, err)
+				}
+			}
+
+			common.Config.Directory = dir
+			common.Config.Pattern = *regexp.MustCompile(common.DefaultPattern)
+			common.Config.Base = language.Und
+			if tt.base != "" {
+				common.Config.Base = language.MustParse(tt.base)
+			}
+
+			files, err := GetLocalizationFiles()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			locs, err := ReadLocalizationFiles(files)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = SelectBaseLocalization(locs)
+			if tt.err != nil {
+				if !slices.Contains(errorKinds(err), tt.err) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := locs[0].Lang.String(); got != tt.want || common.Config.Base != locs[0].Lang {
+				t.Errorf("got base %s, want %s", got, tt.want)
+			}
+
+			// The rest of the localizations keep their order
+			var got, want []string
+			for i := 1; i < len(locs); i++ {
+				got = append(got, locs[i].Lang.String())
+			}
+			for _, file := range files {
+				if lang := file.Lang.String(); lang != tt.want {
+					want = append(want, lang)
+				}
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("got localizations %v after the base one, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFindFallback(t *testing.T) {
+	files := []testFile{
+		{lang: "en", data: "A: a\nB: b\nC: c\nD: d\n"},
+		{lang: "pt", data: "A: a\nB: b\n"},
+		{lang: "pt-BR", data: "A: a\n"},
+		{lang: "de", data: "A: a\nC: c\n"},
+		{lang: "fr", data: "A: a\n"},
+	}
+
+	tests := []struct {
+		name     string
+		fallback string
+		lang     string
+		message  string
+		want     string
+	}{
+		{
+			name:    "parent language",
+			lang:    "pt-BR",
+			message: "B",
+			want:    "pt",
+		},
+		{
+			name:    "base language after parent",
+			lang:    "pt-BR",
+			message: "C",
+			want:    "en",
+		},
+		{
+			name:     "fallback language after parent",
+			fallback: "de",
+			lang:     "pt-BR",
+			message:  "C",
+			want:     "de",
+		},
+		{
+			name:     "parent language before fallback",
+			fallback: "de",
+			lang:     "pt-BR",
+			message:  "B",
+			want:     "pt",
+		},
+		{
+			name:     "fallback language",
+			fallback: "de",
+			lang:     "fr",
+			message:  "C",
+			want:     "de",
+		},
+		{
+			name:     "base language if fallback lacks message",
+			fallback: "de",
+			lang:     "fr",
+			message:  "D",
+			want:     "en",
+		},
+		{
+			name:    "base language",
+			lang:    "fr",
+			message: "B",
+			want:    "en",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallback := common.Config.Fallback
+			t.Cleanup(func() { common.Config.Fallback = fallback })
+
+			common.Config.Fallback = language.Und
+			if tt.fallback != "" {
+				common.Config.Fallback = language.MustParse(tt.fallback)
+			}
+
+			locs, err := readTestLocalizations(t, files...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := CheckLocalizations(locs); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			loc := &locs[scope.LocalizationIndex(locs, language.MustParse(tt.lang))]
+
+			idx := slices.IndexFunc(loc.Scopes, func(ms scope.MessageScope) bool { return ms.Name == tt.message })
+			if idx == -1 || loc.Scopes[idx].Fallback == nil {
+				t.Fatalf("message %s doesn't fall back", tt.message)
+			}
+
+			ms := &loc.Scopes[idx]
+
+			if got := ms.Fallback.Lang.String(); got != tt.want {
+				t.Errorf("got fallback %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckLocalizationsStrict(t *testing.T) {
+	strict := common.Config.Strict
+	t.Cleanup(func() { common.Config.Strict = strict })
+
+	common.Config.Strict = true
+
+	locs, err := readTestLocalizations(t,
+		testFile{lang: "en", data: "A: a\nB: b\n"},
+		testFile{lang: "ru", data: "A: а\n"},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var missing *common.MessageNotSpecifiedError
+	if err := CheckLocalizations(locs); !errors.As(err, &missing) || missing.Message != "B" {
+		t.Errorf("got error %v, want B to be missing", err)
+	}
+}
+
+func TestCheckIdentifiers(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		// Identifiers that are reported to collide
+		want []string
+	}{
+		{
+			name: "no collisions",
+			yaml: "Auth:\n  Login:\n    Title: a\n  Logout:\n    Title: b\nTitle: c\n",
+		},
+		{
+			name: "namespaces",
+			yaml: "Auth:\n  Login:\n    Title: a\nAuth_Login:\n  Subtitle: b\n",
+			want: []string{"Localizer_Auth_Login"},
+		},
+		{
+			name: "messages",
+			yaml: "Auth:\n  Title: a\nAuth_Title: b\n",
+			want: []string{"MessageID_Auth_Title"},
+		},
+		{
+			// Namespaces and messages collide once,
+			// even though they are made into several identifiers
+			name: "namespaces and messages",
+			yaml: "Auth:\n  Login:\n    Title: a\nAuth_Login:\n  Title: b\n",
+			want: []string{"Localizer_Auth_Login", "MessageID_Auth_Login_Title"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Returns identifiers of the collisions that the error reports
+			collisions := func(err error) (idents []string) {
+				for _, err := range common.Errors(err) {
+					var collision *common.IdentifierCollisionError
+					if !errors.As(err, &collision) {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					idents = append(idents, collision.Identifier)
+				}
+				return idents
+			}
+
+			// Collisions are reported when the files are read too
+			locs, err := readTestLocalizations(t, testFile{lang: "en", data: tt.yaml})
+			if got := collisions(err); !slices.Equal(got, tt.want) {
+				t.Fatalf("got error %v, want collisions of %v", err, tt.want)
+			}
+
+			if got := collisions(checkIdentifiers(&locs[0])); !slices.Equal(got, tt.want) {
+				t.Errorf("got collisions of %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLanguageIdentifiers(t *testing.T) {
+	config := common.Config
+	t.Cleanup(func() { common.Config = config })
+
+	common.Config.PackageName = "l10n"
+	common.Config.Output = "l10n"
+
+	tests := []struct {
+		name string
+		// Languages as they are written in the names of the files
+		langs  []string
+		export bool
+		// Names of the generated files of the localizations
+		files []string
+		// Names of the localizer types
+		types []string
+	}{
+		{
+			name:  "region",
+			langs: []string{"en", "pt", "pt_br", "pt-PT"},
+			files: []string{"loc_en.go", "loc_pt.go", "loc_pt_br.go", "loc_pt_pt.go"},
+			types: []string{"en_Localizer", "pt_Localizer", "pt_BR_Localizer", "pt_PT_Localizer"},
+		},
+		{
+			name:   "exported region",
+			langs:  []string{"en", "pt", "pt_br", "pt-PT"},
+			export: true,
+			files:  []string{"loc_en.go", "loc_pt.go", "loc_pt_br.go", "loc_pt_pt.go"},
+			types:  []string{"En", "Pt", "PtBR", "PtPT"},
+		},
+		{
+			name:  "script",
+			langs: []string{"en", "zh", "zh-Hant", "zh_hant_tw"},
+			files: []string{"loc_en.go", "loc_zh.go", "loc_zh_hant.go", "loc_zh_hant_tw.go"},
+			types: []string{"en_Localizer", "zh_Localizer", "zh_Hant_Localizer", "zh_Hant_TW_Localizer"},
+		},
+		{
+			name:   "exported script",
+			langs:  []string{"en", "zh", "zh-Hant", "zh_hant_tw"},
+			export: true,
+			files:  []string{"loc_en.go", "loc_zh.go", "loc_zh_hant.go", "loc_zh_hant_tw.go"},
+			types:  []string{"En", "Zh", "ZhHant", "ZhHantTW"},
+		},
+		{
+			name:   "numeric region",
+			langs:  []string{"en", "es", "es-419"},
+			export: true,
+			files:  []string{"loc_en.go", "loc_es.go", "loc_es_419.go"},
+			types:  []string{"En", "Es", "Es419"},
+		},
+		{
+			// Newari collides with New function of the package
+			name:   "reserved name",
+			langs:  []string{"en", "new"},
+			export: true,
+			files:  []string{"loc_en.go", "loc_new.go"},
+			types:  []string{"En", "New_"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Config.ExportTypes = tt.export
+
+			var files []testFile
+			for _, lang := range tt.langs {
+				// Types of BR namespace of pt are named like pt-BR localizer
+				files = append(files, testFile{lang: lang, data: "Hello: Hello\nBR:\n  Hello: Hello\n"})
+			}
+
+			locs, err := readTestLocalizations(t, files...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := CheckLocalizations(locs); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var gotFiles []string
+			for i := 0; i < len(locs); i++ {
+				gotFiles = append(gotFiles, getLocalizationFilename(&locs[i]))
+			}
+			if !slices.Equal(gotFiles, tt.files) {
+				t.Errorf("got files %v, want %v", gotFiles, tt.files)
+			}
+
+			generated, err := RenderLocalizations(locs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Identifiers declared by the generated files,
+			// which collide if any of them is declared twice
+			declared := make(map[string]string)
+
+			for _, file := range generated {
+				f, err := parser.ParseFile(token.NewFileSet(), file.Path, file.Data, parser.SkipObjectResolution)
+				if err != nil {
+					t.Fatalf("could not parse %s: %v", file.Path, err)
+				}
+
+				for _, decl := range f.Decls {
+					for _, name := range declaredNames(decl) {
+						if other, ok := declared[name]; ok {
+							t.Errorf("%s is declared in both %s and %s", name, other, file.Path)
+						}
+						declared[name] = file.Path
+					}
+				}
+			}
+
+			for i, typ := range tt.types {
+				want := path.Join("l10n", tt.files[i])
+				if got, ok := declared[typ]; !ok || got != want {
+					t.Errorf("type %s is declared in %q, want %q", typ, got, want)
+				}
+			}
+		})
+	}
+}
+
+// Returns names of the package level identifiers that the declaration declares.
+// Methods are not declared at the package level.
+func declaredNames(decl goast.Decl) (names []string) {
+	switch decl := decl.(type) {
+	case *goast.FuncDecl:
+		if decl.Recv == nil && decl.Name.Name != "init" {
+			names = append(names, decl.Name.Name)
+		}
+	case *goast.GenDecl:
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *goast.TypeSpec:
+				names = append(names, spec.Name.Name)
+			case *goast.ValueSpec:
+				for _, name := range spec.Names {
+					if name.Name != "_" {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// Runs main with the arguments given after "--",
+// when the test binary is started by TestMainErrors.
+func TestMain(m *testing.M) {
+	if os.Getenv("GO_L10N_RUN_MAIN") == "1" {
+		flag.Parse()
+		os.Args = append([]string{"go-l10n"}, flag.Args()...)
+		main()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestMainErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"loc.en.yaml": "Hello: \"Hello, ${name\"\nBye:\n  string: Bye\n  strong: Bye\n",
+		"loc.ru.yaml": "Hello: \"Привет, $x\"\n",
+		"loc.yaml":    "Hello: Hello\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(path.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatalf("could not write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		maxErrors int
+		// Errors one per line, with paths relative to the directory
+		want string
+	}{
+		{
+			// Errors of all files are reported, sorted by file and location
+			name: "all errors",
+			want: `loc.en.yaml:1:22: could not unmarshal file "loc.en.yaml": could not unmarshal Hello: no closing bracket
+loc.en.yaml:4:3: could not unmarshal file "loc.en.yaml": could not unmarshal Bye.strong: unknown field, expected any of "arguments", "variables", "plural", "string", "syntax"
+loc.ru.yaml:1:18: could not unmarshal file "loc.ru.yaml": could not unmarshal Hello: unexpected char 'x', expected any of '$', '{'
+loc.yaml: invalid filename "loc.yaml": filename doesn't match the pattern
+`,
+		},
+		{
+			// The first errors found are reported
+			name:      "max errors",
+			maxErrors: 2,
+			want: `loc.en.yaml:1:22: could not unmarshal file "loc.en.yaml": could not unmarshal Hello: no closing bracket
+loc.yaml: invalid filename "loc.yaml": filename doesn't match the pattern
+too many errors
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := []string{"-test.run=^TestMainErrors$", "--", "-d", dir, "-o", dir}
+			if tt.maxErrors != 0 {
+				args = append(args, "--max-errors", strconv.Itoa(tt.maxErrors))
+			}
+
+			var stderr strings.Builder
+
+			cmd := exec.Command(os.Args[0], args...)
+			cmd.Env = append(os.Environ(), "GO_L10N_RUN_MAIN=1")
+			cmd.Dir = dir
+			cmd.Stderr = &stderr
+
+			err := cmd.Run()
+
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+				t.Fatalf("got exit error %v, want exit status 1", err)
+			}
+
+			if got := strings.ReplaceAll(stderr.String(), dir+"/", ""); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
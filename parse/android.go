@@ -0,0 +1,229 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Namespace of <xliff:g> elements that Android uses to mark text that must not be translated.
+const xliffNamespace = "urn:oasis:names:tc:xliff:document:1.2"
+
+// Decodes Android string resources.
+// Each <string> element becomes a string,
+// and each <plurals> element becomes a table of strings of its items keyed by their quantities.
+// Other resources are skipped.
+func DecodeAndroidStrings(data []byte) (node *Node, err error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	node = &Node{Kind: TableNode, Offset: -1}
+
+	for {
+		offset := int(dec.InputOffset())
+
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		var value *Node
+
+		switch start.Name.Local {
+		case "resources":
+			// Resources are contained within it
+			continue
+		case "string":
+			value, err = androidString(dec, start)
+		case "plurals":
+			value, err = androidPlurals(dec)
+		default:
+			err = dec.Skip()
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if value != nil {
+			node.Table = append(node.Table, NodeEntry{
+				Key:       xmlAttr(start, "name"),
+				KeyOffset: offset,
+				Value:     value,
+			})
+		}
+	}
+
+	return node, nil
+}
+
+// Decodes items of <plurals> element.
+func androidPlurals(dec *xml.Decoder) (node *Node, err error) {
+	node = &Node{Kind: TableNode, Offset: int(dec.InputOffset())}
+
+	for {
+		offset := int(dec.InputOffset())
+
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if tok.Name.Local != "item" {
+				if err = dec.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			value, err := androidString(dec, tok)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Table = append(node.Table, NodeEntry{
+				Key:       xmlAttr(tok, "quantity"),
+				KeyOffset: offset,
+				Value:     value,
+			})
+		case xml.EndElement:
+			return node, nil
+		}
+	}
+}
+
+// Decodes contents of <string> or <item> element.
+// Markup is kept as it is, except for <xliff:g> elements, whose contents are kept.
+// Strings that are not formatted have their percent signs escaped.
+func androidString(dec *xml.Decoder, start xml.StartElement) (node *Node, err error) {
+	node = &Node{
+		Kind:   StringNode,
+		Offset: int(dec.InputOffset()),
+		Style:  StringStyle{Escapes: true},
+	}
+
+	var b strings.Builder
+
+	for depth := 0; ; {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.CharData:
+			b.Write(tok)
+		case xml.StartElement:
+			depth++
+			if tok.Name.Space != xliffNamespace {
+				b.WriteString(xmlStartTag(tok))
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				node.Str = unescapeAndroidString(b.String())
+				if xmlAttr(start, "formatted") == "false" {
+					node.Str = strings.ReplaceAll(node.Str, "%", "%%")
+				}
+				return node, nil
+			}
+
+			depth--
+			if tok.Name.Space != xliffNamespace {
+				b.WriteString("</" + tok.Name.Local + ">")
+			}
+		}
+	}
+}
+
+// Resolves escapes and quotes of Android string resource.
+// Whitespace outside of double quotes is collapsed into a single space
+// and trimmed at the beginning and the end of the string.
+func unescapeAndroidString(str string) string {
+	var b strings.Builder
+
+	quoted := false
+	space := false
+
+	runes := []rune(str)
+
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+
+		switch {
+		case c == '"':
+			quoted = !quoted
+			continue
+		case !quoted && unicode.IsSpace(c):
+			space = b.Len() != 0
+			continue
+		}
+
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+
+		if c != '\\' || i+1 == len(runes) {
+			b.WriteRune(c)
+			continue
+		}
+
+		i++
+
+		switch runes[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if i+4 < len(runes) {
+				if code, err := strconv.ParseUint(string(runes[i+1:i+5]), 16, 32); err == nil {
+					b.WriteRune(rune(code))
+					i += 4
+					break
+				}
+			}
+			b.WriteRune(runes[i])
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+
+	return b.String()
+}
+
+// Returns value of the attribute of the element or an empty string if it doesn't have one.
+func xmlAttr(start xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// Returns the start tag the way it could be written in the file.
+func xmlStartTag(start xml.StartElement) string {
+	var b strings.Builder
+
+	b.WriteString("<" + start.Name.Local)
+	for _, attr := range start.Attr {
+		b.WriteString(" " + attr.Name.Local + `="`)
+		xml.EscapeText(&b, []byte(attr.Value))
+		b.WriteByte('"')
+	}
+	b.WriteByte('>')
+
+	return b.String()
+}
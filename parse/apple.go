@@ -0,0 +1,321 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/infastin/go-l10n/common"
+)
+
+// Error of a decoder along with the offset it has occurred at.
+type decodeError struct {
+	Offset int
+	Err    error
+}
+
+func (e *decodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *decodeError) Unwrap() error {
+	return e.Err
+}
+
+// Decodes Apple .strings file, which consists of "key" = "value"; pairs.
+func DecodeAppleStrings(data []byte) (node *Node, err error) {
+	d := &stringsDecoder{data: data}
+	node = &Node{Kind: TableNode, Offset: -1}
+
+	for {
+		if err = d.skipSpaces(); err != nil {
+			return nil, err
+		}
+
+		if d.pos == len(d.data) {
+			return node, nil
+		}
+
+		key, err := d.decodeString()
+		if err != nil {
+			return nil, err
+		}
+
+		if err = d.expect('='); err != nil {
+			return nil, err
+		}
+
+		if err = d.skipSpaces(); err != nil {
+			return nil, err
+		}
+
+		if d.pos == len(d.data) || d.data[d.pos] != '"' {
+			return nil, d.unexpected(common.ErrorExpectedChar('"'))
+		}
+
+		value, err := d.decodeString()
+		if err != nil {
+			return nil, err
+		}
+
+		if err = d.expect(';'); err != nil {
+			return nil, err
+		}
+
+		node.Table = append(node.Table, NodeEntry{
+			Key:       key.Str,
+			KeyOffset: key.Offset,
+			Value:     value,
+		})
+	}
+}
+
+type stringsDecoder struct {
+	data []byte
+	pos  int
+}
+
+// Skips whitespace and comments.
+func (d *stringsDecoder) skipSpaces() (err error) {
+	for d.pos < len(d.data) {
+		switch {
+		case strings.IndexByte(" \t\r\n", d.data[d.pos]) != -1:
+			d.pos++
+		case bytes.HasPrefix(d.data[d.pos:], []byte("//")):
+			end := bytes.IndexByte(d.data[d.pos:], '\n')
+			if end == -1 {
+				d.pos = len(d.data)
+			} else {
+				d.pos += end + 1
+			}
+		case bytes.HasPrefix(d.data[d.pos:], []byte("/*")):
+			end := bytes.Index(d.data[d.pos+2:], []byte("*/"))
+			if end == -1 {
+				return &decodeError{Offset: d.pos, Err: common.ErrUnexpectedEndOfFormat}
+			}
+			d.pos += end + 4
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// Skips whitespace and the character.
+func (d *stringsDecoder) expect(c byte) (err error) {
+	if err = d.skipSpaces(); err != nil {
+		return err
+	}
+
+	if d.pos == len(d.data) || d.data[d.pos] != c {
+		return d.unexpected(common.ErrorExpectedChar(rune(c)))
+	}
+
+	d.pos++
+
+	return nil
+}
+
+func (d *stringsDecoder) unexpected(expected common.ErrorExpected) error {
+	if d.pos == len(d.data) {
+		return &decodeError{Offset: d.pos, Err: common.NewError(common.ErrUnexpectedEndOfFormat, expected)}
+	}
+
+	c, _ := utf8.DecodeRune(d.data[d.pos:])
+
+	return &decodeError{
+		Offset: d.pos,
+		Err:    common.NewError(common.ErrUnexpectedChar, common.ErrorValueChar(c), expected),
+	}
+}
+
+// Decodes either quoted string or unquoted one, which can only be a key.
+func (d *stringsDecoder) decodeString() (node *Node, err error) {
+	node = &Node{Kind: StringNode, Offset: d.pos}
+
+	if d.data[d.pos] != '"' {
+		for d.pos < len(d.data) && isUnquotedStringChar(d.data[d.pos]) {
+			d.pos++
+		}
+
+		if d.pos == node.Offset {
+			return nil, d.unexpected(common.ErrorExpectedChar('"'))
+		}
+
+		node.Str = string(d.data[node.Offset:d.pos])
+
+		return node, nil
+	}
+
+	node.Style = StringStyle{Quote: `"`, Escapes: true}
+	d.pos++
+
+	var b strings.Builder
+
+	for {
+		if d.pos == len(d.data) {
+			return nil, &decodeError{
+				Offset: node.Offset,
+				Err:    common.NewError(common.ErrUnexpectedEndOfFormat, common.ErrorExpectedChar('"')),
+			}
+		}
+
+		c := d.data[d.pos]
+		d.pos++
+
+		switch {
+		case c == '"':
+			node.Str = b.String()
+			return node, nil
+		case c != '\\' || d.pos == len(d.data):
+			b.WriteByte(c)
+			continue
+		}
+
+		c = d.data[d.pos]
+		d.pos++
+
+		switch c {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '0':
+			b.WriteByte(0)
+		case 'u', 'U':
+			if d.pos+4 <= len(d.data) {
+				if code, err := strconv.ParseUint(string(d.data[d.pos:d.pos+4]), 16, 32); err == nil {
+					b.WriteRune(rune(code))
+					d.pos += 4
+					break
+				}
+			}
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+func isUnquotedStringChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '.' || c == '-' || c == '$' || c == ':' || c == '/'
+}
+
+// Decodes Apple .stringsdict file, which is a property list.
+// Dictionaries become tables, arrays become arrays and strings become strings.
+func DecodeStringsdict(data []byte) (node *Node, err error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		offset := int(dec.InputOffset())
+
+		tok, err := dec.Token()
+		if err == io.EOF {
+			// Empty property list
+			return &Node{Kind: TableNode, Offset: -1}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local != "plist" {
+			return plistNode(dec, start, offset)
+		}
+	}
+}
+
+func plistNode(dec *xml.Decoder, start xml.StartElement, offset int) (node *Node, err error) {
+	node = &Node{Kind: OtherNode, Offset: offset}
+
+	switch start.Name.Local {
+	case "dict":
+		node.Kind = TableNode
+
+		var key *NodeEntry
+
+		for {
+			offset := int(dec.InputOffset())
+
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			switch tok := tok.(type) {
+			case xml.StartElement:
+				if tok.Name.Local == "key" && key == nil {
+					var str string
+					if err = dec.DecodeElement(&str, &tok); err != nil {
+						return nil, err
+					}
+
+					key = &NodeEntry{Key: str, KeyOffset: offset}
+					continue
+				}
+
+				if key == nil {
+					return nil, &decodeError{
+						Offset: offset,
+						Err: common.NewError(common.ErrUnexpectedText,
+							common.ErrorValueStr(tok.Name.Local),
+							common.ErrorExpectedStr("key"),
+						),
+					}
+				}
+
+				key.Value, err = plistNode(dec, tok, offset)
+				if err != nil {
+					return nil, err
+				}
+
+				node.Table = append(node.Table, *key)
+				key = nil
+			case xml.EndElement:
+				return node, nil
+			}
+		}
+	case "array":
+		node.Kind = ArrayNode
+
+		for {
+			offset := int(dec.InputOffset())
+
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			switch tok := tok.(type) {
+			case xml.StartElement:
+				elem, err := plistNode(dec, tok, offset)
+				if err != nil {
+					return nil, err
+				}
+
+				node.Array = append(node.Array, elem)
+			case xml.EndElement:
+				return node, nil
+			}
+		}
+	case "string":
+		node.Kind = StringNode
+		node.Offset = int(dec.InputOffset())
+
+		if err = dec.DecodeElement(&node.Str, &start); err != nil {
+			return nil, err
+		}
+	default:
+		if err = dec.Skip(); err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
@@ -0,0 +1,279 @@
+package parse
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+)
+
+// Types of ARB placeholders and specifiers of the arguments they become.
+var arbTypeSpecifiers = map[string]rune{
+	"String":   's',
+	"int":      'd',
+	"double":   'f',
+	"num":      'f',
+	"Object":   'v',
+	"DateTime": 'v',
+}
+
+// ARB file of the base language, whose metadata applies to files of other languages.
+type ARBTemplate struct {
+	Filename string
+	Data     []byte
+}
+
+// Maps messages of the ARB file, whose strings are written in ICU MessageFormat.
+// Names of the messages are converted to CamelCase: helloWorld becomes HelloWorld.
+// Metadata of a message, which is kept under its key prefixed with "@",
+// declares its arguments with their types and its description.
+// Messages without metadata take it from the template file, if it is given,
+// the same way Flutter does for files of languages other than the one of the template.
+// The template file itself is expected to be checked separately, so its errors are ignored.
+// The filename is used in locations of messages and errors.
+// If some of the messages are invalid, the rest of them are returned
+// along with the list of errors.
+func UnmarshalARBMessages(filename string, in []byte, template *ARBTemplate, decode Decoder) (messages []ast.Message, err error) {
+	src := newSource(filename, in)
+
+	root, err := decode(in)
+	if err != nil {
+		return nil, locateDecodeError(src, err)
+	}
+
+	if root.Kind != TableNode {
+		return nil, common.NewError(common.ErrInvalidFieldType,
+			common.ErrorExpectedStr("table"),
+			common.ErrorLocation(src.nodeLocation(root)),
+		)
+	}
+
+	err = checkDuplicateKeys(src, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		templateSrc  *source
+		templateRoot *Node
+	)
+
+	if template != nil {
+		templateSrc = newSource(template.Filename, template.Data)
+		if templateRoot, err = decode(template.Data); err != nil || templateRoot.Kind != TableNode {
+			templateRoot = nil
+		}
+	}
+
+	// Errors of messages are collected to report all of them at once
+	var errs common.ErrorList
+
+	names := make(map[string]struct{})
+
+	for i := 0; i < len(root.Table); i++ {
+		entry := &root.Table[i]
+
+		// Metadata of messages and of the file
+		if strings.HasPrefix(entry.Key, "@") {
+			continue
+		}
+
+		name := camelCaseName(entry.Key)
+
+		err := checkMessageName(name)
+		if err != nil {
+			errs.Add(common.NewMessageError(name, common.NewError(err,
+				common.ErrorValueStr(entry.Key),
+				common.ErrorLocation(src.keyLocation(entry)),
+			)))
+			continue
+		}
+
+		if _, ok := names[name]; ok {
+			errs.Add(common.NewMessageError(name, common.NewError(common.ErrDuplicateField,
+				common.ErrorValueStr(entry.Key),
+				common.ErrorLocation(src.keyLocation(entry)),
+			)))
+			continue
+		}
+
+		names[name] = struct{}{}
+
+		metaSrc, meta := src, root.Get("@"+entry.Key)
+		if meta == nil && templateRoot != nil {
+			metaSrc, meta = templateSrc, templateRoot.Get("@"+entry.Key)
+		}
+
+		message, err := src.mapARBMessage(entry, metaSrc, meta)
+		if err != nil {
+			errs.Add(common.NewMessageError(name, common.NewFieldError(common.ErrCouldNotUnmarshal, name, err)))
+			continue
+		}
+
+		message.Name = name
+		messages = append(messages, message)
+	}
+
+	slices.SortStableFunc(messages, func(a, b ast.Message) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return messages, errs.Err()
+}
+
+// Maps the message along with its metadata, if it has any,
+// which is read from the given source.
+func (src *source) mapARBMessage(entry *NodeEntry, metaSrc *source, meta *Node) (message ast.Message, err error) {
+	if entry.Value.Kind != StringNode {
+		return ast.Message{}, src.invalidFieldType(entry.Value, common.ErrorExpectedStr("string"))
+	}
+
+	conv := &icuConverter{src: src}
+
+	message.String, message.Plural, err = conv.convertMessage(entry.Value)
+	if err != nil {
+		return ast.Message{}, err
+	}
+
+	message.Location = src.keyLocation(entry)
+	message.Variables = conv.variables
+	message.TextOrder = true
+
+	slices.SortStableFunc(message.Variables, func(a, b ast.Variable) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	if meta == nil {
+		return message, nil
+	}
+
+	if meta.Kind != TableNode {
+		err = metaSrc.invalidFieldType(meta, common.ErrorExpectedStr("table"))
+		return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, "@"+entry.Key, err)
+	}
+
+	if value := meta.Get("description"); value != nil && value.Kind == StringNode {
+		message.Description = value.Str
+	}
+
+	if value := meta.Get("placeholders"); value != nil {
+		err = metaSrc.mapARBPlaceholders(value, &message)
+		if err != nil {
+			err = common.NewFieldError(common.ErrCouldNotUnmarshal, "placeholders", err)
+			return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, "@"+entry.Key, err)
+		}
+	}
+
+	return message, nil
+}
+
+// Declares arguments of the message in the order of its placeholders.
+// Arguments of plurals are integers even if their placeholders are of num type,
+// and arguments of selects are strings even if their placeholders are of Object type.
+// Arguments that are written without a format are formatted the same way as in ARB files:
+// numbers are written as short as possible, unless the number of decimal digits is specified.
+func (src *source) mapARBPlaceholders(node *Node, message *ast.Message) (err error) {
+	if node.Kind != TableNode {
+		return src.invalidFieldType(node, common.ErrorExpectedStr("table"))
+	}
+
+	pluralArgs := []string{message.Plural.Arg}
+	var selectArgs []string
+	for i := 0; i < len(message.Variables); i++ {
+		pluralArgs = append(pluralArgs, message.Variables[i].Plural.Arg)
+		selectArgs = append(selectArgs, message.Variables[i].Select.Arg)
+	}
+
+	formats := make(map[string]ast.FmtInfo)
+
+	for i := 0; i < len(node.Table); i++ {
+		entry := &node.Table[i]
+		k, v := entry.Key, entry.Value
+
+		err = checkArgumentName(k)
+		if err != nil {
+			err = common.NewError(err,
+				common.ErrorValueStr(k),
+				common.ErrorLocation(src.keyLocation(entry)),
+			)
+			return common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+		}
+
+		if v.Kind != TableNode {
+			err = src.invalidFieldType(v, common.ErrorExpectedStr("table"))
+			return common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+		}
+
+		// Placeholders without a type are strings
+		arg := ast.ArgInfo{
+			Location: src.keyLocation(entry),
+			Name:     k,
+			FmtInfo:  ast.FmtInfo{Spec: 's'},
+		}
+
+		if typ := v.Get("type"); typ != nil {
+			spec, ok := arbTypeSpecifiers[typ.Str]
+			if typ.Kind != StringNode || !ok {
+				err = common.NewError(common.ErrUnsupportedArgumentType,
+					common.ErrorValueStr(typ.Str),
+					common.ErrorExpectedAnyStr("String", "int", "double", "num", "Object", "DateTime"),
+					common.ErrorLocation(src.nodeLocation(typ)),
+				)
+				return common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+			}
+
+			if typ.Str == "num" && slices.Contains(pluralArgs, k) {
+				spec = 'd'
+			}
+			if typ.Str == "Object" && slices.Contains(selectArgs, k) {
+				spec = 's'
+			}
+
+			arg.FmtInfo.Spec = spec
+		}
+
+		if arg.FmtInfo.Spec == 'f' {
+			formats[k] = ast.FmtInfo{Spec: 'f', Mod: ast.ModOpt{Value: 'v', Valid: true}}
+
+			if params := v.Get("optionalParameters"); params != nil && params.Kind == TableNode {
+				if digits := params.Get("decimalDigits"); digits != nil {
+					if prec, err := strconv.Atoi(digits.Str); err == nil {
+						formats[k] = ast.FmtInfo{Spec: 'f', Prec: ast.PrecOpt{Value: prec, Valid: true}}
+					}
+				}
+			}
+		}
+
+		message.Arguments = append(message.Arguments, arg)
+	}
+
+	formatArgs := func(parts ast.FormatParts) {
+		for i, part := range parts {
+			if arg, ok := part.(ast.ArgInfo); ok && !arg.FmtInfo.HasOptions() && arg.FmtInfo.Spec == 0 {
+				if format, ok := formats[arg.Name]; ok {
+					arg.FmtInfo = format
+					parts[i] = arg
+				}
+			}
+		}
+	}
+
+	formatValue := func(value ast.Value) {
+		for _, form := range value.Forms() {
+			formatArgs(form)
+		}
+	}
+
+	formatValue(message.String)
+	formatValue(&message.Plural)
+
+	for i := 0; i < len(message.Variables); i++ {
+		formatValue(message.Variables[i].String)
+		formatValue(&message.Variables[i].Plural)
+		formatValue(&message.Variables[i].Select)
+	}
+
+	return nil
+}
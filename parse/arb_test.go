@@ -0,0 +1,201 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+)
+
+func TestUnmarshalARB(t *testing.T) {
+	tests := []unmarshalTest{
+		{
+			name:    "string placeholder",
+			in:      `{"hello": "Hello, {name}!", "@hello": {"placeholders": {"name": {"type": "String"}}}}`,
+			message: "Hello",
+			want:    "Hello, ${name}!",
+		},
+		{
+			name:    "placeholder without metadata",
+			in:      `{"hello": "Hello, {name}!"}`,
+			message: "Hello",
+			want:    "Hello, ${name}!",
+		},
+		{
+			name:    "double placeholder",
+			in:      `{"price": "Price: {amount}", "@price": {"placeholders": {"amount": {"type": "double"}}}}`,
+			message: "Price",
+			want:    "Price: ${fv:amount}",
+		},
+		{
+			name: "decimal digits",
+			in: `{"price": "Price: {amount}", "@price": {"placeholders": {"amount": {` +
+				`"type": "num", "format": "decimalPatternDigits", "optionalParameters": {"decimalDigits": 2}}}}}`,
+			message: "Price",
+			want:    "Price: ${.2f:amount}",
+		},
+		{
+			name:    "plural",
+			in:      `{"files": "{count, plural, =0{No files} one{{count} file} other{{count} files}}"}`,
+			message: "Files",
+			want:    "count: =0 {No files} one {${count} file} other {${count} files}",
+		},
+		{
+			name:    "select",
+			in:      `{"invitation": "{host} invited you to {gender, select, male{his} other{their}} party"}`,
+			message: "Invitation",
+			want:    "${host} invited you to &{gender_select} party",
+			vars: map[string]string{
+				"gender_select": "gender: male {his} other {their}",
+			},
+		},
+		{
+			name:    "camel case name",
+			in:      `{"@@locale": "en", "helloWorld": "Hello, world!"}`,
+			message: "HelloWorld",
+			want:    "Hello, world!",
+		},
+		{
+			name: "message of wrong type",
+			in:   `{"hello": 42}`,
+			err:  common.ErrInvalidFieldType,
+		},
+		{
+			name: "metadata of wrong type",
+			in:   `{"hello": "Hello", "@hello": "Greeting"}`,
+			err:  common.ErrInvalidFieldType,
+		},
+		{
+			name: "placeholders of wrong type",
+			in:   `{"hello": "Hello, {name}!", "@hello": {"placeholders": ["name"]}}`,
+			err:  common.ErrInvalidFieldType,
+		},
+		{
+			name: "unsupported placeholder type",
+			in:   `{"hello": "Hello, {name}!", "@hello": {"placeholders": {"name": {"type": "Date"}}}}`,
+			err:  common.ErrUnsupportedArgumentType,
+		},
+		{
+			name: "unsupported argument type",
+			in:   `{"today": "Today is {date, date, short}"}`,
+			err:  common.ErrUnsupportedArgumentType,
+		},
+		{
+			name: "selectordinal",
+			in:   `{"place": "{n, selectordinal, one{#st} other{#th}}"}`,
+			err:  common.ErrUnsupportedArgumentType,
+		},
+		{
+			name: "duplicate name",
+			in:   `{"helloWorld": "Hello", "HelloWorld": "Hi"}`,
+			err:  common.ErrDuplicateField,
+		},
+	}
+
+	runUnmarshalTests(t, tests, func(in string) ([]ast.Message, error) {
+		return UnmarshalARBMessages("app_en.arb", []byte(in), nil, DecodeJSON)
+	})
+}
+
+func TestUnmarshalARBPlaceholders(t *testing.T) {
+	in := `{
+  "summary": "{user}: {count, plural, one{# file} other{# files}}, {size} MB, {ratio}, {kind, select, a{A} other{B}}, {data}, {date}, {note}",
+  "@summary": {
+    "placeholders": {
+      "user": {"type": "String"},
+      "count": {"type": "num"},
+      "size": {"type": "int"},
+      "ratio": {"type": "double"},
+      "kind": {"type": "Object"},
+      "data": {"type": "Object"},
+      "date": {"type": "DateTime"},
+      "note": {}
+    }
+  }
+}`
+
+	messages, err := UnmarshalARBMessages("app_en.arb", []byte(in), nil, DecodeJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []struct {
+		name string
+		spec rune
+	}{
+		{"user", 's'},
+		// num argument of a plural is an integer
+		{"count", 'd'},
+		{"size", 'd'},
+		{"ratio", 'f'},
+		// Object argument of a select is a string
+		{"kind", 's'},
+		{"data", 'v'},
+		{"date", 'v'},
+		{"note", 's'},
+	}
+
+	args := messages[0].Arguments
+	if len(args) != len(want) {
+		t.Fatalf("got %d arguments, want %d", len(args), len(want))
+	}
+
+	for i, arg := range args {
+		if arg.Name != want[i].name || arg.FmtInfo.Spec != want[i].spec {
+			t.Errorf("argument %d: got %s of %q, want %s of %q", i, arg.Name, arg.FmtInfo.Spec, want[i].name, want[i].spec)
+		}
+	}
+}
+
+func TestUnmarshalARBMetadata(t *testing.T) {
+	template := &ARBTemplate{
+		Filename: "app_en.arb",
+		Data: []byte(`{
+  "@@locale": "en",
+  "hello": "Hello, {name}!",
+  "@hello": {"description": "Greeting", "placeholders": {"name": {"type": "String"}}},
+  "price": "Price: {amount}",
+  "@price": {"description": "Price of the order", "placeholders": {"amount": {"type": "double"}}}
+}`),
+	}
+
+	// The file has its own metadata of Price, and the metadata of Hello is taken from the template
+	in := `{
+  "@@locale": "de",
+  "hello": "Hallo, {name}!",
+  "price": "Preis: {amount}",
+  "@price": {"description": "Preis", "placeholders": {"amount": {"type": "int"}}}
+}`
+
+	messages, err := UnmarshalARBMessages("app_de.arb", []byte(in), template, DecodeJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+
+	tests := []struct {
+		message     *ast.Message
+		name        string
+		description string
+		spec        rune
+	}{
+		{&messages[0], "Hello", "Greeting", 's'},
+		{&messages[1], "Price", "Preis", 'd'},
+	}
+
+	for _, tt := range tests {
+		if tt.message.Name != tt.name {
+			t.Errorf("got message %s, want %s", tt.message.Name, tt.name)
+			continue
+		}
+		if tt.message.Description != tt.description {
+			t.Errorf("%s: got description %q, want %q", tt.name, tt.message.Description, tt.description)
+		}
+		if len(tt.message.Arguments) != 1 || tt.message.Arguments[0].FmtInfo.Spec != tt.spec {
+			t.Errorf("%s: got arguments %v, want one of %q", tt.name, tt.message.Arguments, tt.spec)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/infastin/go-l10n/common"
+	"gopkg.in/yaml.v3"
+)
+
+// Decodes contents of a localization file into a tree of nodes.
+type Decoder func(data []byte) (node *Node, err error)
+
+func DecodeYAML(data []byte) (node *Node, err error) {
+	var doc yaml.Node
+
+	err = yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Empty document
+	if len(doc.Content) == 0 {
+		return &Node{Kind: TableNode, Offset: -1}, nil
+	}
+
+	src := newSource("", data)
+
+	node = yamlNode(src, doc.Content[0])
+	node.Comments.Head = joinComments(doc.HeadComment, node.Comments.Head)
+	node.Comments.Foot = joinComments(node.Comments.Foot, doc.FootComment)
+
+	return node, nil
+}
+
+func yamlNode(src *source, yn *yaml.Node) (node *Node) {
+	for yn.Kind == yaml.AliasNode {
+		yn = yn.Alias
+	}
+
+	node = &Node{
+		Kind:   OtherNode,
+		Offset: src.offset(yn.Line, yn.Column),
+		Comments: Comments{
+			Head: yn.HeadComment,
+			Line: yn.LineComment,
+			Foot: yn.FootComment,
+		},
+	}
+
+	switch yn.Kind {
+	case yaml.MappingNode:
+		node.Kind = TableNode
+
+		for i := 0; i+1 < len(yn.Content); i += 2 {
+			key, value := yn.Content[i], yn.Content[i+1]
+
+			node.Table = append(node.Table, NodeEntry{
+				Key:       key.Value,
+				KeyOffset: src.offset(key.Line, key.Column),
+				KeyComments: Comments{
+					Head: key.HeadComment,
+					Line: key.LineComment,
+					Foot: key.FootComment,
+				},
+				Value: yamlNode(src, value),
+			})
+		}
+	case yaml.SequenceNode:
+		node.Kind = ArrayNode
+
+		for _, elem := range yn.Content {
+			node.Array = append(node.Array, yamlNode(src, elem))
+		}
+	case yaml.ScalarNode:
+		if yn.ShortTag() != "!!str" {
+			break
+		}
+
+		node.Kind = StringNode
+		node.Str = yn.Value
+
+		switch {
+		case yn.Style&yaml.DoubleQuotedStyle != 0:
+			node.Style = StringStyle{Quote: `"`, Escapes: true}
+		case yn.Style&yaml.SingleQuotedStyle != 0:
+			node.Style = StringStyle{Quote: `'`, DoubledQuotes: true}
+		case yn.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+			node.Style = StringStyle{Block: true}
+		}
+	}
+
+	return node
+}
+
+func joinComments(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "\n\n" + b
+}
+
+func DecodeJSON(data []byte) (node *Node, err error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	node, err = jsonNode(dec, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// There must be nothing after the value
+	_, err = dec.Token()
+	if err != io.EOF {
+		if err == nil {
+			err = &json.SyntaxError{Offset: dec.InputOffset()}
+		}
+		return nil, err
+	}
+
+	return node, nil
+}
+
+func jsonNode(dec *json.Decoder, data []byte) (node *Node, err error) {
+	offset := jsonTokenOffset(dec, data)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	node = &Node{
+		Kind:   OtherNode,
+		Offset: offset,
+	}
+
+	switch tok := tok.(type) {
+	case string:
+		node.Kind = StringNode
+		node.Str = tok
+		node.Style = StringStyle{Quote: `"`, Escapes: true}
+	case json.Number:
+		// Numbers are kept as they are written
+		node.Str = tok.String()
+	case json.Delim:
+		switch tok {
+		case '{':
+			node.Kind = TableNode
+
+			for dec.More() {
+				keyOffset := jsonTokenOffset(dec, data)
+
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := jsonNode(dec, data)
+				if err != nil {
+					return nil, err
+				}
+
+				node.Table = append(node.Table, NodeEntry{
+					Key:       key.(string),
+					KeyOffset: keyOffset,
+					Value:     value,
+				})
+			}
+		case '[':
+			node.Kind = ArrayNode
+
+			for dec.More() {
+				elem, err := jsonNode(dec, data)
+				if err != nil {
+					return nil, err
+				}
+
+				node.Array = append(node.Array, elem)
+			}
+		default:
+			return nil, common.NewError(common.ErrUnexpectedChar, common.ErrorValueChar(rune(tok)))
+		}
+
+		// Closing delimiter
+		_, err = dec.Token()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
+
+// Returns offset of the next token.
+func jsonTokenOffset(dec *json.Decoder, data []byte) (offset int) {
+	offset = int(dec.InputOffset())
+
+	for offset < len(data) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+
+	return offset
+}
@@ -0,0 +1,148 @@
+package parse
+
+import (
+	"strings"
+)
+
+func NewStringNode(str string) *Node {
+	return &Node{
+		Kind:   StringNode,
+		Str:    str,
+		Offset: -1,
+	}
+}
+
+func NewTableNode() *Node {
+	return &Node{
+		Kind:   TableNode,
+		Offset: -1,
+	}
+}
+
+// Returns value of the table entry with the given key or nil if there is none.
+func (node *Node) Get(key string) *Node {
+	for i := 0; i < len(node.Table); i++ {
+		if node.Table[i].Key == key {
+			return node.Table[i].Value
+		}
+	}
+	return nil
+}
+
+// Sets value of the table entry with the given key.
+// If there is no such entry, it is added to the end of the table.
+func (node *Node) Set(key string, value *Node) {
+	for i := 0; i < len(node.Table); i++ {
+		if node.Table[i].Key == key {
+			node.Table[i].Value = value
+			return
+		}
+	}
+
+	node.Kind = TableNode
+	node.Table = append(node.Table, NodeEntry{
+		Key:       key,
+		KeyOffset: -1,
+		Value:     value,
+	})
+}
+
+// Returns a copy of the tree, that can be changed independently of it.
+func (node *Node) Clone() *Node {
+	clone := *node
+
+	clone.Table = make([]NodeEntry, len(node.Table))
+	for i, entry := range node.Table {
+		entry.Value = entry.Value.Clone()
+		clone.Table[i] = entry
+	}
+
+	clone.Array = make([]*Node, len(node.Array))
+	for i, elem := range node.Array {
+		clone.Array[i] = elem.Clone()
+	}
+
+	return &clone
+}
+
+// Returns value of the message with the given name or nil if there is none.
+// The message is looked up the same way it is unmarshaled:
+// namespaces can be written both as nested tables and as dotted keys.
+func FindMessage(root *Node, name string) *Node {
+	for i := 0; i < len(root.Table); i++ {
+		entry := &root.Table[i]
+
+		if !isNamespace(entry.Value) {
+			if entry.Key == name {
+				return entry.Value
+			}
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(name, entry.Key+"."); ok {
+			if value := FindMessage(entry.Value, rest); value != nil {
+				return value
+			}
+		}
+	}
+
+	return nil
+}
+
+// Sets value of the message with the given name.
+// If there is no such message, it is added to the deepest namespace table
+// that exists, and the rest of namespaces are added as nested tables.
+func SetMessage(root *Node, name string, value *Node) {
+	if replaceMessage(root, name, value) {
+		return
+	}
+
+	for table := root; ; {
+		var next *Node
+
+		for i := 0; i < len(table.Table); i++ {
+			entry := &table.Table[i]
+
+			if rest, ok := strings.CutPrefix(name, entry.Key+"."); ok && isNamespace(entry.Value) {
+				next, name = entry.Value, rest
+				break
+			}
+		}
+
+		if next == nil {
+			keys := strings.Split(name, ".")
+
+			for _, key := range keys[:len(keys)-1] {
+				namespace := NewTableNode()
+				table.Set(key, namespace)
+				table = namespace
+			}
+
+			table.Set(keys[len(keys)-1], value)
+
+			return
+		}
+
+		table = next
+	}
+}
+
+func replaceMessage(root *Node, name string, value *Node) bool {
+	for i := 0; i < len(root.Table); i++ {
+		entry := &root.Table[i]
+
+		if !isNamespace(entry.Value) {
+			if entry.Key == name {
+				entry.Value = value
+				return true
+			}
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(name, entry.Key+"."); ok && replaceMessage(entry.Value, rest, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,412 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/infastin/go-l10n/common"
+	"gopkg.in/yaml.v3"
+)
+
+// Encodes a tree of nodes into contents of a localization file.
+// Strings keep their style whenever it is possible.
+type Encoder func(node *Node) (data []byte, err error)
+
+func EncodeYAML(node *Node) (data []byte, err error) {
+	yn, err := yamlEncodeNode(node)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &yaml.Node{
+		Kind:        yaml.DocumentNode,
+		HeadComment: yn.HeadComment,
+		FootComment: yn.FootComment,
+		Content:     []*yaml.Node{yn},
+	}
+	yn.HeadComment, yn.FootComment = "", ""
+
+	var b bytes.Buffer
+
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+
+	err = enc.Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	err = enc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func yamlEncodeNode(node *Node) (yn *yaml.Node, err error) {
+	yn = &yaml.Node{
+		HeadComment: node.Comments.Head,
+		LineComment: node.Comments.Line,
+		FootComment: node.Comments.Foot,
+	}
+
+	switch node.Kind {
+	case StringNode:
+		yn.Kind = yaml.ScalarNode
+		yn.Tag = "!!str"
+		yn.Value = node.Str
+
+		switch {
+		case node.Style.Quote == `"`:
+			yn.Style = yaml.DoubleQuotedStyle
+		case node.Style.Quote == "'":
+			yn.Style = yaml.SingleQuotedStyle
+		case node.Style.Block:
+			yn.Style = yaml.LiteralStyle
+		}
+	case TableNode:
+		yn.Kind = yaml.MappingNode
+		yn.Tag = "!!map"
+
+		for i := 0; i < len(node.Table); i++ {
+			entry := &node.Table[i]
+
+			value, err := yamlEncodeNode(entry.Value)
+			if err != nil {
+				return nil, common.NewFieldError(common.ErrCouldNotMarshal, entry.Key, err)
+			}
+
+			yn.Content = append(yn.Content, &yaml.Node{
+				Kind:        yaml.ScalarNode,
+				Tag:         "!!str",
+				Value:       entry.Key,
+				HeadComment: entry.KeyComments.Head,
+				LineComment: entry.KeyComments.Line,
+				FootComment: entry.KeyComments.Foot,
+			}, value)
+		}
+	case ArrayNode:
+		yn.Kind = yaml.SequenceNode
+		yn.Tag = "!!seq"
+		yn.Style = yaml.FlowStyle
+
+		for i, elem := range node.Array {
+			value, err := yamlEncodeNode(elem)
+			if err != nil {
+				return nil, common.NewFieldError(common.ErrCouldNotMarshal, strconv.Itoa(i), err)
+			}
+
+			yn.Content = append(yn.Content, value)
+		}
+	default:
+		return nil, common.NewError(common.ErrInvalidFieldType, common.ErrorExpectedAnyStr("string", "table", "array"))
+	}
+
+	return yn, nil
+}
+
+func EncodeJSON(node *Node) (data []byte, err error) {
+	var b bytes.Buffer
+
+	err = jsonEncodeNode(&b, node, "")
+	if err != nil {
+		return nil, err
+	}
+
+	b.WriteByte('\n')
+
+	return b.Bytes(), nil
+}
+
+func jsonEncodeNode(b *bytes.Buffer, node *Node, indent string) (err error) {
+	switch node.Kind {
+	case StringNode:
+		jsonEncodeString(b, node.Str)
+	case TableNode:
+		if len(node.Table) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+
+		b.WriteString("{\n")
+
+		for i := 0; i < len(node.Table); i++ {
+			entry := &node.Table[i]
+
+			b.WriteString(indent + "  ")
+			jsonEncodeString(b, entry.Key)
+			b.WriteString(": ")
+
+			err = jsonEncodeNode(b, entry.Value, indent+"  ")
+			if err != nil {
+				return common.NewFieldError(common.ErrCouldNotMarshal, entry.Key, err)
+			}
+
+			if i != len(node.Table)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+		}
+
+		b.WriteString(indent + "}")
+	case ArrayNode:
+		b.WriteByte('[')
+
+		for i, elem := range node.Array {
+			if i != 0 {
+				b.WriteString(", ")
+			}
+
+			err = jsonEncodeNode(b, elem, indent)
+			if err != nil {
+				return common.NewFieldError(common.ErrCouldNotMarshal, strconv.Itoa(i), err)
+			}
+		}
+
+		b.WriteByte(']')
+	case OtherNode:
+		if _, err := strconv.ParseFloat(node.Str, 64); err != nil {
+			return common.NewError(common.ErrInvalidFieldType, common.ErrorExpectedAnyStr("string", "table", "array", "number"))
+		}
+		b.WriteString(node.Str)
+	}
+
+	return nil
+}
+
+func jsonEncodeString(b *bytes.Buffer, str string) {
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	// Encoding of a string never fails
+	_ = enc.Encode(str)
+	// Encoder terminates values with a newline
+	b.Truncate(b.Len() - 1)
+}
+
+// Encodes the tree into a TOML document.
+// Tables that contain anything besides other tables are written as sections,
+// arrays are written inline.
+func EncodeTOML(node *Node) (data []byte, err error) {
+	if node.Kind != TableNode {
+		return nil, common.NewError(common.ErrInvalidFieldType, common.ErrorExpectedStr("table"))
+	}
+
+	var b bytes.Buffer
+
+	err = tomlEncodeTable(&b, nil, node)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func tomlEncodeTable(b *bytes.Buffer, path []string, node *Node) (err error) {
+	// Values go right after the header of the table
+	var hasValues bool
+
+	for i := 0; i < len(node.Table); i++ {
+		if node.Table[i].Value.Kind != TableNode {
+			hasValues = true
+			break
+		}
+	}
+
+	if hasValues && len(path) != 0 {
+		if b.Len() != 0 {
+			b.WriteByte('\n')
+		}
+
+		b.WriteByte('[')
+		for i, key := range path {
+			if i != 0 {
+				b.WriteByte('.')
+			}
+			tomlEncodeKey(b, key)
+		}
+		b.WriteString("]\n")
+	}
+
+	for i := 0; i < len(node.Table); i++ {
+		entry := &node.Table[i]
+
+		if entry.Value.Kind == TableNode {
+			continue
+		}
+
+		tomlEncodeKey(b, entry.Key)
+		b.WriteString(" = ")
+
+		err = tomlEncodeValue(b, entry.Value)
+		if err != nil {
+			return common.NewFieldError(common.ErrCouldNotMarshal, entry.Key, err)
+		}
+
+		b.WriteByte('\n')
+	}
+
+	for i := 0; i < len(node.Table); i++ {
+		entry := &node.Table[i]
+
+		if entry.Value.Kind != TableNode {
+			continue
+		}
+
+		err = tomlEncodeTable(b, append(path[:len(path):len(path)], entry.Key), entry.Value)
+		if err != nil {
+			return common.NewFieldError(common.ErrCouldNotMarshal, entry.Key, err)
+		}
+	}
+
+	return nil
+}
+
+func tomlEncodeValue(b *bytes.Buffer, node *Node) (err error) {
+	switch node.Kind {
+	case StringNode:
+		tomlEncodeString(b, node.Str, node.Style)
+	case TableNode:
+		b.WriteByte('{')
+
+		for i := 0; i < len(node.Table); i++ {
+			entry := &node.Table[i]
+
+			if i != 0 {
+				b.WriteByte(',')
+			}
+
+			b.WriteByte(' ')
+			tomlEncodeKey(b, entry.Key)
+			b.WriteString(" = ")
+
+			err = tomlEncodeValue(b, entry.Value)
+			if err != nil {
+				return common.NewFieldError(common.ErrCouldNotMarshal, entry.Key, err)
+			}
+		}
+
+		if len(node.Table) != 0 {
+			b.WriteByte(' ')
+		}
+
+		b.WriteByte('}')
+	case ArrayNode:
+		b.WriteByte('[')
+
+		for i, elem := range node.Array {
+			if i != 0 {
+				b.WriteString(", ")
+			}
+
+			err = tomlEncodeValue(b, elem)
+			if err != nil {
+				return common.NewFieldError(common.ErrCouldNotMarshal, strconv.Itoa(i), err)
+			}
+		}
+
+		b.WriteByte(']')
+	case OtherNode:
+		if _, err := strconv.ParseFloat(node.Str, 64); err != nil {
+			return common.NewError(common.ErrInvalidFieldType, common.ErrorExpectedAnyStr("string", "table", "array", "number"))
+		}
+		b.WriteString(node.Str)
+	}
+
+	return nil
+}
+
+func tomlEncodeKey(b *bytes.Buffer, key string) {
+	bare := key != ""
+
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' && c != '-' {
+			bare = false
+			break
+		}
+	}
+
+	if bare {
+		b.WriteString(key)
+	} else {
+		tomlEncodeString(b, key, StringStyle{Quote: `"`, Escapes: true})
+	}
+}
+
+func tomlEncodeString(b *bytes.Buffer, str string, style StringStyle) {
+	// Literal strings can't contain control characters other than tab,
+	// and multiline ones can contain newlines too
+	literal := !style.Escapes && style.Quote != ""
+	multiline := len(style.Quote) == 3
+
+	for _, c := range str {
+		if c == '\n' && multiline {
+			continue
+		}
+		if c == '\t' {
+			continue
+		}
+		if c < 0x20 || c == 0x7f {
+			literal = false
+			break
+		}
+	}
+
+	if literal && strings.Contains(str, "'") {
+		literal = false
+	}
+
+	if literal {
+		b.WriteString(style.Quote)
+		if multiline && strings.HasPrefix(str, "\n") {
+			// The first newline of a multiline string is trimmed
+			b.WriteByte('\n')
+		}
+		b.WriteString(str)
+		b.WriteString(style.Quote)
+		return
+	}
+
+	quote := `"`
+	if multiline {
+		quote = `"""`
+	}
+
+	b.WriteString(quote)
+	if multiline && strings.HasPrefix(str, "\n") {
+		b.WriteByte('\n')
+	}
+
+	for len(str) > 0 {
+		c, size := utf8.DecodeRuneInString(str)
+		str = str[size:]
+
+		switch {
+		case c == '"':
+			// Any quote is escaped in multiline strings too,
+			// so that three quotes in a row never occur
+			b.WriteString(`\"`)
+		case c == '\\':
+			b.WriteString(`\\`)
+		case c == '\n' && multiline:
+			b.WriteByte('\n')
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c < 0x20 || c == 0x7f:
+			b.WriteString(`\u`)
+			b.WriteString(strings.Repeat("0", 4-len(strconv.FormatInt(int64(c), 16))))
+			b.WriteString(strconv.FormatInt(int64(c), 16))
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	b.WriteString(quote)
+}
@@ -0,0 +1,1066 @@
+package parse
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+)
+
+// Entry of Fluent resource: either a message or a term.
+type fluentEntry struct {
+	// Offset of the identifier, or of the dash of the term
+	Offset int
+	// Offset of the end of the entry
+	End        int
+	ID         string
+	Term       bool
+	Comment    string
+	Value      fluentPattern
+	Attributes []fluentAttribute
+}
+
+type fluentAttribute struct {
+	Offset int
+	ID     string
+	Value  fluentPattern
+}
+
+type fluentPattern []fluentElement
+
+// Element of Fluent pattern: text, argument, reference to a message or a term, or select expression.
+type fluentElement struct {
+	Offset int
+	Text   string
+	Arg    *ast.ArgInfo
+	Ref    *fluentRef
+	Select *fluentSelect
+	// Number of spaces the text of the line of multiline pattern starts with, -1 for other elements
+	indent int
+}
+
+type fluentRef struct {
+	Term bool
+	ID   string
+	Attr string
+}
+
+type fluentSelect struct {
+	Arg      string
+	Variants []fluentVariant
+}
+
+type fluentVariant struct {
+	Offset  int
+	Key     string
+	Default bool
+	Value   fluentPattern
+}
+
+type fluentParser struct {
+	src  *source
+	data []byte
+	pos  int
+}
+
+// Maps messages of Fluent resource.
+// Names of the messages and their attributes are converted to CamelCase:
+// login-input becomes LoginInput, and its .placeholder attribute becomes LoginInputPlaceholder message.
+// Variables become arguments, whose names are converted to camelCase: $user-name becomes userName,
+// and NUMBER function makes them float64, whose number of fraction digits can be set
+// with minimumFractionDigits option.
+// Select expressions on variables become plurals, whose variants are CLDR plural categories
+// or numbers, which match the argument exactly,
+// and select expressions within the text become variables of the message named after their arguments.
+// Terms and references to other messages are written in place of their references.
+// Comments of messages become their descriptions.
+// The filename is used in locations of messages and errors.
+// If some of the messages are invalid, the rest of them are returned
+// along with the list of errors.
+func UnmarshalFluentMessages(filename string, in []byte) (messages []ast.Message, err error) {
+	src := newSource(filename, in)
+	p := &fluentParser{src: src, data: in}
+
+	entries, err := p.parseResource()
+
+	var errs common.ErrorList
+	errs.Add(err)
+
+	r := &fluentResolver{
+		src:      src,
+		messages: make(map[string]*fluentEntry),
+		terms:    make(map[string]*fluentEntry),
+	}
+
+	for i := 0; i < len(entries); i++ {
+		entry := &entries[i]
+
+		ids := r.messages
+		if entry.Term {
+			ids = r.terms
+		}
+
+		if _, ok := ids[entry.ID]; ok {
+			errs.Add(common.NewMessageError(camelCaseName(entry.ID), common.NewError(common.ErrDuplicateField,
+				common.ErrorValueStr(entry.ID),
+				common.ErrorLocation(src.location(entry.Offset)),
+			)))
+			continue
+		}
+
+		ids[entry.ID] = entry
+	}
+
+	names := make(map[string]struct{})
+
+	for i := 0; i < len(entries); i++ {
+		entry := &entries[i]
+		if entry.Term || r.messages[entry.ID] != entry {
+			continue
+		}
+
+		name := camelCaseName(entry.ID)
+
+		if entry.Value != nil {
+			message, err := r.mapMessage(entry, "", entry.Value)
+			if err != nil {
+				errs.Add(common.NewMessageError(name, common.NewFieldError(common.ErrCouldNotUnmarshal, name, err)))
+			} else {
+				message.Name = name
+				message.Location = src.location(entry.Offset)
+				message.Description = entry.Comment
+				messages = append(messages, message)
+			}
+		}
+
+		for j := 0; j < len(entry.Attributes); j++ {
+			attr := &entry.Attributes[j]
+			attrName := name + camelCaseName(attr.ID)
+
+			message, err := r.mapMessage(entry, attr.ID, attr.Value)
+			if err != nil {
+				errs.Add(common.NewMessageError(attrName, common.NewFieldError(common.ErrCouldNotUnmarshal, attrName, err)))
+				continue
+			}
+
+			message.Name = attrName
+			message.Location = src.location(attr.Offset)
+			messages = append(messages, message)
+		}
+	}
+
+	for i := 0; i < len(messages); i++ {
+		if _, ok := names[messages[i].Name]; ok {
+			errs.Add(common.NewMessageError(messages[i].Name, common.NewError(common.ErrDuplicateField,
+				common.ErrorValueStr(messages[i].Name),
+				common.ErrorLocation(messages[i].Location),
+			)))
+			messages = slices.Delete(messages, i, i+1)
+			i--
+			continue
+		}
+
+		names[messages[i].Name] = struct{}{}
+	}
+
+	slices.SortStableFunc(messages, func(a, b ast.Message) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return messages, errs.Err()
+}
+
+// Parses entries of the resource.
+// Entries with syntax errors are skipped up to the next entry,
+// and the errors are returned as a list along with the rest of the entries.
+func (p *fluentParser) parseResource() (entries []fluentEntry, err error) {
+	var (
+		errs    common.ErrorList
+		comment []string
+	)
+
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case c == '\n' || c == '\r' || c == ' ':
+			p.skipSpaces()
+
+			// Only blank lines can start with spaces
+			if p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				errs.Add(p.unexpected())
+				p.skipJunk()
+			} else {
+				p.skipLine()
+			}
+
+			// Comments that are followed by a blank line are not attached to messages
+			comment = nil
+		case c == '#':
+			level, text := p.parseComment()
+			if level == 1 {
+				comment = append(comment, text)
+			} else {
+				comment = nil
+			}
+		case isFluentIDStart(c) || c == '-':
+			entry, err := p.parseEntry()
+			if err != nil {
+				errs.Add(err)
+				p.skipJunk()
+			} else {
+				entry.Comment = strings.Join(comment, "\n")
+				entries = append(entries, entry)
+			}
+			comment = nil
+		default:
+			errs.Add(p.unexpected())
+			p.skipJunk()
+			comment = nil
+		}
+	}
+
+	return entries, errs.Err()
+}
+
+// Skips the rest of the line along with its line break.
+func (p *fluentParser) skipLine() {
+	for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+		p.pos++
+	}
+
+	if p.pos < len(p.data) {
+		p.pos++
+	}
+}
+
+// Skips lines up to the next one that starts an entry or a comment.
+func (p *fluentParser) skipJunk() {
+	for {
+		p.skipLine()
+
+		if p.pos == len(p.data) {
+			return
+		}
+
+		if c := p.data[p.pos]; isFluentIDStart(c) || c == '-' || c == '#' {
+			return
+		}
+	}
+}
+
+// Parses the comment line and returns its level, the number of number signs, and its text.
+func (p *fluentParser) parseComment() (level int, text string) {
+	for p.pos < len(p.data) && p.data[p.pos] == '#' {
+		level++
+		p.pos++
+	}
+
+	start := p.pos
+	p.skipLine()
+
+	text = strings.TrimRight(string(p.data[start:p.pos]), "\r\n")
+	text = strings.TrimPrefix(text, " ")
+
+	return level, text
+}
+
+func (p *fluentParser) parseEntry() (entry fluentEntry, err error) {
+	entry.Offset = p.pos
+
+	if p.data[p.pos] == '-' {
+		entry.Term = true
+		p.pos++
+	}
+
+	entry.ID, err = p.parseID()
+	if err != nil {
+		return fluentEntry{}, err
+	}
+
+	p.skipSpaces()
+
+	if err = p.expect('='); err != nil {
+		return fluentEntry{}, err
+	}
+
+	entry.Value, err = p.parsePattern()
+	if err != nil {
+		return fluentEntry{}, err
+	}
+
+	for {
+		start, indent := p.peekLine()
+		if indent == 0 || start == len(p.data) || p.data[start] != '.' {
+			break
+		}
+
+		p.pos = start + 1
+
+		attr := fluentAttribute{Offset: start}
+
+		attr.ID, err = p.parseID()
+		if err != nil {
+			return fluentEntry{}, err
+		}
+
+		p.skipSpaces()
+
+		if err = p.expect('='); err != nil {
+			return fluentEntry{}, err
+		}
+
+		attr.Value, err = p.parsePattern()
+		if err != nil {
+			return fluentEntry{}, err
+		}
+
+		if attr.Value == nil {
+			return fluentEntry{}, common.NewError(common.ErrFieldNotSpecified,
+				common.ErrorValueStr("value"),
+				common.ErrorLocation(p.src.location(attr.Offset)),
+			)
+		}
+
+		entry.Attributes = append(entry.Attributes, attr)
+	}
+
+	if p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+		return fluentEntry{}, p.unexpected()
+	}
+
+	if entry.Value == nil && (entry.Term || entry.Attributes == nil) {
+		return fluentEntry{}, common.NewError(common.ErrFieldNotSpecified,
+			common.ErrorValueStr("value"),
+			common.ErrorLocation(p.src.location(entry.Offset)),
+		)
+	}
+
+	entry.End = p.pos
+
+	return entry, nil
+}
+
+// Returns offset of the first character of the next line that is not blank
+// and the number of spaces that go before it.
+// Returns zero indent if there is no next line.
+func (p *fluentParser) peekLine() (start int, indent int) {
+	start = p.pos
+
+	for {
+		if start < len(p.data) && p.data[start] == '\r' {
+			start++
+		}
+
+		if start == len(p.data) || p.data[start] != '\n' {
+			return start, 0
+		}
+
+		start++
+		indent = 0
+
+		for start < len(p.data) && p.data[start] == ' ' {
+			start++
+			indent++
+		}
+
+		if start < len(p.data) && p.data[start] != '\n' && p.data[start] != '\r' {
+			return start, indent
+		}
+	}
+}
+
+// Parses pattern that goes up to the end of the line
+// and continues on the following indented lines.
+// Lines that start with [, *, . or } are not the part of the pattern.
+// Common indentation of the lines is removed, and so are trailing spaces.
+// Returns nil if the pattern is empty.
+func (p *fluentParser) parsePattern() (pattern fluentPattern, err error) {
+	p.skipSpaces()
+
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; c {
+		case '\n', '\r':
+			start, indent := p.peekLine()
+			if indent == 0 || start == len(p.data) || strings.IndexByte("[*.}", p.data[start]) != -1 {
+				return dedentFluentPattern(pattern), nil
+			}
+
+			// Blank lines are kept, but the pattern can't start with them
+			var text string
+			if pattern != nil {
+				text = strings.Repeat("\n", strings.Count(string(p.data[p.pos:start]), "\n"))
+			}
+
+			pattern = append(pattern, fluentElement{
+				Offset: start - indent,
+				Text:   text + strings.Repeat(" ", indent),
+				indent: indent,
+			})
+
+			p.pos = start
+		case '{':
+			elem, err := p.parsePlaceable()
+			if err != nil {
+				return nil, err
+			}
+
+			pattern = append(pattern, elem)
+		case '}':
+			return dedentFluentPattern(pattern), nil
+		default:
+			start := p.pos
+			for p.pos < len(p.data) && strings.IndexByte("{}\r\n", p.data[p.pos]) == -1 {
+				p.pos++
+			}
+
+			pattern = append(pattern, fluentElement{Offset: start, Text: string(p.data[start:p.pos]), indent: -1})
+		}
+	}
+
+	return dedentFluentPattern(pattern), nil
+}
+
+// Removes common indentation of the lines of the pattern and trailing spaces.
+func dedentFluentPattern(pattern fluentPattern) fluentPattern {
+	indent := -1
+	for _, elem := range pattern {
+		if elem.indent > 0 && (indent == -1 || elem.indent < indent) {
+			indent = elem.indent
+		}
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i].indent > 0 {
+			pattern[i].Text = pattern[i].Text[:len(pattern[i].Text)-indent]
+		}
+	}
+
+	if last := len(pattern) - 1; last >= 0 && pattern[last].Arg == nil && pattern[last].Ref == nil && pattern[last].Select == nil {
+		pattern[last].Text = strings.TrimRight(pattern[last].Text, " ")
+	}
+
+	return slices.DeleteFunc(pattern, func(elem fluentElement) bool {
+		return elem.Text == "" && elem.Arg == nil && elem.Ref == nil && elem.Select == nil
+	})
+}
+
+// Parses placeable, which is either an inline expression or a select expression.
+func (p *fluentParser) parsePlaceable() (elem fluentElement, err error) {
+	offset := p.pos
+	p.pos++
+	p.skipBlank()
+
+	elem, err = p.parseExpression()
+	if err != nil {
+		return fluentElement{}, err
+	}
+
+	p.skipBlank()
+
+	if p.pos+1 < len(p.data) && p.data[p.pos] == '-' && p.data[p.pos+1] == '>' {
+		if elem.Arg == nil {
+			return fluentElement{}, common.NewError(common.ErrUnsupportedArgumentType,
+				common.ErrorValueStr(string(p.data[elem.Offset:p.pos])),
+				common.ErrorExpectedStr("variable"),
+				common.ErrorLocation(p.src.location(elem.Offset)),
+			)
+		}
+
+		p.pos += 2
+
+		sel, err := p.parseVariants(elem.Arg.Name)
+		if err != nil {
+			return fluentElement{}, err
+		}
+
+		elem = fluentElement{Select: sel}
+	}
+
+	if err = p.expect('}'); err != nil {
+		return fluentElement{}, err
+	}
+
+	elem.Offset = offset
+	elem.indent = -1
+
+	return elem, nil
+}
+
+// Parses variants of select expression up to its closing bracket.
+func (p *fluentParser) parseVariants(arg string) (sel *fluentSelect, err error) {
+	sel = &fluentSelect{Arg: arg}
+
+	for {
+		p.skipBlank()
+
+		if p.pos == len(p.data) || p.data[p.pos] == '}' {
+			break
+		}
+
+		variant := fluentVariant{Offset: p.pos}
+
+		if p.data[p.pos] == '*' {
+			variant.Default = true
+			p.pos++
+		}
+
+		if err = p.expect('['); err != nil {
+			return nil, err
+		}
+
+		p.skipSpaces()
+
+		start := p.pos
+		for p.pos < len(p.data) && (isFluentIDChar(p.data[p.pos]) || p.data[p.pos] == '.') {
+			p.pos++
+		}
+
+		variant.Key = string(p.data[start:p.pos])
+		if variant.Key == "" {
+			return nil, p.unexpected()
+		}
+
+		p.skipSpaces()
+
+		if err = p.expect(']'); err != nil {
+			return nil, err
+		}
+
+		variant.Value, err = p.parsePattern()
+		if err != nil {
+			return nil, err
+		}
+
+		sel.Variants = append(sel.Variants, variant)
+	}
+
+	defaults := 0
+	for i := 0; i < len(sel.Variants); i++ {
+		if sel.Variants[i].Default {
+			defaults++
+		}
+	}
+
+	if defaults != 1 {
+		err = common.ErrFieldNotSpecified
+		if defaults > 1 {
+			err = common.ErrDuplicateField
+		}
+
+		return nil, common.NewError(err,
+			common.ErrorValueStr("default variant"),
+			common.ErrorLocation(p.src.location(p.pos)),
+		)
+	}
+
+	return sel, nil
+}
+
+// Parses inline expression: literal, variable, function call,
+// reference to a message or a term, or nested placeable.
+func (p *fluentParser) parseExpression() (elem fluentElement, err error) {
+	if p.pos == len(p.data) {
+		return fluentElement{}, p.unexpected()
+	}
+
+	offset := p.pos
+	c := p.data[p.pos]
+
+	switch {
+	case c == '"':
+		elem.Text, err = p.parseString()
+	case c >= '0' && c <= '9' || c == '-' && p.pos+1 < len(p.data) && p.data[p.pos+1] >= '0' && p.data[p.pos+1] <= '9':
+		elem.Text = p.parseNumber()
+	case c == '$':
+		p.pos++
+		elem.Arg, err = p.parseVariable()
+	case c == '-':
+		p.pos++
+		elem.Ref, err = p.parseReference(true)
+	case c == '{':
+		elem, err = p.parsePlaceable()
+	case isFluentIDStart(c):
+		start := p.pos
+		for p.pos < len(p.data) && isFluentIDChar(p.data[p.pos]) {
+			p.pos++
+		}
+
+		if p.pos < len(p.data) && p.data[p.pos] == '(' {
+			elem.Arg, err = p.parseFunction(string(p.data[start:p.pos]), start)
+			break
+		}
+
+		p.pos = start
+		elem.Ref, err = p.parseReference(false)
+	default:
+		return fluentElement{}, p.unexpected()
+	}
+
+	if err != nil {
+		return fluentElement{}, err
+	}
+
+	elem.Offset = offset
+	elem.indent = -1
+
+	return elem, nil
+}
+
+// Parses string literal, whose escapes are \", \\, \uXXXX and \UXXXXXX.
+func (p *fluentParser) parseString() (str string, err error) {
+	start := p.pos
+	p.pos++
+
+	var b strings.Builder
+
+	for {
+		if p.pos == len(p.data) || p.data[p.pos] == '\n' || p.data[p.pos] == '\r' {
+			return "", common.NewError(common.ErrNoClosingBracket,
+				common.ErrorExpectedChar('"'),
+				common.ErrorLocation(p.src.location(start)),
+			)
+		}
+
+		c := p.data[p.pos]
+		p.pos++
+
+		switch {
+		case c == '"':
+			return b.String(), nil
+		case c != '\\':
+			b.WriteByte(c)
+			continue
+		}
+
+		if p.pos == len(p.data) {
+			continue
+		}
+
+		escape := p.data[p.pos]
+		p.pos++
+
+		switch escape {
+		case '"', '\\':
+			b.WriteByte(escape)
+		case 'u', 'U':
+			n := 4
+			if escape == 'U' {
+				n = 6
+			}
+
+			if p.pos+n <= len(p.data) {
+				if code, err := strconv.ParseUint(string(p.data[p.pos:p.pos+n]), 16, 32); err == nil {
+					b.WriteRune(rune(code))
+					p.pos += n
+					break
+				}
+			}
+
+			fallthrough
+		default:
+			return "", common.NewError(common.ErrInvalidChar,
+				common.ErrorValueChar(rune(escape)),
+				common.ErrorLocation(p.src.location(p.pos-2)),
+			)
+		}
+	}
+}
+
+func (p *fluentParser) parseNumber() string {
+	start := p.pos
+	p.pos++
+
+	for p.pos < len(p.data) && (p.data[p.pos] >= '0' && p.data[p.pos] <= '9' || p.data[p.pos] == '.') {
+		p.pos++
+	}
+
+	return string(p.data[start:p.pos])
+}
+
+// Parses variable, which becomes an argument whose name is in camelCase.
+func (p *fluentParser) parseVariable() (arg *ast.ArgInfo, err error) {
+	start := p.pos
+
+	id, err := p.parseID()
+	if err != nil {
+		return nil, err
+	}
+
+	name := camelCaseName(id)
+	name = strings.ToLower(name[:1]) + name[1:]
+
+	if err = checkArgumentName(name); err != nil {
+		return nil, common.NewError(err,
+			common.ErrorValueStr(id),
+			common.ErrorLocation(p.src.location(start)),
+		)
+	}
+
+	return &ast.ArgInfo{Name: name}, nil
+}
+
+// Parses reference to a message or a term along with its attribute.
+// Terms can't be given arguments.
+func (p *fluentParser) parseReference(term bool) (ref *fluentRef, err error) {
+	ref = &fluentRef{Term: term}
+
+	ref.ID, err = p.parseID()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.data) && p.data[p.pos] == '.' {
+		p.pos++
+		if ref.Attr, err = p.parseID(); err != nil {
+			return nil, err
+		}
+	}
+
+	if term && p.pos < len(p.data) && p.data[p.pos] == '(' {
+		return nil, common.NewError(common.ErrUnsupportedArgumentStyle,
+			common.ErrorValueStr("-"+ref.ID+"(...)"),
+			common.ErrorLocation(p.src.location(p.pos)),
+		)
+	}
+
+	return ref, nil
+}
+
+// Parses call of the function, only NUMBER function is supported.
+// Its only positional argument is a variable,
+// and number of fraction digits can be set with minimumFractionDigits option.
+func (p *fluentParser) parseFunction(name string, offset int) (arg *ast.ArgInfo, err error) {
+	if name != "NUMBER" {
+		return nil, common.NewError(common.ErrUnsupportedArgumentType,
+			common.ErrorValueStr(name),
+			common.ErrorExpectedStr("NUMBER"),
+			common.ErrorLocation(p.src.location(offset)),
+		)
+	}
+
+	p.pos++
+	p.skipBlank()
+
+	if p.pos == len(p.data) || p.data[p.pos] != '$' {
+		return nil, p.unexpected(common.ErrorExpectedChar('$'))
+	}
+
+	p.pos++
+
+	arg, err = p.parseVariable()
+	if err != nil {
+		return nil, err
+	}
+
+	arg.FmtInfo = ast.FmtInfo{Spec: 'f', Mod: ast.ModOpt{Value: 'v', Valid: true}}
+
+	for {
+		p.skipBlank()
+
+		if p.pos < len(p.data) && p.data[p.pos] == ')' {
+			p.pos++
+			return arg, nil
+		}
+
+		if err = p.expect(','); err != nil {
+			return nil, err
+		}
+
+		p.skipBlank()
+
+		start := p.pos
+
+		option, err := p.parseID()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipBlank()
+
+		if err = p.expect(':'); err != nil {
+			return nil, err
+		}
+
+		p.skipBlank()
+
+		valueStart := p.pos
+		value := p.parseNumber()
+
+		digits, err := strconv.Atoi(value)
+		if option != "minimumFractionDigits" || err != nil {
+			return nil, common.NewError(common.ErrUnsupportedArgumentStyle,
+				common.ErrorValueStr(string(p.data[start:p.pos])),
+				common.ErrorExpectedStr("minimumFractionDigits"),
+				common.ErrorLocation(p.src.location(valueStart)),
+			)
+		}
+
+		arg.FmtInfo = ast.FmtInfo{Spec: 'f', Prec: ast.PrecOpt{Value: digits, Valid: true}}
+	}
+}
+
+func (p *fluentParser) parseID() (id string, err error) {
+	start := p.pos
+
+	if p.pos == len(p.data) || !isFluentIDStart(p.data[p.pos]) {
+		return "", p.unexpected()
+	}
+
+	for p.pos < len(p.data) && isFluentIDChar(p.data[p.pos]) {
+		p.pos++
+	}
+
+	return string(p.data[start:p.pos]), nil
+}
+
+func isFluentIDStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isFluentIDChar(c byte) bool {
+	return isFluentIDStart(c) || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *fluentParser) skipSpaces() {
+	for p.pos < len(p.data) && p.data[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// Skips spaces and line breaks.
+func (p *fluentParser) skipBlank() {
+	for p.pos < len(p.data) && strings.IndexByte(" \r\n", p.data[p.pos]) != -1 {
+		p.pos++
+	}
+}
+
+func (p *fluentParser) expect(c byte) (err error) {
+	if p.pos == len(p.data) || p.data[p.pos] != c {
+		return p.unexpected(common.ErrorExpectedChar(rune(c)))
+	}
+
+	p.pos++
+
+	return nil
+}
+
+func (p *fluentParser) unexpected(opts ...any) error {
+	opts = append(opts, common.ErrorLocation(p.src.location(p.pos)))
+
+	if p.pos == len(p.data) {
+		return common.NewError(common.ErrUnexpectedEndOfFormat, opts...)
+	}
+
+	c, _ := utf8.DecodeRune(p.data[p.pos:])
+
+	return common.NewError(common.ErrUnexpectedChar, append(opts, common.ErrorValueChar(c))...)
+}
+
+// Resolves references of Fluent patterns and converts them into messages.
+type fluentResolver struct {
+	src      *source
+	messages map[string]*fluentEntry
+	terms    map[string]*fluentEntry
+	// References that are being resolved
+	stack []fluentRef
+}
+
+// Maps the value or the attribute of the entry.
+func (r *fluentResolver) mapMessage(entry *fluentEntry, attr string, pattern fluentPattern) (message ast.Message, err error) {
+	r.stack = []fluentRef{{ID: entry.ID, Attr: attr}}
+
+	elems, err := r.resolve(entry, pattern, -1)
+	if err != nil {
+		return ast.Message{}, err
+	}
+
+	// Positions of elements are counted from the start of the entry
+	node := &Node{
+		Kind:   StringNode,
+		Str:    string(r.src.data[entry.Offset:entry.End]),
+		Offset: entry.Offset,
+	}
+
+	conv := &icuConverter{src: r.src}
+
+	message.String, message.Plural, err = conv.convertRoot(node, elems)
+	if err != nil {
+		return ast.Message{}, err
+	}
+
+	message.Variables = conv.variables
+	message.TextOrder = true
+
+	slices.SortStableFunc(message.Variables, func(a, b ast.Variable) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return message, nil
+}
+
+// Resolves the pattern of the entry into ICU elements.
+// Elements of referenced patterns are given the position of the reference,
+// which is passed as inlinePos, otherwise it is -1.
+func (r *fluentResolver) resolve(entry *fluentEntry, pattern fluentPattern, inlinePos int) (elems []icuElement, err error) {
+	for _, elem := range pattern {
+		pos := inlinePos
+		if pos == -1 {
+			pos = utf8.RuneCount(r.src.data[entry.Offset:elem.Offset])
+		}
+
+		switch {
+		case elem.Arg != nil:
+			elems = append(elems, icuElement{Pos: pos, Arg: elem.Arg})
+		case elem.Ref != nil:
+			refElems, err := r.resolveRef(elem, pos)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, refElem := range refElems {
+				elems = appendICUElement(elems, refElem)
+			}
+		case elem.Select != nil:
+			plural, err := r.resolveSelect(entry, elem.Select, inlinePos)
+			if err != nil {
+				return nil, err
+			}
+
+			elems = append(elems, icuElement{Pos: pos, Plural: plural})
+		default:
+			elems = appendICUElement(elems, icuElement{Pos: pos, Text: elem.Text})
+		}
+	}
+
+	return elems, nil
+}
+
+// Appends the element, merging adjacent texts.
+func appendICUElement(elems []icuElement, elem icuElement) []icuElement {
+	if last := len(elems) - 1; last >= 0 && elem.Arg == nil && elem.Plural == nil &&
+		elems[last].Arg == nil && elems[last].Plural == nil {
+		elems[last].Text += elem.Text
+		return elems
+	}
+	return append(elems, elem)
+}
+
+// Resolves the reference into the elements of the pattern it refers to.
+func (r *fluentResolver) resolveRef(elem fluentElement, pos int) (elems []icuElement, err error) {
+	ref := *elem.Ref
+
+	name := ref.ID
+	if ref.Term {
+		name = "-" + name
+	}
+	if ref.Attr != "" {
+		name += "." + ref.Attr
+	}
+
+	if slices.Contains(r.stack, ref) {
+		return nil, common.NewError(common.ErrCyclicReference,
+			common.ErrorValueStr(name),
+			common.ErrorLocation(r.src.location(elem.Offset)),
+		)
+	}
+
+	entries := r.messages
+	if ref.Term {
+		entries = r.terms
+	}
+
+	var pattern fluentPattern
+
+	entry, ok := entries[ref.ID]
+	if ok && ref.Attr == "" {
+		pattern = entry.Value
+	} else if ok {
+		for i := 0; i < len(entry.Attributes); i++ {
+			if entry.Attributes[i].ID == ref.Attr {
+				pattern = entry.Attributes[i].Value
+				break
+			}
+		}
+	}
+
+	if pattern == nil {
+		return nil, common.NewError(common.ErrUnknownReference,
+			common.ErrorValueStr(name),
+			common.ErrorLocation(r.src.location(elem.Offset)),
+		)
+	}
+
+	r.stack = append(r.stack, ref)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+
+	return r.resolve(entry, pattern, pos)
+}
+
+// Resolves select expression into a plural, whose variants are plural categories or numbers,
+// which become exact forms.
+// The default variant is also the other form, if there is no such variant.
+func (r *fluentResolver) resolveSelect(entry *fluentEntry, sel *fluentSelect, inlinePos int) (plural *icuPlural, err error) {
+	plural = &icuPlural{Arg: sel.Arg}
+
+	defaultIdx := -1
+
+	for i := 0; i < len(sel.Variants); i++ {
+		variant := &sel.Variants[i]
+
+		pos := inlinePos
+		if pos == -1 {
+			pos = utf8.RuneCount(r.src.data[entry.Offset:variant.Offset])
+		}
+
+		form := icuForm{Pos: pos}
+
+		switch variant.Key {
+		case "zero", "one", "two", "few", "many", "other":
+			form.Name = variant.Key
+		default:
+			if _, ok := exactFormNumber("=" + variant.Key); ok {
+				form.Name = "=" + variant.Key
+				break
+			}
+			return nil, common.NewError(common.ErrUnknownField,
+				common.ErrorValueStr(variant.Key),
+				common.ErrorExpectedAnyStr("N", "zero", "one", "two", "few", "many", "other"),
+				common.ErrorLocation(r.src.location(variant.Offset)),
+			)
+		}
+
+		for j := 0; j < len(plural.Forms); j++ {
+			if plural.Forms[j].Name == form.Name {
+				return nil, common.NewError(common.ErrDuplicateField,
+					common.ErrorValueStr(variant.Key),
+					common.ErrorLocation(r.src.location(variant.Offset)),
+				)
+			}
+		}
+
+		form.Elements, err = r.resolve(entry, variant.Value, inlinePos)
+		if err != nil {
+			return nil, common.NewFieldError(common.ErrCouldNotUnmarshal, form.Name, err)
+		}
+
+		plural.Forms = append(plural.Forms, form)
+
+		if variant.Default {
+			defaultIdx = len(plural.Forms) - 1
+		}
+	}
+
+	hasOther := slices.ContainsFunc(plural.Forms, func(form icuForm) bool {
+		return form.Name == "other"
+	})
+
+	if !hasOther {
+		other := plural.Forms[defaultIdx]
+		other.Name = "other"
+		plural.Forms = append(plural.Forms, other)
+	}
+
+	return plural, nil
+}
@@ -0,0 +1,139 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+)
+
+func TestUnmarshalFluent(t *testing.T) {
+	tests := []unmarshalTest{
+		{
+			name:    "variable",
+			in:      "hello = Hello, { $user-name }!\n",
+			message: "Hello",
+			want:    "Hello, ${userName}!",
+		},
+		{
+			name:    "number",
+			in:      "price = { NUMBER($amount) } or { NUMBER($amount, minimumFractionDigits: 2) }\n",
+			message: "Price",
+			want:    "${fv:amount} or ${.2f:amount}",
+		},
+		{
+			name: "plural",
+			in: "files = { $count ->\n" +
+				"    [one] { $count } file\n" +
+				"   *[other] { $count } files\n" +
+				"}\n",
+			message: "Files",
+			want:    "count: one {${count} file} other {${count} files}",
+		},
+		{
+			name: "numeric variants",
+			in: "files = { $count ->\n" +
+				"    [0] No files\n" +
+				"    [1] One file\n" +
+				"    [one] { $count } file\n" +
+				"   *[other] { $count } files\n" +
+				"}\n",
+			message: "Files",
+			want:    "count: =0 {No files} =1 {One file} one {${count} file} other {${count} files}",
+		},
+		{
+			name: "default variant",
+			in: "files = { $count ->\n" +
+				"   *[one] { $count } file\n" +
+				"    [few] { $count } files\n" +
+				"}\n",
+			message: "Files",
+			want:    "count: one {${count} file} few {${count} files} other {${count} file}",
+		},
+		{
+			name: "select in text",
+			in: "files = You have { $count ->\n" +
+				"    [one] a file\n" +
+				"   *[other] { $count } files\n" +
+				"}.\n",
+			message: "Files",
+			want:    "You have &{count_plural}.",
+			vars: map[string]string{
+				"count_plural": "count: one {a file} other {${count} files}",
+			},
+		},
+		{
+			name: "term",
+			in: "-brand = Firefox\n" +
+				"about = About { -brand }\n",
+			message: "About",
+			want:    "About Firefox",
+		},
+		{
+			name: "message reference",
+			in: "brand = Firefox\n" +
+				"about = About { brand }\n",
+			message: "About",
+			want:    "About Firefox",
+		},
+		{
+			name: "attribute",
+			in: "login = Log in\n" +
+				"    .placeholder = Email of { $user }\n",
+			message: "LoginPlaceholder",
+			want:    "Email of ${user}",
+		},
+		{
+			name:    "unsupported function",
+			in:      "today = { DATETIME($date) }\n",
+			message: "Today",
+			err:     common.ErrUnsupportedArgumentType,
+		},
+		{
+			name: "unknown variant",
+			in: "files = { $count ->\n" +
+				"    [lots] Many files\n" +
+				"   *[other] { $count } files\n" +
+				"}\n",
+			message: "Files",
+			err:     common.ErrUnknownField,
+		},
+		{
+			name: "numeric variant with leading zero",
+			in: "files = { $count ->\n" +
+				"    [01] One file\n" +
+				"   *[other] { $count } files\n" +
+				"}\n",
+			message: "Files",
+			err:     common.ErrUnknownField,
+		},
+		{
+			name: "duplicate variant",
+			in: "files = { $count ->\n" +
+				"    [0] No files\n" +
+				"    [0] Nothing\n" +
+				"   *[other] { $count } files\n" +
+				"}\n",
+			message: "Files",
+			err:     common.ErrDuplicateField,
+		},
+	}
+
+	runUnmarshalTests(t, tests, func(in string) ([]ast.Message, error) {
+		return UnmarshalFluentMessages("test.ftl", []byte(in))
+	})
+}
+
+func TestUnmarshalFluentDescription(t *testing.T) {
+	in := "# Greeting on the main page\n" +
+		"hello = Hello!\n"
+
+	messages, err := UnmarshalFluentMessages("test.ftl", []byte(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := messages[0].Description, "Greeting on the main page"; got != want {
+		t.Errorf("got description %q, want %q", got, want)
+	}
+}
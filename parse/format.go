@@ -10,7 +10,10 @@ import (
 	"github.com/infastin/go-l10n/common"
 )
 
-func parseFormat(fmt string) (parts ast.FormatParts, err error) {
+// Parses format string.
+// Locations of arguments and variables are determined
+// by the position of the character their blocks start with.
+func parseFormat(fmt string, locate func(pos int) ast.Location) (parts ast.FormatParts, err error) {
 	pos := 0
 
 	for fmt != "" {
@@ -24,6 +27,9 @@ func parseFormat(fmt string) (parts ast.FormatParts, err error) {
 			break
 		}
 
+		// Position of '$' or '&' character
+		blockPos := pos
+
 		// Preserve '$' or '&' character
 		text := fmt[:idx+1]
 		fmt = fmt[idx:]
@@ -63,6 +69,9 @@ func parseFormat(fmt string) (parts ast.FormatParts, err error) {
 			parts = append(parts, ast.Text(text))
 		}
 
+		// Position of the block contents
+		contentPos := pos
+
 		idx, err = findClosingBracket(fmt, &pos)
 		if err != nil {
 			return nil, err
@@ -70,24 +79,26 @@ func parseFormat(fmt string) (parts ast.FormatParts, err error) {
 
 		switch cur {
 		case '$':
-			arg, addPos, err := parseArgument(fmt[:idx])
+			arg, _, err := parseArgument(fmt[:idx])
 			if err != nil {
-				err.(*common.Error).Pos += common.ErrorPosition(pos)
+				err.(*common.Error).Pos += common.ErrorPosition(contentPos)
 				return nil, err
 			}
 
+			arg.Location = locate(blockPos)
 			parts = append(parts, arg)
-			pos += addPos
+			pos++
 			fmt = fmt[idx+1:]
 		case '&':
-			variable, addPos, err := parseVariable(fmt[:idx])
+			variable, _, err := parseVariable(fmt[:idx])
 			if err != nil {
-				err.(*common.Error).Pos += common.ErrorPosition(pos)
+				err.(*common.Error).Pos += common.ErrorPosition(contentPos)
 				return nil, err
 			}
 
+			variable.Location = locate(blockPos)
 			parts = append(parts, variable)
-			pos += addPos
+			pos++
 			fmt = fmt[idx+1:]
 		}
 	}
@@ -186,7 +197,8 @@ func parseArgument(arg string) (info ast.ArgInfo, pos int, err error) {
 		}
 
 		info.FmtInfo = formatInfo
-		pos = addPos
+		// Skip ':' character
+		pos = addPos + 1
 		arg = arg[colonIdx+1:]
 	}
 
@@ -0,0 +1,930 @@
+package parse
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+)
+
+// Syntax of message strings.
+type Syntax string
+
+const (
+	// Arguments are written as ${...} blocks and variables as &{...} blocks
+	SyntaxDefault Syntax = "default"
+	// ICU MessageFormat: {name}, {name, number}, {name, plural, ...}
+	SyntaxICU Syntax = "icu"
+)
+
+// Returns syntax specified by the string node.
+func (src *source) mapSyntax(node *Node) (syntax Syntax, err error) {
+	if node.Kind != StringNode {
+		return "", src.invalidFieldType(node, common.ErrorExpectedStr("string"))
+	}
+
+	switch syntax := Syntax(node.Str); syntax {
+	case SyntaxDefault, SyntaxICU:
+		return syntax, nil
+	default:
+		return "", common.NewError(common.ErrUnknownSyntax,
+			common.ErrorValueStr(node.Str),
+			common.ErrorExpectedAnyStr(string(SyntaxDefault), string(SyntaxICU)),
+			common.ErrorLocation(src.nodeLocation(node)),
+		)
+	}
+}
+
+// Returns syntax of the messages of the file, which is specified by the "syntax" key of the root table.
+func FileSyntax(root *Node) Syntax {
+	if value := root.Get("syntax"); value != nil && value.Kind == StringNode && Syntax(value.Str) == SyntaxICU {
+		return SyntaxICU
+	}
+	return SyntaxDefault
+}
+
+// Returns syntax of the message, which can be different from the one of the file.
+func MessageSyntax(fileSyntax Syntax, message *Node) Syntax {
+	if message.Kind != TableNode {
+		return fileSyntax
+	}
+	if value := message.Get("syntax"); value != nil && value.Kind == StringNode {
+		return Syntax(value.Str)
+	}
+	return fileSyntax
+}
+
+// Element of a message written in ICU MessageFormat:
+// either text, simple argument, plural or select.
+type icuElement struct {
+	// Position of the text or the opening bracket of the argument
+	Pos    int
+	Text   string
+	Arg    *ast.ArgInfo
+	Plural *icuPlural
+	Select *icuSelect
+}
+
+type icuPlural struct {
+	Arg   string
+	Forms []icuForm
+}
+
+// Select, whose forms are named after the values of the argument.
+type icuSelect struct {
+	Arg   string
+	Forms []icuForm
+}
+
+type icuForm struct {
+	// Position of the selector
+	Pos      int
+	Name     string
+	Elements []icuElement
+}
+
+type icuParser struct {
+	str []rune
+	pos int
+}
+
+// Parses message written in ICU MessageFormat.
+// Number signs are replaced with the argument of the plural,
+// or left as they are if the message is not a form of a plural.
+func parseICU(str, pluralArg string) (elems []icuElement, err error) {
+	p := &icuParser{str: []rune(str)}
+
+	elems, err = p.parseMessage(pluralArg)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos != len(p.str) {
+		return nil, common.NewError(common.ErrUnexpectedChar,
+			common.ErrorValueChar(p.str[p.pos]),
+			common.ErrorPosition(p.pos),
+		)
+	}
+
+	return elems, nil
+}
+
+// Parses message up to the end of the string or the closing bracket of the form it is in.
+func (p *icuParser) parseMessage(pluralArg string) (elems []icuElement, err error) {
+	var (
+		text    strings.Builder
+		textPos int
+	)
+
+	flush := func() {
+		if text.Len() != 0 {
+			elems = append(elems, icuElement{Pos: textPos, Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.str) {
+		c := p.str[p.pos]
+
+		if text.Len() == 0 {
+			textPos = p.pos
+		}
+
+		switch {
+		case c == '}':
+			flush()
+			return elems, nil
+		case c == '{':
+			flush()
+
+			elem, err := p.parseArgument(pluralArg)
+			if err != nil {
+				return nil, err
+			}
+
+			elems = append(elems, elem)
+		case c == '#' && pluralArg != "":
+			flush()
+			elems = append(elems, icuElement{Pos: p.pos, Arg: &ast.ArgInfo{Name: pluralArg}})
+			p.pos++
+		case c == '\'':
+			p.pos++
+			p.parseQuoted(&text, pluralArg != "")
+		default:
+			text.WriteRune(c)
+			p.pos++
+		}
+	}
+
+	flush()
+
+	return elems, nil
+}
+
+// Parses text that goes after an apostrophe.
+// Two apostrophes in a row are a single apostrophe,
+// and an apostrophe followed by a special character starts quoted text,
+// which lasts until the next single apostrophe.
+func (p *icuParser) parseQuoted(text *strings.Builder, inPlural bool) {
+	if p.pos == len(p.str) {
+		text.WriteByte('\'')
+		return
+	}
+
+	switch c := p.str[p.pos]; {
+	case c == '\'':
+		text.WriteByte('\'')
+		p.pos++
+		return
+	case c == '{' || c == '}' || c == '|' || (c == '#' && inPlural):
+	default:
+		text.WriteByte('\'')
+		return
+	}
+
+	for p.pos < len(p.str) {
+		c := p.str[p.pos]
+		p.pos++
+
+		if c != '\'' {
+			text.WriteRune(c)
+			continue
+		}
+
+		if p.pos < len(p.str) && p.str[p.pos] == '\'' {
+			text.WriteByte('\'')
+			p.pos++
+			continue
+		}
+
+		return
+	}
+}
+
+func (p *icuParser) skipSpaces() {
+	for p.pos < len(p.str) && unicode.IsSpace(p.str[p.pos]) {
+		p.pos++
+	}
+}
+
+// Returns the word, that consists of anything but spaces and syntax characters.
+func (p *icuParser) parseWord() (word string, pos int) {
+	pos = p.pos
+	for p.pos < len(p.str) && !unicode.IsSpace(p.str[p.pos]) && !strings.ContainsRune("{},#'", p.str[p.pos]) {
+		p.pos++
+	}
+	return string(p.str[pos:p.pos]), pos
+}
+
+func (p *icuParser) expect(c rune) (err error) {
+	if p.pos == len(p.str) {
+		if c == '}' {
+			return common.NewError(common.ErrNoClosingBracket, common.ErrorPosition(p.pos))
+		}
+		return common.NewError(common.ErrUnexpectedEndOfFormat,
+			common.ErrorExpectedChar(c),
+			common.ErrorPosition(p.pos),
+		)
+	}
+
+	if p.str[p.pos] != c {
+		return common.NewError(common.ErrUnexpectedChar,
+			common.ErrorValueChar(p.str[p.pos]),
+			common.ErrorExpectedChar(c),
+			common.ErrorPosition(p.pos),
+		)
+	}
+
+	p.pos++
+
+	return nil
+}
+
+// Parses argument that starts with the opening bracket.
+// Number signs of selects are replaced with the argument of the plural they are in.
+func (p *icuParser) parseArgument(pluralArg string) (elem icuElement, err error) {
+	elem.Pos = p.pos
+	p.pos++
+	p.skipSpaces()
+
+	name, namePos := p.parseWord()
+
+	switch err = checkArgumentName(name); err {
+	case common.ErrInvalidArgumentName:
+		return icuElement{}, common.NewError(err,
+			common.ErrorValueStr(name),
+			common.ErrorPosition(namePos),
+		)
+	case common.ErrNoArgumentName:
+		return icuElement{}, common.NewError(err, common.ErrorPosition(namePos))
+	}
+
+	p.skipSpaces()
+
+	if p.pos == len(p.str) || p.str[p.pos] == '}' {
+		if err = p.expect('}'); err != nil {
+			return icuElement{}, err
+		}
+
+		elem.Arg = &ast.ArgInfo{Name: name}
+
+		return elem, nil
+	}
+
+	if err = p.expect(','); err != nil {
+		return icuElement{}, err
+	}
+
+	p.skipSpaces()
+	argType, typePos := p.parseWord()
+	p.skipSpaces()
+
+	switch argType {
+	case "number":
+		elem.Arg, err = p.parseNumber(name)
+	case "plural":
+		elem.Plural, err = p.parsePlural(name)
+	case "select":
+		elem.Select, err = p.parseSelect(name, pluralArg)
+	default:
+		err = common.NewError(common.ErrUnsupportedArgumentType,
+			common.ErrorValueStr(argType),
+			common.ErrorExpectedAnyStr("number", "plural", "select"),
+			common.ErrorPosition(typePos),
+		)
+	}
+
+	if err != nil {
+		return icuElement{}, err
+	}
+
+	return elem, nil
+}
+
+// Parses style of the number argument, that goes after its type.
+// Numbers are float64, and integers are int.
+func (p *icuParser) parseNumber(name string) (arg *ast.ArgInfo, err error) {
+	arg = &ast.ArgInfo{
+		Name: name,
+		FmtInfo: ast.FmtInfo{
+			Spec: 'f',
+			Mod:  ast.ModOpt{Value: 'v', Valid: true},
+		},
+	}
+
+	if p.pos < len(p.str) && p.str[p.pos] == ',' {
+		p.pos++
+		p.skipSpaces()
+
+		style, stylePos := p.parseWord()
+		if style != "integer" {
+			return nil, common.NewError(common.ErrUnsupportedArgumentStyle,
+				common.ErrorValueStr(style),
+				common.ErrorExpectedStr("integer"),
+				common.ErrorPosition(stylePos),
+			)
+		}
+
+		arg.FmtInfo = ast.FmtInfo{Spec: 'd'}
+		p.skipSpaces()
+	}
+
+	if err = p.expect('}'); err != nil {
+		return nil, err
+	}
+
+	return arg, nil
+}
+
+// Parses forms of the plural argument, that go after its type.
+func (p *icuParser) parsePlural(name string) (plural *icuPlural, err error) {
+	if err = p.expect(','); err != nil {
+		return nil, err
+	}
+
+	plural = &icuPlural{Arg: name}
+
+	for {
+		p.skipSpaces()
+
+		if p.pos < len(p.str) && p.str[p.pos] == '}' {
+			p.pos++
+			break
+		}
+
+		selector, selectorPos := p.parseWord()
+
+		form := icuForm{Pos: selectorPos}
+
+		switch selector {
+		case "zero", "one", "two", "few", "many", "other":
+			form.Name = selector
+		case "":
+			return nil, p.expect('}')
+		default:
+			if _, ok := exactFormNumber(selector); ok {
+				form.Name = selector
+				break
+			}
+			if strings.HasPrefix(selector, "offset:") {
+				return nil, common.NewError(common.ErrUnsupportedArgumentStyle,
+					common.ErrorValueStr(selector),
+					common.ErrorPosition(selectorPos),
+				)
+			}
+			return nil, common.NewError(common.ErrUnknownField,
+				common.ErrorValueStr(selector),
+				common.ErrorExpectedAnyStr("=N", "zero", "one", "two", "few", "many", "other"),
+				common.ErrorPosition(selectorPos),
+			)
+		}
+
+		for i := 0; i < len(plural.Forms); i++ {
+			if plural.Forms[i].Name == form.Name {
+				return nil, common.NewError(common.ErrDuplicateField,
+					common.ErrorValueStr(selector),
+					common.ErrorPosition(selectorPos),
+				)
+			}
+		}
+
+		p.skipSpaces()
+
+		if err = p.expect('{'); err != nil {
+			return nil, err
+		}
+
+		form.Elements, err = p.parseMessage(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = p.expect('}'); err != nil {
+			return nil, err
+		}
+
+		plural.Forms = append(plural.Forms, form)
+	}
+
+	if len(plural.Forms) == 0 {
+		return nil, common.NewError(common.ErrFieldsNotSpecified,
+			common.ErrorExpectedAnyStr("zero", "one", "two", "few", "many", "other"),
+			common.ErrorPosition(p.pos-1),
+		)
+	}
+
+	return plural, nil
+}
+
+// Parses forms of the select argument, that go after its type.
+func (p *icuParser) parseSelect(name, pluralArg string) (sel *icuSelect, err error) {
+	if err = p.expect(','); err != nil {
+		return nil, err
+	}
+
+	sel = &icuSelect{Arg: name}
+
+	for {
+		p.skipSpaces()
+
+		if p.pos < len(p.str) && p.str[p.pos] == '}' {
+			p.pos++
+			break
+		}
+
+		selector, selectorPos := p.parseWord()
+		if selector == "" {
+			return nil, p.expect('}')
+		}
+
+		for i := 0; i < len(sel.Forms); i++ {
+			if sel.Forms[i].Name == selector {
+				return nil, common.NewError(common.ErrDuplicateField,
+					common.ErrorValueStr(selector),
+					common.ErrorPosition(selectorPos),
+				)
+			}
+		}
+
+		form := icuForm{Pos: selectorPos, Name: selector}
+
+		p.skipSpaces()
+
+		if err = p.expect('{'); err != nil {
+			return nil, err
+		}
+
+		form.Elements, err = p.parseMessage(pluralArg)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = p.expect('}'); err != nil {
+			return nil, err
+		}
+
+		sel.Forms = append(sel.Forms, form)
+	}
+
+	if len(sel.Forms) == 0 {
+		return nil, common.NewError(common.ErrFieldsNotSpecified,
+			common.ErrorExpectedStr("other"),
+			common.ErrorPosition(p.pos-1),
+		)
+	}
+
+	return sel, nil
+}
+
+// Converts messages written in ICU MessageFormat into the tree.
+// Plurals that are not the whole message, as well as selects,
+// become variables of the message, which are named after their arguments.
+type icuConverter struct {
+	src       *source
+	variables []ast.Variable
+}
+
+// Converts the string, which is either the message or the plural of the message.
+func (c *icuConverter) convertMessage(node *Node) (str ast.FormatParts, plural ast.Plural, err error) {
+	elems, err := c.parse(node, "")
+	if err != nil {
+		return nil, ast.Plural{}, err
+	}
+
+	return c.convertRoot(node, elems)
+}
+
+// Converts elements of the whole message, which is a plural if it is the only element.
+func (c *icuConverter) convertRoot(node *Node, elems []icuElement) (str ast.FormatParts, plural ast.Plural, err error) {
+	if len(elems) == 1 && elems[0].Plural != nil {
+		plural, err = c.convertPlural(node, elems[0].Plural)
+		if err != nil {
+			return nil, ast.Plural{}, err
+		}
+
+		plural.Location = c.src.charLocation(node, elems[0].Pos)
+
+		return nil, plural, nil
+	}
+
+	str, err = c.convertElements(node, elems)
+	if err != nil {
+		return nil, ast.Plural{}, err
+	}
+
+	return str, ast.Plural{}, nil
+}
+
+// Converts the string, which is the form of a plural with the given argument.
+func (c *icuConverter) convertForm(node *Node, pluralArg string) (str ast.FormatParts, err error) {
+	elems, err := c.parse(node, pluralArg)
+	if err != nil {
+		return nil, err
+	}
+	return c.convertElements(node, elems)
+}
+
+func (c *icuConverter) parse(node *Node, pluralArg string) (elems []icuElement, err error) {
+	elems, err = parseICU(node.Str, pluralArg)
+	if err != nil {
+		return nil, c.src.locateFormatError(node, err)
+	}
+	return elems, nil
+}
+
+// Converts elements into format parts.
+// Plurals and selects become variables, which can refer to each other if they are nested.
+func (c *icuConverter) convertElements(node *Node, elems []icuElement) (parts ast.FormatParts, err error) {
+	for _, elem := range elems {
+		switch {
+		case elem.Arg != nil:
+			arg := *elem.Arg
+			arg.Location = c.src.charLocation(node, elem.Pos)
+			parts = append(parts, arg)
+		case elem.Select != nil:
+			sel, err := c.convertSelect(node, elem.Select)
+			if err != nil {
+				return nil, err
+			}
+
+			loc := c.src.charLocation(node, elem.Pos)
+			sel.Location = loc
+
+			variable := ast.Variable{
+				Location: loc,
+				Name:     c.variableName(elem.Select.Arg + "_select"),
+				Select:   sel,
+			}
+
+			c.variables = append(c.variables, variable)
+			parts = append(parts, ast.VarInfo{Location: loc, Name: variable.Name})
+		case elem.Plural != nil:
+			plural, err := c.convertPlural(node, elem.Plural)
+			if err != nil {
+				return nil, err
+			}
+
+			loc := c.src.charLocation(node, elem.Pos)
+			plural.Location = loc
+
+			variable := ast.Variable{
+				Location: loc,
+				Name:     c.variableName(elem.Plural.Arg + "_plural"),
+				Plural:   plural,
+			}
+
+			c.variables = append(c.variables, variable)
+			parts = append(parts, ast.VarInfo{Location: loc, Name: variable.Name})
+		default:
+			parts = append(parts, ast.Text(elem.Text))
+		}
+	}
+
+	return parts, nil
+}
+
+func (c *icuConverter) convertPlural(node *Node, elemPlural *icuPlural) (plural ast.Plural, err error) {
+	plural.Arg = elemPlural.Arg
+
+	for i := 0; i < len(elemPlural.Forms); i++ {
+		form := &elemPlural.Forms[i]
+
+		parts, err := c.convertElements(node, form.Elements)
+		if err != nil {
+			return ast.Plural{}, common.NewFieldError(common.ErrCouldNotUnmarshal, form.Name, err)
+		}
+
+		// Empty forms are specified too
+		if parts == nil {
+			parts = ast.FormatParts{ast.Text("")}
+		}
+
+		if n, ok := exactFormNumber(form.Name); ok {
+			plural.Exact = append(plural.Exact, ast.ExactForm{Number: n, Parts: parts})
+			continue
+		}
+
+		switch form.Name {
+		case "zero":
+			plural.Zero = parts
+		case "one":
+			plural.One = parts
+		case "two":
+			plural.Two = parts
+		case "few":
+			plural.Few = parts
+		case "many":
+			plural.Many = parts
+		case "other":
+			plural.Other = parts
+		}
+	}
+
+	return plural, nil
+}
+
+func (c *icuConverter) convertSelect(node *Node, elemSelect *icuSelect) (sel ast.Select, err error) {
+	sel.Arg = elemSelect.Arg
+
+	for i := 0; i < len(elemSelect.Forms); i++ {
+		form := &elemSelect.Forms[i]
+
+		parts, err := c.convertElements(node, form.Elements)
+		if err != nil {
+			return ast.Select{}, common.NewFieldError(common.ErrCouldNotUnmarshal, form.Name, err)
+		}
+
+		// Empty forms are specified too
+		if parts == nil {
+			parts = ast.FormatParts{ast.Text("")}
+		}
+
+		if form.Name == "other" {
+			sel.Other = parts
+		} else {
+			sel.Cases = append(sel.Cases, ast.SelectCase{Value: form.Name, Parts: parts})
+		}
+	}
+
+	return sel, nil
+}
+
+// Returns number of the plural form that matches it exactly, which is written as "=N".
+// Numbers are non-negative and written without leading zeros.
+func exactFormNumber(name string) (n int, ok bool) {
+	if !strings.HasPrefix(name, "=") {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(name[1:])
+	if err != nil || n < 0 || strconv.Itoa(n) != name[1:] {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// Returns name of the variable that is not taken yet.
+func (c *icuConverter) variableName(name string) string {
+	for {
+		taken := false
+		for i := 0; i < len(c.variables); i++ {
+			if c.variables[i].Name == name {
+				taken = true
+				break
+			}
+		}
+
+		if !taken {
+			return name
+		}
+
+		name += "_"
+	}
+}
+
+// Returns the text written in ICU MessageFormat.
+// Variables of the message are written in place of their references,
+// and number signs are written in place of the argument of the plural the text is the form of.
+// Formats of arguments that can't be written in ICU MessageFormat result in an error.
+func FormatICU(text ast.FormatParts, variables []ast.Variable, pluralArg string, useZero bool) (str string, err error) {
+	f := &icuFormatter{useZero: useZero}
+
+	err = f.format(text, variables, pluralArg)
+	if err != nil {
+		return "", err
+	}
+
+	return f.b.String(), nil
+}
+
+// Returns the plural written in ICU MessageFormat.
+// Exact forms go first, and zero form is written as "=0" if the language doesn't use it.
+func FormatICUPlural(plural *ast.Plural, variables []ast.Variable, useZero bool) (str string, err error) {
+	f := &icuFormatter{useZero: useZero}
+
+	err = f.formatPlural(plural, variables)
+	if err != nil {
+		return "", err
+	}
+
+	return f.b.String(), nil
+}
+
+// Returns the text written the way ARB files have it:
+// in ICU MessageFormat, whose arguments are written as {name} whatever their formats are.
+func FormatARB(text ast.FormatParts, variables []ast.Variable, useZero bool) (str string, err error) {
+	f := &icuFormatter{useZero: useZero, plainArgs: true}
+
+	err = f.format(text, variables, "")
+	if err != nil {
+		return "", err
+	}
+
+	return f.b.String(), nil
+}
+
+// Returns the plural written the way ARB files have it.
+func FormatARBPlural(plural *ast.Plural, variables []ast.Variable, useZero bool) (str string, err error) {
+	f := &icuFormatter{useZero: useZero, plainArgs: true}
+
+	err = f.formatPlural(plural, variables)
+	if err != nil {
+		return "", err
+	}
+
+	return f.b.String(), nil
+}
+
+type icuFormatter struct {
+	b strings.Builder
+	// Whether zero form is written as "zero" rather than "=0"
+	useZero bool
+	// Whether arguments are written as {name}, even the ones of plurals
+	plainArgs bool
+}
+
+func (f *icuFormatter) format(text ast.FormatParts, variables []ast.Variable, pluralArg string) (err error) {
+	b := &f.b
+
+	for _, part := range text {
+		switch part := part.(type) {
+		case ast.Text:
+			formatICUText(b, string(part), pluralArg != "")
+		case ast.ArgInfo:
+			switch {
+			case f.plainArgs:
+				b.WriteString("{" + part.Name + "}")
+			case part.Name == pluralArg && !part.FmtInfo.HasOptions() && part.FmtInfo.Spec == 0:
+				b.WriteByte('#')
+			case part.FmtInfo.Spec == 'f' && part.FmtInfo.Mod == (ast.ModOpt{Value: 'v', Valid: true}) &&
+				part.FmtInfo.Flags == nil && !part.FmtInfo.Width.Valid && !part.FmtInfo.Prec.Valid:
+				b.WriteString("{" + part.Name + ", number}")
+			case part.FmtInfo.Spec == 'd' && !part.FmtInfo.HasOptions():
+				b.WriteString("{" + part.Name + ", number, integer}")
+			case part.FmtInfo.Spec == 0 && !part.FmtInfo.HasOptions():
+				b.WriteString("{" + part.Name + "}")
+			default:
+				return common.NewError(common.ErrUnsupportedArgumentStyle,
+					common.ErrorValueStr(ast.FormatParts{part}.String()),
+				)
+			}
+		case ast.VarInfo:
+			idx := -1
+			for i := 0; i < len(variables); i++ {
+				if variables[i].Name == part.Name {
+					idx = i
+					break
+				}
+			}
+
+			if idx == -1 {
+				return common.NewError(common.ErrVariableNotSpecified, common.ErrorValueStr(part.Name))
+			}
+
+			variable := &variables[idx]
+
+			switch {
+			case !variable.Plural.IsZero():
+				err = f.formatPlural(&variable.Plural, variables)
+			case !variable.Select.IsZero():
+				err = f.formatSelect(&variable.Select, variables, pluralArg)
+			default:
+				err = f.format(variable.String, variables, pluralArg)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *icuFormatter) formatPlural(plural *ast.Plural, variables []ast.Variable) (err error) {
+	b := &f.b
+
+	b.WriteString("{" + plural.Arg + ", plural,")
+
+	for i := 0; i < len(plural.Exact); i++ {
+		form := &plural.Exact[i]
+
+		b.WriteString(" " + form.Name() + " {")
+
+		err = f.format(form.Parts, variables, plural.Arg)
+		if err != nil {
+			return err
+		}
+
+		b.WriteByte('}')
+	}
+
+	forms := []struct {
+		Name  string
+		Value ast.FormatParts
+	}{
+		{"zero", plural.Zero},
+		{"one", plural.One},
+		{"two", plural.Two},
+		{"few", plural.Few},
+		{"many", plural.Many},
+		{"other", plural.Other},
+	}
+
+	for _, form := range forms {
+		if form.Value == nil {
+			continue
+		}
+
+		if form.Name == "zero" && !f.useZero {
+			// Zero form can't be reached if there is "=0" form already
+			if plural.ExactForm(0) != nil {
+				continue
+			}
+			b.WriteString(" =0 {")
+		} else {
+			b.WriteString(" " + form.Name + " {")
+		}
+
+		err = f.format(form.Value, variables, plural.Arg)
+		if err != nil {
+			return err
+		}
+
+		b.WriteByte('}')
+	}
+
+	b.WriteByte('}')
+
+	return nil
+}
+
+// Writes the select, whose forms are in the plural with the given argument.
+// Values that are not ICU MessageFormat selectors result in an error.
+func (f *icuFormatter) formatSelect(sel *ast.Select, variables []ast.Variable, pluralArg string) (err error) {
+	b := &f.b
+
+	b.WriteString("{" + sel.Arg + ", select,")
+
+	writeForm := func(name string, text ast.FormatParts) error {
+		if name == "" || strings.ContainsFunc(name, func(c rune) bool {
+			return unicode.IsSpace(c) || strings.ContainsRune("{},#'", c)
+		}) {
+			return common.NewError(common.ErrUnsupportedArgumentStyle, common.ErrorValueStr(name))
+		}
+
+		b.WriteString(" " + name + " {")
+
+		if err := f.format(text, variables, pluralArg); err != nil {
+			return err
+		}
+
+		b.WriteByte('}')
+
+		return nil
+	}
+
+	for i := 0; i < len(sel.Cases); i++ {
+		if err = writeForm(sel.Cases[i].Value, sel.Cases[i].Parts); err != nil {
+			return err
+		}
+	}
+
+	if err = writeForm("other", sel.Other); err != nil {
+		return err
+	}
+
+	b.WriteByte('}')
+
+	return nil
+}
+
+// Writes the text, whose syntax characters are quoted.
+// Apostrophes are only doubled if they could start quoted text otherwise,
+// that is, if they are followed by an apostrophe or a syntax character,
+// or they are at the end of the text, which can be followed by anything.
+func formatICUText(b *strings.Builder, text string, inPlural bool) {
+	special := func(c rune) bool {
+		return c == '{' || c == '}' || c == '|' || (c == '#' && inPlural)
+	}
+
+	runes := []rune(text)
+
+	for i, c := range runes {
+		switch {
+		case c == '\'':
+			if i+1 == len(runes) || runes[i+1] == '\'' || special(runes[i+1]) {
+				b.WriteString("''")
+			} else {
+				b.WriteByte('\'')
+			}
+		case c == '{' || c == '}' || (c == '#' && inPlural):
+			b.WriteString("'" + string(c) + "'")
+		default:
+			b.WriteRune(c)
+		}
+	}
+}
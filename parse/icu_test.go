@@ -0,0 +1,189 @@
+package parse
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+)
+
+func TestUnmarshalICU(t *testing.T) {
+	tests := []unmarshalTest{
+		{
+			name: "text",
+			in:   "Hello, world!",
+			want: "Hello, world!",
+		},
+		{
+			name: "quoted",
+			in:   "It''s '{literal}' and '#'",
+			want: "It's {literal} and '#'",
+		},
+		{
+			name: "quoted in plural",
+			in:   "{n, plural, other {'#' is #}}",
+			want: "n: other {# is ${n}}",
+		},
+		{
+			name: "arguments",
+			in:   "{name} has {count, number, integer} of {total, number}",
+			want: "${name} has ${d:count} of ${fv:total}",
+		},
+		{
+			name: "plural",
+			in:   "{n, plural, =0 {No files} one {# file} other {# files}}",
+			want: "n: =0 {No files} one {${n} file} other {${n} files}",
+		},
+		{
+			name: "zero form",
+			in:   "{n, plural, zero {No files} other {# files}}",
+			want: "n: zero {No files} other {${n} files}",
+		},
+		{
+			name: "plural in text",
+			in:   "You have {n, plural, one {# file} other {# files}}.",
+			want: "You have &{n_plural}.",
+			vars: map[string]string{
+				"n_plural": "n: one {${n} file} other {${n} files}",
+			},
+		},
+		{
+			name: "select",
+			in:   "{gender, select, female {She} male {He} other {They}} replied",
+			want: "&{gender_select} replied",
+			vars: map[string]string{
+				"gender_select": "gender: female {She} male {He} other {They}",
+			},
+		},
+		{
+			name: "plural in select",
+			in:   "{gender, select, female {{n, plural, one {her file} other {her # files}}} other {files}}",
+			want: "&{gender_select}",
+			vars: map[string]string{
+				"gender_select": "gender: female {&{n_plural}} other {files}",
+				"n_plural":      "n: one {her file} other {her ${n} files}",
+			},
+		},
+		{
+			name: "select in plural",
+			in:   "{n, plural, one {{gender, select, female {her file} other {their file}}} other {# files}}",
+			want: "n: one {&{gender_select}} other {${n} files}",
+			vars: map[string]string{
+				"gender_select": "gender: female {her file} other {their file}",
+			},
+		},
+		{
+			name: "number sign in select in plural",
+			in:   "{n, plural, other {{gender, select, female {her # files} other {# files}}}}",
+			want: "n: other {&{gender_select}}",
+			vars: map[string]string{
+				"gender_select": "gender: female {her ${n} files} other {${n} files}",
+			},
+		},
+		{
+			name: "unsupported argument type",
+			in:   "{day, date, short}",
+			err:  common.ErrUnsupportedArgumentType,
+		},
+		{
+			name: "plural offset",
+			in:   "{n, plural, offset:1 one {# file} other {# files}}",
+			err:  common.ErrUnsupportedArgumentStyle,
+		},
+		{
+			name: "exact form with leading zero",
+			in:   "{n, plural, =01 {one file} other {# files}}",
+			err:  common.ErrUnknownField,
+		},
+		{
+			name: "duplicate exact form",
+			in:   "{n, plural, =1 {one file} =1 {a file} other {# files}}",
+			err:  common.ErrDuplicateField,
+		},
+		{
+			name: "duplicate select case",
+			in:   "{gender, select, male {He} male {Him} other {They}}",
+			err:  common.ErrDuplicateField,
+		},
+	}
+
+	// Messages are written in YAML file with ICU syntax
+	runUnmarshalTests(t, tests, func(in string) ([]ast.Message, error) {
+		in = "syntax: icu\nMessage: " + strconv.Quote(in) + "\n"
+		return UnmarshalMessages("test.yaml", []byte(in), DecodeYAML)
+	})
+}
+
+func TestFormatICU(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		useZero bool
+		want    string
+	}{
+		{
+			name:    "arguments",
+			message: "{name} has {count, number, integer} files",
+			want:    "{name} has {count, number, integer} files",
+		},
+		{
+			name:    "quoted",
+			message: "It''s '{literal}'",
+			want:    "It's '{'literal'}'",
+		},
+		{
+			name:    "plural",
+			message: "{n, plural, =0 {No files} one {# file} other {# files}}",
+			want:    "{n, plural, =0 {No files} one {# file} other {# files}}",
+		},
+		{
+			name:    "zero form",
+			message: "{n, plural, zero {No files} other {# files}}",
+			useZero: true,
+			want:    "{n, plural, zero {No files} other {# files}}",
+		},
+		{
+			name:    "zero form in language without it",
+			message: "{n, plural, zero {No files} other {# files}}",
+			want:    "{n, plural, =0 {No files} other {# files}}",
+		},
+		{
+			name:    "zero form along with exact one",
+			message: "{n, plural, =0 {No files} zero {Zero files} other {# files}}",
+			want:    "{n, plural, =0 {No files} other {# files}}",
+		},
+		{
+			name:    "select with plural",
+			message: "{gender, select, female {{n, plural, one {her file} other {her # files}}} other {files}}",
+			want:    "{gender, select, female {{n, plural, one {her file} other {her # files}}} other {files}}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := "syntax: icu\nMessage: " + strconv.Quote(tt.message) + "\n"
+
+			messages, err := UnmarshalMessages("test.yaml", []byte(in), DecodeYAML)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			message := &messages[0]
+
+			var got string
+			if !message.Plural.IsZero() {
+				got, err = FormatICUPlural(&message.Plural, message.Variables, tt.useZero)
+			} else {
+				got, err = FormatICU(message.String, message.Variables, "", tt.useZero)
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got:  %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}
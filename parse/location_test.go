@@ -0,0 +1,313 @@
+package parse
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+)
+
+// Returns locations of arguments and variables of the text.
+func partLocations(parts ast.FormatParts) (locs []string) {
+	for _, part := range parts {
+		switch part := part.(type) {
+		case ast.ArgInfo:
+			locs = append(locs, part.Location.String())
+		case ast.VarInfo:
+			locs = append(locs, part.Location.String())
+		}
+	}
+	return locs
+}
+
+// Returns location of the message followed by locations of what it consists of:
+// declared arguments, arguments and variables of its text,
+// and then its variables with their own texts.
+func messageLocations(message *ast.Message) (locs []string) {
+	locs = append(locs, message.Location.String())
+
+	for i := 0; i < len(message.Arguments); i++ {
+		locs = append(locs, message.Arguments[i].Location.String())
+	}
+
+	if !message.Plural.IsZero() {
+		locs = append(locs, message.Plural.Location.String())
+		for _, form := range message.Plural.Forms() {
+			locs = append(locs, partLocations(form)...)
+		}
+	} else {
+		locs = append(locs, partLocations(message.String)...)
+	}
+
+	for i := 0; i < len(message.Variables); i++ {
+		variable := &message.Variables[i]
+		locs = append(locs, variable.Location.String())
+
+		switch {
+		case !variable.Plural.IsZero():
+			locs = append(locs, variable.Plural.Location.String())
+			for _, form := range variable.Plural.Forms() {
+				locs = append(locs, partLocations(form)...)
+			}
+		case !variable.Select.IsZero():
+			locs = append(locs, variable.Select.Location.String())
+			for _, form := range variable.Select.Forms() {
+				locs = append(locs, partLocations(form)...)
+			}
+		default:
+			locs = append(locs, partLocations(variable.String)...)
+		}
+	}
+
+	return locs
+}
+
+// Test of locations of a message or of the error it has.
+type locationTest struct {
+	name string
+	in   string
+	// Locations of the message as returned by messageLocations
+	want []string
+	// Location of the only error
+	err string
+}
+
+func runLocationTests(t *testing.T, filename string, tests []locationTest, decode Decoder) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := UnmarshalMessages(filename, []byte(tt.in), decode)
+			if tt.err != "" {
+				errs := common.Errors(err)
+				if len(errs) != 1 {
+					t.Fatalf("got errors %v, want one at %s", err, tt.err)
+				}
+				loc, ok := common.ErrorLocationOf(errs[0])
+				if !ok {
+					t.Fatalf("got error without location: %v", err)
+				}
+				if loc.String() != tt.err {
+					t.Fatalf("got error at %s, want at %s: %v", loc, tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(messages) != 1 {
+				t.Fatalf("got %d messages, want 1", len(messages))
+			}
+
+			if got := messageLocations(&messages[0]); !slices.Equal(got, tt.want) {
+				t.Errorf("got locations %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocationsYAML(t *testing.T) {
+	tests := []locationTest{
+		{
+			name: "plain string",
+			in:   "Hello: Hello, ${name}!",
+			want: []string{"loc.en.yaml:1:1", "loc.en.yaml:1:15"},
+		},
+		{
+			name: "double quoted string",
+			in:   `Hello: "Hello, ${name}!"`,
+			want: []string{"loc.en.yaml:1:1", "loc.en.yaml:1:16"},
+		},
+		{
+			name: "escapes",
+			in:   `Hello: "\u0041\t${name}"`,
+			want: []string{"loc.en.yaml:1:1", "loc.en.yaml:1:17"},
+		},
+		{
+			name: "doubled quotes",
+			in:   `Hello: 'It''s ${name}'`,
+			want: []string{"loc.en.yaml:1:1", "loc.en.yaml:1:15"},
+		},
+		{
+			name: "multibyte characters",
+			in:   `Hello: "Привет, ${name}!"`,
+			want: []string{"loc.en.yaml:1:1", "loc.en.yaml:1:17"},
+		},
+		{
+			name: "literal block scalar",
+			in:   "Hello: |\n  Hello,\n    ${name}!\n",
+			want: []string{"loc.en.yaml:1:1", "loc.en.yaml:3:5"},
+		},
+		{
+			name: "folded block scalar",
+			in:   "Hello: >-\n  Hello,\n  dear ${name}!\n",
+			want: []string{"loc.en.yaml:1:1", "loc.en.yaml:3:8"},
+		},
+		{
+			name: "variables",
+			in: `# Comment
+Late:
+  variables:
+    minutes:
+      plural:
+        arg: count
+        one: "a minute"
+        other: "${count} minutes"
+  string: "You are &{minutes} late, ${name}."
+`,
+			want: []string{
+				"loc.en.yaml:2:1", "loc.en.yaml:9:20", "loc.en.yaml:9:37",
+				"loc.en.yaml:4:5", "loc.en.yaml:5:7", "loc.en.yaml:8:17",
+			},
+		},
+		{
+			name: "unexpected character after escapes",
+			in:   `Hello: "\u0041 $x"`,
+			err:  "loc.en.yaml:1:17",
+		},
+		{
+			name: "unknown field",
+			in:   "Hello:\n  string: Hello\n  strong: Hello\n",
+			err:  "loc.en.yaml:3:3",
+		},
+		{
+			name: "syntax error",
+			in:   "Hello: Hello\n World: Hi\n",
+			err:  "loc.en.yaml:2",
+		},
+	}
+
+	runLocationTests(t, "loc.en.yaml", tests, DecodeYAML)
+}
+
+func TestLocationsJSON(t *testing.T) {
+	tests := []locationTest{
+		{
+			name: "string",
+			in:   `{"Hello": "Hello, ${name}!"}`,
+			want: []string{"loc.en.json:1:2", "loc.en.json:1:19"},
+		},
+		{
+			name: "escapes",
+			in:   `{"Hello": "\u0041\n\"${name}\""}`,
+			want: []string{"loc.en.json:1:2", "loc.en.json:1:22"},
+		},
+		{
+			name: "surrogate pair",
+			in:   `{"Hello": "\ud83d\ude00 ${name}"}`,
+			want: []string{"loc.en.json:1:2", "loc.en.json:1:25"},
+		},
+		{
+			name: "table",
+			in: `{
+  "Hello": {
+    "arguments": ["name"],
+    "string": "Hi, ${name}"
+  }
+}`,
+			want: []string{"loc.en.json:2:3", "loc.en.json:3:19", "loc.en.json:4:20"},
+		},
+		{
+			name: "unexpected character after escapes",
+			in:   `{"Hello": "\u0041 $x"}`,
+			err:  "loc.en.json:1:20",
+		},
+		{
+			name: "duplicate key",
+			in:   "{\n  \"Hello\": \"Hello\",\n  \"Hello\": \"Hi\"\n}",
+			err:  "loc.en.json:3:3",
+		},
+		{
+			name: "syntax error",
+			in:   "{\n  \"Hello\" \"Hello\"\n}",
+			err:  "loc.en.json:2:11",
+		},
+	}
+
+	runLocationTests(t, "loc.en.json", tests, DecodeJSON)
+}
+
+func TestLocationsTOML(t *testing.T) {
+	tests := []locationTest{
+		{
+			name: "basic string",
+			in:   `Hello = "Hello, ${name}!"`,
+			want: []string{"loc.en.toml:1:1", "loc.en.toml:1:17"},
+		},
+		{
+			name: "escapes",
+			in:   `Hello = "\u0041\t${name}"`,
+			want: []string{"loc.en.toml:1:1", "loc.en.toml:1:18"},
+		},
+		{
+			name: "literal string",
+			in:   `Hello = 'C:\${name}'`,
+			want: []string{"loc.en.toml:1:1", "loc.en.toml:1:13"},
+		},
+		{
+			name: "multiline basic string",
+			in:   "Hello = \"\"\"\nHello,\n  ${name}!\"\"\"\n",
+			want: []string{"loc.en.toml:1:1", "loc.en.toml:3:3"},
+		},
+		{
+			name: "line ending backslash",
+			in:   "Hello = \"\"\"\nHello, \\\n    ${name}!\"\"\"\n",
+			want: []string{"loc.en.toml:1:1", "loc.en.toml:3:5"},
+		},
+		{
+			name: "multiline literal string",
+			in:   "Hello = '''\nHello,\n${name}!'''\n",
+			want: []string{"loc.en.toml:1:1", "loc.en.toml:3:1"},
+		},
+		{
+			name: "dotted keys",
+			in:   "# Comment\nHello.arguments = [\"name\"]\nHello.string = \"Hi, ${name}\"\n",
+			want: []string{"loc.en.toml:2:1", "loc.en.toml:2:20", "loc.en.toml:3:21"},
+		},
+		{
+			name: "quoted dotted keys",
+			in:   `"Hello" . "string" = "Hi, ${name}"`,
+			want: []string{"loc.en.toml:1:1", "loc.en.toml:1:27"},
+		},
+		{
+			name: "table",
+			in:   "[Hello]\nstring = \"Hi, ${name}\"\n",
+			want: []string{"loc.en.toml:1:2", "loc.en.toml:2:15"},
+		},
+		{
+			name: "inline table",
+			in:   `Hello = { arguments = ["name"], string = "Hi, ${name}" }`,
+			want: []string{"loc.en.toml:1:1", "loc.en.toml:1:24", "loc.en.toml:1:47"},
+		},
+		{
+			name: "variables in inline tables",
+			in: `[Late]
+variables = { minutes = { plural = { arg = "count", other = "${count} minutes" } } }
+string = "You are &{minutes} late."
+`,
+			want: []string{
+				"loc.en.toml:1:2", "loc.en.toml:3:19",
+				"loc.en.toml:2:15", "loc.en.toml:2:27", "loc.en.toml:2:62",
+			},
+		},
+		{
+			name: "unexpected character after escapes",
+			in:   `Hello = "\u0041 $x"`,
+			err:  "loc.en.toml:1:18",
+		},
+		{
+			name: "unknown field",
+			in:   "[Hello]\nstring = \"Hello\"\nstrong = \"Hello\"\n",
+			err:  "loc.en.toml:3:1",
+		},
+		{
+			name: "syntax error",
+			in:   "Hello = \"Hello\"\nWorld = \n",
+			err:  "loc.en.toml:2:9",
+		},
+	}
+
+	runLocationTests(t, "loc.en.toml", tests, DecodeTOML)
+}
@@ -0,0 +1,241 @@
+package parse
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/infastin/go-l10n/ast"
+)
+
+type NodeKind byte
+
+const (
+	// Any value that is not a string, a table or an array
+	OtherNode NodeKind = iota
+	StringNode
+	TableNode
+	ArrayNode
+)
+
+// Value decoded from a localization file
+// along with its location in the file.
+type Node struct {
+	Kind  NodeKind
+	Str   string
+	Table []NodeEntry
+	Array []*Node
+	// Offset of the value in the file or -1 if it is unknown
+	Offset int
+	// The way the string is written in the file
+	Style StringStyle
+	// Comments of the value, only YAML keeps them
+	Comments Comments
+}
+
+type NodeEntry struct {
+	Key string
+	// Offset of the key in the file or -1 if it is unknown
+	KeyOffset int
+	// Comments of the key, only YAML keeps them
+	KeyComments Comments
+	Value       *Node
+}
+
+// Comments that are written before, on the same line and after a value.
+type Comments struct {
+	Head string
+	Line string
+	Foot string
+}
+
+type StringStyle struct {
+	// Quotes the string starts with
+	Quote string
+	// Whether the string can contain backslash escapes
+	Escapes bool
+	// Whether quotes are escaped by doubling them
+	DoubledQuotes bool
+	// Whether the string starts on the next line
+	Block bool
+}
+
+// Localization file that locations are computed for.
+type source struct {
+	file string
+	data []byte
+	// Offsets of the beginnings of the lines
+	lines []int
+}
+
+func newSource(file string, data []byte) *source {
+	src := &source{
+		file:  file,
+		data:  data,
+		lines: []int{0},
+	}
+
+	for i, c := range data {
+		if c == '\n' {
+			src.lines = append(src.lines, i+1)
+		}
+	}
+
+	return src
+}
+
+// Returns location of the byte at the given offset.
+// Columns are counted in characters.
+func (src *source) location(offset int) ast.Location {
+	if offset < 0 || offset > len(src.data) {
+		return ast.Location{File: src.file}
+	}
+
+	line := sort.SearchInts(src.lines, offset+1) - 1
+
+	return ast.Location{
+		File:   src.file,
+		Line:   line + 1,
+		Column: utf8.RuneCount(src.data[src.lines[line]:offset]) + 1,
+	}
+}
+
+// Returns offset of the character at the given line and column.
+func (src *source) offset(line, column int) int {
+	if line < 1 || line > len(src.lines) {
+		return -1
+	}
+
+	offset := src.lines[line-1]
+	for i := 1; i < column && offset < len(src.data); i++ {
+		_, n := utf8.DecodeRune(src.data[offset:])
+		offset += n
+	}
+
+	return offset
+}
+
+// Returns location of the node.
+func (src *source) nodeLocation(node *Node) ast.Location {
+	return src.location(node.Offset)
+}
+
+// Returns location of the key of the entry.
+func (src *source) keyLocation(entry *NodeEntry) ast.Location {
+	return src.location(entry.KeyOffset)
+}
+
+// Returns location of the character of the string node at the given position.
+// The position is the index of the character in the decoded string.
+// If the location can't be determined, the location of the node is returned.
+func (src *source) charLocation(node *Node, pos int) ast.Location {
+	if node.Offset < 0 {
+		return src.location(-1)
+	}
+
+	raw := src.data[node.Offset:]
+	i := 0
+
+	if node.Style.Block {
+		for i < len(raw) && raw[i] != '\n' {
+			i++
+		}
+	} else if len(raw) >= len(node.Style.Quote) {
+		i += len(node.Style.Quote)
+	}
+
+	// Offset of the last found character
+	last := -1
+
+	// Find characters of the decoded string in the raw one,
+	// skipping what is not the part of the string: escapes, indentation, etc.
+	for j, c := range []rune(node.Str) {
+		for {
+			if i >= len(raw) {
+				return src.nodeLocation(node)
+			}
+
+			if node.Style.Escapes && raw[i] == '\\' {
+				n, produces := escapeLength(raw[i:])
+				if !produces {
+					i += n
+					continue
+				}
+
+				if j == pos {
+					return src.location(node.Offset + i)
+				}
+
+				last = i
+				i += n
+				break
+			}
+
+			if node.Style.DoubledQuotes && c == rune(node.Style.Quote[0]) &&
+				i+1 < len(raw) && raw[i] == raw[i+1] && raw[i] == node.Style.Quote[0] {
+				if j == pos {
+					return src.location(node.Offset + i)
+				}
+
+				last = i
+				i += 2
+				break
+			}
+
+			r, n := utf8.DecodeRune(raw[i:])
+			if r == c {
+				if j == pos {
+					return src.location(node.Offset + i)
+				}
+
+				last = i
+				i += n
+				break
+			}
+
+			i += n
+		}
+	}
+
+	// Position right after the end of the string points
+	// at the closing quote or, if there is none, at the last character
+	if node.Style.Quote == "" || node.Style.Block {
+		if last == -1 {
+			return src.nodeLocation(node)
+		}
+		return src.location(node.Offset + last)
+	}
+
+	return src.location(node.Offset + i)
+}
+
+// Returns length of the escape sequence at the beginning of the string
+// and whether it produces a character.
+func escapeLength(raw []byte) (n int, produces bool) {
+	if len(raw) < 2 {
+		return len(raw), false
+	}
+
+	switch raw[1] {
+	case '\n', '\r', ' ', '\t':
+		// Line ending backslash trims all the whitespace after it
+		n = 1
+		for n < len(raw) && (raw[n] == '\n' || raw[n] == '\r' || raw[n] == ' ' || raw[n] == '\t') {
+			n++
+		}
+		return n, false
+	case 'x':
+		n = 4
+	case 'u':
+		n = 6
+		// Surrogate pair
+		if n+1 < len(raw) && raw[n] == '\\' && raw[n+1] == 'u' && (raw[2] == 'd' || raw[2] == 'D') {
+			n += 6
+		}
+	case 'U':
+		n = 10
+	default:
+		n = 2
+	}
+
+	return min(n, len(raw)), true
+}
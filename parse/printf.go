@@ -0,0 +1,566 @@
+package parse
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+)
+
+// Keys of .stringsdict files.
+const (
+	stringsdictFormatKey    = "NSStringLocalizedFormatKey"
+	stringsdictSpecTypeKey  = "NSStringFormatSpecTypeKey"
+	stringsdictValueTypeKey = "NSStringFormatValueTypeKey"
+	stringsdictPluralRule   = "NSStringPluralRuleType"
+)
+
+// Name of the argument of plurals that don't have any integer placeholders.
+const printfCountArg = "count"
+
+// Maps messages of the localization file of a mobile platform,
+// whose strings are written in printf style: %s, %1$d, %.2f, etc.
+// Names of the messages are converted to CamelCase: app_name becomes AppName.
+// Arguments are named after their positions: %1$s becomes argA, %2$s becomes argB, etc.
+// The filename is used in locations of messages and errors.
+// If some of the messages are invalid, the rest of them are returned
+// along with the list of errors.
+func UnmarshalPrintfMessages(filename string, in []byte, decode Decoder) (messages []ast.Message, err error) {
+	src := newSource(filename, in)
+
+	root, err := decode(in)
+	if err != nil {
+		return nil, locateDecodeError(src, err)
+	}
+
+	if root.Kind != TableNode {
+		return nil, common.NewError(common.ErrInvalidFieldType,
+			common.ErrorExpectedStr("table"),
+			common.ErrorLocation(src.nodeLocation(root)),
+		)
+	}
+
+	// Errors of messages are collected to report all of them at once
+	var errs common.ErrorList
+
+	names := make(map[string]struct{})
+
+	for i := 0; i < len(root.Table); i++ {
+		entry := &root.Table[i]
+		name := camelCaseName(entry.Key)
+
+		err := checkMessageName(name)
+		if err != nil {
+			errs.Add(common.NewMessageError(name, common.NewError(err,
+				common.ErrorValueStr(entry.Key),
+				common.ErrorLocation(src.keyLocation(entry)),
+			)))
+			continue
+		}
+
+		if _, ok := names[name]; ok {
+			errs.Add(common.NewMessageError(name, common.NewError(common.ErrDuplicateField,
+				common.ErrorValueStr(entry.Key),
+				common.ErrorLocation(src.keyLocation(entry)),
+			)))
+			continue
+		}
+
+		names[name] = struct{}{}
+
+		message, err := src.mapPrintfMessage(entry)
+		if err != nil {
+			errs.Add(common.NewMessageError(name, common.NewFieldError(common.ErrCouldNotUnmarshal, name, err)))
+			continue
+		}
+
+		message.Name = name
+		messages = append(messages, message)
+	}
+
+	slices.SortStableFunc(messages, func(a, b ast.Message) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return messages, errs.Err()
+}
+
+// Converts the key of a mobile platform to the name of the message,
+// capitalizing words separated with underscores and hyphens.
+// Dots are kept as they are, so that the keys can be grouped into namespaces.
+func camelCaseName(key string) string {
+	idents := strings.Split(key, ".")
+
+	for i, ident := range idents {
+		var b strings.Builder
+
+		for _, word := range strings.FieldsFunc(ident, func(c rune) bool { return c == '_' || c == '-' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+
+		idents[i] = b.String()
+	}
+
+	return strings.Join(idents, ".")
+}
+
+// Maps the message, which is either a string,
+// a table of plural forms of Android <plurals> element,
+// or a dictionary of .stringsdict file.
+// Arguments of the message are declared in the order of their positions.
+func (src *source) mapPrintfMessage(entry *NodeEntry) (message ast.Message, err error) {
+	conv := &printfConverter{src: src, args: make(map[int]ast.ArgInfo)}
+	node := entry.Value
+
+	switch {
+	case node.Kind == StringNode:
+		message.String, err = conv.convert(node, 0)
+		if err != nil {
+			return ast.Message{}, err
+		}
+	case node.Kind == TableNode && node.Get(stringsdictFormatKey) != nil:
+		err = checkDuplicateKeys(src, node)
+		if err != nil {
+			return ast.Message{}, err
+		}
+
+		message, err = conv.mapStringsdict(node)
+		if err != nil {
+			return ast.Message{}, err
+		}
+	case node.Kind == TableNode:
+		err = checkDuplicateKeys(src, node)
+		if err != nil {
+			return ast.Message{}, err
+		}
+
+		message.Plural, err = conv.mapPlural(node, 0)
+		if err != nil {
+			return ast.Message{}, err
+		}
+
+		// Android passes the quantity separately from the arguments
+		if message.Plural.Arg == "" {
+			message.Plural.Arg = printfCountArg
+			conv.args[0] = ast.ArgInfo{
+				Location: src.keyLocation(entry),
+				Name:     printfCountArg,
+				FmtInfo:  ast.FmtInfo{Spec: 'd'},
+			}
+		}
+
+		message.Plural.Location = src.keyLocation(entry)
+	default:
+		return ast.Message{}, src.invalidFieldType(node, common.ErrorExpectedAnyStr("string", "table"))
+	}
+
+	message.Location = src.keyLocation(entry)
+	message.Arguments = conv.arguments()
+
+	return message, nil
+}
+
+// Converts strings written in printf style.
+type printfConverter struct {
+	src *source
+	// Arguments found in the strings by their positions
+	args map[int]ast.ArgInfo
+	// Positions of the arguments that variables are substituted with
+	variables map[string]int
+}
+
+// Maps the dictionary of .stringsdict file.
+// Format that consists of a single variable is the plural itself,
+// otherwise each variable of the format becomes a variable of the message.
+func (c *printfConverter) mapStringsdict(node *Node) (message ast.Message, err error) {
+	format := node.Get(stringsdictFormatKey)
+	if format.Kind != StringNode {
+		err = c.src.invalidFieldType(format, common.ErrorExpectedStr("string"))
+		return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, stringsdictFormatKey, err)
+	}
+
+	c.variables = make(map[string]int)
+
+	str, err := c.convert(format, 0)
+	if err != nil {
+		return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, stringsdictFormatKey, err)
+	}
+
+	for i := 0; i < len(node.Table); i++ {
+		entry := &node.Table[i]
+		k, v := entry.Key, entry.Value
+
+		pos, ok := c.variables[k]
+		if !ok {
+			// Only the variables of the format are used
+			continue
+		}
+
+		if v.Kind != TableNode {
+			err = c.src.invalidFieldType(v, common.ErrorExpectedStr("table"))
+			return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+		}
+
+		plural, err := c.mapPlural(v, pos)
+		if err != nil {
+			return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+		}
+
+		plural.Location = c.src.keyLocation(entry)
+
+		message.Variables = append(message.Variables, ast.Variable{
+			Location: c.src.keyLocation(entry),
+			Name:     k,
+			Plural:   plural,
+		})
+	}
+
+	if _, ok := str[0].(ast.VarInfo); ok && len(str) == 1 && len(message.Variables) == 1 {
+		message.Plural = message.Variables[0].Plural
+		message.Variables = nil
+		return message, nil
+	}
+
+	slices.SortStableFunc(message.Variables, func(a, b ast.Variable) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	message.String = str
+
+	return message, nil
+}
+
+// Maps forms of the plural.
+// Placeholders of the forms without positions refer to the arguments
+// starting from the one at the given position, or from the first one if it is zero.
+// The argument at the given position is the argument of the plural,
+// otherwise it is the first integer argument of the forms, if there is one.
+func (c *printfConverter) mapPlural(node *Node, pos int) (plural ast.Plural, err error) {
+	if pos != 0 {
+		plural.Arg = c.args[pos].Name
+	}
+
+	for i := 0; i < len(node.Table); i++ {
+		entry := &node.Table[i]
+		k, v := entry.Key, entry.Value
+
+		switch k {
+		case stringsdictSpecTypeKey:
+			if v.Kind != StringNode || v.Str != stringsdictPluralRule {
+				err = common.NewError(common.ErrUnsupportedArgumentType,
+					common.ErrorValueStr(v.Str),
+					common.ErrorExpectedStr(stringsdictPluralRule),
+					common.ErrorLocation(c.src.nodeLocation(v)),
+				)
+				return ast.Plural{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+			}
+			continue
+		case stringsdictValueTypeKey:
+			// Arguments of plurals are always integers
+			continue
+		}
+
+		if v.Kind != StringNode {
+			err = c.src.invalidFieldType(v, common.ErrorExpectedStr("string"))
+			return ast.Plural{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+		}
+
+		format, err := c.convert(v, pos)
+		if err != nil {
+			return ast.Plural{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+		}
+
+		switch k {
+		case "zero":
+			plural.Zero = format
+		case "one":
+			plural.One = format
+		case "two":
+			plural.Two = format
+		case "few":
+			plural.Few = format
+		case "many":
+			plural.Many = format
+		case "other":
+			plural.Other = format
+		default:
+			err = c.src.unknownField(entry, common.ErrorExpectedAnyStr("zero", "one", "two", "few", "many", "other"))
+			return ast.Plural{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+		}
+	}
+
+	if plural.Arg == "" {
+		forms := []ast.FormatParts{plural.Other, plural.One, plural.Few, plural.Many, plural.Two, plural.Zero}
+
+	loop:
+		for _, form := range forms {
+			for _, part := range form {
+				if arg, ok := part.(ast.ArgInfo); ok && arg.FmtInfo.Spec == 'd' {
+					plural.Arg = arg.Name
+					break loop
+				}
+			}
+		}
+	}
+
+	return plural, nil
+}
+
+// Returns arguments in the order of their positions.
+func (c *printfConverter) arguments() (args []ast.ArgInfo) {
+	positions := make([]int, 0, len(c.args))
+	for pos := range c.args {
+		positions = append(positions, pos)
+	}
+
+	slices.Sort(positions)
+
+	for _, pos := range positions {
+		args = append(args, c.args[pos])
+	}
+
+	return args
+}
+
+// Converts the string written in printf style.
+// Placeholders without positions refer to the arguments
+// starting from the one at the given position, or from the first one if it is zero.
+// Variables of .stringsdict files can only be referred to if the position is zero.
+func (c *printfConverter) convert(node *Node, pos int) (parts ast.FormatParts, err error) {
+	p := &printfParser{str: []rune(node.Str)}
+
+	next := max(pos, 1)
+
+	var text strings.Builder
+
+	for p.pos < len(p.str) {
+		if p.str[p.pos] != '%' {
+			text.WriteRune(p.str[p.pos])
+			p.pos++
+			continue
+		}
+
+		if p.pos+1 < len(p.str) && p.str[p.pos+1] == '%' {
+			text.WriteByte('%')
+			p.pos += 2
+			continue
+		}
+
+		start := p.pos
+
+		ph, err := p.parsePlaceholder()
+		if err != nil {
+			return nil, c.src.locateFormatError(node, err)
+		}
+
+		if text.Len() != 0 {
+			parts = append(parts, ast.Text(text.String()))
+			text.Reset()
+		}
+
+		if ph.Pos == 0 {
+			ph.Pos = next
+			next++
+		}
+
+		location := c.src.charLocation(node, start)
+
+		if ph.Variable != "" {
+			if c.variables == nil || pos != 0 {
+				err = common.NewError(common.ErrNestedPlural, common.ErrorLocation(location))
+				return nil, err
+			}
+
+			c.variables[ph.Variable] = ph.Pos
+			ph.FmtInfo = ast.FmtInfo{Spec: 'd'}
+		}
+
+		arg, ok := c.args[ph.Pos]
+		if !ok {
+			arg = ast.ArgInfo{
+				Location: location,
+				Name:     printfArgName(ph.Pos),
+				FmtInfo:  ast.FmtInfo{Spec: ph.FmtInfo.Spec},
+			}
+			c.args[ph.Pos] = arg
+		}
+
+		if ph.Variable != "" {
+			parts = append(parts, ast.VarInfo{Location: location, Name: ph.Variable})
+			continue
+		}
+
+		parts = append(parts, ast.ArgInfo{
+			Location: location,
+			Name:     arg.Name,
+			FmtInfo:  ph.FmtInfo,
+		})
+	}
+
+	if text.Len() != 0 || parts == nil {
+		parts = append(parts, ast.Text(text.String()))
+	}
+
+	return parts, nil
+}
+
+// Returns name of the argument at the position, which starts from 1:
+// argA, argB, ..., argZ, argAA, argAB, etc.
+func printfArgName(pos int) string {
+	var suffix []byte
+	for ; pos > 0; pos = (pos - 1) / 26 {
+		suffix = append(suffix, byte('A'+(pos-1)%26))
+	}
+
+	slices.Reverse(suffix)
+
+	return "arg" + string(suffix)
+}
+
+// Placeholder of printf style string.
+type printfPlaceholder struct {
+	// Position of the argument starting from 1, or zero if it is not specified
+	Pos int
+	// Name of the variable of .stringsdict file: %#@name@
+	Variable string
+	FmtInfo  ast.FmtInfo
+}
+
+type printfParser struct {
+	str []rune
+	pos int
+}
+
+// Parses placeholder that starts at the current position with '%' character.
+func (p *printfParser) parsePlaceholder() (ph printfPlaceholder, err error) {
+	// Skip '%' character
+	p.pos++
+
+	// Position of the argument goes first
+	if digits := p.digits(); digits != "" && p.pos < len(p.str) && p.str[p.pos] == '$' {
+		ph.Pos, _ = strconv.Atoi(digits)
+		p.pos++
+	} else {
+		p.pos -= len(digits)
+	}
+
+	for p.pos < len(p.str) && strings.ContainsRune("+- 0#", p.str[p.pos]) {
+		if !slices.Contains(ph.FmtInfo.Flags, p.str[p.pos]) {
+			ph.FmtInfo.Flags = append(ph.FmtInfo.Flags, p.str[p.pos])
+		}
+		p.pos++
+	}
+
+	if slices.Contains(ph.FmtInfo.Flags, '#') && p.pos < len(p.str) && p.str[p.pos] == '@' {
+		return p.parseVariable(ph)
+	}
+
+	if digits := p.digits(); digits != "" {
+		width, err := strconv.Atoi(digits)
+		if err != nil {
+			return printfPlaceholder{}, common.NewError(common.ErrInvalidWidth,
+				common.ErrorValueStr(digits),
+				common.ErrorPosition(p.pos-len(digits)),
+				common.ErrorWrapped(err),
+			)
+		}
+		ph.FmtInfo.Width = ast.WidthOpt{Value: width, Valid: true}
+	}
+
+	if p.pos < len(p.str) && p.str[p.pos] == '.' {
+		p.pos++
+
+		prec := 0
+		if digits := p.digits(); digits != "" {
+			prec, err = strconv.Atoi(digits)
+			if err != nil {
+				return printfPlaceholder{}, common.NewError(common.ErrInvalidPrecision,
+					common.ErrorValueStr(digits),
+					common.ErrorPosition(p.pos-len(digits)),
+					common.ErrorWrapped(err),
+				)
+			}
+		}
+		ph.FmtInfo.Prec = ast.PrecOpt{Value: prec, Valid: true}
+	}
+
+	// Length modifiers don't matter
+	for p.pos < len(p.str) && strings.ContainsRune("hlqLztj", p.str[p.pos]) {
+		p.pos++
+	}
+
+	if p.pos == len(p.str) {
+		return printfPlaceholder{}, common.NewError(common.ErrUnexpectedEndOfFormat, common.ErrorPosition(p.pos))
+	}
+
+	switch c := p.str[p.pos]; c {
+	case 's', 'S', '@':
+		ph.FmtInfo.Spec = 's'
+	case 'd', 'i', 'u', 'D', 'U':
+		ph.FmtInfo.Spec = 'd'
+	case 'x', 'X', 'o', 'c':
+		ph.FmtInfo.Spec = 'd'
+		ph.FmtInfo.Mod = ast.ModOpt{Value: c, Valid: true}
+	case 'O':
+		ph.FmtInfo.Spec = 'd'
+		ph.FmtInfo.Mod = ast.ModOpt{Value: 'o', Valid: true}
+	case 'f', 'F':
+		ph.FmtInfo.Spec = 'f'
+	case 'e', 'E', 'g', 'G':
+		ph.FmtInfo.Spec = 'f'
+		ph.FmtInfo.Mod = ast.ModOpt{Value: c, Valid: true}
+	default:
+		return printfPlaceholder{}, common.NewError(common.ErrInvalidSpecifier,
+			common.ErrorValueChar(c),
+			common.ErrorPosition(p.pos),
+		)
+	}
+
+	p.pos++
+
+	return ph, nil
+}
+
+// Parses name of the variable of .stringsdict file that goes after '#' flag.
+func (p *printfParser) parseVariable(ph printfPlaceholder) (printfPlaceholder, error) {
+	// Skip '@' character
+	p.pos++
+	start := p.pos
+
+	for p.pos < len(p.str) && p.str[p.pos] != '@' {
+		p.pos++
+	}
+
+	if p.pos == len(p.str) {
+		return printfPlaceholder{}, common.NewError(common.ErrUnexpectedEndOfFormat,
+			common.ErrorPosition(p.pos),
+			common.ErrorExpectedChar('@'),
+		)
+	}
+
+	name := string(p.str[start:p.pos])
+	p.pos++
+
+	switch err := checkVariableName(name); err {
+	case common.ErrInvalidVariableName:
+		return printfPlaceholder{}, common.NewError(err,
+			common.ErrorValueStr(name),
+			common.ErrorPosition(start),
+		)
+	case common.ErrNoVariableName:
+		return printfPlaceholder{}, common.NewError(err, common.ErrorPosition(start))
+	}
+
+	return printfPlaceholder{Pos: ph.Pos, Variable: name}, nil
+}
+
+// Returns digits at the current position and skips them.
+func (p *printfParser) digits() string {
+	start := p.pos
+	for p.pos < len(p.str) && p.str[p.pos] >= '0' && p.str[p.pos] <= '9' {
+		p.pos++
+	}
+	return string(p.str[start:p.pos])
+}
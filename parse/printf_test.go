@@ -0,0 +1,268 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+)
+
+func TestUnmarshalPrintf(t *testing.T) {
+	tests := []unmarshalTest{
+		{
+			name:    "placeholders",
+			in:      "files: \"%s has %d files, %%\"\n",
+			message: "Files",
+			want:    "${s:argA} has ${d:argB} files, %",
+		},
+		{
+			name:    "positions",
+			in:      "files: \"%2$@ of %1$s\"\n",
+			message: "Files",
+			want:    "${s:argB} of ${s:argA}",
+		},
+		{
+			name:    "formats",
+			in:      "price: \"%05.2f %x %lld\"\n",
+			message: "Price",
+			want:    "${05.2f:argA} ${dx:argB} ${d:argC}",
+		},
+		{
+			name: "plural",
+			in: "files_count:\n" +
+				"  one: \"%d file in %s\"\n" +
+				"  other: \"%d files in %s\"\n",
+			message: "FilesCount",
+			want:    "argA: one {${d:argA} file in ${s:argB}} other {${d:argA} files in ${s:argB}}",
+		},
+		{
+			name: "plural without integer",
+			in: "files:\n" +
+				"  one: \"A file\"\n" +
+				"  other: \"Files\"\n",
+			message: "Files",
+			want:    "count: one {A file} other {Files}",
+		},
+		{
+			name: "stringsdict plural",
+			in: "files:\n" +
+				"  NSStringLocalizedFormatKey: \"%#@files@\"\n" +
+				"  files:\n" +
+				"    NSStringFormatSpecTypeKey: NSStringPluralRuleType\n" +
+				"    NSStringFormatValueTypeKey: d\n" +
+				"    one: \"%d file\"\n" +
+				"    other: \"%d files\"\n",
+			message: "Files",
+			want:    "argA: one {${d:argA} file} other {${d:argA} files}",
+		},
+		{
+			name: "stringsdict variables",
+			in: "files:\n" +
+				"  NSStringLocalizedFormatKey: \"%#@files@ in %@\"\n" +
+				"  files:\n" +
+				"    NSStringFormatSpecTypeKey: NSStringPluralRuleType\n" +
+				"    NSStringFormatValueTypeKey: d\n" +
+				"    one: \"%d file\"\n" +
+				"    other: \"%d files\"\n",
+			message: "Files",
+			want:    "&{files} in ${s:argB}",
+			vars: map[string]string{
+				"files": "argA: one {${d:argA} file} other {${d:argA} files}",
+			},
+		},
+		{
+			name:    "invalid specifier",
+			in:      "files: \"%k files\"\n",
+			message: "Files",
+			err:     common.ErrInvalidSpecifier,
+		},
+		{
+			name:    "unexpected end",
+			in:      "files: \"files %\"\n",
+			message: "Files",
+			err:     common.ErrUnexpectedEndOfFormat,
+		},
+		{
+			name: "unknown plural form",
+			in: "files:\n" +
+				"  lots: \"%d files\"\n" +
+				"  other: \"%d files\"\n",
+			message: "Files",
+			err:     common.ErrUnknownField,
+		},
+		{
+			name: "nested plural",
+			in: "files:\n" +
+				"  NSStringLocalizedFormatKey: \"%#@files@\"\n" +
+				"  files:\n" +
+				"    NSStringFormatSpecTypeKey: NSStringPluralRuleType\n" +
+				"    one: \"%#@folders@\"\n" +
+				"    other: \"%d files\"\n",
+			message: "Files",
+			err:     common.ErrNestedPlural,
+		},
+	}
+
+	runUnmarshalTests(t, tests, func(in string) ([]ast.Message, error) {
+		return UnmarshalPrintfMessages("test.yaml", []byte(in), DecodeYAML)
+	})
+}
+
+// Returns .stringsdict file with the entries of its dictionary.
+func stringsdict(entries string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+` + entries + `</dict>
+</plist>
+`
+}
+
+// Returns entry of .stringsdict variable that is a plural,
+// whose forms are given as pairs of their names and texts.
+func stringsdictPlural(name string, forms ...string) string {
+	entry := `		<key>` + name + `</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>d</string>
+`
+	for i := 0; i+1 < len(forms); i += 2 {
+		entry += "\t\t\t<key>" + forms[i] + "</key>\n\t\t\t<string>" + forms[i+1] + "</string>\n"
+	}
+
+	return entry + "\t\t</dict>\n"
+}
+
+func TestUnmarshalStringsdict(t *testing.T) {
+	tests := []unmarshalTest{
+		{
+			name: "plural",
+			in: stringsdict(`	<key>files</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@files@</string>
+` + stringsdictPlural("files", "one", "%d file", "other", "%d files") + `	</dict>
+`),
+			message: "Files",
+			want:    "argA: one {${d:argA} file} other {${d:argA} files}",
+		},
+		{
+			name: "variables",
+			in: stringsdict(`	<key>files_and_folders</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@files@ and %#@folders@</string>
+` + stringsdictPlural("files", "one", "%d file", "other", "%d files") +
+				stringsdictPlural("folders", "one", "%d folder", "other", "%d folders") + `	</dict>
+`),
+			message: "FilesAndFolders",
+			want:    "&{files} and &{folders}",
+			vars: map[string]string{
+				"files":   "argA: one {${d:argA} file} other {${d:argA} files}",
+				"folders": "argB: one {${d:argB} folder} other {${d:argB} folders}",
+			},
+		},
+		{
+			name: "escaped text",
+			in: stringsdict(`	<key>files</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>&lt;b&gt;%#@files@&lt;/b&gt; &amp; %@</string>
+` + stringsdictPlural("files", "zero", "no files", "one", "%d file", "other", "%d files") + `	</dict>
+`),
+			message: "Files",
+			want:    "<b>&{files}</b> && ${s:argB}",
+			vars: map[string]string{
+				"files": "argA: zero {no files} one {${d:argA} file} other {${d:argA} files}",
+			},
+		},
+		{
+			name: "several entries",
+			in: stringsdict(`	<key>files</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@files@</string>
+` + stringsdictPlural("files", "one", "%d file", "other", "%d files") + `	</dict>
+	<key>folders</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@folders@</string>
+` + stringsdictPlural("folders", "one", "%d folder", "other", "%d folders") + `	</dict>
+`),
+			message: "Folders",
+			want:    "argA: one {${d:argA} folder} other {${d:argA} folders}",
+		},
+		{
+			name: "nested plural",
+			in: stringsdict(`	<key>files</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@files@</string>
+` + stringsdictPlural("files", "one", "%#@folders@", "other", "%d files") + `	</dict>
+`),
+			message: "Files",
+			err:     common.ErrNestedPlural,
+		},
+		{
+			name: "invalid specifier",
+			in: stringsdict(`	<key>files</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@files@</string>
+` + stringsdictPlural("files", "one", "%k file", "other", "%d files") + `	</dict>
+`),
+			message: "Files",
+			err:     common.ErrInvalidSpecifier,
+		},
+	}
+
+	runUnmarshalTests(t, tests, func(in string) ([]ast.Message, error) {
+		return UnmarshalPrintfMessages("Localizable.stringsdict", []byte(in), DecodeStringsdict)
+	})
+}
+
+func TestUnmarshalAndroidStrings(t *testing.T) {
+	in := `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <string name="app_name">Files</string>
+    <plurals name="files_count">
+        <item quantity="one">%d file</item>
+        <item quantity="other">%d files</item>
+    </plurals>
+</resources>
+`
+
+	messages, err := UnmarshalPrintfMessages("strings.xml", []byte(in), DecodeAndroidStrings)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+
+	checkMessage(t, &messages[0], "Files", nil)
+	checkMessage(t, &messages[1], "argA: one {${d:argA} file} other {${d:argA} files}", nil)
+}
+
+func TestUnmarshalAppleStrings(t *testing.T) {
+	in := `/* Title of the app */
+"app_name" = "Files";
+"greeting" = "Hello, %@!";
+`
+
+	messages, err := UnmarshalPrintfMessages("Localizable.strings", []byte(in), DecodeAppleStrings)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+
+	checkMessage(t, &messages[0], "Files", nil)
+	checkMessage(t, &messages[1], "Hello, ${s:argA}!", nil)
+}
@@ -0,0 +1,354 @@
+package parse
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+func DecodeTOML(data []byte) (node *Node, err error) {
+	var table map[string]any
+
+	_, err = toml.Decode(string(data), &table)
+	if err != nil {
+		return nil, err
+	}
+
+	// The document is valid, so it is safe to scan it
+	// to find out where the keys and values are located
+	s := &tomlScanner{
+		data:      data,
+		positions: make(map[string]tomlPosition),
+		arrays:    make(map[string]int),
+	}
+	s.scan()
+
+	return s.node(nil, table), nil
+}
+
+// Location of a key and its value in a TOML document.
+type tomlPosition struct {
+	KeyOffset int
+	Offset    int
+	Style     StringStyle
+}
+
+// Scanner of valid TOML documents
+// that maps paths of keys to their positions.
+type tomlScanner struct {
+	data []byte
+	pos  int
+	// Path of the current table
+	table []string
+	// Paths of keys to their positions
+	positions map[string]tomlPosition
+	// Paths of arrays of tables to the index of their last table
+	arrays map[string]int
+}
+
+func tomlPath(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+func (s *tomlScanner) node(path []string, value any) (node *Node) {
+	node = &Node{
+		Kind:   OtherNode,
+		Offset: -1,
+	}
+
+	if pos, ok := s.positions[tomlPath(path)]; ok {
+		node.Offset = pos.Offset
+		node.Style = pos.Style
+	}
+
+	switch value := value.(type) {
+	case string:
+		node.Kind = StringNode
+		node.Str = value
+	case map[string]any:
+		node.Kind = TableNode
+
+		for k, v := range value {
+			keyPath := append(slices.Clip(path), k)
+
+			keyOffset := -1
+			if pos, ok := s.positions[tomlPath(keyPath)]; ok {
+				keyOffset = pos.KeyOffset
+			}
+
+			node.Table = append(node.Table, NodeEntry{
+				Key:       k,
+				KeyOffset: keyOffset,
+				Value:     s.node(keyPath, v),
+			})
+		}
+
+		// Maps are unordered, so entries are sorted
+		// in the order they appear in the document
+		slices.SortFunc(node.Table, func(a, b NodeEntry) int {
+			if a.KeyOffset != b.KeyOffset {
+				return a.KeyOffset - b.KeyOffset
+			}
+			return strings.Compare(a.Key, b.Key)
+		})
+	case []any:
+		node.Kind = ArrayNode
+
+		for i, elem := range value {
+			node.Array = append(node.Array, s.node(append(slices.Clip(path), strconv.Itoa(i)), elem))
+		}
+	case []map[string]any:
+		node.Kind = ArrayNode
+
+		for i, elem := range value {
+			node.Array = append(node.Array, s.node(append(slices.Clip(path), strconv.Itoa(i)), elem))
+		}
+	}
+
+	return node
+}
+
+func (s *tomlScanner) peek(prefix string) bool {
+	return strings.HasPrefix(string(s.data[s.pos:min(s.pos+len(prefix), len(s.data))]), prefix)
+}
+
+// Skips whitespace, and optionally newlines and comments.
+func (s *tomlScanner) skip(newlines bool) {
+	for s.pos < len(s.data) {
+		switch c := s.data[s.pos]; {
+		case c == ' ' || c == '\t':
+			s.pos++
+		case newlines && (c == '\r' || c == '\n'):
+			s.pos++
+		case newlines && c == '#':
+			for s.pos < len(s.data) && s.data[s.pos] != '\n' {
+				s.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Records position of the key if it hasn't been recorded yet.
+func (s *tomlScanner) recordKey(path []string, keyOffset int) {
+	key := tomlPath(path)
+	if _, ok := s.positions[key]; !ok {
+		s.positions[key] = tomlPosition{KeyOffset: keyOffset, Offset: keyOffset}
+	}
+}
+
+func (s *tomlScanner) scan() {
+	for {
+		s.skip(true)
+		if s.pos >= len(s.data) {
+			return
+		}
+
+		if s.peek("[[") {
+			s.pos += 2
+			s.scanTableHeader(true)
+		} else if s.peek("[") {
+			s.pos++
+			s.scanTableHeader(false)
+		} else {
+			s.scanKeyValue(s.table)
+		}
+	}
+}
+
+func (s *tomlScanner) scanTableHeader(array bool) {
+	s.table = nil
+
+	for {
+		s.skip(false)
+		keyOffset := s.pos
+		key := s.scanKey()
+		s.skip(false)
+
+		s.table = append(s.table, key)
+		last := s.peek("]")
+
+		if last && array {
+			path := tomlPath(s.table)
+
+			idx, ok := s.arrays[path]
+			if ok {
+				idx++
+			}
+			s.arrays[path] = idx
+
+			s.recordKey(s.table, keyOffset)
+			s.table = append(s.table, strconv.Itoa(idx))
+		}
+
+		s.recordKey(s.table, keyOffset)
+
+		// Keys of arrays of tables refer to their last table
+		if idx, ok := s.arrays[tomlPath(s.table)]; ok && !last {
+			s.table = append(s.table, strconv.Itoa(idx))
+		}
+
+		if last {
+			break
+		}
+
+		// Dot
+		s.pos++
+	}
+
+	for s.pos < len(s.data) && s.data[s.pos] == ']' {
+		s.pos++
+	}
+}
+
+func (s *tomlScanner) scanKeyValue(table []string) {
+	path := slices.Clip(table)
+
+	var keyOffset int
+
+	for {
+		s.skip(false)
+		keyOffset = s.pos
+		path = append(path, s.scanKey())
+		s.skip(false)
+
+		if s.peek("=") {
+			s.pos++
+			break
+		}
+
+		s.recordKey(path, keyOffset)
+
+		// Dot
+		s.pos++
+	}
+
+	s.skip(false)
+
+	s.positions[tomlPath(path)] = tomlPosition{
+		KeyOffset: keyOffset,
+		Offset:    s.pos,
+	}
+
+	s.scanValue(path)
+}
+
+func (s *tomlScanner) scanKey() (key string) {
+	if s.peek(`"`) || s.peek("'") {
+		start := s.pos
+		s.scanString(`"`, `'`)
+
+		key, err := strconv.Unquote(string(s.data[start:s.pos]))
+		if err != nil {
+			// Literal strings are taken as is
+			return string(s.data[start+1 : s.pos-1])
+		}
+
+		return key
+	}
+
+	start := s.pos
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' && c != '-' {
+			break
+		}
+		s.pos++
+	}
+
+	return string(s.data[start:s.pos])
+}
+
+func (s *tomlScanner) scanValue(path []string) {
+	pos := s.positions[tomlPath(path)]
+	pos.Offset = s.pos
+
+	switch {
+	case s.peek(`"""`):
+		pos.Style = StringStyle{Quote: `"""`, Escapes: true}
+		s.scanString(`"""`, `'''`)
+	case s.peek("'''"):
+		pos.Style = StringStyle{Quote: "'''"}
+		s.scanString(`"""`, `'''`)
+	case s.peek(`"`):
+		pos.Style = StringStyle{Quote: `"`, Escapes: true}
+		s.scanString(`"`, `'`)
+	case s.peek("'"):
+		pos.Style = StringStyle{Quote: "'"}
+		s.scanString(`"`, `'`)
+	case s.peek("["):
+		s.pos++
+
+		for i := 0; ; i++ {
+			s.skip(true)
+			if s.peek("]") {
+				s.pos++
+				break
+			}
+
+			elemPath := append(slices.Clip(path), strconv.Itoa(i))
+			s.positions[tomlPath(elemPath)] = tomlPosition{KeyOffset: s.pos, Offset: s.pos}
+			s.scanValue(elemPath)
+
+			s.skip(true)
+			if s.peek(",") {
+				s.pos++
+			}
+		}
+	case s.peek("{"):
+		s.pos++
+
+		for {
+			s.skip(false)
+			if s.peek("}") {
+				s.pos++
+				break
+			}
+
+			s.scanKeyValue(path)
+
+			s.skip(false)
+			if s.peek(",") {
+				s.pos++
+			}
+		}
+	default:
+		for s.pos < len(s.data) && !strings.ContainsRune(" \t\r\n,]}#", rune(s.data[s.pos])) {
+			s.pos++
+		}
+	}
+
+	s.positions[tomlPath(path)] = pos
+}
+
+// Scans a string starting with one of the given quotes.
+// The first quote supports escapes, the second doesn't.
+func (s *tomlScanner) scanString(escaped, literal string) {
+	quote := literal
+	if s.peek(escaped) {
+		quote = escaped
+	}
+
+	s.pos += len(quote)
+
+	for s.pos < len(s.data) {
+		if quote == escaped && s.data[s.pos] == '\\' {
+			s.pos += 2
+			continue
+		}
+
+		if s.peek(quote) {
+			s.pos += len(quote)
+			// Multiline strings can end with up to two additional quotes
+			for len(quote) == 3 && s.peek(quote[:1]) {
+				s.pos++
+			}
+			return
+		}
+
+		s.pos++
+	}
+}
@@ -1,142 +1,504 @@
 package parse
 
 import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/infastin/go-l10n/ast"
 	"github.com/infastin/go-l10n/common"
 )
 
-func UnmarshalMessages(in []byte, unmarshaler func(in []byte, out any) (err error),
-) (messages []ast.Message, err error) {
-	msgs := make(map[string]any)
+// Maps messages of the localization file.
+// The filename is used in locations of messages and errors.
+// If some of the messages are invalid, the rest of them are returned
+// along with the list of errors.
+func UnmarshalMessages(filename string, in []byte, decode Decoder) (messages []ast.Message, err error) {
+	src := newSource(filename, in)
 
-	err = unmarshaler(in, &msgs)
+	root, err := decode(in)
 	if err != nil {
-		return nil, err
+		return nil, locateDecodeError(src, err)
 	}
 
-	for name, msg := range msgs {
-		if str, ok := msg.(string); ok {
-			format, err := parseFormat(str)
-			if err != nil {
-				return nil, common.NewFieldError(common.ErrCouldNotUnmarshal, name, err)
-			}
+	if root.Kind != TableNode {
+		return nil, common.NewError(common.ErrInvalidFieldType,
+			common.ErrorExpectedStr("table"),
+			common.ErrorLocation(src.nodeLocation(root)),
+		)
+	}
 
-			messages = append(messages, ast.Message{
-				Name:   name,
-				String: format,
-			})
+	syntax := SyntaxDefault
+	if value := root.Get("syntax"); value != nil {
+		syntax, err = src.mapSyntax(value)
+		if err != nil {
+			return nil, common.NewFieldError(common.ErrCouldNotUnmarshal, "syntax", err)
+		}
+	}
+
+	// Errors of messages are collected to report all of them at once
+	var errs common.ErrorList
+
+	src.mapNamespace(root, "", syntax, make(map[string]struct{}), &messages, &errs)
 
+	slices.SortStableFunc(messages, func(a, b ast.Message) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return messages, errs.Err()
+}
+
+// Maps messages of the namespace table.
+// Names of the messages are prefixed with the name of the namespace.
+// Strings of the messages are written in the given syntax, unless the messages specify their own.
+// Names of the messages that have been mapped already are used to check for duplicates.
+func (src *source) mapNamespace(
+	node *Node,
+	namespace string,
+	syntax Syntax,
+	names map[string]struct{},
+	messages *[]ast.Message,
+	errs *common.ErrorList,
+) {
+	for i := 0; i < len(node.Table); i++ {
+		entry := &node.Table[i]
+
+		// Syntax of the file is not a message
+		if namespace == "" && entry.Key == "syntax" {
 			continue
 		}
 
-		table, ok := msg.(map[string]any)
-		if !ok {
-			err = common.NewError(common.ErrInvalidFieldType, common.ErrorExpectedAnyStr("string", "table"))
-			return nil, common.NewFieldError(common.ErrCouldNotUnmarshal, name, err)
+		name := entry.Key
+		if namespace != "" {
+			name = namespace + "." + entry.Key
 		}
 
-		message, err := mapMessage(table)
+		err := checkMessageName(entry.Key)
 		if err != nil {
-			return nil, common.NewFieldError(common.ErrCouldNotUnmarshal, name, err)
+			errs.Add(common.NewMessageError(name, common.NewError(err,
+				common.ErrorValueStr(entry.Key),
+				common.ErrorLocation(src.keyLocation(entry)),
+			)))
+			continue
+		}
+
+		if isNamespace(entry.Value) {
+			src.mapNamespace(entry.Value, name, syntax, names, messages, errs)
+			continue
+		}
+
+		if _, ok := names[name]; ok {
+			errs.Add(common.NewMessageError(name, common.NewError(common.ErrDuplicateField,
+				common.ErrorValueStr(name),
+				common.ErrorLocation(src.keyLocation(entry)),
+			)))
+			continue
+		}
+
+		names[name] = struct{}{}
+
+		message, err := src.mapMessageEntry(name, entry, syntax)
+		if err != nil {
+			errs.Add(common.NewMessageError(name, err))
+			continue
+		}
+
+		*messages = append(*messages, message)
+	}
+}
+
+// Reports whether the node is a namespace table,
+// that is, a non-empty table that contains no fields of a message.
+func isNamespace(node *Node) bool {
+	if node.Kind != TableNode || len(node.Table) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(node.Table); i++ {
+		switch node.Table[i].Key {
+		case "arguments", "variables", "plural", "string", "syntax":
+			return false
+		}
+	}
+
+	return true
+}
+
+// Checks that the name consists of Go identifiers separated with dots.
+func checkMessageName(name string) (err error) {
+	for _, ident := range strings.Split(name, ".") {
+		if ident == "" || (ident[0] >= '0' && ident[0] <= '9') {
+			return common.ErrInvalidMessageName
+		}
+
+		for i := 0; i < len(ident); i++ {
+			if c := ident[i]; (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' {
+				return common.ErrInvalidMessageName
+			}
+		}
+	}
+
+	return nil
+}
+
+// Maps the message defined by the entry of a namespace table.
+func (src *source) mapMessageEntry(name string, entry *NodeEntry, syntax Syntax) (message ast.Message, err error) {
+	msg := entry.Value
+
+	err = checkDuplicateKeys(src, msg)
+	if err != nil {
+		return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, name, err)
+	}
+
+	if msg.Kind == StringNode && syntax == SyntaxICU {
+		conv := &icuConverter{src: src}
+
+		str, plural, err := conv.convertMessage(msg)
+		if err != nil {
+			return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, name, err)
+		}
+
+		message = ast.Message{
+			Location:  src.keyLocation(entry),
+			Name:      name,
+			Variables: conv.variables,
+			Plural:    plural,
+			String:    str,
+			TextOrder: true,
 		}
 
 		slices.SortStableFunc(message.Variables, func(a, b ast.Variable) int {
 			return strings.Compare(a.Name, b.Name)
 		})
 
-		message.Name = name
-		messages = append(messages, message)
+		return message, nil
 	}
 
-	slices.SortStableFunc(messages, func(a, b ast.Message) int {
+	if msg.Kind == StringNode {
+		format, err := src.parseFormat(msg)
+		if err != nil {
+			return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, name, err)
+		}
+
+		return ast.Message{
+			Location: src.keyLocation(entry),
+			Name:     name,
+			String:   format,
+		}, nil
+	}
+
+	if msg.Kind != TableNode {
+		err = src.invalidFieldType(msg, common.ErrorExpectedAnyStr("string", "table"))
+		return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, name, err)
+	}
+
+	message, err = src.mapMessage(msg, syntax)
+	if err != nil {
+		return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, name, err)
+	}
+
+	slices.SortStableFunc(message.Variables, func(a, b ast.Variable) int {
 		return strings.Compare(a.Name, b.Name)
 	})
 
-	return messages, nil
+	message.Location = src.keyLocation(entry)
+	message.Name = name
+
+	return message, nil
 }
 
-func mapMessage(table map[string]any) (message ast.Message, err error) {
-	for k, v := range table {
-		switch k {
-		case "variables":
-			v, ok := v.(map[string]any)
-			if !ok {
-				err = common.NewError(common.ErrInvalidFieldType, common.ErrorExpectedStr("table"))
+// Adds location to errors of decoders, if they have any.
+func locateDecodeError(src *source, err error) error {
+	var offset int
+
+	var jsonErr *json.SyntaxError
+	var tomlErr toml.ParseError
+	var xmlErr *xml.SyntaxError
+	var decErr *decodeError
+
+	switch {
+	case errors.As(err, &jsonErr):
+		// Offset is right after the invalid character
+		offset = max(int(jsonErr.Offset)-1, 0)
+	case errors.As(err, &tomlErr):
+		offset = tomlErr.Position.Start
+	case errors.As(err, &decErr):
+		offset = decErr.Offset
+		err = decErr.Err
+	case errors.As(err, &xmlErr):
+		// XML errors only have line numbers too
+		return common.NewError(common.ErrInvalidSyntax,
+			common.ErrorLocation(ast.Location{File: src.file, Line: xmlErr.Line}),
+			common.ErrorWrapped(err),
+		)
+	default:
+		// YAML errors only have line numbers
+		var line int
+		if _, scanErr := fmt.Sscanf(err.Error(), "yaml: line %d:", &line); scanErr == nil {
+			return common.NewError(common.ErrInvalidSyntax,
+				common.ErrorLocation(ast.Location{File: src.file, Line: line}),
+				common.ErrorWrapped(err),
+			)
+		}
+		return err
+	}
+
+	return common.NewError(common.ErrInvalidSyntax,
+		common.ErrorLocation(src.location(offset)),
+		common.ErrorWrapped(err),
+	)
+}
+
+// Checks that keys of tables are not repeated.
+// Not every decoder does this.
+func checkDuplicateKeys(src *source, node *Node) (err error) {
+	switch node.Kind {
+	case TableNode:
+		for i := 0; i < len(node.Table); i++ {
+			entry := &node.Table[i]
+
+			if slices.ContainsFunc(node.Table[:i], func(other NodeEntry) bool { return other.Key == entry.Key }) {
+				return common.NewError(common.ErrDuplicateField,
+					common.ErrorValueStr(entry.Key),
+					common.ErrorLocation(src.keyLocation(entry)),
+				)
+			}
+
+			err = checkDuplicateKeys(src, entry.Value)
+			if err != nil {
+				return err
+			}
+		}
+	case ArrayNode:
+		for _, elem := range node.Array {
+			err = checkDuplicateKeys(src, elem)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (src *source) invalidFieldType(node *Node, expected common.ErrorExpected) error {
+	return common.NewError(common.ErrInvalidFieldType,
+		expected,
+		common.ErrorLocation(src.nodeLocation(node)),
+	)
+}
+
+func (src *source) unknownField(entry *NodeEntry, expected common.ErrorExpected) error {
+	return common.NewError(common.ErrUnknownField,
+		expected,
+		common.ErrorLocation(src.keyLocation(entry)),
+	)
+}
+
+// Parses format string of the node.
+func (src *source) parseFormat(node *Node) (format ast.FormatParts, err error) {
+	locate := func(pos int) ast.Location {
+		return src.charLocation(node, pos)
+	}
+
+	format, err = parseFormat(node.Str, locate)
+	if err != nil {
+		return nil, src.locateFormatError(node, err)
+	}
+
+	return format, nil
+}
+
+// Adds location of the character the format error has occurred at.
+func (src *source) locateFormatError(node *Node, err error) error {
+	if e, ok := err.(*common.Error); ok {
+		if e.Pos != -1 {
+			e.Location = src.charLocation(node, int(e.Pos))
+		} else {
+			e.Location = src.nodeLocation(node)
+		}
+	}
+	return err
+}
+
+func (src *source) mapMessage(node *Node, syntax Syntax) (message ast.Message, err error) {
+	if value := node.Get("syntax"); value != nil {
+		syntax, err = src.mapSyntax(value)
+		if err != nil {
+			return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, "syntax", err)
+		}
+	}
+
+	// Strings written in ICU MessageFormat are converted by it
+	var conv *icuConverter
+	if syntax == SyntaxICU {
+		conv = &icuConverter{src: src}
+	}
+
+	for i := 0; i < len(node.Table); i++ {
+		entry := &node.Table[i]
+		k, v := entry.Key, entry.Value
+
+		switch {
+		case k == "syntax":
+			// Has been mapped already
+		case k == "arguments":
+			if v.Kind != ArrayNode {
+				err = src.invalidFieldType(v, common.ErrorExpectedStr("array"))
 				return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 			}
 
-			message.Variables, err = mapVariables(v)
+			message.Arguments, err = src.mapArguments(v)
 			if err != nil {
 				return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 			}
-		case "plural":
-			v, ok := v.(map[string]any)
-			if !ok {
-				err = common.NewError(common.ErrInvalidFieldType, common.ErrorExpectedStr("table"))
+		case k == "variables" && conv == nil:
+			if v.Kind != TableNode {
+				err = src.invalidFieldType(v, common.ErrorExpectedStr("table"))
 				return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 			}
 
-			message.Plural, err = mapPlural(v)
+			message.Variables, err = src.mapVariables(v)
 			if err != nil {
 				return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 			}
-		case "string":
-			v, ok := v.(string)
-			if !ok {
-				err = common.NewError(common.ErrInvalidFieldType, common.ErrorExpectedStr("string"))
+		case k == "plural":
+			if v.Kind != TableNode {
+				err = src.invalidFieldType(v, common.ErrorExpectedStr("table"))
+				return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+			}
+
+			message.Plural, err = src.mapPlural(v, conv)
+			if err != nil {
+				return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+			}
+
+			message.Plural.Location = src.keyLocation(entry)
+		case k == "string" && conv != nil:
+			if v.Kind != StringNode {
+				err = src.invalidFieldType(v, common.ErrorExpectedStr("string"))
+				return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+			}
+
+			str, plural, err := conv.convertMessage(v)
+			if err != nil {
+				return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+			}
+
+			// String that is the whole plural can't be specified along with the plural
+			if !plural.IsZero() && node.Get("plural") != nil {
+				err = common.NewError(common.ErrFieldsSpecifiedAtTheSameTime, common.ErrorLocation(src.keyLocation(entry)))
+				return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, "plural, string", err)
+			}
+
+			message.String, message.Plural = str, plural
+		case k == "string":
+			if v.Kind != StringNode {
+				err = src.invalidFieldType(v, common.ErrorExpectedStr("string"))
 				return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 			}
 
-			format, err := parseFormat(v)
+			format, err := src.parseFormat(v)
 			if err != nil {
 				return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 			}
 
 			message.String = format
+		case conv != nil:
+			// Variables can't be referred to in ICU MessageFormat
+			err = src.unknownField(entry, common.ErrorExpectedAnyStr("arguments", "plural", "string", "syntax"))
+			return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 		default:
-			err = common.NewError(common.ErrUnknownField, common.ErrorExpectedAnyStr("variables", "plural", "string"))
+			err = src.unknownField(entry, common.ErrorExpectedAnyStr("arguments", "variables", "plural", "string", "syntax"))
 			return ast.Message{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 		}
 	}
 
+	if conv != nil {
+		message.Variables = conv.variables
+		message.TextOrder = true
+	}
+
 	return message, nil
 }
 
-func mapVariables(table map[string]any) (variables []ast.Variable, err error) {
-	for k, v := range table {
+func (src *source) mapArguments(node *Node) (args []ast.ArgInfo, err error) {
+	for i, v := range node.Array {
+		field := strconv.Itoa(i)
+
+		if v.Kind != StringNode {
+			err = src.invalidFieldType(v, common.ErrorExpectedStr("string"))
+			return nil, common.NewFieldError(common.ErrCouldNotUnmarshal, field, err)
+		}
+
+		// Arguments are declared the same way they are specified
+		// inside of ${...} blocks
+		arg, _, err := parseArgument(v.Str)
+		if err != nil {
+			return nil, common.NewFieldError(common.ErrCouldNotUnmarshal, field, src.locateFormatError(v, err))
+		}
+
+		arg.Location = src.nodeLocation(v)
+
+		if slices.ContainsFunc(args, func(other ast.ArgInfo) bool { return other.Name == arg.Name }) {
+			err = common.NewError(common.ErrDuplicateArgument,
+				common.ErrorValueStr(arg.Name),
+				common.ErrorLocation(arg.Location),
+			)
+			return nil, common.NewFieldError(common.ErrCouldNotUnmarshal, field, err)
+		}
+
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+func (src *source) mapVariables(node *Node) (variables []ast.Variable, err error) {
+	for i := 0; i < len(node.Table); i++ {
+		entry := &node.Table[i]
+		k, v := entry.Key, entry.Value
+
 		err = checkVariableName(k)
 		if err != nil {
+			err = common.NewError(err,
+				common.ErrorValueStr(k),
+				common.ErrorLocation(src.keyLocation(entry)),
+			)
 			return nil, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 		}
 
-		if str, ok := v.(string); ok {
-			format, err := parseFormat(str)
+		if v.Kind == StringNode {
+			format, err := src.parseFormat(v)
 			if err != nil {
 				return nil, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 			}
 
 			variables = append(variables, ast.Variable{
-				Name:   k,
-				String: format,
+				Location: src.keyLocation(entry),
+				Name:     k,
+				String:   format,
 			})
 
 			continue
 		}
 
-		v, ok := v.(map[string]any)
-		if !ok {
-			err = common.NewError(common.ErrInvalidFieldType, common.ErrorExpectedAnyStr("string", "table"))
+		if v.Kind != TableNode {
+			err = src.invalidFieldType(v, common.ErrorExpectedAnyStr("string", "table"))
 			return nil, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 		}
 
-		variable, err := mapVariable(v)
+		variable, err := src.mapVariable(v)
 		if err != nil {
 			return nil, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 		}
 
+		variable.Location = src.keyLocation(entry)
 		variable.Name = k
 		variables = append(variables, variable)
 	}
@@ -144,35 +506,50 @@ func mapVariables(table map[string]any) (variables []ast.Variable, err error) {
 	return variables, nil
 }
 
-func mapVariable(table map[string]any) (variable ast.Variable, err error) {
-	for k, v := range table {
+func (src *source) mapVariable(node *Node) (variable ast.Variable, err error) {
+	for i := 0; i < len(node.Table); i++ {
+		entry := &node.Table[i]
+		k, v := entry.Key, entry.Value
+
 		switch k {
 		case "plural":
-			v, ok := v.(map[string]any)
-			if !ok {
-				err = common.NewError(common.ErrInvalidFieldType, common.ErrorExpectedStr("table"))
+			if v.Kind != TableNode {
+				err = src.invalidFieldType(v, common.ErrorExpectedStr("table"))
 				return ast.Variable{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 			}
 
-			variable.Plural, err = mapPlural(v)
+			variable.Plural, err = src.mapPlural(v, nil)
 			if err != nil {
 				return ast.Variable{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 			}
+
+			variable.Plural.Location = src.keyLocation(entry)
+		case "select":
+			if v.Kind != TableNode {
+				err = src.invalidFieldType(v, common.ErrorExpectedStr("table"))
+				return ast.Variable{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+			}
+
+			variable.Select, err = src.mapSelect(v)
+			if err != nil {
+				return ast.Variable{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+			}
+
+			variable.Select.Location = src.keyLocation(entry)
 		case "string":
-			v, ok := v.(string)
-			if !ok {
-				err = common.NewError(common.ErrInvalidFieldType, common.ErrorExpectedStr("string"))
+			if v.Kind != StringNode {
+				err = src.invalidFieldType(v, common.ErrorExpectedStr("string"))
 				return ast.Variable{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 			}
 
-			format, err := parseFormat(v)
+			format, err := src.parseFormat(v)
 			if err != nil {
 				return ast.Variable{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 			}
 
 			variable.String = format
 		default:
-			err = common.NewError(common.ErrUnknownField, common.ErrorExpectedAnyStr("plural"))
+			err = src.unknownField(entry, common.ErrorExpectedAnyStr("plural", "select", "string"))
 			return ast.Variable{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 		}
 	}
@@ -180,25 +557,88 @@ func mapVariable(table map[string]any) (variable ast.Variable, err error) {
 	return variable, nil
 }
 
-func mapPlural(table map[string]any) (plural ast.Plural, err error) {
-	for k, v := range table {
-		v, ok := v.(string)
-		if !ok {
-			err = common.NewError(common.ErrInvalidFieldType, common.ErrorExpectedStr("string"))
+// Maps the select, whose keys other than "arg" and "other" are the values of the argument.
+func (src *source) mapSelect(node *Node) (sel ast.Select, err error) {
+	for i := 0; i < len(node.Table); i++ {
+		entry := &node.Table[i]
+		k, v := entry.Key, entry.Value
+
+		if v.Kind != StringNode {
+			err = src.invalidFieldType(v, common.ErrorExpectedStr("string"))
+			return ast.Select{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+		}
+
+		if k == "arg" {
+			err = checkArgumentName(v.Str)
+			if err != nil {
+				err = common.NewError(err,
+					common.ErrorValueStr(v.Str),
+					common.ErrorLocation(src.nodeLocation(v)),
+				)
+				return ast.Select{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+			}
+
+			sel.Arg = v.Str
+			continue
+		}
+
+		format, err := src.parseFormat(v)
+		if err != nil {
+			return ast.Select{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
+		}
+
+		// Empty texts are specified too
+		if format == nil {
+			format = ast.FormatParts{ast.Text("")}
+		}
+
+		if k == "other" {
+			sel.Other = format
+		} else {
+			sel.Cases = append(sel.Cases, ast.SelectCase{Value: k, Parts: format})
+		}
+	}
+
+	return sel, nil
+}
+
+// Maps the plural, whose forms are written in ICU MessageFormat if there is a converter.
+func (src *source) mapPlural(node *Node, conv *icuConverter) (plural ast.Plural, err error) {
+	// Number signs of the forms are replaced with the argument
+	var arg string
+	if value := node.Get("arg"); value != nil && value.Kind == StringNode {
+		arg = value.Str
+	}
+
+	for i := 0; i < len(node.Table); i++ {
+		entry := &node.Table[i]
+		k, v := entry.Key, entry.Value
+
+		if v.Kind != StringNode {
+			err = src.invalidFieldType(v, common.ErrorExpectedStr("string"))
 			return ast.Plural{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 		}
 
 		if k == "arg" {
-			err = checkArgumentName(v)
+			err = checkArgumentName(v.Str)
 			if err != nil {
+				err = common.NewError(err,
+					common.ErrorValueStr(v.Str),
+					common.ErrorLocation(src.nodeLocation(v)),
+				)
 				return ast.Plural{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 			}
 
-			plural.Arg = v
+			plural.Arg = v.Str
 			continue
 		}
 
-		format, err := parseFormat(v)
+		var format ast.FormatParts
+		if conv != nil {
+			format, err = conv.convertForm(v, arg)
+		} else {
+			format, err = src.parseFormat(v)
+		}
 		if err != nil {
 			return ast.Plural{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 		}
@@ -208,12 +648,20 @@ func mapPlural(table map[string]any) (plural ast.Plural, err error) {
 			plural.Zero = format
 		case "one":
 			plural.One = format
+		case "two":
+			plural.Two = format
+		case "few":
+			plural.Few = format
 		case "many":
 			plural.Many = format
 		case "other":
 			plural.Other = format
 		default:
-			err = common.NewError(common.ErrUnknownField, common.ErrorExpectedAnyStr("arg", "zero", "one", "many", "other"))
+			if n, ok := exactFormNumber(k); ok {
+				plural.Exact = append(plural.Exact, ast.ExactForm{Number: n, Parts: format})
+				break
+			}
+			err = src.unknownField(entry, common.ErrorExpectedAnyStr("arg", "=N", "zero", "one", "two", "few", "many", "other"))
 			return ast.Plural{}, common.NewFieldError(common.ErrCouldNotUnmarshal, k, err)
 		}
 	}
@@ -0,0 +1,155 @@
+package parse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/infastin/go-l10n/ast"
+	"github.com/infastin/go-l10n/common"
+)
+
+// Returns the value written the way the tests expect it:
+// plurals and selects are written as "arg: form {text} ...".
+func valueString(value ast.Value) string {
+	var b strings.Builder
+
+	writeForm := func(name string, parts ast.FormatParts) {
+		if parts == nil {
+			return
+		}
+		b.WriteString(" " + name + " {" + parts.String() + "}")
+	}
+
+	switch v := value.(type) {
+	case *ast.Plural:
+		b.WriteString(v.Arg + ":")
+		for i := 0; i < len(v.Exact); i++ {
+			writeForm(v.Exact[i].Name(), v.Exact[i].Parts)
+		}
+		writeForm("zero", v.Zero)
+		writeForm("one", v.One)
+		writeForm("two", v.Two)
+		writeForm("few", v.Few)
+		writeForm("many", v.Many)
+		writeForm("other", v.Other)
+	case *ast.Select:
+		b.WriteString(v.Arg + ":")
+		for i := 0; i < len(v.Cases); i++ {
+			writeForm(v.Cases[i].Value, v.Cases[i].Parts)
+		}
+		writeForm("other", v.Other)
+	case ast.FormatParts:
+		b.WriteString(v.String())
+	}
+
+	return b.String()
+}
+
+// Returns the message and its variables written the way the tests expect them.
+func messageString(message *ast.Message) (str string, vars map[string]string) {
+	if !message.Plural.IsZero() {
+		str = valueString(&message.Plural)
+	} else {
+		str = valueString(message.String)
+	}
+
+	vars = make(map[string]string)
+
+	for i := 0; i < len(message.Variables); i++ {
+		variable := &message.Variables[i]
+		switch {
+		case !variable.Plural.IsZero():
+			vars[variable.Name] = valueString(&variable.Plural)
+		case !variable.Select.IsZero():
+			vars[variable.Name] = valueString(&variable.Select)
+		default:
+			vars[variable.Name] = valueString(variable.String)
+		}
+	}
+
+	return str, vars
+}
+
+func checkMessage(t *testing.T, message *ast.Message, want string, wantVars map[string]string) {
+	t.Helper()
+
+	got, gotVars := messageString(message)
+	if got != want {
+		t.Errorf("message:\ngot:  %s\nwant: %s", got, want)
+	}
+
+	if len(gotVars) != len(wantVars) {
+		t.Errorf("variables: got %v, want %v", gotVars, wantVars)
+		return
+	}
+
+	for name, want := range wantVars {
+		if got := gotVars[name]; got != want {
+			t.Errorf("variable %s:\ngot:  %s\nwant: %s", name, got, want)
+		}
+	}
+}
+
+// Returns kind of the innermost error in the chain.
+func errorKind(err error) (kind error) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if list, ok := err.(*common.ErrorList); ok && len(list.Errors) != 0 {
+			err = list.Errors[0]
+		}
+		if e, ok := err.(*common.Error); ok {
+			kind = e.ErrKind
+		}
+	}
+	return kind
+}
+
+// Test of unmarshaling a message in one of the formats.
+type unmarshalTest struct {
+	name string
+	in   string
+	// Name of the message that is checked, if there are several of them
+	message string
+	want    string
+	vars    map[string]string
+	err     error
+}
+
+// Runs the tests, unmarshaling their input with the function.
+// Tests that expect an error check its kind, and the rest of them check the message.
+func runUnmarshalTests(t *testing.T, tests []unmarshalTest, unmarshal func(in string) ([]ast.Message, error)) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := unmarshal(tt.in)
+			if tt.err != nil {
+				if kind := errorKind(err); kind != tt.err {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.message == "" {
+				if len(messages) != 1 {
+					t.Fatalf("got %d messages, want 1", len(messages))
+				}
+
+				checkMessage(t, &messages[0], tt.want, tt.vars)
+				return
+			}
+
+			for i := 0; i < len(messages); i++ {
+				if messages[i].Name == tt.message {
+					checkMessage(t, &messages[i], tt.want, tt.vars)
+					return
+				}
+			}
+
+			t.Fatalf("message %s is missing", tt.message)
+		})
+	}
+}
@@ -2,575 +2,330 @@ package printer
 
 import (
 	"bytes"
+	"errors"
 	"go/ast"
+	"go/format"
+	"go/parser"
+	goprinter "go/printer"
 	"go/token"
 	"io"
+	"slices"
+	"strconv"
 	"strings"
 )
 
-type astPrinter struct {
-	b     *bytes.Buffer
-	level int
-}
+var errSyntaxMismatch = errors.New("printed file doesn't match its syntax tree")
 
-func (p *astPrinter) indentLine() {
-	p.b.WriteString(strings.Repeat("\t", p.level))
+// Text that replaces the range of the printed file,
+// which is empty in case of insertions.
+type edit struct {
+	start, end int
+	text       string
 }
 
-func (p *astPrinter) next() *astPrinter {
-	return &astPrinter{
-		b:     p.b,
-		level: p.level + 1,
-	}
+// Generated syntax trees have no positions, so go/printer can place neither comments
+// nor line breaks in them. The file is printed without both and parsed back,
+// then they are inserted before the nodes of the parsed file,
+// and the result is formatted with go/format.
+type layout struct {
+	fset  *token.FileSet
+	edits []edit
+	// Doc comments of the parsed nodes, taken from the generated ones
+	docs map[ast.Node]*ast.CommentGroup
 }
 
-func (p *astPrinter) writeFile(f *ast.File) {
-	if f.Doc != nil {
-		for _, comment := range f.Doc.List {
-			p.b.WriteString(comment.Text)
-			p.b.WriteByte('\n')
-		}
-	}
-
-	p.b.WriteString("package ")
-	p.writeIdent(f.Name)
-	p.b.WriteByte('\n')
+func (l *layout) line(pos token.Pos) int {
+	return l.fset.Position(pos).Line
 }
 
-func (p *astPrinter) writeGenDecl(d *ast.GenDecl) {
-	p.b.WriteString(d.Tok.String())
-	p.b.WriteByte(' ')
-
-	if len(d.Specs) == 1 {
-		p.writeSpec(d.Specs[0])
-		return
-	}
-
-	p.b.WriteString("(\n")
-
-	next := p.next()
-	for _, spec := range d.Specs {
-		next.indentLine()
-		next.writeSpec(spec)
-		next.b.WriteByte('\n')
-	}
-
-	p.indentLine()
-	p.b.WriteString(")")
+func (l *layout) offset(pos token.Pos) int {
+	return l.fset.Position(pos).Offset
 }
 
-func (p *astPrinter) writeFuncDecl(f *ast.FuncDecl) {
-	p.b.WriteString("func")
-	p.b.WriteByte(' ')
-
-	if f.Recv != nil {
-		p.b.WriteByte('(')
-		p.writeField(f.Recv.List[0])
-		p.b.WriteString(") ")
-	}
-
-	p.writeIdent(f.Name)
-	p.writeFuncParams(f.Type.Params)
-
-	if f.Type.Results != nil {
-		p.writeFuncResults(f.Type.Results)
-	}
-
-	if f.Body != nil {
-		p.b.WriteByte(' ')
-		p.writeBlockStmt(f.Body)
-	}
+func (l *layout) insert(pos token.Pos, text string) {
+	l.edits = append(l.edits, edit{
+		start: l.offset(pos),
+		end:   l.offset(pos),
+		text:  text,
+	})
 }
 
-func (p *astPrinter) writeFuncParams(params *ast.FieldList) {
-	p.b.WriteByte('(')
-
-	for i, field := range params.List {
-		if i != 0 {
-			p.b.WriteString(", ")
-		}
-		p.writeField(field)
-	}
-
-	p.b.WriteString(") ")
+func (l *layout) remove(start, end token.Pos) {
+	l.edits = append(l.edits, edit{
+		start: l.offset(start),
+		end:   l.offset(end),
+	})
 }
 
-func (p *astPrinter) writeFuncResults(r *ast.FieldList) {
-	if len(r.List) == 1 && r.List[0].Names == nil {
-		p.writeExpr(r.List[0].Type)
-		return
-	}
-
-	p.b.WriteByte('(')
-
-	for i, field := range r.List {
-		if i != 0 {
-			p.b.WriteString(", ")
-		}
-		p.writeField(field)
-	}
-
-	p.b.WriteByte(')')
-}
-
-func (p *astPrinter) writeSpec(s ast.Spec) {
-	switch s := s.(type) {
+// Returns doc comment of the node and whether the node can have one.
+func getDoc(node ast.Node) (doc *ast.CommentGroup, ok bool) {
+	switch node := node.(type) {
+	case *ast.File:
+		return node.Doc, true
+	case *ast.GenDecl:
+		return node.Doc, true
+	case *ast.FuncDecl:
+		return node.Doc, true
 	case *ast.ImportSpec:
-		p.writeImportSpec(s)
+		return node.Doc, true
 	case *ast.TypeSpec:
-		p.writeTypeSpec(s)
+		return node.Doc, true
 	case *ast.ValueSpec:
-		p.writeValueSpec(s)
+		return node.Doc, true
+	case *ast.Field:
+		return node.Doc, true
 	}
-}
-
-func (p *astPrinter) writeImportSpec(s *ast.ImportSpec) {
-	if s.Name != nil {
-		p.writeIdent(s.Name)
-		p.b.WriteByte(' ')
-	}
-
-	p.writeBasicLit(s.Path)
-}
 
-func (p *astPrinter) writeTypeSpec(s *ast.TypeSpec) {
-	p.writeIdent(s.Name)
-	p.b.WriteByte(' ')
-	p.writeExpr(s.Type)
+	return nil, false
 }
 
-func (p *astPrinter) writeValueSpec(s *ast.ValueSpec) {
-	for i, name := range s.Names {
-		if i != 0 {
-			p.b.WriteString(", ")
+// Returns nodes that can have doc comments in the order they appear in the file.
+func getDocumentable(f *ast.File) (nodes []ast.Node) {
+	ast.Inspect(f, func(node ast.Node) bool {
+		if _, ok := getDoc(node); ok {
+			nodes = append(nodes, node)
 		}
-		p.writeIdent(name)
-	}
+		return true
+	})
 
-	if s.Type != nil {
-		p.b.WriteByte(' ')
-		p.writeExpr(s.Type)
-	}
-
-	if len(s.Values) == 0 {
-		return
-	}
+	return nodes
+}
 
-	p.b.WriteString(" = ")
+// Matches doc comments of the generated file with the nodes of the parsed one.
+func (l *layout) matchDocs(generated, parsed *ast.File) (err error) {
+	generatedNodes := getDocumentable(generated)
+	parsedNodes := getDocumentable(parsed)
 
-	for i, val := range s.Values {
-		if i != 0 {
-			p.b.WriteString(", ")
-		}
-		p.writeExpr(val)
+	if len(generatedNodes) != len(parsedNodes) {
+		return errSyntaxMismatch
 	}
-}
 
-func (p *astPrinter) writeField(f *ast.Field) {
-	for i, name := range f.Names {
-		if i != 0 {
-			p.b.WriteString(", ")
+	for i := 0; i < len(generatedNodes); i++ {
+		doc, _ := getDoc(generatedNodes[i])
+		if doc == nil {
+			continue
 		}
-		p.writeIdent(name)
-	}
 
-	if f.Type != nil {
-		p.b.WriteByte(' ')
-		p.writeExpr(f.Type)
-	}
-}
+		if _, ok := getDoc(parsedNodes[i]); !ok {
+			return errSyntaxMismatch
+		}
 
-func (p *astPrinter) writeExpr(e ast.Expr) {
-	switch e := e.(type) {
-	case *ast.Ident:
-		p.writeIdent(e)
-	case *ast.SelectorExpr:
-		p.writeSelectorExpr(e)
-	case *ast.StarExpr:
-		p.writeStarExpr(e)
-	case *ast.StructType:
-		p.writeStructType(e)
-	case *ast.InterfaceType:
-		p.writeInterfaceType(e)
-	case *ast.FuncType:
-		p.writeFuncType(e)
-	case *ast.CallExpr:
-		p.writeCallExpr(e)
-	case *ast.BasicLit:
-		p.writeBasicLit(e)
-	case *ast.BinaryExpr:
-		p.writeBinaryExpr(e)
-	case *ast.UnaryExpr:
-		p.writeUnaryExpr(e)
-	case *ast.IndexExpr:
-		p.writeIndexExpr(e)
-	case *ast.CompositeLit:
-		p.writeComposeLit(e)
-	case *ast.KeyValueExpr:
-		p.writeKeyValueExpr(e)
-	case *ast.MapType:
-		p.writeMapType(e)
-	case *ast.ArrayType:
-		p.writeArrayType(e)
-	case *ast.TypeAssertExpr:
-		p.writeTypeAssertExpr(e)
+		l.docs[parsedNodes[i]] = doc
 	}
-}
 
-func (p *astPrinter) writeIdent(i *ast.Ident) {
-	p.b.WriteString(i.Name)
+	return nil
 }
 
-func (p *astPrinter) writeSelectorExpr(s *ast.SelectorExpr) {
-	p.writeExpr(s.X)
-	p.b.WriteByte('.')
-	p.writeIdent(s.Sel)
+func isStdImport(spec ast.Spec) bool {
+	path, _ := strconv.Unquote(spec.(*ast.ImportSpec).Path.Value)
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
 }
 
-func (p *astPrinter) writeBasicLit(l *ast.BasicLit) {
-	p.b.WriteString(l.Value)
-}
+// Returns copy of the file to print, whose imports of the standard library
+// go before the other ones.
+func prepareFile(f *ast.File) *ast.File {
+	file := *f
+	file.Decls = slices.Clone(f.Decls)
 
-func (p *astPrinter) writeStarExpr(s *ast.StarExpr) {
-	p.b.WriteByte('*')
-	p.writeExpr(s.X)
-}
+	// go/printer writes doc comments of the nodes only if the file has no comments,
+	// but it can't place them without positions
+	file.Comments = []*ast.CommentGroup{}
 
-func (p *astPrinter) writeStructType(s *ast.StructType) {
-	p.b.WriteString("struct")
+	for i, decl := range file.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || decl.Tok != token.IMPORT {
+			continue
+		}
 
-	if len(s.Fields.List) == 0 {
-		p.b.WriteString("{}")
-		return
-	}
+		imports := *decl
+		imports.Specs = slices.Clone(decl.Specs)
 
-	p.b.WriteString(" {\n")
+		slices.SortStableFunc(imports.Specs, func(a, b ast.Spec) int {
+			switch {
+			case isStdImport(a) && !isStdImport(b):
+				return -1
+			case !isStdImport(a) && isStdImport(b):
+				return 1
+			}
+			return 0
+		})
 
-	next := p.next()
-	for _, field := range s.Fields.List {
-		next.indentLine()
-		next.writeField(field)
-		next.b.WriteByte('\n')
+		file.Decls[i] = &imports
 	}
 
-	p.indentLine()
-	p.b.WriteByte('}')
+	return &file
 }
 
-func (p *astPrinter) writeInterfaceType(i *ast.InterfaceType) {
-	p.b.WriteString("interface")
-
-	if len(i.Methods.List) == 0 {
-		p.b.WriteString("{}")
-		return
-	}
-
-	p.b.WriteString(" {\n")
-
-	next := p.next()
-	for _, field := range i.Methods.List {
-		next.indentLine()
-		next.writeField(field)
-		next.b.WriteByte('\n')
+// Separates imports of the standard library from the other ones,
+// so that go/format sorts them separately.
+func (l *layout) visitImports(d *ast.GenDecl) {
+	for i := 1; i < len(d.Specs); i++ {
+		if isStdImport(d.Specs[i-1]) && !isStdImport(d.Specs[i]) {
+			l.insert(d.Specs[i].Pos(), "\n")
+		}
 	}
-
-	p.indentLine()
-	p.b.WriteByte('}')
 }
 
-func (p *astPrinter) writeFuncType(f *ast.FuncType) {
-	if f.Func == token.NoPos {
-		p.b.Truncate(p.b.Len() - 1)
+// Puts documented fields after the first one on their own paragraphs
+// and empty structs and interfaces on one line.
+func (l *layout) visitFieldList(list *ast.FieldList) {
+	if len(list.List) == 0 && list.Opening.IsValid() && list.Closing.IsValid() {
+		l.remove(list.Opening+1, list.Closing)
 	}
 
-	p.writeFuncParams(f.Params)
-
-	if f.Results != nil {
-		p.writeFuncResults(f.Results)
-	}
-}
-
-func (p *astPrinter) writeCallExpr(c *ast.CallExpr) {
-	p.writeExpr(c.Fun)
-	p.b.WriteByte('(')
-
-	for i, expr := range c.Args {
-		if i != 0 {
-			p.b.WriteString(", ")
+	for i := 1; i < len(list.List); i++ {
+		if l.docs[list.List[i]] != nil {
+			l.insert(list.List[i].Pos(), "\n")
 		}
-		p.writeExpr(expr)
 	}
-
-	p.b.WriteByte(')')
 }
 
-func (p *astPrinter) writeBinaryExpr(b *ast.BinaryExpr) {
-	p.writeExpr(b.X)
-	p.b.WriteByte(' ')
-	p.b.WriteString(b.Op.String())
-	p.b.WriteByte(' ')
-	p.writeExpr(b.Y)
-}
-
-func (p *astPrinter) writeUnaryExpr(u *ast.UnaryExpr) {
-	p.b.WriteString(u.Op.String())
-	p.writeExpr(u.X)
-}
-
-func (p *astPrinter) writeIndexExpr(i *ast.IndexExpr) {
-	p.writeExpr(i.X)
-	p.b.WriteByte('[')
-	p.writeExpr(i.Index)
-	p.b.WriteByte(']')
-}
-
-func (p *astPrinter) writeComposeLit(c *ast.CompositeLit) {
-	if c.Type != nil {
-		p.writeExpr(c.Type)
-	}
-
+// Puts elements of the composite literal on their own lines.
+func (l *layout) visitCompositeLit(c *ast.CompositeLit) {
 	if len(c.Elts) == 0 {
-		p.b.WriteString("{}")
 		return
 	}
 
-	p.b.WriteString("{\n")
-
-	next := p.next()
-	for _, expr := range c.Elts {
-		next.indentLine()
-		next.writeExpr(expr)
-		next.b.WriteString(",\n")
+	for _, elt := range c.Elts {
+		l.insert(elt.Pos(), "\n")
 	}
 
-	p.indentLine()
-	p.b.WriteByte('}')
-}
-
-func (p *astPrinter) writeKeyValueExpr(kv *ast.KeyValueExpr) {
-	p.writeExpr(kv.Key)
-	p.b.WriteString(": ")
-	p.writeExpr(kv.Value)
+	l.insert(c.Rbrace, ",\n")
 }
 
-func (p *astPrinter) writeMapType(m *ast.MapType) {
-	p.b.WriteString("map[")
-	p.writeExpr(m.Key)
-	p.b.WriteByte(']')
-	p.writeExpr(m.Value)
-}
-
-func (p *astPrinter) writeArrayType(a *ast.ArrayType) {
-	p.b.WriteByte('[')
-
-	if a.Len != nil {
-		p.writeExpr(a.Len)
-	}
-
-	p.b.WriteByte(']')
-	p.writeExpr(a.Elt)
-}
-
-func (p *astPrinter) writeTypeAssertExpr(t *ast.TypeAssertExpr) {
-	p.writeExpr(t.X)
-	p.b.WriteString(".(")
-
-	if t.Type != nil {
-		p.writeExpr(t.Type)
-	} else {
-		p.b.WriteString("type")
-	}
-
-	p.b.WriteByte(')')
-}
+// Puts statements of the block on their own lines, as go/printer writes small function bodies on one line.
+// Returns are separated from the preceding statements, unless there is only one,
+// and declarations are separated from the following statements.
+func (l *layout) visitBlockStmt(b *ast.BlockStmt) {
+	prevLine := l.line(b.Lbrace)
 
-func (p *astPrinter) writeStmt(s ast.Stmt) {
-	switch s := s.(type) {
-	case *ast.ExprStmt:
-		p.writeExpr(s.X)
-	case *ast.DeclStmt:
-		p.writeDeclStmt(s)
-	case *ast.SwitchStmt:
-		p.writeSwitchStmt(s)
-	case *ast.TypeSwitchStmt:
-		p.writeTypeSwitchStmt(s)
-	case *ast.CaseClause:
-		p.writeCaseClause(s)
-	case *ast.AssignStmt:
-		p.writeAssignStmt(s)
-	case *ast.ReturnStmt:
-		p.writeReturnStmt(s)
-	}
-}
+	for i, stmt := range b.List {
+		var text string
 
-func (p *astPrinter) writeDeclStmt(s *ast.DeclStmt) {
-	genDecl, ok := s.Decl.(*ast.GenDecl)
-	if !ok {
-		return
-	}
+		if l.line(stmt.Pos()) == prevLine {
+			text += "\n"
+		}
 
-	p.writeGenDecl(genDecl)
-}
+		if i != 0 {
+			_, isReturn := stmt.(*ast.ReturnStmt)
+			_, afterDecl := b.List[i-1].(*ast.DeclStmt)
 
-func (p *astPrinter) writeSwitchStmt(s *ast.SwitchStmt) {
-	p.b.WriteString("switch ")
+			if (isReturn && len(b.List) != 2) || afterDecl {
+				text += "\n"
+			}
+		}
 
-	if s.Init != nil {
-		p.writeStmt(s.Init)
-		p.b.WriteString("; ")
-	}
+		if text != "" {
+			l.insert(stmt.Pos(), text)
+		}
 
-	if s.Tag != nil {
-		p.writeExpr(s.Tag)
-		p.b.WriteByte(' ')
+		prevLine = l.line(stmt.End())
 	}
 
-	p.b.WriteString("{\n")
-
-	for _, stmt := range s.Body.List {
-		p.indentLine()
-		p.writeStmt(stmt)
-		p.b.WriteByte('\n')
+	if len(b.List) != 0 && l.line(b.Rbrace) == prevLine {
+		l.insert(b.Rbrace, "\n")
 	}
-
-	p.indentLine()
-	p.b.WriteByte('}')
 }
 
-func (p *astPrinter) writeTypeSwitchStmt(t *ast.TypeSwitchStmt) {
-	p.b.WriteString("switch ")
-
-	if t.Init != nil {
-		p.writeStmt(t.Init)
-		p.b.WriteString("; ")
-	}
-
-	if t.Assign != nil {
-		p.writeStmt(t.Assign)
-		p.b.WriteByte(' ')
-	}
-
-	p.b.WriteString("{\n")
-
-	for _, stmt := range t.Body.List {
-		p.indentLine()
-		p.writeStmt(stmt)
-		p.b.WriteByte('\n')
+func (l *layout) visit(node ast.Node) bool {
+	switch node := node.(type) {
+	case *ast.File:
+		for i := 1; i < len(node.Decls); i++ {
+			l.insert(node.Decls[i].Pos(), "\n")
+		}
+	case *ast.GenDecl:
+		if node.Tok == token.IMPORT {
+			l.visitImports(node)
+		}
+	case *ast.FieldList:
+		l.visitFieldList(node)
+	case *ast.CompositeLit:
+		l.visitCompositeLit(node)
+	case *ast.BlockStmt:
+		l.visitBlockStmt(node)
 	}
 
-	p.indentLine()
-	p.b.WriteByte('}')
+	return true
 }
 
-func (p *astPrinter) writeCaseClause(c *ast.CaseClause) {
-	if c.List != nil {
-		p.b.WriteString("case ")
-
-		for i, expr := range c.List {
-			if i != 0 {
-				p.b.WriteString(", ")
-			}
-			p.writeExpr(expr)
+// Inserts doc comments before their nodes, or before the package clause in case of the file.
+func (l *layout) insertDocs(parsed *ast.File) {
+	ast.Inspect(parsed, func(node ast.Node) bool {
+		doc := l.docs[node]
+		if doc == nil {
+			return true
 		}
-	} else {
-		p.b.WriteString("default")
-	}
-
-	p.b.WriteString(":\n")
 
-	next := p.next()
-	for i, stmt := range c.Body {
-		if i != 0 {
-			next.b.WriteByte('\n')
+		var text strings.Builder
+		for _, comment := range doc.List {
+			text.WriteString(comment.Text)
+			text.WriteByte('\n')
 		}
 
-		next.indentLine()
-		next.writeStmt(stmt)
-	}
-}
-
-func (p *astPrinter) writeAssignStmt(a *ast.AssignStmt) {
-	for i, expr := range a.Lhs {
-		if i != 0 {
-			p.b.WriteString(", ")
+		if file, ok := node.(*ast.File); ok {
+			l.insert(file.Package, text.String())
+		} else {
+			l.insert(node.Pos(), text.String())
 		}
-		p.writeExpr(expr)
-	}
-
-	p.b.WriteByte(' ')
-	p.b.WriteString(a.Tok.String())
-	p.b.WriteByte(' ')
 
-	for i, expr := range a.Rhs {
-		if i != 0 {
-			p.b.WriteString(", ")
-		}
-		p.writeExpr(expr)
-	}
+		return true
+	})
 }
 
-func (p *astPrinter) writeReturnStmt(r *ast.ReturnStmt) {
-	p.b.WriteString("return")
+// Returns the source with the edits applied.
+func (l *layout) apply(src []byte) []byte {
+	slices.SortStableFunc(l.edits, func(a, b edit) int {
+		return a.start - b.start
+	})
 
-	if len(r.Results) == 0 {
-		return
+	var b bytes.Buffer
+
+	offset := 0
+	for _, e := range l.edits {
+		b.Write(src[offset:e.start])
+		b.WriteString(e.text)
+		offset = e.end
 	}
 
-	p.b.WriteByte(' ')
+	b.Write(src[offset:])
 
-	for i, expr := range r.Results {
-		if i != 0 {
-			p.b.WriteString(", ")
-		}
-		p.writeExpr(expr)
-	}
+	return b.Bytes()
 }
 
-func (p *astPrinter) writeBlockStmt(b *ast.BlockStmt) {
-	p.b.WriteString("{\n")
-
-	next := p.next()
-	for i, stmt := range b.List {
-		if _, ok := stmt.(*ast.ReturnStmt); ok && i != 0 && len(b.List) != 2 {
-			next.b.WriteByte('\n')
-		}
+// Prints the generated file with go/printer and formats it with go/format.
+func FprintAstFile(w io.Writer, f *ast.File) (err error) {
+	f = prepareFile(f)
 
-		next.indentLine()
-		next.writeStmt(stmt)
-		next.b.WriteByte('\n')
+	var printed bytes.Buffer
 
-		if _, ok := stmt.(*ast.DeclStmt); ok && i != len(b.List)-1 {
-			next.b.WriteByte('\n')
-		}
+	fset := token.NewFileSet()
+	if err = goprinter.Fprint(&printed, fset, f); err != nil {
+		return err
 	}
 
-	p.indentLine()
-	p.b.WriteByte('}')
-}
-
-func FprintAstFile(w io.Writer, f *ast.File) (err error) {
-	p := &astPrinter{
-		b:     bytes.NewBuffer(nil),
-		level: 0,
+	parsed, err := parser.ParseFile(fset, "", printed.Bytes(), parser.SkipObjectResolution)
+	if err != nil {
+		return err
 	}
 
-	p.writeFile(f)
+	l := &layout{
+		fset: fset,
+		docs: make(map[ast.Node]*ast.CommentGroup),
+	}
 
-	for i, decl := range f.Decls {
-		if i != 0 {
-			p.b.WriteByte('\n')
-		}
+	if err = l.matchDocs(f, parsed); err != nil {
+		return err
+	}
 
-		p.b.WriteByte('\n')
+	ast.Inspect(parsed, l.visit)
+	l.insertDocs(parsed)
 
-		switch decl := decl.(type) {
-		case *ast.GenDecl:
-			p.writeGenDecl(decl)
-		case *ast.FuncDecl:
-			p.writeFuncDecl(decl)
-		}
+	src, err := format.Source(l.apply(printed.Bytes()))
+	if err != nil {
+		return err
 	}
 
-	_, err = io.Copy(w, p.b)
+	_, err = w.Write(src)
 	return err
 }
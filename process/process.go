@@ -1,11 +1,14 @@
 package process
 
 import (
+	"cmp"
+	"slices"
 	"strings"
 
 	"github.com/infastin/go-l10n/ast"
 	"github.com/infastin/go-l10n/common"
 	"github.com/infastin/go-l10n/scope"
+	"golang.org/x/text/language"
 )
 
 type FieldValue struct {
@@ -13,24 +16,33 @@ type FieldValue struct {
 	Value ast.Value
 }
 
-func ProcessMessages(msgs []ast.Message) (mss []scope.MessageScope, err error) {
+// Processes messages of the language.
+// If some of the messages are invalid, the rest of them are returned
+// along with the list of errors.
+func ProcessMessages(msgs []ast.Message, lang language.Tag) (mss []scope.MessageScope, err error) {
+	var errs common.ErrorList
+
 	for i := 0; i < len(msgs); i++ {
-		ms, err := processMessage(&msgs[i])
+		ms, err := processMessage(&msgs[i], lang)
 		if err != nil {
-			return nil, common.NewFieldError(common.ErrCouldNotProcess, msgs[i].Name, err)
+			err = common.NewFieldError(common.ErrCouldNotProcess, msgs[i].Name, err)
+			errs.Add(common.NewMessageError(msgs[i].Name, err))
+			continue
 		}
 
 		mss = append(mss, ms)
 	}
 
-	return mss, nil
+	return mss, errs.Err()
 }
 
-func processMessage(msg *ast.Message) (ms scope.MessageScope, err error) {
+func processMessage(msg *ast.Message, lang language.Tag) (ms scope.MessageScope, err error) {
 	ms = scope.MessageScope{
-		Name:   msg.Name,
-		Plural: msg.Plural,
-		String: msg.String,
+		Location:    msg.Location,
+		Name:        msg.Name,
+		Plural:      msg.Plural,
+		String:      msg.String,
+		Description: msg.Description,
 	}
 
 	fields := []FieldValue{
@@ -40,12 +52,29 @@ func processMessage(msg *ast.Message) (ms scope.MessageScope, err error) {
 
 	err = checkFielsXor(fields)
 	if err != nil {
+		err = common.NewError(err, common.ErrorLocation(msg.Location))
 		return scope.MessageScope{}, common.NewFieldError(common.ErrCouldNotProcess, getFieldNames(fields), err)
 	}
 
+	// Declared arguments are processed first,
+	// so that they define the order of arguments
+	for i := 0; i < len(msg.Arguments); i++ {
+		arg := &msg.Arguments[i]
+
+		var goType ast.GoType
+		if arg.FmtInfo.Spec != 0 {
+			goType = common.Config.SpecifierToGoType[arg.FmtInfo.Spec]
+		}
+
+		err = processArg(&ms, arg.Name, goType, arg.Location)
+		if err != nil {
+			return scope.MessageScope{}, common.NewFieldError(common.ErrCouldNotProcess, "arguments", err)
+		}
+	}
+
 	for i := 0; i < len(msg.Variables); i++ {
 		var argNames []string
-		values := []ast.Value{&msg.Variables[i].Plural, msg.Variables[i].String}
+		values := []ast.Value{&msg.Variables[i].Plural, &msg.Variables[i].Select, msg.Variables[i].String}
 
 		for _, val := range values {
 			if !val.IsZero() {
@@ -61,17 +90,43 @@ func processMessage(msg *ast.Message) (ms scope.MessageScope, err error) {
 	}
 
 	for i := 0; i < len(ms.Variables); i++ {
-		err = processVariable(&ms, &ms.Variables[i])
+		err = processVariable(&ms, &ms.Variables[i], lang)
 		if err != nil {
 			return scope.MessageScope{}, common.NewFieldError(common.ErrCouldNotProcess, ms.Variables[i].Name, err)
 		}
 	}
 
-	err = processFields(&ms, fields)
+	// Variables also depend on the arguments of the variables they refer to
+	argNames := make([][]string, len(ms.Variables))
+	for i := 0; i < len(ms.Variables); i++ {
+		argNames[i], err = variableArgumentNames(&ms, &ms.Variables[i], nil)
+		if err != nil {
+			return scope.MessageScope{}, common.NewFieldError(common.ErrCouldNotProcess, ms.Variables[i].Name, err)
+		}
+	}
+	for i := 0; i < len(ms.Variables); i++ {
+		ms.Variables[i].ArgumentNames = argNames[i]
+	}
+
+	err = processFields(&ms, fields, lang)
 	if err != nil {
 		return scope.MessageScope{}, err
 	}
 
+	// If arguments are declared, all of them must be
+	if len(msg.Arguments) != 0 && len(ms.Arguments) != len(msg.Arguments) {
+		arg := &ms.Arguments[len(msg.Arguments)]
+		err = common.NewError(common.ErrArgumentNotDeclared, common.ErrorLocation(arg.Location))
+		return scope.MessageScope{}, common.NewFieldError(common.ErrCouldNotProcess, arg.Name, err)
+	}
+
+	// Otherwise arguments of ICU-like messages go in the order in which they first appear in the text,
+	// rather than in the one they have been processed in, which puts arguments
+	// of the variables before the ones of the message
+	if len(msg.Arguments) == 0 && msg.TextOrder {
+		sortArguments(&ms, fields)
+	}
+
 	for i := 0; i < len(ms.Arguments); i++ {
 		arg := &ms.Arguments[i]
 		if arg.GoType.IsZero() {
@@ -82,18 +137,20 @@ func processMessage(msg *ast.Message) (ms scope.MessageScope, err error) {
 	return ms, nil
 }
 
-func processVariable(ms *scope.MessageScope, variable *scope.VariableScope) (err error) {
+func processVariable(ms *scope.MessageScope, variable *scope.VariableScope, lang language.Tag) (err error) {
 	fields := []FieldValue{
 		{"plural", &variable.Plural},
+		{"select", &variable.Select},
 		{"string", variable.String},
 	}
 
 	err = checkFielsXor(fields)
 	if err != nil {
+		err = common.NewError(err, common.ErrorLocation(variable.Location))
 		return common.NewFieldError(common.ErrCouldNotProcess, getFieldNames(fields), err)
 	}
 
-	err = processFields(ms, fields)
+	err = processFields(ms, fields, lang)
 	if err != nil {
 		return err
 	}
@@ -101,29 +158,171 @@ func processVariable(ms *scope.MessageScope, variable *scope.VariableScope) (err
 	return nil
 }
 
-func processPlural(ms *scope.MessageScope, plural *ast.Plural) (err error) {
+// Returns names of the arguments of the variable,
+// followed by the ones of the variables it refers to.
+// Variables are referred to by the ones in the path,
+// and the variable that is already in the path references itself.
+func variableArgumentNames(ms *scope.MessageScope, variable *scope.VariableScope, path []string) (names []string, err error) {
+	if slices.Contains(path, variable.Name) {
+		return nil, common.NewError(common.ErrCyclicVariable,
+			common.ErrorValueStr(variable.Name),
+			common.ErrorLocation(variable.Location),
+		)
+	}
+
+	path = append(path, variable.Name)
+	names = slices.Clone(variable.ArgumentNames)
+
+	values := []ast.Value{&variable.Plural, &variable.Select, variable.String}
+
+	for _, value := range values {
+		for _, form := range value.Forms() {
+			for _, name := range form.GetVariableNames() {
+				other := &ms.Variables[scope.VariableScopeIndex(ms.Variables, name)]
+
+				otherNames, err := variableArgumentNames(ms, other, path)
+				if err != nil {
+					return nil, err
+				}
+
+				for _, name := range otherNames {
+					if !slices.Contains(names, name) {
+						names = append(names, name)
+					}
+				}
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// Sorts arguments of the message by their first appearance in the value of the fields.
+func sortArguments(ms *scope.MessageScope, fields []FieldValue) {
+	var names, expanded []string
+
+	for _, field := range fields {
+		if !field.Value.IsZero() {
+			names = appendArgumentNames(ms, names, &expanded, field.Value)
+		}
+	}
+
+	rank := func(name string) int {
+		if i := slices.Index(names, name); i != -1 {
+			return i
+		}
+		return len(names)
+	}
+
+	slices.SortStableFunc(ms.Arguments, func(a, b scope.Argument) int {
+		return cmp.Compare(rank(a.Name), rank(b.Name))
+	})
+}
+
+// Appends names of the arguments of the value in the order in which they first appear in it.
+// Variables the value refers to are expanded in place of their first reference,
+// and the argument of a plural or a select goes before the ones of its forms.
+func appendArgumentNames(ms *scope.MessageScope, names []string, expanded *[]string, value ast.Value) []string {
+	add := func(name string) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	switch value := value.(type) {
+	case *ast.Plural:
+		add(value.Arg)
+	case *ast.Select:
+		add(value.Arg)
+	}
+
+	for _, form := range value.Forms() {
+		for _, part := range form {
+			switch part := part.(type) {
+			case ast.ArgInfo:
+				add(part.Name)
+			case ast.VarInfo:
+				if slices.Contains(*expanded, part.Name) {
+					continue
+				}
+				*expanded = append(*expanded, part.Name)
+
+				variable := &ms.Variables[scope.VariableScopeIndex(ms.Variables, part.Name)]
+				for _, val := range []ast.Value{&variable.Plural, &variable.Select, variable.String} {
+					if !val.IsZero() {
+						names = appendArgumentNames(ms, names, expanded, val)
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+func processPlural(ms *scope.MessageScope, plural *ast.Plural, lang language.Tag) (err error) {
 	if plural.Arg == "" {
-		return common.NewFieldError(common.ErrCouldNotProcess, "arg", common.ErrFieldNotSpecified)
+		err = common.NewError(common.ErrFieldNotSpecified, common.ErrorLocation(plural.Location))
+		return common.NewFieldError(common.ErrCouldNotProcess, "arg", err)
 	}
 
 	goType := common.Config.SpecifierToGoType['d']
 
-	err = processArg(ms, plural.Arg, goType)
+	err = processArg(ms, plural.Arg, goType, plural.Location)
 	if err != nil {
 		return common.NewFieldError(common.ErrCouldNotProcess, "arg", err)
 	}
 
+	for i := 0; i < len(plural.Exact); i++ {
+		form := &plural.Exact[i]
+
+		err = processFormatParts(ms, form.Parts)
+		if err != nil {
+			return common.NewFieldError(common.ErrCouldNotProcess, form.Name(), err)
+		}
+	}
+
 	fields := []struct {
 		Name        string
 		FormatParts ast.FormatParts
 	}{
 		{"zero", plural.Zero},
 		{"one", plural.One},
+		{"two", plural.Two},
+		{"few", plural.Few},
 		{"many", plural.Many},
 		{"other", plural.Other},
 	}
 
+	// Plural forms used by the language
+	forms := scope.PluralForms(lang)
+
 	for _, field := range fields {
+		if field.FormatParts == nil {
+			// Forms not used by the language can be omitted,
+			// as well as any form if "other" is specified
+			if plural.Other == nil && slices.Contains(forms, field.Name) {
+				err = common.NewError(common.ErrPluralFormRequired,
+					common.ErrorValueStr(lang.String()),
+					common.ErrorLocation(plural.Location),
+				)
+				return common.NewFieldError(common.ErrCouldNotProcess, field.Name, err)
+			}
+
+			continue
+		}
+
+		// "zero" is always allowed: if the language doesn't use it,
+		// it simply matches zero
+		if field.Name != "zero" && field.Name != "other" && !slices.Contains(forms, field.Name) {
+			err = common.NewError(common.ErrPluralFormNotUsed,
+				common.ErrorValueStr(lang.String()),
+				common.ErrorLocation(plural.Location),
+			)
+			return common.NewFieldError(common.ErrCouldNotProcess, field.Name, err)
+		}
+
 		err = processFormatParts(ms, field.FormatParts)
 		if err != nil {
 			return common.NewFieldError(common.ErrCouldNotProcess, field.Name, err)
@@ -133,6 +332,42 @@ func processPlural(ms *scope.MessageScope, plural *ast.Plural) (err error) {
 	return nil
 }
 
+func processSelect(ms *scope.MessageScope, sel *ast.Select) (err error) {
+	if sel.Arg == "" {
+		err = common.NewError(common.ErrFieldNotSpecified, common.ErrorLocation(sel.Location))
+		return common.NewFieldError(common.ErrCouldNotProcess, "arg", err)
+	}
+
+	goType := common.Config.SpecifierToGoType['s']
+
+	err = processArg(ms, sel.Arg, goType, sel.Location)
+	if err != nil {
+		return common.NewFieldError(common.ErrCouldNotProcess, "arg", err)
+	}
+
+	for i := 0; i < len(sel.Cases); i++ {
+		c := &sel.Cases[i]
+
+		err = processFormatParts(ms, c.Parts)
+		if err != nil {
+			return common.NewFieldError(common.ErrCouldNotProcess, c.Value, err)
+		}
+	}
+
+	// Values are arbitrary strings, so there must be a text for the rest of them
+	if sel.Other == nil {
+		err = common.NewError(common.ErrFieldNotSpecified, common.ErrorLocation(sel.Location))
+		return common.NewFieldError(common.ErrCouldNotProcess, "other", err)
+	}
+
+	err = processFormatParts(ms, sel.Other)
+	if err != nil {
+		return common.NewFieldError(common.ErrCouldNotProcess, "other", err)
+	}
+
+	return nil
+}
+
 func processFormatParts(ms *scope.MessageScope, parts ast.FormatParts) (err error) {
 	for _, cell := range parts {
 		switch cell := cell.(type) {
@@ -142,14 +377,15 @@ func processFormatParts(ms *scope.MessageScope, parts ast.FormatParts) (err erro
 				goType = common.Config.SpecifierToGoType[cell.FmtInfo.Spec]
 			}
 
-			err = processArg(ms, cell.Name, goType)
+			err = processArg(ms, cell.Name, goType, cell.Location)
 			if err != nil {
 				return err
 			}
 		case ast.VarInfo:
 			idx := scope.VariableScopeIndex(ms.Variables, cell.Name)
 			if idx == -1 {
-				return common.NewFieldError(common.ErrCouldNotProcess, cell.Name, common.ErrVariableNotSpecified)
+				err = common.NewError(common.ErrVariableNotSpecified, common.ErrorLocation(cell.Location))
+				return common.NewFieldError(common.ErrCouldNotProcess, cell.Name, err)
 			}
 		}
 	}
@@ -157,13 +393,14 @@ func processFormatParts(ms *scope.MessageScope, parts ast.FormatParts) (err erro
 	return nil
 }
 
-func processArg(ms *scope.MessageScope, arg string, goType ast.GoType) (err error) {
+func processArg(ms *scope.MessageScope, arg string, goType ast.GoType, loc ast.Location) (err error) {
 	otherIdx := scope.ArgumentIndex(ms.Arguments, arg)
 
 	if otherIdx == -1 {
 		ms.Arguments = append(ms.Arguments, scope.Argument{
-			Name:   arg,
-			GoType: goType,
+			Location: loc,
+			Name:     arg,
+			GoType:   goType,
 		})
 
 		return nil
@@ -181,7 +418,8 @@ func processArg(ms *scope.MessageScope, arg string, goType ast.GoType) (err erro
 	}
 
 	if other.GoType != goType {
-		return common.NewFieldError(common.ErrCouldNotProcess, arg, common.ErrTypesDontMatch)
+		err = common.NewError(common.ErrTypesDontMatch, common.ErrorLocation(loc))
+		return common.NewFieldError(common.ErrCouldNotProcess, arg, err)
 	}
 
 	return nil
@@ -227,7 +465,7 @@ func getFieldNames(fields []FieldValue) (str string) {
 	return b.String()
 }
 
-func processFields(ms *scope.MessageScope, fields []FieldValue) (err error) {
+func processFields(ms *scope.MessageScope, fields []FieldValue, lang language.Tag) (err error) {
 	for _, field := range fields {
 		if field.Value.IsZero() {
 			continue
@@ -235,7 +473,9 @@ func processFields(ms *scope.MessageScope, fields []FieldValue) (err error) {
 
 		switch v := field.Value.(type) {
 		case *ast.Plural:
-			err = processPlural(ms, v)
+			err = processPlural(ms, v, lang)
+		case *ast.Select:
+			err = processSelect(ms, v)
 		case ast.FormatParts:
 			err = processFormatParts(ms, v)
 		}
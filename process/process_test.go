@@ -0,0 +1,111 @@
+package process
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/infastin/go-l10n/parse"
+	"golang.org/x/text/language"
+)
+
+func TestArgumentOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{
+			name: "text",
+			yaml: `Message: "${host} invited ${guest}"`,
+			want: []string{"host", "guest"},
+		},
+		{
+			name: "plural and select after arguments",
+			yaml: "syntax: icu\n" +
+				`Message: "{host} invited {guest} to {gender, select, male {his} other {their}} party` +
+				` with {n, plural, one {# friend} other {# friends}}."`,
+			want: []string{"host", "guest", "gender", "n"},
+		},
+		{
+			name: "plural before arguments",
+			yaml: "syntax: icu\n" +
+				`Message: "{n, plural, one {# file} other {# files}} in {dir}"`,
+			want: []string{"n", "dir"},
+		},
+		{
+			name: "arguments of forms",
+			yaml: "syntax: icu\n" +
+				`Message: "{n, plural, one {{owner} has # file in {dir}} other {{owner} has # files}}"`,
+			want: []string{"n", "owner", "dir"},
+		},
+		{
+			name: "select in plural",
+			yaml: "syntax: icu\n" +
+				`Message: "{n, plural, one {{gender, select, male {his} other {their}} file} other {# files of {owner}}}"`,
+			want: []string{"n", "gender", "owner"},
+		},
+		{
+			name: "variables",
+			yaml: `
+Message:
+  variables:
+    pronoun:
+      select:
+        arg: gender
+        male: "his ${thing}"
+        other: "their ${thing}"
+  string: "${host} lost &{pronoun} at ${place}, &{pronoun}!"`,
+			// Arguments of variables go first in messages of the default syntax,
+			// as they always have
+			want: []string{"gender", "thing", "host", "place"},
+		},
+		{
+			// Both arguments are strings, so swapping them would still compile
+			name: "existing message with arguments of the same type",
+			yaml: `
+Invitation:
+  variables:
+    invitee:
+      string: "${guest}"
+  string: "${host} invited &{invitee}"`,
+			want: []string{"guest", "host"},
+		},
+		{
+			name: "variables of ICU message",
+			yaml: "syntax: icu\n" +
+				`Message: "{host} lost {gender, select, male {his {thing}} other {their {thing}}}"`,
+			want: []string{"host", "gender", "thing"},
+		},
+		{
+			name: "declared arguments",
+			yaml: `
+Message:
+  arguments: [guest, host]
+  string: "${host} invited ${guest}"`,
+			want: []string{"guest", "host"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs, err := parse.UnmarshalMessages("test.yaml", []byte(tt.yaml), parse.DecodeYAML)
+			if err != nil {
+				t.Fatalf("could not unmarshal: %v", err)
+			}
+
+			mss, err := ProcessMessages(msgs, language.English)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, arg := range mss[0].Arguments {
+				got = append(got, arg.Name)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
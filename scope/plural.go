@@ -0,0 +1,65 @@
+package scope
+
+import (
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// Names of plural forms in CLDR order.
+var pluralFormNames = []struct {
+	Form plural.Form
+	Name string
+}{
+	{plural.Zero, "zero"},
+	{plural.One, "one"},
+	{plural.Two, "two"},
+	{plural.Few, "few"},
+	{plural.Many, "many"},
+	{plural.Other, "other"},
+}
+
+// Numbers that are enough to hit every CLDR plural form of integers
+// in every language, in addition to numbers from 0 to 1000.
+var pluralFormSamples = []int{
+	10000, 100000, 1000000, 2000000, 10000000,
+}
+
+// Returns numbers that are enough to hit every CLDR plural form
+// of integers in every language.
+func PluralFormSamples() (samples []int) {
+	for i := 0; i <= 1000; i++ {
+		samples = append(samples, i)
+	}
+	return append(samples, pluralFormSamples...)
+}
+
+// Returns name of plural form that the given language uses for the integer.
+func PluralFormOf(lang language.Tag, n int) (name string) {
+	form := plural.Cardinal.MatchPlural(lang, n, 0, 0, 0, 0)
+
+	for _, formName := range pluralFormNames {
+		if formName.Form == form {
+			return formName.Name
+		}
+	}
+
+	return "other"
+}
+
+// Returns names of plural forms that the given language uses for integers.
+// Names are returned in CLDR order.
+func PluralForms(lang language.Tag) (names []string) {
+	used := make(map[plural.Form]struct{})
+
+	for _, i := range PluralFormSamples() {
+		used[plural.Cardinal.MatchPlural(lang, i, 0, 0, 0, 0)] = struct{}{}
+	}
+
+	for _, form := range pluralFormNames {
+		if _, ok := used[form.Form]; ok {
+			names = append(names, form.Name)
+		}
+	}
+
+	return names
+}
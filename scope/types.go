@@ -8,8 +8,10 @@ import (
 )
 
 type Argument struct {
-	Name   string
-	GoType ast.GoType
+	// Location of the first occurrence of the argument
+	Location ast.Location
+	Name     string
+	GoType   ast.GoType
 }
 
 func ArgumentIndex(arguments []Argument, name string) (idx int) {
@@ -36,11 +38,17 @@ func VariableScopeIndex(variables []VariableScope, name string) (idx int) {
 }
 
 type MessageScope struct {
+	Location  ast.Location
 	Name      string
 	Variables []VariableScope
 	Plural    ast.Plural
 	String    ast.FormatParts
 	Arguments []Argument
+	// Description of the message for developers and translators
+	Description string
+	// If not nil, the message is missing in the localization
+	// and is taken from the fallback localization instead
+	Fallback *Localization
 }
 
 func (m *MessageScope) IsSimple() bool {
@@ -55,6 +63,10 @@ type Localization struct {
 	Lang    language.Tag
 	Scopes  []MessageScope
 	Imports []ast.GoImport
+	// Names of messages that couldn't be read because of errors
+	InvalidMessages map[string]struct{}
+	// Whether some of the localization files couldn't be read at all
+	Incomplete bool
 }
 
 func (loc *Localization) AddImport(imp ast.GoImport) {